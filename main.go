@@ -2,27 +2,419 @@ package main
 
 import (
 	"bufio"
+	"context"
+	"errors"
+	"flag"
 	"fmt"
+	"io"
 	"log"
 	"math/rand"
+	"net/http"
 	"os"
+	"os/signal"
 	"path/filepath"
 	"strings"
+	"syscall"
 	"time"
 
 	"github.com/e6a5/radar/radar"
+	"github.com/e6a5/radar/radar/estimation"
+	"github.com/e6a5/radar/radar/export"
+	"github.com/e6a5/radar/radar/scanner"
+	"github.com/e6a5/radar/radar/session"
+	"github.com/e6a5/radar/radar/wifi"
 	"github.com/gdamore/tcell/v2"
 )
 
+// version is the release version, overridable at build time with
+// -ldflags "-X main.version=..."
+var version = "2.0.0"
+
+// command is a radar subcommand
+type command struct {
+	name    string
+	summary string
+	run     func(args []string) int
+}
+
+// commands lists the subcommands; "run" is used when none is given
+var commands = []command{
+	{"run", "Start the interactive radar display (default)", runDisplay},
+	{"scan", "Scan without the display and stream JSON lines to stdout", runScan},
+	{"replay", "Play a recorded session file on the display", runReplay},
+	{"export", "Export signals and their history to CSV, GeoJSON or KML", runExport},
+	{"list-scanners", "List the scanners that can be selected with --scanners", runListScanners},
+	{"update-oui", "Download the IEEE OUI registry used to name MAC address vendors", runUpdateOUI},
+	{"version", "Print the radar version", runVersion},
+}
+
 func main() {
 	rand.Seed(time.Now().UnixNano())
 
+	args := os.Args[1:]
+	name := "run"
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		name, args = args[0], args[1:]
+	}
+
+	for _, cmd := range commands {
+		if cmd.name == name {
+			os.Exit(cmd.run(args))
+		}
+	}
+
+	if name != "help" {
+		fmt.Fprintf(os.Stderr, "radar: unknown command %q\n\n", name)
+	}
+	printUsage()
+	if name != "help" {
+		os.Exit(2)
+	}
+}
+
+// printUsage lists the available commands
+func printUsage() {
+	fmt.Fprintln(os.Stderr, "Usage: radar [command] [flags]")
+	fmt.Fprintln(os.Stderr)
+	fmt.Fprintln(os.Stderr, "Commands:")
+	for _, cmd := range commands {
+		fmt.Fprintf(os.Stderr, "  %-14s %s\n", cmd.name, cmd.summary)
+	}
+	fmt.Fprintln(os.Stderr)
+	fmt.Fprintln(os.Stderr, "Run 'radar <command> -h' for the flags of a command.")
+}
+
+// configFlags registers the flags that override radar.Config fields. The
+// returned function applies the flags that were set on the command line.
+func configFlags(flags *flag.FlagSet) func(config *radar.Config) error {
+	speed := flags.Float64("speed", 1.0, "radar sweep speed multiplier")
+	maxSignals := flags.Int("max-signals", 0, "maximum signals on the scope, or per scanner for scan")
+	scanInterval := flags.Duration("scan-interval", 0, "time between real data scans")
+	persistence := flags.Duration("persistence", 0, "how long signals persist after a sweep")
+	scanRange := flags.Float64("range", 0, "maximum distance for real signals")
+	theme := flags.String("theme", "", "color theme: modern-dark, classic-green, blue-neon, military")
+	refresh := flags.Duration("refresh", 0, "frame refresh interval")
+	bearing := flags.String("bearing", "", "bearing strategy for real signals: hash, sector, pinned")
+	sweep := flags.Bool("sweep", false, "ping the local subnets to discover LAN hosts")
+	displayRange := flags.Float64("display-range", 0, "meters shown at the scope's edge")
+	guardRange := flags.Float64("guard-range", 0, "alert when a moving signal will pass within this distance; 0 disables")
+	environment := flags.String("environment", "", "path-loss profile for distance estimates: "+strings.Join(estimation.EnvironmentNames(), ", "))
+	lat := flags.Float64("lat", 0, "observer latitude for map exports")
+	lon := flags.Float64("lon", 0, "observer longitude for map exports")
+	metersPerUnit := flags.Float64("meters-per-unit", 1.0, "meters per scope distance unit in map exports")
+
+	return func(config *radar.Config) error {
+		var err error
+		flags.Visit(func(f *flag.Flag) {
+			if err != nil {
+				return
+			}
+			switch f.Name {
+			case "speed":
+				if *speed <= 0 {
+					err = errors.New("--speed must be positive")
+				}
+				// A multiplier of the default, so it replaces the file's speed
+				config.RadarSpeed = radar.NewConfig().RadarSpeed * *speed
+			case "max-signals":
+				if *maxSignals <= 0 {
+					err = errors.New("--max-signals must be positive")
+				}
+				config.MaxSignals = *maxSignals
+			case "scan-interval":
+				if *scanInterval <= 0 {
+					err = errors.New("--scan-interval must be positive")
+				}
+				config.ScanInterval = scanInterval.Seconds()
+			case "persistence":
+				config.PersistenceTime = persistence.Seconds()
+				config.EnablePersistence = *persistence > 0
+			case "range":
+				if *scanRange <= 0 {
+					err = errors.New("--range must be positive")
+				}
+				config.MaxScanRange = *scanRange
+			case "theme":
+				config.Theme, err = radar.ParseThemeType(*theme)
+			case "refresh":
+				if *refresh <= 0 {
+					err = errors.New("--refresh must be positive")
+				}
+				config.RefreshRate = *refresh
+			case "bearing":
+				switch *bearing {
+				case scanner.BearingHash, scanner.BearingSector, scanner.BearingPinned:
+					config.BearingMode = *bearing
+				default:
+					err = fmt.Errorf("unknown bearing strategy %q", *bearing)
+				}
+			case "sweep":
+				config.ActiveSweep = *sweep
+			case "display-range":
+				if *displayRange <= 0 {
+					err = errors.New("--display-range must be positive")
+				}
+				config.DisplayRange = *displayRange
+			case "guard-range":
+				if *guardRange < 0 {
+					err = errors.New("--guard-range must not be negative")
+				}
+				config.GuardRange = *guardRange
+			case "environment":
+				if _, ok := estimation.LookupEnvironment(*environment); !ok {
+					err = fmt.Errorf("unknown environment %q", *environment)
+				}
+				config.Environment = *environment
+			case "lat":
+				if *lat < -90 || *lat > 90 {
+					err = errors.New("--lat must be between -90 and 90")
+				}
+				config.ObserverLatitude = *lat
+			case "lon":
+				if *lon < -180 || *lon > 180 {
+					err = errors.New("--lon must be between -180 and 180")
+				}
+				config.ObserverLongitude = *lon
+			case "meters-per-unit":
+				if *metersPerUnit <= 0 {
+					err = errors.New("--meters-per-unit must be positive")
+				}
+				config.MetersPerUnit = *metersPerUnit
+			}
+		})
+		return err
+	}
+}
+
+// isFlagSet reports whether a flag was given on the command line
+func isFlagSet(flags *flag.FlagSet, name string) bool {
+	set := false
+	flags.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			set = true
+		}
+	})
+	return set
+}
+
+// runDisplay runs the interactive radar display
+func runDisplay(args []string) int {
+	flags := flag.NewFlagSet("run", flag.ExitOnError)
+	configSource := configFileFlags(flags)
+	simulate := flags.Bool("sim", false, "start in simulation mode without collecting real data")
+	noPrompt := flags.Bool("no-consent-prompt", false, "collect real data without asking for consent")
+	openRecorder := recordFlags(flags)
+	flags.Parse(args)
+
+	source := configSource()
+	if *simulate {
+		source = withoutRealData(source)
+	}
+	config, filters, err := source.Load()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "radar: %v\n", err)
+		return 2
+	}
+
 	// Check for existing consent or ask for permission to collect real data
-	if !hasConsent() && !askForPermission() {
+	if config.EnableRealData && !*noPrompt && !hasConsent() && !askForPermission() {
 		fmt.Println("Permission denied. Exiting.")
-		os.Exit(0)
+		return 0
+	}
+
+	recorder, err := openRecorder()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "radar: %v\n", err)
+		return 1
+	}
+	if recorder != nil {
+		defer recorder.Close()
+	}
+
+	return runScreen(config, filters, source, func(display *radar.Display) {
+		if recorder != nil {
+			display.AddScanObserver(recorder)
+		}
+	})
+}
+
+// runReplay plays a recorded session on the interactive display
+func runReplay(args []string) int {
+	flags := flag.NewFlagSet("replay", flag.ExitOnError)
+	configSource := configFileFlags(flags)
+	rate := flags.Float64("rate", 1.0, "playback speed, 0.25 to 16")
+	seek := flags.String("seek", "", "start at an offset (e.g. 5m) or a time (RFC 3339) within the session")
+	loop := flags.Bool("loop", false, "restart from the beginning at the end of the session")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: radar replay [flags] SESSION_FILE")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return 2
+	}
+
+	// Replayed sessions never touch live scanners, so no consent is needed
+	source := withoutRealData(configSource())
+	config, filters, err := source.Load()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "radar: %v\n", err)
+		return 2
 	}
 
+	player, err := session.OpenPlayer(flags.Arg(0))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "radar: %v\n", err)
+		return 1
+	}
+	player.SetSpeed(*rate)
+	player.SetLoop(*loop)
+	if *seek != "" {
+		if offset, err := time.ParseDuration(*seek); err == nil {
+			player.Seek(offset)
+		} else if at, err := time.Parse(time.RFC3339, *seek); err == nil {
+			player.SeekTime(at)
+		} else {
+			fmt.Fprintf(os.Stderr, "radar: invalid --seek %q: use an offset such as 5m or an RFC 3339 time\n", *seek)
+			return 2
+		}
+	}
+
+	return runScreen(config, filters, source, func(display *radar.Display) {
+		display.StartReplay(player)
+	})
+}
+
+// runExport writes signals with their position history to a file, either
+// from a recorded session or from a live scan
+func runExport(args []string) int {
+	flags := flag.NewFlagSet("export", flag.ExitOnError)
+	configSource := configFileFlags(flags)
+	output := flags.String("o", "", "output file (default stdout)")
+	formatName := flags.String("format", "", "csv, geojson or kml (default from the output file extension, else csv)")
+	duration := flags.Duration("duration", 0, "without a session file, keep scanning this long (default a single scan)")
+	scanners := flags.String("scanners", "", "comma-separated scanners to run for a live export (default all)")
+	noPrompt := flags.Bool("no-consent-prompt", false, "collect real data without a saved consent")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: radar export [flags] [SESSION_FILE]")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	if flags.NArg() > 1 {
+		flags.Usage()
+		return 2
+	}
+
+	config, _, err := configSource().Load()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "radar: %v\n", err)
+		return 2
+	}
+
+	format := export.FormatCSV
+	switch {
+	case *formatName != "":
+		format, err = export.ParseFormat(*formatName)
+	case *output != "":
+		format, err = export.FormatForPath(*output)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "radar: %v\n", err)
+		return 2
+	}
+
+	var records []session.Record
+	if flags.NArg() == 1 {
+		records, err = session.ReadSession(flags.Arg(0))
+	} else {
+		records, err = scanForExport(config, *duration, *scanners, *noPrompt)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "radar: %v\n", err)
+		return 1
+	}
+
+	signals := session.Tracks(records)
+	options := config.ExportOptions(time.Now())
+	if *output == "" {
+		err = export.Write(os.Stdout, format, signals, options)
+	} else {
+		err = radar.ExportFile(*output, format, signals, options)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "radar: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// scanForExport runs the scanners for the export command and returns every
+// scan result, scanning once when duration is zero
+func scanForExport(config radar.Config, duration time.Duration, scanners string, noPrompt bool) ([]session.Record, error) {
+	// Never prompt here: stdout may carry the export
+	if !noPrompt && !hasConsent() {
+		return nil, errors.New("no consent to collect data; run radar interactively once or pass --no-consent-prompt")
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	if duration > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, duration)
+		defer cancel()
+	}
+
+	collector := &session.Collector{}
+	options := radar.HeadlessOptions{
+		Once:      duration == 0,
+		Observers: []scanner.Observer{collector},
+	}
+	if scanners != "" {
+		options.Scanners = strings.Split(scanners, ",")
+	}
+	if err := radar.RunHeadless(ctx, config, options, io.Discard); err != nil {
+		return nil, err
+	}
+	return collector.Records(), nil
+}
+
+// configFileFlags registers the config file flag and the flags overriding
+// config fields, shared by every command that scans or displays signals. The
+// returned function gives the config source: the config file, with the flags
+// applied on top each time it is loaded.
+func configFileFlags(flags *flag.FlagSet) func() radar.ConfigSource {
+	applyConfig := configFlags(flags)
+	configPath := flags.String("config", radar.DefaultConfigPath(), "config file; the display reloads it when it changes")
+
+	return func() radar.ConfigSource {
+		return radar.ConfigSource{
+			Path:      *configPath,
+			Required:  isFlagSet(flags, "config"),
+			Overrides: applyConfig,
+		}
+	}
+}
+
+// withoutRealData returns source with real data collection always disabled
+func withoutRealData(source radar.ConfigSource) radar.ConfigSource {
+	overrides := source.Overrides
+	source.Overrides = func(config *radar.Config) error {
+		config.EnableRealData = false
+		if overrides == nil {
+			return nil
+		}
+		return overrides(config)
+	}
+	return source
+}
+
+// runScreen runs the display on the terminal until the user quits, reloading
+// the config from source when its file changes. setup is called once the
+// display exists, before the first frame.
+func runScreen(config radar.Config, filters radar.FilterState, source radar.ConfigSource, setup func(display *radar.Display)) int {
 	screen, err := tcell.NewScreen()
 	if err != nil {
 		log.Fatalf("Error creating screen: %v", err)
@@ -34,7 +426,11 @@ func main() {
 
 	// Get initial terminal size
 	width, height := screen.Size()
-	display := radar.NewDisplay(width, height)
+	display := radar.NewDisplayWithConfig(width, height, config)
+	defer display.Close()
+	display.SetFilters(filters)
+	display.WatchConfigFile(source)
+	setup(display)
 
 	// Main loop with adaptive refresh rate
 	for {
@@ -59,6 +455,118 @@ func main() {
 			time.Sleep(refreshRate - frameTime)
 		}
 	}
+	return 0
+}
+
+// headlessMaxSignals is the default limit on signals reported per scanner by
+// the scan command; radar.max_signals in the config file limits the scope
+const headlessMaxSignals = 50
+
+// runScan runs the scanners without the display and streams JSON lines to stdout
+func runScan(args []string) int {
+	flags := flag.NewFlagSet("scan", flag.ExitOnError)
+	configSource := configFileFlags(flags)
+	scanners := flags.String("scanners", "", "comma-separated scanners to run (default from the config file, else all)")
+	once := flags.Bool("once", false, "run a single scan and exit")
+	noPrompt := flags.Bool("no-consent-prompt", false, "collect real data without a saved consent")
+	openRecorder := recordFlags(flags)
+	flags.Parse(args)
+
+	config, _, err := configSource().Load()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "radar: %v\n", err)
+		return 2
+	}
+	if !isFlagSet(flags, "max-signals") {
+		config.MaxSignals = headlessMaxSignals
+	}
+
+	// Never prompt here: stdout carries the JSON stream
+	if !*noPrompt && !hasConsent() {
+		fmt.Fprintln(os.Stderr, "radar: no consent to collect data; run radar interactively once or pass --no-consent-prompt")
+		return 1
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	options := radar.HeadlessOptions{Once: *once}
+	if *scanners != "" {
+		options.Scanners = strings.Split(*scanners, ",")
+	}
+
+	recorder, err := openRecorder()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "radar: %v\n", err)
+		return 1
+	}
+	if recorder != nil {
+		defer recorder.Close()
+		options.Observers = append(options.Observers, recorder)
+	}
+
+	if err := radar.RunHeadless(ctx, config, options, os.Stdout); err != nil {
+		fmt.Fprintf(os.Stderr, "radar: %v\n", err)
+		return 1
+	}
+	if recorder != nil && recorder.Err() != nil {
+		fmt.Fprintf(os.Stderr, "radar: %v\n", recorder.Err())
+		return 1
+	}
+	return 0
+}
+
+// recordFlags registers the session recording flags. The returned function
+// opens the recorder, or returns nil if recording was not requested.
+func recordFlags(flags *flag.FlagSet) func() (*session.Recorder, error) {
+	path := flags.String("record", "", "append every scan result to this session file (gzip JSON lines)")
+	maxSize := flags.Int64("record-max-mb", session.DefaultMaxSize>>20, "rotate the session file after this many megabytes")
+	maxFiles := flags.Int("record-keep", session.DefaultMaxFiles, "number of rotated session files to keep")
+
+	return func() (*session.Recorder, error) {
+		if *path == "" {
+			return nil, nil
+		}
+		return session.NewRecorder(*path, session.RecorderOptions{
+			MaxSize:  *maxSize << 20,
+			MaxFiles: *maxFiles,
+		})
+	}
+}
+
+// runListScanners prints the registered scanners
+func runListScanners(args []string) int {
+	flags := flag.NewFlagSet("list-scanners", flag.ExitOnError)
+	flags.Parse(args)
+
+	for _, info := range radar.Scanners() {
+		fmt.Printf("%-10s %s\n", info.Name, info.Description)
+	}
+	return 0
+}
+
+// runUpdateOUI downloads the IEEE registries used for vendor lookups
+func runUpdateOUI(args []string) int {
+	flags := flag.NewFlagSet("update-oui", flag.ExitOnError)
+	output := flags.String("output", wifi.DefaultOUIPath(), "where to install the registry")
+	timeout := flags.Duration("timeout", 2*time.Minute, "time allowed for the download")
+	flags.Parse(args)
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+	count, err := wifi.UpdateOUIDatabase(ctx, http.DefaultClient, wifi.OUIRegistryURLs, *output)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "radar: %v\n", err)
+		return 1
+	}
+	fmt.Printf("Installed %d assignments to %s\n", count, *output)
+	return 0
+}
+
+// runVersion prints the version
+func runVersion(args []string) int {
+	fmt.Printf("radar %s\n", version)
+	return 0
 }
 
 // askForPermission prompts the user for permission to collect real data
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"flag"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/e6a5/radar/radar"
+)
+
+func TestSpeedFlagOverridesConfigFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte(`{"radar": {"speed": 1.5, "display_range": 60}}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	defaultSpeed := radar.NewConfig().RadarSpeed
+
+	tests := []struct {
+		args []string
+		want float64 // Multiple of the default speed
+	}{
+		{nil, 1.5},
+		{[]string{"--speed", "2"}, 2},
+		{[]string{"--speed", "0.5"}, 0.5},
+	}
+	for _, tt := range tests {
+		flags := flag.NewFlagSet("radar", flag.ContinueOnError)
+		overrides := configFlags(flags)
+		if err := flags.Parse(tt.args); err != nil {
+			t.Fatal(err)
+		}
+		source := radar.ConfigSource{Path: path, Required: true, Overrides: overrides}
+
+		// Startup and every reload build the same speed
+		for load := 0; load < 3; load++ {
+			config, _, err := source.Load()
+			if err != nil {
+				t.Fatalf("%v: Load: %v", tt.args, err)
+			}
+			if got := config.RadarSpeed / defaultSpeed; math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("%v, load %d: speed = %gx, want %gx", tt.args, load, got, tt.want)
+			}
+			if config.DisplayRange != 60 {
+				t.Errorf("%v: display range = %g, want the file's 60", tt.args, config.DisplayRange)
+			}
+		}
+	}
+}
@@ -0,0 +1,284 @@
+//go:build linux
+// +build linux
+
+package bluetooth
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/e6a5/radar/radar/model"
+	"github.com/e6a5/radar/radar/scanner"
+)
+
+// BlueZ names
+const (
+	bluezService       = "org.bluez"
+	bluezAdapter       = "org.bluez.Adapter1"
+	bluezDevice        = "org.bluez.Device1"
+	objectManagerIface = "org.freedesktop.DBus.ObjectManager"
+	propertiesIface    = "org.freedesktop.DBus.Properties"
+)
+
+// deviceTimeout is how long a device stays on the scope after its last RSSI
+// update. BlueZ only signals RSSI changes, so a still device can be quiet.
+const deviceTimeout = 2 * time.Minute
+
+// BlueZScanner discovers Bluetooth LE devices through BlueZ on the system bus.
+// Discovery runs continuously once started, until Close stops it.
+type BlueZScanner struct {
+	config   *scanner.Config
+	address  string
+	dial     func(ctx context.Context) (*dbusConn, error) // Opens the bus connection
+	conn     *dbusConn
+	devices  map[objectPath]*Device
+	adapters map[objectPath]bool
+	closed   bool
+	mutex    sync.Mutex
+}
+
+// NewBlueZScanner creates a scanner for the system bus, which can be
+// overridden with DBUS_SYSTEM_BUS_ADDRESS
+func NewBlueZScanner(config *scanner.Config) *BlueZScanner {
+	b := &BlueZScanner{
+		config:   config,
+		address:  systemBusAddress(),
+		devices:  make(map[objectPath]*Device),
+		adapters: make(map[objectPath]bool),
+	}
+	b.dial = func(ctx context.Context) (*dbusConn, error) {
+		return dialBus(ctx, b.address)
+	}
+	return b
+}
+
+// Name returns the scanner name
+func (b *BlueZScanner) Name() string {
+	return "BlueZ Bluetooth Scanner"
+}
+
+// IsAvailable checks that the system bus socket exists
+func (b *BlueZScanner) IsAvailable() bool {
+	return busAvailable(b.address)
+}
+
+// Scan returns the devices heard recently, strongest first
+func (b *BlueZScanner) Scan(ctx context.Context) ([]model.Signal, error) {
+	if err := b.connect(ctx); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	b.mutex.Lock()
+	devices := make([]*Device, 0, len(b.devices))
+	for _, d := range b.devices {
+		if d.Address == "" || !d.HasRSSI || now.Sub(d.LastSeen) > deviceTimeout {
+			continue
+		}
+		copied := *d
+		devices = append(devices, &copied)
+	}
+	b.mutex.Unlock()
+
+	sort.Slice(devices, func(i, j int) bool {
+		if devices[i].RSSI != devices[j].RSSI {
+			return devices[i].RSSI > devices[j].RSSI
+		}
+		return devices[i].Address < devices[j].Address
+	})
+
+	signals := make([]model.Signal, 0, len(devices))
+	for _, d := range devices {
+		signals = append(signals, deviceSignal(d, b.config, now))
+		if len(signals) >= b.config.MaxSignals {
+			break
+		}
+	}
+	return signals, nil
+}
+
+// connect opens the bus connection, loads the known objects and starts
+// discovery, unless a connection is already running
+func (b *BlueZScanner) connect(ctx context.Context) error {
+	b.mutex.Lock()
+	conn, closed := b.conn, b.closed
+	b.mutex.Unlock()
+	if closed {
+		return net.ErrClosed
+	}
+	if conn != nil && conn.Err() == nil {
+		return nil
+	}
+
+	conn, err := b.dial(ctx)
+	if err != nil {
+		return err
+	}
+
+	rules := []string{
+		"type='signal',sender='org.bluez',interface='org.freedesktop.DBus.ObjectManager',member='InterfacesAdded'",
+		"type='signal',sender='org.bluez',interface='org.freedesktop.DBus.ObjectManager',member='InterfacesRemoved'",
+		"type='signal',sender='org.bluez',interface='org.freedesktop.DBus.Properties',member='PropertiesChanged',arg0='org.bluez.Device1'",
+	}
+	for _, rule := range rules {
+		if err := conn.AddMatch(ctx, rule); err != nil {
+			conn.Close()
+			return err
+		}
+	}
+
+	reply, err := conn.Call(ctx, bluezService, "/", objectManagerIface, "GetManagedObjects", "")
+	if err != nil {
+		conn.Close()
+		return err
+	}
+
+	b.mutex.Lock()
+	if b.closed {
+		b.mutex.Unlock()
+		conn.Close()
+		return net.ErrClosed
+	}
+	b.conn = conn
+	b.devices = make(map[objectPath]*Device)
+	b.adapters = make(map[objectPath]bool)
+	if len(reply) > 0 {
+		objects, _ := reply[0].(map[any]any)
+		for path, ifaces := range objects {
+			if p, ok := path.(objectPath); ok {
+				b.addInterfaces(p, ifaces, time.Now())
+			}
+		}
+	}
+	adapters := make([]objectPath, 0, len(b.adapters))
+	for path := range b.adapters {
+		adapters = append(adapters, path)
+	}
+	b.mutex.Unlock()
+
+	if len(adapters) == 0 {
+		conn.Close()
+		return errors.New("bluetooth: no adapters found")
+	}
+	for _, adapter := range adapters {
+		if err := b.startDiscovery(ctx, conn, adapter); err != nil {
+			conn.Close()
+			return err
+		}
+	}
+
+	go b.watch(conn)
+	return nil
+}
+
+// Close stops discovery on the adapters and closes the bus connection,
+// which ends the signal watch. A closed scanner fails every later scan.
+func (b *BlueZScanner) Close() error {
+	b.mutex.Lock()
+	conn := b.conn
+	b.conn = nil
+	b.closed = true
+	adapters := make([]objectPath, 0, len(b.adapters))
+	for path := range b.adapters {
+		adapters = append(adapters, path)
+	}
+	b.mutex.Unlock()
+
+	if conn == nil {
+		return nil
+	}
+	// BlueZ stops discovery for a client that disconnects anyway, so a
+	// failed stop is not worth reporting
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	for _, adapter := range adapters {
+		conn.Call(ctx, bluezService, adapter, bluezAdapter, "StopDiscovery", "")
+	}
+	return conn.Close()
+}
+
+// startDiscovery starts LE discovery on an adapter, tolerating discovery
+// already being in progress
+func (b *BlueZScanner) startDiscovery(ctx context.Context, conn *dbusConn, adapter objectPath) error {
+	filter := map[string]variant{"Transport": {"s", "le"}}
+	conn.Call(ctx, bluezService, adapter, bluezAdapter, "SetDiscoveryFilter", "a{sv}", filter)
+
+	_, err := conn.Call(ctx, bluezService, adapter, bluezAdapter, "StartDiscovery", "")
+	var dbusErr *dbusError
+	if errors.As(err, &dbusErr) && dbusErr.Name == "org.bluez.Error.InProgress" {
+		return nil
+	}
+	return err
+}
+
+// watch applies BlueZ signals to the device table until the connection closes
+func (b *BlueZScanner) watch(conn *dbusConn) {
+	for msg := range conn.Signals() {
+		now := time.Now()
+		switch {
+		case msg.iface == objectManagerIface && msg.member == "InterfacesAdded" && len(msg.body) == 2:
+			path, _ := msg.body[0].(objectPath)
+			b.mutex.Lock()
+			_, known := b.adapters[path]
+			b.addInterfaces(path, msg.body[1], now)
+			isNewAdapter := !known && b.adapters[path]
+			b.mutex.Unlock()
+
+			if isNewAdapter {
+				// An adapter that appeared later, such as a plugged-in dongle
+				ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+				b.startDiscovery(ctx, conn, path)
+				cancel()
+			}
+		case msg.iface == objectManagerIface && msg.member == "InterfacesRemoved" && len(msg.body) == 2:
+			path, _ := msg.body[0].(objectPath)
+			ifaces, _ := msg.body[1].([]any)
+			b.mutex.Lock()
+			for _, iface := range ifaces {
+				switch iface {
+				case bluezDevice:
+					delete(b.devices, path)
+				case bluezAdapter:
+					delete(b.adapters, path)
+				}
+			}
+			b.mutex.Unlock()
+		case msg.iface == propertiesIface && msg.member == "PropertiesChanged" && len(msg.body) == 3:
+			if iface, _ := msg.body[0].(string); iface != bluezDevice {
+				continue
+			}
+			changed, _ := msg.body[1].(map[any]any)
+			invalidated, _ := msg.body[2].([]any)
+			b.mutex.Lock()
+			if device, ok := b.devices[msg.path]; ok {
+				device.applyProperties(changed, now)
+				device.invalidate(invalidated)
+			}
+			b.mutex.Unlock()
+		}
+	}
+}
+
+// addInterfaces records the adapter and device interfaces of an object.
+// Callers must hold the lock.
+func (b *BlueZScanner) addInterfaces(path objectPath, value any, now time.Time) {
+	ifaces, _ := value.(map[any]any)
+	for name, props := range ifaces {
+		switch name {
+		case bluezAdapter:
+			b.adapters[path] = true
+		case bluezDevice:
+			device, ok := b.devices[path]
+			if !ok {
+				device = &Device{}
+				b.devices[path] = device
+			}
+			properties, _ := props.(map[any]any)
+			device.applyProperties(properties, now)
+		}
+	}
+}
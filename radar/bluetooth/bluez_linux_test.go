@@ -0,0 +1,336 @@
+//go:build linux
+// +build linux
+
+package bluetooth
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/e6a5/radar/radar/model"
+	"github.com/e6a5/radar/radar/scanner"
+)
+
+// fakeBus plays the bus daemon and BlueZ on one end of a socketpair
+type fakeBus struct {
+	t       *testing.T
+	conn    net.Conn
+	objects map[objectPath]map[string]map[string]variant
+
+	mutex     sync.Mutex
+	serial    uint32
+	calls     []string          // Interface.Member of every method call
+	filter    map[any]any       // Last SetDiscoveryFilter argument
+	replies   map[string]string // Error names to reply with, by member
+	started   chan struct{}     // Closed once discovery has started
+	startOnce sync.Once
+}
+
+// newFakeBus returns a bus serving objects and a scanner connected to it
+func newFakeBus(t *testing.T, objects map[objectPath]map[string]map[string]variant) (*fakeBus, *BlueZScanner) {
+	t.Helper()
+	fds, err := syscall.Socketpair(syscall.AF_UNIX, syscall.SOCK_STREAM|syscall.SOCK_CLOEXEC, 0)
+	if err != nil {
+		t.Fatalf("socketpair: %v", err)
+	}
+	client, server := fileConn(t, fds[0]), fileConn(t, fds[1])
+	t.Cleanup(func() {
+		client.Close()
+		server.Close()
+	})
+
+	bus := &fakeBus{t: t, conn: server, objects: objects, started: make(chan struct{})}
+	go bus.serve()
+
+	s := NewBlueZScanner(&scanner.Config{MaxSignals: 10, MaxScanRange: 1000})
+	dialed := false
+	s.dial = func(ctx context.Context) (*dbusConn, error) {
+		if dialed {
+			return nil, os.ErrClosed // The socketpair cannot be redialed
+		}
+		dialed = true
+		return openConn(ctx, client)
+	}
+	return bus, s
+}
+
+// fileConn wraps a socket descriptor in a net.Conn
+func fileConn(t *testing.T, fd int) net.Conn {
+	t.Helper()
+	file := os.NewFile(uintptr(fd), "bus")
+	defer file.Close()
+	conn, err := net.FileConn(file)
+	if err != nil {
+		t.Fatalf("FileConn: %v", err)
+	}
+	return conn
+}
+
+// serve authenticates the client, then answers method calls until the
+// connection closes
+func (b *fakeBus) serve() {
+	reader := bufio.NewReader(b.conn)
+	line, err := reader.ReadString('\n')
+	if err != nil || !strings.HasPrefix(line, "\x00AUTH EXTERNAL ") {
+		b.t.Errorf("auth line = %q, %v", line, err)
+		return
+	}
+	b.conn.Write([]byte("OK 0123456789abcdef0123456789abcdef\r\n"))
+	if line, err := reader.ReadString('\n'); err != nil || line != "BEGIN\r\n" {
+		b.t.Errorf("begin line = %q, %v", line, err)
+		return
+	}
+
+	for {
+		msg, err := readMessage(reader)
+		if err != nil {
+			return
+		}
+		if msg.kind != msgMethodCall {
+			continue
+		}
+
+		b.mutex.Lock()
+		b.calls = append(b.calls, msg.iface+"."+msg.member)
+		errorName := b.replies[msg.member]
+		b.mutex.Unlock()
+
+		reply := &message{kind: msgMethodReturn, replySerial: msg.serial, destination: ":1.1"}
+		switch {
+		case errorName != "":
+			reply.kind = msgError
+			reply.errorName = errorName
+			reply.sig, reply.body = "s", []any{"refused by the fake bus"}
+		case msg.member == "Hello":
+			reply.sig, reply.body = "s", []any{":1.1"}
+		case msg.member == "GetManagedObjects":
+			reply.sig, reply.body = "a{oa{sa{sv}}}", []any{b.objects}
+		case msg.member == "SetDiscoveryFilter":
+			b.mutex.Lock()
+			b.filter, _ = msg.body[0].(map[any]any)
+			b.mutex.Unlock()
+		}
+		b.send(reply)
+		if msg.member == "StartDiscovery" && errorName == "" {
+			b.startOnce.Do(func() { close(b.started) })
+		}
+	}
+}
+
+// send writes a message from the bus
+func (b *fakeBus) send(msg *message) {
+	b.mutex.Lock()
+	b.serial++
+	msg.serial = b.serial
+	b.mutex.Unlock()
+
+	data, err := msg.marshal()
+	if err != nil {
+		b.t.Errorf("marshal %s: %v", msg.member, err)
+		return
+	}
+	b.conn.Write(data)
+}
+
+// propertiesChanged emits a PropertiesChanged signal for a device
+func (b *fakeBus) propertiesChanged(path objectPath, changed map[string]variant, invalidated ...string) {
+	b.send(&message{
+		kind:   msgSignal,
+		path:   path,
+		iface:  propertiesIface,
+		member: "PropertiesChanged",
+		sender: ":1.0",
+		sig:    "sa{sv}as",
+		body:   []any{bluezDevice, changed, append([]string{}, invalidated...)},
+	})
+}
+
+// Calls returns the method calls received so far
+func (b *fakeBus) Calls() []string {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return append([]string(nil), b.calls...)
+}
+
+const (
+	testAdapter  = objectPath("/org/bluez/hci0")
+	testKeyboard = objectPath("/org/bluez/hci0/dev_AA_BB_CC_DD_EE_01")
+	testTag      = objectPath("/org/bluez/hci0/dev_AA_BB_CC_DD_EE_02")
+)
+
+// testObjects returns an adapter, a keyboard heard at -62 dBm and a tag
+// BlueZ knows of but has not heard since discovery started
+func testObjects() map[objectPath]map[string]map[string]variant {
+	return map[objectPath]map[string]map[string]variant{
+		"/org/bluez": {
+			"org.bluez.AgentManager1": {},
+		},
+		testAdapter: {
+			bluezAdapter: {"Address": {"s", "00:1A:7D:DA:71:13"}, "Powered": {"b", true}},
+		},
+		testKeyboard: {
+			bluezDevice: {
+				"Address":          {"s", "AA:BB:CC:DD:EE:01"},
+				"AddressType":      {"s", "public"},
+				"Alias":            {"s", "MX Keys"},
+				"RSSI":             {"n", int16(-62)},
+				"ManufacturerData": {"a{qv}", map[uint16]variant{0x0006: {"ay", []byte{1, 9}}}},
+				"UUIDs":            {"as", []string{"00001812-0000-1000-8000-00805f9b34fb"}},
+			},
+			propertiesIface: {},
+		},
+		testTag: {
+			bluezDevice: {
+				"Address":     {"s", "AA:BB:CC:DD:EE:02"},
+				"AddressType": {"s", "random"},
+			},
+		},
+	}
+}
+
+func TestBlueZScannerFakeBus(t *testing.T) {
+	bus, s := newFakeBus(t, testObjects())
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	signals, err := s.Scan(ctx)
+	if err != nil {
+		t.Fatalf("Scan: %v", err)
+	}
+	select {
+	case <-bus.started:
+	case <-ctx.Done():
+		t.Fatal("discovery was not started")
+	}
+
+	wantCalls := []string{
+		"org.freedesktop.DBus.Hello",
+		"org.freedesktop.DBus.AddMatch",
+		"org.freedesktop.DBus.AddMatch",
+		"org.freedesktop.DBus.AddMatch",
+		objectManagerIface + ".GetManagedObjects",
+		bluezAdapter + ".SetDiscoveryFilter",
+		bluezAdapter + ".StartDiscovery",
+	}
+	if got := bus.Calls(); strings.Join(got, " ") != strings.Join(wantCalls, " ") {
+		t.Errorf("calls = %q\nwant %q", got, wantCalls)
+	}
+	bus.mutex.Lock()
+	transport := bus.filter["Transport"]
+	bus.mutex.Unlock()
+	if transport != (variant{"s", "le"}) {
+		t.Errorf("discovery filter transport = %v, want le", transport)
+	}
+
+	// Only the keyboard has been heard
+	if len(signals) != 1 {
+		t.Fatalf("got %d signals, want the keyboard only: %+v", len(signals), signals)
+	}
+	keyboard := signals[0]
+	if keyboard.ID != "bt:AA:BB:CC:DD:EE:01" || keyboard.Attributes.Get(model.AttrRSSI) != "-62" {
+		t.Errorf("keyboard = %s with RSSI %s", keyboard.ID, keyboard.Attributes.Get(model.AttrRSSI))
+	}
+	if !strings.Contains(keyboard.Name, "MX Keys") {
+		t.Errorf("keyboard name = %q, want the alias", keyboard.Name)
+	}
+
+	// The tag is heard and the keyboard moves away
+	bus.propertiesChanged(testTag, map[string]variant{"RSSI": {"n", int16(-48)}})
+	bus.propertiesChanged(testKeyboard, map[string]variant{"RSSI": {"n", int16(-80)}})
+	// Changes to other interfaces are ignored
+	bus.send(&message{
+		kind:   msgSignal,
+		path:   testKeyboard,
+		iface:  propertiesIface,
+		member: "PropertiesChanged",
+		sig:    "sa{sv}as",
+		body:   []any{"org.bluez.Battery1", map[string]variant{"Percentage": {"y", byte(80)}}, []string{}},
+	})
+
+	want := map[string]string{"bt:AA:BB:CC:DD:EE:02": "-48", "bt:AA:BB:CC:DD:EE:01": "-80"}
+	var got map[string]string
+	for ctx.Err() == nil {
+		signals, err := s.Scan(ctx)
+		if err != nil {
+			t.Fatalf("Scan: %v", err)
+		}
+		got = make(map[string]string)
+		for _, signal := range signals {
+			got[signal.ID] = signal.Attributes.Get(model.AttrRSSI)
+		}
+		if len(got) == len(want) && got["bt:AA:BB:CC:DD:EE:02"] == "-48" && got["bt:AA:BB:CC:DD:EE:01"] == "-80" {
+			if signals[0].ID != "bt:AA:BB:CC:DD:EE:02" {
+				t.Errorf("first signal = %s, want the strongest", signals[0].ID)
+			}
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("signals after PropertiesChanged = %v, want %v", got, want)
+}
+
+func TestBlueZScannerNoAdapter(t *testing.T) {
+	objects := testObjects()
+	delete(objects, testAdapter)
+	_, s := newFakeBus(t, objects)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if _, err := s.Scan(ctx); err == nil || !strings.Contains(err.Error(), "no adapters") {
+		t.Errorf("Scan error = %v, want no adapters", err)
+	}
+}
+
+func TestBlueZScannerDiscoveryInProgress(t *testing.T) {
+	bus, s := newFakeBus(t, testObjects())
+	bus.mutex.Lock()
+	bus.replies = map[string]string{"StartDiscovery": "org.bluez.Error.InProgress"}
+	bus.mutex.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if _, err := s.Scan(ctx); err != nil {
+		t.Errorf("Scan: %v, want discovery already in progress to be accepted", err)
+	}
+}
+
+func TestBlueZScannerClose(t *testing.T) {
+	bus, s := newFakeBus(t, testObjects())
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if _, err := s.Scan(ctx); err != nil {
+		t.Fatalf("Scan: %v", err)
+	}
+	conn := s.conn
+
+	if err := s.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	calls := bus.Calls()
+	if last := calls[len(calls)-1]; last != bluezAdapter+".StopDiscovery" {
+		t.Errorf("last call = %s, want StopDiscovery", last)
+	}
+	if conn.Err() == nil {
+		t.Error("bus connection still open after Close")
+	}
+	// The signal watch ends with the connection
+	select {
+	case _, ok := <-conn.Signals():
+		if ok {
+			t.Error("signal delivered after Close")
+		}
+	case <-ctx.Done():
+		t.Error("signal channel not closed")
+	}
+	if _, err := s.Scan(ctx); !errors.Is(err, net.ErrClosed) {
+		t.Errorf("Scan after Close = %v, want net.ErrClosed", err)
+	}
+}
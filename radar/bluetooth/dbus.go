@@ -0,0 +1,965 @@
+package bluetooth
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"os"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// This file implements the small part of the D-Bus wire protocol needed to
+// talk to BlueZ: connecting to a unix socket bus, EXTERNAL authentication,
+// method calls and signals, and the marshalling of the basic and container
+// types BlueZ uses.
+
+// defaultSystemBusAddress is the system bus used when the environment names none
+const defaultSystemBusAddress = "unix:path=/var/run/dbus/system_bus_socket"
+
+// maxMessageSize bounds the messages accepted from the bus
+const maxMessageSize = 64 << 20
+
+// protocolVersion is the major protocol version of every message
+const protocolVersion = 1
+
+// Message types
+const (
+	msgMethodCall   = 1
+	msgMethodReturn = 2
+	msgError        = 3
+	msgSignal       = 4
+)
+
+// Header field codes
+const (
+	fieldPath        = 1
+	fieldInterface   = 2
+	fieldMember      = 3
+	fieldErrorName   = 4
+	fieldReplySerial = 5
+	fieldDestination = 6
+	fieldSender      = 7
+	fieldSignature   = 8
+)
+
+// objectPath is a D-Bus object path
+type objectPath string
+
+// variant is a D-Bus variant: a value tagged with its signature
+type variant struct {
+	sig   string
+	value any
+}
+
+// message is a D-Bus message
+type message struct {
+	kind        byte
+	flags       byte
+	serial      uint32
+	path        objectPath
+	iface       string
+	member      string
+	errorName   string
+	replySerial uint32
+	destination string
+	sender      string
+	sig         string
+	body        []any
+}
+
+// dbusError is an error reply from the bus or a remote object
+type dbusError struct {
+	Name    string
+	Message string
+}
+
+func (e *dbusError) Error() string {
+	if e.Message == "" {
+		return e.Name
+	}
+	return e.Name + ": " + e.Message
+}
+
+// systemBusAddress returns the system bus address, honouring
+// DBUS_SYSTEM_BUS_ADDRESS
+func systemBusAddress() string {
+	if address := os.Getenv("DBUS_SYSTEM_BUS_ADDRESS"); address != "" {
+		return address
+	}
+	return defaultSystemBusAddress
+}
+
+// busSocket returns the network and socket address of the first unix
+// transport in a D-Bus address list
+func busSocket(address string) (string, bool) {
+	for _, entry := range strings.Split(address, ";") {
+		transport, params, ok := strings.Cut(entry, ":")
+		if !ok || transport != "unix" {
+			continue
+		}
+		for _, param := range strings.Split(params, ",") {
+			key, value, _ := strings.Cut(param, "=")
+			value = unescapeAddress(value)
+			switch key {
+			case "path":
+				return value, true
+			case "abstract":
+				return "@" + value, true
+			}
+		}
+	}
+	return "", false
+}
+
+// unescapeAddress decodes %XX escapes in an address value
+func unescapeAddress(value string) string {
+	if !strings.Contains(value, "%") {
+		return value
+	}
+	var b strings.Builder
+	for i := 0; i < len(value); i++ {
+		if value[i] == '%' && i+2 < len(value) {
+			if decoded, err := hex.DecodeString(value[i+1 : i+3]); err == nil {
+				b.WriteByte(decoded[0])
+				i += 2
+				continue
+			}
+		}
+		b.WriteByte(value[i])
+	}
+	return b.String()
+}
+
+// busAvailable reports whether the bus socket at address appears to exist
+func busAvailable(address string) bool {
+	socket, ok := busSocket(address)
+	if !ok {
+		return false
+	}
+	if strings.HasPrefix(socket, "@") {
+		return true // Abstract sockets have no file to check
+	}
+	_, err := os.Stat(socket)
+	return err == nil
+}
+
+// dbusConn is a connection to a message bus
+type dbusConn struct {
+	conn       net.Conn
+	reader     *bufio.Reader
+	uniqueName string
+	writeMutex sync.Mutex
+	mutex      sync.Mutex
+	serial     uint32
+	pending    map[uint32]chan *message
+	signals    chan *message
+	closed     chan struct{}
+	err        error
+	closeOnce  sync.Once
+}
+
+// dialBus connects and authenticates to the bus at address, then registers
+// with the bus by calling Hello
+func dialBus(ctx context.Context, address string) (*dbusConn, error) {
+	socket, ok := busSocket(address)
+	if !ok {
+		return nil, fmt.Errorf("dbus: no unix transport in address %q", address)
+	}
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "unix", socket)
+	if err != nil {
+		return nil, fmt.Errorf("dbus: %w", err)
+	}
+	return openConn(ctx, conn)
+}
+
+// openConn authenticates and registers with the bus over an established
+// connection, closing it on failure
+func openConn(ctx context.Context, conn net.Conn) (*dbusConn, error) {
+	c := &dbusConn{
+		conn:    conn,
+		reader:  bufio.NewReader(conn),
+		pending: make(map[uint32]chan *message),
+		signals: make(chan *message, 64),
+		closed:  make(chan struct{}),
+	}
+
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	} else {
+		conn.SetDeadline(time.Now().Add(5 * time.Second))
+	}
+	if err := c.authenticate(); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	conn.SetDeadline(time.Time{})
+
+	go c.readLoop()
+
+	reply, err := c.Call(ctx, "org.freedesktop.DBus", "/org/freedesktop/DBus", "org.freedesktop.DBus", "Hello", "")
+	if err != nil {
+		c.Close()
+		return nil, err
+	}
+	if len(reply) > 0 {
+		c.uniqueName, _ = reply[0].(string)
+	}
+	return c, nil
+}
+
+// authenticate runs the SASL EXTERNAL handshake, identifying as the
+// process's user
+func (c *dbusConn) authenticate() error {
+	uid := hex.EncodeToString([]byte(strconv.Itoa(os.Getuid())))
+	if _, err := c.conn.Write([]byte("\x00AUTH EXTERNAL " + uid + "\r\n")); err != nil {
+		return fmt.Errorf("dbus: auth: %w", err)
+	}
+
+	line, err := c.reader.ReadString('\n')
+	if err != nil {
+		return fmt.Errorf("dbus: auth: %w", err)
+	}
+	if !strings.HasPrefix(line, "OK ") {
+		return fmt.Errorf("dbus: auth rejected: %s", strings.TrimSpace(line))
+	}
+
+	if _, err := c.conn.Write([]byte("BEGIN\r\n")); err != nil {
+		return fmt.Errorf("dbus: auth: %w", err)
+	}
+	return nil
+}
+
+// Close closes the connection
+func (c *dbusConn) Close() error {
+	return c.closeWithError(errors.New("dbus: connection closed"))
+}
+
+// closeWithError closes the connection, recording why
+func (c *dbusConn) closeWithError(err error) error {
+	var closeErr error
+	c.closeOnce.Do(func() {
+		c.mutex.Lock()
+		c.err = err
+		c.mutex.Unlock()
+		closeErr = c.conn.Close()
+		close(c.closed)
+	})
+	return closeErr
+}
+
+// Err returns why the connection closed, or nil while it is open
+func (c *dbusConn) Err() error {
+	select {
+	case <-c.closed:
+		c.mutex.Lock()
+		defer c.mutex.Unlock()
+		return c.err
+	default:
+		return nil
+	}
+}
+
+// Signals delivers the signals matched by AddMatch rules. The channel is
+// closed when the connection closes. Signals are dropped while the channel is
+// full rather than stalling method replies.
+func (c *dbusConn) Signals() <-chan *message {
+	return c.signals
+}
+
+// AddMatch asks the bus to route signals matching rule to this connection
+func (c *dbusConn) AddMatch(ctx context.Context, rule string) error {
+	_, err := c.Call(ctx, "org.freedesktop.DBus", "/org/freedesktop/DBus", "org.freedesktop.DBus", "AddMatch", "s", rule)
+	return err
+}
+
+// Call invokes a method and waits for its reply body
+func (c *dbusConn) Call(ctx context.Context, destination string, path objectPath, iface, member, sig string, args ...any) ([]any, error) {
+	reply := make(chan *message, 1)
+
+	c.mutex.Lock()
+	c.serial++
+	serial := c.serial
+	c.pending[serial] = reply
+	c.mutex.Unlock()
+
+	defer func() {
+		c.mutex.Lock()
+		delete(c.pending, serial)
+		c.mutex.Unlock()
+	}()
+
+	msg := &message{
+		kind:        msgMethodCall,
+		serial:      serial,
+		path:        path,
+		iface:       iface,
+		member:      member,
+		destination: destination,
+		sig:         sig,
+		body:        args,
+	}
+	if err := c.send(msg); err != nil {
+		return nil, err
+	}
+
+	select {
+	case msg := <-reply:
+		if msg.kind == msgError {
+			return nil, replyError(msg)
+		}
+		return msg.body, nil
+	case <-c.closed:
+		return nil, c.Err()
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// send writes a message to the bus
+func (c *dbusConn) send(msg *message) error {
+	data, err := msg.marshal()
+	if err != nil {
+		return err
+	}
+
+	c.writeMutex.Lock()
+	defer c.writeMutex.Unlock()
+	if _, err := c.conn.Write(data); err != nil {
+		c.closeWithError(fmt.Errorf("dbus: %w", err))
+		return c.Err()
+	}
+	return nil
+}
+
+// readLoop reads messages until the connection fails, routing replies to
+// waiting calls and signals to the signal channel
+func (c *dbusConn) readLoop() {
+	defer close(c.signals)
+
+	for {
+		msg, err := readMessage(c.reader)
+		if err != nil {
+			c.closeWithError(fmt.Errorf("dbus: %w", err))
+			return
+		}
+
+		switch msg.kind {
+		case msgMethodReturn, msgError:
+			c.mutex.Lock()
+			reply, ok := c.pending[msg.replySerial]
+			c.mutex.Unlock()
+			if ok {
+				reply <- msg
+			}
+		case msgSignal:
+			select {
+			case c.signals <- msg:
+			default:
+			}
+		}
+	}
+}
+
+// replyError converts an error reply into an error
+func replyError(msg *message) error {
+	err := &dbusError{Name: msg.errorName}
+	if len(msg.body) > 0 {
+		err.Message, _ = msg.body[0].(string)
+	}
+	return err
+}
+
+// marshal encodes the message in little-endian byte order
+func (m *message) marshal() ([]byte, error) {
+	body := &encoder{}
+	types, err := splitSignature(m.sig)
+	if err != nil {
+		return nil, err
+	}
+	if len(types) != len(m.body) {
+		return nil, fmt.Errorf("dbus: signature %q needs %d values, got %d", m.sig, len(types), len(m.body))
+	}
+	for i, t := range types {
+		if err := body.encode(t, m.body[i]); err != nil {
+			return nil, err
+		}
+	}
+
+	fields := make([]any, 0, 8)
+	addField := func(code byte, sig string, value any) {
+		fields = append(fields, []any{code, variant{sig, value}})
+	}
+	if m.path != "" {
+		addField(fieldPath, "o", m.path)
+	}
+	if m.iface != "" {
+		addField(fieldInterface, "s", m.iface)
+	}
+	if m.member != "" {
+		addField(fieldMember, "s", m.member)
+	}
+	if m.errorName != "" {
+		addField(fieldErrorName, "s", m.errorName)
+	}
+	if m.replySerial != 0 {
+		addField(fieldReplySerial, "u", m.replySerial)
+	}
+	if m.destination != "" {
+		addField(fieldDestination, "s", m.destination)
+	}
+	if m.sender != "" {
+		addField(fieldSender, "s", m.sender)
+	}
+	if m.sig != "" {
+		addField(fieldSignature, "g", m.sig)
+	}
+
+	header := &encoder{}
+	header.buf = append(header.buf, 'l', m.kind, m.flags, protocolVersion)
+	header.encode("u", uint32(len(body.buf)))
+	header.encode("u", m.serial)
+	if err := header.encode("a(yv)", fields); err != nil {
+		return nil, err
+	}
+	header.align(8)
+
+	return append(header.buf, body.buf...), nil
+}
+
+// readMessage reads one message from r
+func readMessage(r io.Reader) (*message, error) {
+	fixed := make([]byte, 16)
+	if _, err := io.ReadFull(r, fixed); err != nil {
+		return nil, err
+	}
+
+	var order binary.ByteOrder
+	switch fixed[0] {
+	case 'l':
+		order = binary.LittleEndian
+	case 'B':
+		order = binary.BigEndian
+	default:
+		return nil, fmt.Errorf("invalid byte order %q", fixed[0])
+	}
+	if fixed[3] != protocolVersion {
+		return nil, fmt.Errorf("unsupported protocol version %d", fixed[3])
+	}
+
+	bodyLen := order.Uint32(fixed[4:])
+	fieldsLen := order.Uint32(fixed[12:])
+	if bodyLen > maxMessageSize || fieldsLen > maxMessageSize {
+		return nil, errors.New("message too large")
+	}
+	headerLen := 16 + int(fieldsLen)
+	padded := (headerLen + 7) &^ 7
+
+	data := make([]byte, padded+int(bodyLen))
+	copy(data, fixed)
+	if _, err := io.ReadFull(r, data[16:]); err != nil {
+		return nil, err
+	}
+
+	msg := &message{
+		kind:   fixed[1],
+		flags:  fixed[2],
+		serial: order.Uint32(fixed[8:]),
+	}
+
+	header := &decoder{buf: data[:headerLen], pos: 12, order: order}
+	fields, err := header.decode("a(yv)")
+	if err != nil {
+		return nil, fmt.Errorf("header: %w", err)
+	}
+	for _, field := range fields.([]any) {
+		entry := field.([]any)
+		code, _ := entry[0].(byte)
+		value := entry[1].(variant).value
+		switch code {
+		case fieldPath:
+			msg.path, _ = value.(objectPath)
+		case fieldInterface:
+			msg.iface, _ = value.(string)
+		case fieldMember:
+			msg.member, _ = value.(string)
+		case fieldErrorName:
+			msg.errorName, _ = value.(string)
+		case fieldReplySerial:
+			msg.replySerial, _ = value.(uint32)
+		case fieldDestination:
+			msg.destination, _ = value.(string)
+		case fieldSender:
+			msg.sender, _ = value.(string)
+		case fieldSignature:
+			msg.sig, _ = value.(string)
+		}
+	}
+
+	types, err := splitSignature(msg.sig)
+	if err != nil {
+		return nil, err
+	}
+	body := &decoder{buf: data[padded:], order: order}
+	for _, t := range types {
+		value, err := body.decode(t)
+		if err != nil {
+			return nil, fmt.Errorf("body of %s.%s: %w", msg.iface, msg.member, err)
+		}
+		msg.body = append(msg.body, value)
+	}
+	return msg, nil
+}
+
+// splitSignature splits a signature into its complete types
+func splitSignature(sig string) ([]string, error) {
+	types := make([]string, 0)
+	for sig != "" {
+		n, err := typeLength(sig)
+		if err != nil {
+			return nil, err
+		}
+		types = append(types, sig[:n])
+		sig = sig[n:]
+	}
+	return types, nil
+}
+
+// typeLength returns the length of the first complete type in sig
+func typeLength(sig string) (int, error) {
+	if sig == "" {
+		return 0, errors.New("dbus: empty signature")
+	}
+	switch sig[0] {
+	case 'y', 'b', 'n', 'q', 'i', 'u', 'x', 't', 'd', 'h', 's', 'o', 'g', 'v':
+		return 1, nil
+	case 'a':
+		n, err := typeLength(sig[1:])
+		return n + 1, err
+	case '(', '{':
+		closing := byte(')')
+		if sig[0] == '{' {
+			closing = '}'
+		}
+		i := 1
+		for i < len(sig) && sig[i] != closing {
+			n, err := typeLength(sig[i:])
+			if err != nil {
+				return 0, err
+			}
+			i += n
+		}
+		if i >= len(sig) {
+			return 0, fmt.Errorf("dbus: unterminated signature %q", sig)
+		}
+		return i + 1, nil
+	default:
+		return 0, fmt.Errorf("dbus: invalid signature %q", sig)
+	}
+}
+
+// alignment returns the alignment of the type starting sig
+func alignment(sig string) int {
+	switch sig[0] {
+	case 'y', 'g', 'v':
+		return 1
+	case 'n', 'q':
+		return 2
+	case 'x', 't', 'd', '(', '{':
+		return 8
+	default:
+		return 4
+	}
+}
+
+// encoder marshals values in little-endian byte order
+type encoder struct {
+	buf []byte
+}
+
+// align pads the buffer to a multiple of n
+func (e *encoder) align(n int) {
+	for len(e.buf)%n != 0 {
+		e.buf = append(e.buf, 0)
+	}
+}
+
+// encode marshals one value of a complete type
+func (e *encoder) encode(sig string, value any) error {
+	e.align(alignment(sig))
+	le := binary.LittleEndian
+	rv := reflect.ValueOf(value)
+
+	switch sig[0] {
+	case 'y':
+		if !isInteger(rv) {
+			return typeError(sig, value)
+		}
+		e.buf = append(e.buf, byte(integer(rv)))
+	case 'b':
+		b, ok := value.(bool)
+		if !ok {
+			return typeError(sig, value)
+		}
+		v := uint32(0)
+		if b {
+			v = 1
+		}
+		e.buf = le.AppendUint32(e.buf, v)
+	case 'n', 'q':
+		if !isInteger(rv) {
+			return typeError(sig, value)
+		}
+		e.buf = le.AppendUint16(e.buf, uint16(integer(rv)))
+	case 'i', 'u', 'h':
+		if !isInteger(rv) {
+			return typeError(sig, value)
+		}
+		e.buf = le.AppendUint32(e.buf, uint32(integer(rv)))
+	case 'x', 't':
+		if !isInteger(rv) {
+			return typeError(sig, value)
+		}
+		e.buf = le.AppendUint64(e.buf, uint64(integer(rv)))
+	case 'd':
+		if rv.Kind() != reflect.Float64 && rv.Kind() != reflect.Float32 {
+			return typeError(sig, value)
+		}
+		e.buf = le.AppendUint64(e.buf, math.Float64bits(rv.Float()))
+	case 's', 'o':
+		if rv.Kind() != reflect.String {
+			return typeError(sig, value)
+		}
+		e.buf = le.AppendUint32(e.buf, uint32(rv.Len()))
+		e.buf = append(e.buf, rv.String()...)
+		e.buf = append(e.buf, 0)
+	case 'g':
+		if rv.Kind() != reflect.String || rv.Len() > 255 {
+			return typeError(sig, value)
+		}
+		e.buf = append(e.buf, byte(rv.Len()))
+		e.buf = append(e.buf, rv.String()...)
+		e.buf = append(e.buf, 0)
+	case 'v':
+		v, ok := value.(variant)
+		if !ok {
+			return typeError(sig, value)
+		}
+		if err := e.encode("g", v.sig); err != nil {
+			return err
+		}
+		return e.encode(v.sig, v.value)
+	case 'a':
+		return e.encodeArray(sig[1:], rv)
+	case '(':
+		fields, ok := value.([]any)
+		if !ok {
+			return typeError(sig, value)
+		}
+		types, err := splitSignature(sig[1 : len(sig)-1])
+		if err != nil {
+			return err
+		}
+		if len(types) != len(fields) {
+			return typeError(sig, value)
+		}
+		for i, t := range types {
+			if err := e.encode(t, fields[i]); err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("dbus: cannot encode signature %q", sig)
+	}
+	return nil
+}
+
+// encodeArray marshals a slice, or a map for an array of dict entries
+func (e *encoder) encodeArray(elem string, rv reflect.Value) error {
+	e.buf = binary.LittleEndian.AppendUint32(e.buf, 0)
+	lengthAt := len(e.buf) - 4
+	e.align(alignment(elem))
+	start := len(e.buf)
+
+	if elem[0] == '{' {
+		if rv.Kind() != reflect.Map {
+			return typeError("a"+elem, rv.Interface())
+		}
+		types, err := splitSignature(elem[1 : len(elem)-1])
+		if err != nil {
+			return err
+		}
+		if len(types) != 2 {
+			return fmt.Errorf("dbus: invalid dict entry %q", elem)
+		}
+		// Sort keys so output is deterministic
+		keys := rv.MapKeys()
+		sort.Slice(keys, func(i, j int) bool {
+			return fmt.Sprint(keys[i].Interface()) < fmt.Sprint(keys[j].Interface())
+		})
+		for _, key := range keys {
+			e.align(8)
+			if err := e.encode(types[0], key.Interface()); err != nil {
+				return err
+			}
+			if err := e.encode(types[1], rv.MapIndex(key).Interface()); err != nil {
+				return err
+			}
+		}
+	} else {
+		if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+			return typeError("a"+elem, rv.Interface())
+		}
+		for i := 0; i < rv.Len(); i++ {
+			if err := e.encode(elem, rv.Index(i).Interface()); err != nil {
+				return err
+			}
+		}
+	}
+
+	binary.LittleEndian.PutUint32(e.buf[lengthAt:], uint32(len(e.buf)-start))
+	return nil
+}
+
+// isInteger reports whether rv holds an integer of any size
+func isInteger(rv reflect.Value) bool {
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return true
+	}
+	return false
+}
+
+// integer returns an integer value's bits
+func integer(rv reflect.Value) int64 {
+	switch rv.Kind() {
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return int64(rv.Uint())
+	default:
+		return rv.Int()
+	}
+}
+
+// typeError reports a value that does not match its signature
+func typeError(sig string, value any) error {
+	return fmt.Errorf("dbus: cannot encode %T as %q", value, sig)
+}
+
+// decoder unmarshals values; positions are relative to the start of the
+// message header or body, which share the same alignment
+type decoder struct {
+	buf   []byte
+	pos   int
+	order binary.ByteOrder
+}
+
+// align skips padding to a multiple of n
+func (d *decoder) align(n int) error {
+	pos := (d.pos + n - 1) / n * n
+	if pos > len(d.buf) {
+		return io.ErrUnexpectedEOF
+	}
+	d.pos = pos
+	return nil
+}
+
+// take returns the next n bytes
+func (d *decoder) take(n int) ([]byte, error) {
+	if n < 0 || d.pos+n > len(d.buf) {
+		return nil, io.ErrUnexpectedEOF
+	}
+	b := d.buf[d.pos : d.pos+n]
+	d.pos += n
+	return b, nil
+}
+
+// decode unmarshals one value of a complete type. Arrays of dict entries
+// decode to map[any]any, byte arrays to []byte, other arrays and structs to
+// []any, object paths to objectPath and variants to variant.
+func (d *decoder) decode(sig string) (any, error) {
+	if err := d.align(alignment(sig)); err != nil {
+		return nil, err
+	}
+
+	switch sig[0] {
+	case 'y':
+		b, err := d.take(1)
+		if err != nil {
+			return nil, err
+		}
+		return b[0], nil
+	case 'b':
+		b, err := d.take(4)
+		if err != nil {
+			return nil, err
+		}
+		return d.order.Uint32(b) != 0, nil
+	case 'n':
+		b, err := d.take(2)
+		if err != nil {
+			return nil, err
+		}
+		return int16(d.order.Uint16(b)), nil
+	case 'q':
+		b, err := d.take(2)
+		if err != nil {
+			return nil, err
+		}
+		return d.order.Uint16(b), nil
+	case 'i':
+		b, err := d.take(4)
+		if err != nil {
+			return nil, err
+		}
+		return int32(d.order.Uint32(b)), nil
+	case 'u', 'h':
+		b, err := d.take(4)
+		if err != nil {
+			return nil, err
+		}
+		return d.order.Uint32(b), nil
+	case 'x':
+		b, err := d.take(8)
+		if err != nil {
+			return nil, err
+		}
+		return int64(d.order.Uint64(b)), nil
+	case 't':
+		b, err := d.take(8)
+		if err != nil {
+			return nil, err
+		}
+		return d.order.Uint64(b), nil
+	case 'd':
+		b, err := d.take(8)
+		if err != nil {
+			return nil, err
+		}
+		return math.Float64frombits(d.order.Uint64(b)), nil
+	case 's', 'o':
+		b, err := d.take(4)
+		if err != nil {
+			return nil, err
+		}
+		s, err := d.take(int(d.order.Uint32(b)) + 1)
+		if err != nil {
+			return nil, err
+		}
+		if sig[0] == 'o' {
+			return objectPath(s[:len(s)-1]), nil
+		}
+		return string(s[:len(s)-1]), nil
+	case 'g':
+		n, err := d.take(1)
+		if err != nil {
+			return nil, err
+		}
+		s, err := d.take(int(n[0]) + 1)
+		if err != nil {
+			return nil, err
+		}
+		return string(s[:len(s)-1]), nil
+	case 'v':
+		inner, err := d.decode("g")
+		if err != nil {
+			return nil, err
+		}
+		innerSig := inner.(string)
+		if n, err := typeLength(innerSig); err != nil || n != len(innerSig) {
+			return nil, fmt.Errorf("invalid variant signature %q", innerSig)
+		}
+		value, err := d.decode(innerSig)
+		if err != nil {
+			return nil, err
+		}
+		return variant{innerSig, value}, nil
+	case 'a':
+		return d.decodeArray(sig[1:])
+	case '(':
+		types, err := splitSignature(sig[1 : len(sig)-1])
+		if err != nil {
+			return nil, err
+		}
+		fields := make([]any, 0, len(types))
+		for _, t := range types {
+			value, err := d.decode(t)
+			if err != nil {
+				return nil, err
+			}
+			fields = append(fields, value)
+		}
+		return fields, nil
+	default:
+		return nil, fmt.Errorf("cannot decode signature %q", sig)
+	}
+}
+
+// decodeArray unmarshals an array whose element type is elem
+func (d *decoder) decodeArray(elem string) (any, error) {
+	b, err := d.take(4)
+	if err != nil {
+		return nil, err
+	}
+	length := int(d.order.Uint32(b))
+	if err := d.align(alignment(elem)); err != nil {
+		return nil, err
+	}
+	end := d.pos + length
+	if length < 0 || end > len(d.buf) {
+		return nil, io.ErrUnexpectedEOF
+	}
+
+	if elem == "y" {
+		data, _ := d.take(length)
+		return append([]byte(nil), data...), nil
+	}
+
+	if elem[0] == '{' {
+		types, err := splitSignature(elem[1 : len(elem)-1])
+		if err != nil {
+			return nil, err
+		}
+		if len(types) != 2 || strings.ContainsAny(types[0][:1], "av({") {
+			return nil, fmt.Errorf("invalid dict entry %q", elem)
+		}
+		entries := make(map[any]any)
+		for d.pos < end {
+			if err := d.align(8); err != nil {
+				return nil, err
+			}
+			key, err := d.decode(types[0])
+			if err != nil {
+				return nil, err
+			}
+			value, err := d.decode(types[1])
+			if err != nil {
+				return nil, err
+			}
+			entries[key] = value
+		}
+		return entries, nil
+	}
+
+	values := make([]any, 0)
+	for d.pos < end {
+		value, err := d.decode(elem)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+	}
+	return values, nil
+}
@@ -0,0 +1,339 @@
+package bluetooth
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestEncodeDecodeRoundTrip(t *testing.T) {
+	tests := []struct {
+		name  string
+		sig   string
+		value any
+		want  any // Decoded form: dicts as map[any]any, other containers as []any
+	}{
+		{
+			name: "device properties",
+			sig:  "a{sv}",
+			value: map[string]variant{
+				"Address":   {"s", "AA:BB:CC:DD:EE:FF"},
+				"RSSI":      {"n", int16(-67)},
+				"Connected": {"b", true},
+				"UUIDs":     {"as", []string{"0000180f-0000-1000-8000-00805f9b34fb"}},
+			},
+			want: map[any]any{
+				"Address":   variant{"s", "AA:BB:CC:DD:EE:FF"},
+				"RSSI":      variant{"n", int16(-67)},
+				"Connected": variant{"b", true},
+				"UUIDs":     variant{"as", []any{"0000180f-0000-1000-8000-00805f9b34fb"}},
+			},
+		},
+		{
+			name: "manufacturer data",
+			sig:  "a{qv}",
+			value: map[uint16]variant{
+				0x004c: {"ay", []byte{0x02, 0x15, 0xaa}},
+				0x0006: {"ay", []byte{}},
+			},
+			want: map[any]any{
+				uint16(0x004c): variant{"ay", []byte{0x02, 0x15, 0xaa}},
+				uint16(0x0006): variant{"ay", []byte(nil)},
+			},
+		},
+		{
+			name: "managed objects",
+			sig:  "a{oa{sa{sv}}}",
+			value: map[objectPath]map[string]map[string]variant{
+				"/org/bluez/hci0": {
+					"org.bluez.Adapter1": {"Powered": {"b", true}},
+				},
+				"/org/bluez/hci0/dev_AA_BB_CC_DD_EE_FF": {
+					"org.bluez.Device1": {
+						"Address": {"s", "AA:BB:CC:DD:EE:FF"},
+						"TxPower": {"n", int16(-4)},
+						"ManufacturerData": {"a{qv}", map[uint16]variant{
+							0x0075: {"ay", []byte{1, 2, 3}},
+						}},
+					},
+					"org.freedesktop.DBus.Properties": {},
+				},
+			},
+			want: map[any]any{
+				objectPath("/org/bluez/hci0"): map[any]any{
+					"org.bluez.Adapter1": map[any]any{"Powered": variant{"b", true}},
+				},
+				objectPath("/org/bluez/hci0/dev_AA_BB_CC_DD_EE_FF"): map[any]any{
+					"org.bluez.Device1": map[any]any{
+						"Address": variant{"s", "AA:BB:CC:DD:EE:FF"},
+						"TxPower": variant{"n", int16(-4)},
+						"ManufacturerData": variant{"a{qv}", map[any]any{
+							uint16(0x0075): variant{"ay", []byte{1, 2, 3}},
+						}},
+					},
+					"org.freedesktop.DBus.Properties": map[any]any{},
+				},
+			},
+		},
+		{
+			name:  "struct of every basic type",
+			sig:   "(ybnqiuxtdsog)",
+			value: []any{byte(7), false, int16(-2), uint16(3), int32(-4), uint32(5), int64(-6), uint64(7), 0.5, "s", objectPath("/o"), "a{sv}"},
+			want:  []any{byte(7), false, int16(-2), uint16(3), int32(-4), uint32(5), int64(-6), uint64(7), 0.5, "s", objectPath("/o"), "a{sv}"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Start unaligned so every container has to pad
+			e := &encoder{}
+			e.encode("y", byte(1))
+			if err := e.encode(tt.sig, tt.value); err != nil {
+				t.Fatalf("encode: %v", err)
+			}
+
+			d := &decoder{buf: e.buf, pos: 1, order: binary.LittleEndian}
+			got, err := d.decode(tt.sig)
+			if err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			if d.pos != len(e.buf) {
+				t.Errorf("decoded %d of %d bytes", d.pos, len(e.buf))
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got  %#v\nwant %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEncodeAlignment(t *testing.T) {
+	tests := []struct {
+		name   string
+		encode func(e *encoder) error
+		want   string // Hex
+	}{
+		{
+			name: "uint32 after byte",
+			encode: func(e *encoder) error {
+				e.encode("y", byte(1))
+				return e.encode("u", uint32(2))
+			},
+			want: "01000000" + "02000000",
+		},
+		{
+			name: "int16 after byte",
+			encode: func(e *encoder) error {
+				e.encode("y", byte(1))
+				return e.encode("n", int16(-1))
+			},
+			want: "0100" + "ffff",
+		},
+		{
+			name: "struct starts on 8 bytes",
+			encode: func(e *encoder) error {
+				e.encode("y", byte(1))
+				return e.encode("(yt)", []any{byte(2), uint64(3)})
+			},
+			want: "0100000000000000" + "0200000000000000" + "0300000000000000",
+		},
+		{
+			name: "empty array still pads to its element",
+			encode: func(e *encoder) error {
+				return e.encode("at", []uint64{})
+			},
+			want: "00000000" + "00000000",
+		},
+		{
+			name: "array length excludes the padding before the first element",
+			encode: func(e *encoder) error {
+				return e.encode("a{sv}", map[string]variant{"a": {"y", byte(7)}})
+			},
+			// Length 10, pad to 8, "a" (len 1, NUL), variant signature "y", value 7
+			want: "0a000000" + "00000000" + "01000000" + "6100" + "017900" + "07",
+		},
+		{
+			name: "dict entries each start on 8 bytes",
+			encode: func(e *encoder) error {
+				return e.encode("a{qy}", map[uint16]byte{1: 0xaa, 2: 0xbb})
+			},
+			want: "0b000000" + "00000000" + "0100" + "aa" + "0000000000" + "0200" + "bb",
+		},
+		{
+			name: "variant value aligns after its signature",
+			encode: func(e *encoder) error {
+				return e.encode("v", variant{"u", uint32(9)})
+			},
+			want: "017500" + "00" + "09000000",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := &encoder{}
+			if err := tt.encode(e); err != nil {
+				t.Fatalf("encode: %v", err)
+			}
+			if got := hex.EncodeToString(e.buf); got != tt.want {
+				t.Errorf("got  %s\nwant %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecodeTruncated(t *testing.T) {
+	e := &encoder{}
+	value := map[string]variant{"Name": {"s", "Keyboard"}, "RSSI": {"n", int16(-50)}}
+	if err := e.encode("a{sv}", value); err != nil {
+		t.Fatalf("encode: %v", err)
+	}
+
+	for n := 0; n < len(e.buf); n++ {
+		d := &decoder{buf: e.buf[:n], order: binary.LittleEndian}
+		if _, err := d.decode("a{sv}"); err == nil {
+			t.Errorf("decoding %d of %d bytes succeeded", n, len(e.buf))
+		}
+	}
+}
+
+func TestMessageRoundTrip(t *testing.T) {
+	msg := &message{
+		kind:   msgSignal,
+		serial: 42,
+		path:   "/org/bluez/hci0/dev_AA_BB_CC_DD_EE_FF",
+		iface:  "org.freedesktop.DBus.Properties",
+		member: "PropertiesChanged",
+		sender: ":1.7",
+		sig:    "sa{sv}as",
+		body: []any{
+			"org.bluez.Device1",
+			map[string]variant{"RSSI": {"n", int16(-58)}},
+			[]string{"TxPower"},
+		},
+	}
+	data, err := msg.marshal()
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+
+	// The body starts on a multiple of 8 after the header fields
+	fieldsLen := int(binary.LittleEndian.Uint32(data[12:]))
+	bodyStart := (16 + fieldsLen + 7) &^ 7
+	if bodyLen := int(binary.LittleEndian.Uint32(data[4:])); bodyStart+bodyLen != len(data) {
+		t.Errorf("header says %d body bytes at %d, message is %d bytes", bodyLen, bodyStart, len(data))
+	}
+	for _, b := range data[16+fieldsLen : bodyStart] {
+		if b != 0 {
+			t.Errorf("header padding is not zero: % x", data[16+fieldsLen:bodyStart])
+			break
+		}
+	}
+
+	got, err := readMessage(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("readMessage: %v", err)
+	}
+	want := &message{
+		kind:   msgSignal,
+		serial: 42,
+		path:   msg.path,
+		iface:  msg.iface,
+		member: msg.member,
+		sender: msg.sender,
+		sig:    msg.sig,
+		body: []any{
+			"org.bluez.Device1",
+			map[any]any{"RSSI": variant{"n", int16(-58)}},
+			[]any{"TxPower"},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got  %+v\nwant %+v", got, want)
+	}
+}
+
+func TestReadMessageBigEndian(t *testing.T) {
+	// A method return with reply serial 3 and a uint32 body of 42
+	data := mustHex(t, ""+
+		"42020001"+"00000004"+"00000007"+"0000000f"+ // Fixed header, fields length 15
+		"05017500"+"00000003"+ // (REPLY_SERIAL, <u> 3)
+		"08016700"+"01750000"+ // (SIGNATURE, <g> "u"), padded to 8
+		"0000002a") // Body
+
+	msg, err := readMessage(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("readMessage: %v", err)
+	}
+	if msg.kind != msgMethodReturn || msg.serial != 7 || msg.replySerial != 3 || msg.sig != "u" {
+		t.Errorf("got %+v", msg)
+	}
+	if !reflect.DeepEqual(msg.body, []any{uint32(42)}) {
+		t.Errorf("body = %v, want [42]", msg.body)
+	}
+}
+
+func TestReadMessageRejects(t *testing.T) {
+	valid, err := (&message{kind: msgMethodReturn, serial: 1, replySerial: 1}).marshal()
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		modify func(data []byte) []byte
+		want   string
+	}{
+		{"byte order", func(data []byte) []byte { data[0] = 'x'; return data }, "byte order"},
+		{"protocol version 0", func(data []byte) []byte { data[3] = 0; return data }, "protocol version 0"},
+		{"protocol version 2", func(data []byte) []byte { data[3] = 2; return data }, "protocol version 2"},
+		{"oversized body", func(data []byte) []byte { binary.LittleEndian.PutUint32(data[4:], maxMessageSize+1); return data }, "too large"},
+		{"truncated", func(data []byte) []byte { return data[:len(data)-1] }, "EOF"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := tt.modify(append([]byte(nil), valid...))
+			_, err := readMessage(bytes.NewReader(data))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("error = %v, want one mentioning %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestSplitSignature(t *testing.T) {
+	tests := []struct {
+		sig     string
+		want    []string
+		wantErr bool
+	}{
+		{"", []string{}, false},
+		{"sa{sv}as", []string{"s", "a{sv}", "as"}, false},
+		{"oa{sa{sv}}", []string{"o", "a{sa{sv}}"}, false},
+		{"(ii)y", []string{"(ii)", "y"}, false},
+		{"a{sv", nil, true},
+		{"a", nil, true},
+		{"z", nil, true},
+	}
+	for _, tt := range tests {
+		got, err := splitSignature(tt.sig)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("splitSignature(%q) error = %v, want error %v", tt.sig, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("splitSignature(%q) = %q, want %q", tt.sig, got, tt.want)
+		}
+	}
+}
+
+func mustHex(t *testing.T, s string) []byte {
+	t.Helper()
+	data, err := hex.DecodeString(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
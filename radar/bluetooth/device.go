@@ -0,0 +1,260 @@
+// Package bluetooth reports nearby Bluetooth Low Energy devices. On Linux it
+// drives BlueZ discovery over the system D-Bus.
+package bluetooth
+
+import (
+	"encoding/hex"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/e6a5/radar/radar/estimation"
+	"github.com/e6a5/radar/radar/model"
+	"github.com/e6a5/radar/radar/scanner"
+	"github.com/e6a5/radar/radar/wifi"
+)
+
+// bluetoothFrequency is the middle of the 2.4 GHz band BLE advertises in, MHz
+const bluetoothFrequency = 2440
+
+// Device describes one Bluetooth device as reported by the backend
+type Device struct {
+	Address          string
+	AddressType      string // "public" or "random"
+	Name             string
+	Alias            string
+	RSSI             int // dBm
+	HasRSSI          bool
+	TxPower          int // dBm
+	HasTxPower       bool
+	ManufacturerData map[uint16][]byte // Company identifier -> data
+	ServiceData      map[string][]byte // Service UUID -> data
+	UUIDs            []string
+	Connected        bool
+	Paired           bool
+	LastSeen         time.Time // Last RSSI update
+}
+
+// companyNames maps Bluetooth SIG company identifiers of common vendors
+var companyNames = map[uint16]string{
+	0x0002: "Intel",
+	0x0006: "Microsoft",
+	0x000F: "Broadcom",
+	0x001D: "Qualcomm",
+	0x004C: "Apple",
+	0x0059: "Nordic Semiconductor",
+	0x0075: "Samsung",
+	0x0087: "Garmin",
+	0x00E0: "Google",
+	0x02E5: "Espressif",
+	0x0499: "Ruuvi Innovations",
+}
+
+// Vendor returns the manufacturer named by the device's manufacturer data,
+// or by the OUI of a public address
+func (d *Device) Vendor() string {
+	for _, id := range d.companyIDs() {
+		if name, ok := companyNames[id]; ok {
+			return name
+		}
+	}
+	if d.AddressType == "public" {
+		if mac, err := net.ParseMAC(d.Address); err == nil {
+			return wifi.LookupVendor(mac)
+		}
+	}
+	return ""
+}
+
+// DisplayName returns the advertised name, or a vendor-based fallback
+func (d *Device) DisplayName() string {
+	if d.Name != "" {
+		return d.Name
+	}
+	// BlueZ sets the alias to the dashed address when there is no name
+	if d.Alias != "" && d.Alias != strings.ReplaceAll(d.Address, ":", "-") {
+		return d.Alias
+	}
+	suffix := d.Address
+	if len(suffix) > 5 {
+		suffix = suffix[len(suffix)-5:]
+	}
+	if vendor := d.Vendor(); vendor != "" {
+		return vendor + " " + suffix
+	}
+	return "BLE " + suffix
+}
+
+// companyIDs returns the manufacturer data company identifiers in order
+func (d *Device) companyIDs() []uint16 {
+	ids := make([]uint16, 0, len(d.ManufacturerData))
+	for id := range d.ManufacturerData {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
+
+// applyProperties updates the device from org.bluez.Device1 properties
+func (d *Device) applyProperties(props map[any]any, now time.Time) {
+	for key, value := range props {
+		name, _ := key.(string)
+		v, ok := value.(variant)
+		if !ok {
+			continue
+		}
+
+		switch name {
+		case "Address":
+			d.Address, _ = v.value.(string)
+		case "AddressType":
+			d.AddressType, _ = v.value.(string)
+		case "Name":
+			d.Name, _ = v.value.(string)
+		case "Alias":
+			d.Alias, _ = v.value.(string)
+		case "RSSI":
+			if rssi, ok := v.value.(int16); ok {
+				d.RSSI = int(rssi)
+				d.HasRSSI = true
+				d.LastSeen = now
+			}
+		case "TxPower":
+			if power, ok := v.value.(int16); ok {
+				d.TxPower = int(power)
+				d.HasTxPower = true
+			}
+		case "ManufacturerData":
+			entries, _ := v.value.(map[any]any)
+			d.ManufacturerData = make(map[uint16][]byte, len(entries))
+			for id, data := range entries {
+				company, _ := id.(uint16)
+				d.ManufacturerData[company] = variantBytes(data)
+			}
+		case "ServiceData":
+			entries, _ := v.value.(map[any]any)
+			d.ServiceData = make(map[string][]byte, len(entries))
+			for uuid, data := range entries {
+				service, _ := uuid.(string)
+				d.ServiceData[service] = variantBytes(data)
+			}
+		case "UUIDs":
+			values, _ := v.value.([]any)
+			d.UUIDs = d.UUIDs[:0]
+			for _, uuid := range values {
+				if s, ok := uuid.(string); ok {
+					d.UUIDs = append(d.UUIDs, s)
+				}
+			}
+		case "Connected":
+			d.Connected, _ = v.value.(bool)
+		case "Paired":
+			d.Paired, _ = v.value.(bool)
+		}
+	}
+}
+
+// invalidate clears properties that BlueZ reports as no longer valid
+func (d *Device) invalidate(names []any) {
+	for _, name := range names {
+		switch name {
+		case "RSSI":
+			d.HasRSSI = false
+		case "TxPower":
+			d.HasTxPower = false
+		}
+	}
+}
+
+// variantBytes returns the byte array held by a variant
+func variantBytes(value any) []byte {
+	if v, ok := value.(variant); ok {
+		value = v.value
+	}
+	data, _ := value.([]byte)
+	return data
+}
+
+// deviceSignal converts a device into a radar signal
+func deviceSignal(d *Device, config *scanner.Config, now time.Time) model.Signal {
+	strength := rssiToStrength(d.RSSI)
+	severity := model.SeverityNormal
+	if d.Connected {
+		severity = model.SeverityActive
+	}
+	// BlueZ keeps reporting the last RSSI, so readings are timed by when it changed
+	estimate := config.Estimate("bt:"+d.Address, d.RSSI, d.source(), d.LastSeen)
+
+	signal := model.Signal{
+		ID:           "bt:" + d.Address,
+		Type:         "Bluetooth",
+		Icon:         "β",
+		Name:         d.DisplayName(),
+		Category:     model.CategoryBluetooth,
+		Severity:     severity,
+		Strength:     strength,
+		Distance:     estimate.Distance,
+		DistanceLow:  estimate.Low,
+		DistanceHigh: estimate.High,
+		Angle:        config.Bearing("Bluetooth", "bt:"+d.Address),
+		Phase:        0,
+		Lifetime:     now,
+		LastSeen:     now,
+		Persistence:  1.0,
+		History:      make([]model.PositionHistory, 0, 20),
+		MaxHistory:   20,
+		Attributes:   deviceAttributes(d),
+	}
+
+	signal.AddToHistory(signal.Distance, signal.Angle, signal.Strength, true, now)
+	return signal
+}
+
+// deviceAttributes collects the per-device details shown alongside the signal
+func deviceAttributes(d *Device) model.Attributes {
+	attrs := model.Attributes{}
+	attrs.Set(model.AttrMAC, d.Address)
+	attrs.Set(model.AttrAddressType, d.AddressType)
+	attrs.Set(model.AttrVendor, d.Vendor())
+	attrs.SetInt(model.AttrRSSI, d.RSSI)
+	if d.HasTxPower {
+		attrs.SetInt(model.AttrTxPower, d.TxPower)
+	}
+	attrs.SetBool(model.AttrConnected, d.Connected)
+	attrs.SetBool(model.AttrPaired, d.Paired)
+	if len(d.UUIDs) > 0 {
+		attrs.Set(model.AttrServices, strings.Join(d.UUIDs, ", "))
+	}
+
+	data := make([]string, 0, len(d.ManufacturerData))
+	for _, id := range d.companyIDs() {
+		data = append(data, fmt.Sprintf("%04x:%s", id, hex.EncodeToString(d.ManufacturerData[id])))
+	}
+	attrs.Set(model.AttrManufacturer, strings.Join(data, " "))
+	return attrs
+}
+
+// rssiToStrength converts a Bluetooth RSSI to a percentage. BLE signals are
+// weaker than WiFi, so the scale runs from -100 to -40 dBm.
+func rssiToStrength(rssi int) int {
+	if rssi >= -40 {
+		return 100
+	}
+	if rssi <= -100 {
+		return 0
+	}
+	return int(100 * (float64(rssi+100) / 60.0))
+}
+
+// source describes the device's transmitter for distance estimation. The
+// reference power at 1 m is derived from the advertised TX power when known
+// (about 40 dB lower at 2.4 GHz), otherwise a typical -59 dBm.
+func (d *Device) source() estimation.Source {
+	reference := estimation.DefaultBluetoothReference
+	if d.HasTxPower {
+		reference = estimation.ReferenceFromTxPower(float64(d.TxPower), bluetoothFrequency)
+	}
+	return estimation.Source{Frequency: bluetoothFrequency, ReferencePower: reference}
+}
@@ -0,0 +1,38 @@
+//go:build !linux
+// +build !linux
+
+package bluetooth
+
+import (
+	"context"
+
+	"github.com/e6a5/radar/radar/model"
+	"github.com/e6a5/radar/radar/scanner"
+)
+
+// StubBluetoothScanner is a no-op scanner for platforms without BlueZ
+type StubBluetoothScanner struct {
+	config *scanner.Config
+}
+
+// NewStubBluetoothScanner creates a new stub Bluetooth scanner
+func NewStubBluetoothScanner(config *scanner.Config) *StubBluetoothScanner {
+	return &StubBluetoothScanner{
+		config: config,
+	}
+}
+
+// Name returns the scanner name
+func (s *StubBluetoothScanner) Name() string {
+	return "Stub Bluetooth Scanner"
+}
+
+// IsAvailable returns false for unsupported platforms
+func (s *StubBluetoothScanner) IsAvailable() bool {
+	return false
+}
+
+// Scan returns empty signals for unsupported platforms
+func (s *StubBluetoothScanner) Scan(ctx context.Context) ([]model.Signal, error) {
+	return []model.Signal{}, nil
+}
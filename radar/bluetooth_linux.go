@@ -0,0 +1,14 @@
+//go:build linux
+// +build linux
+
+package radar
+
+import (
+	"github.com/e6a5/radar/radar/bluetooth"
+	"github.com/e6a5/radar/radar/scanner"
+)
+
+// createBluetoothScanner creates a BlueZ Bluetooth scanner for Linux
+func createBluetoothScanner(config *scanner.Config) scanner.Scanner {
+	return bluetooth.NewBlueZScanner(config)
+}
@@ -0,0 +1,14 @@
+//go:build !linux
+// +build !linux
+
+package radar
+
+import (
+	"github.com/e6a5/radar/radar/bluetooth"
+	"github.com/e6a5/radar/radar/scanner"
+)
+
+// createBluetoothScanner creates a stub Bluetooth scanner for platforms without BlueZ
+func createBluetoothScanner(config *scanner.Config) scanner.Scanner {
+	return bluetooth.NewStubBluetoothScanner(config)
+}
@@ -3,6 +3,9 @@ package radar
 import (
 	"math"
 	"time"
+
+	"github.com/e6a5/radar/radar/estimation"
+	"github.com/e6a5/radar/radar/guard"
 )
 
 type Config struct {
@@ -28,11 +31,32 @@ type Config struct {
 	// Signal name display configuration
 	ShowSignalNames  bool // Show signal names/identifiers on radar
 	ShowNamesOnHover bool // Show names only for strong signals or selected signals
+	ShowTracks       bool // Show track numbers, coasting tracks and predicted positions
+	// Scope scale
+	DisplayRange float64 // Distance at the scope's edge at zoom 1.0, meters
+	// Closest point of approach alerting
+	GuardRange float64 // Alert when a moving signal will pass within this distance of the center; 0 disables
+	// Guard zones and alarms
+	GuardZones []guard.Zone // Zones that raise alarms when signals enter, leave or linger in them
+	AlarmBell  bool         // Ring the terminal bell on alarms
+	AlarmFlash bool         // Flash the scope's border on alarms
+	AlarmLog   string       // File alarms are appended to; none if empty
+	AlarmHook  string       // Shell command run for every alarm with RADAR_* variables; none if empty
 	// Real data collection configuration
 	EnableRealData   bool    // Enable real device data collection
 	ScanInterval     float64 // How often to scan for real devices (seconds)
 	UseSimulatedData bool    // Fallback to simulated data if real data fails
 	MaxScanRange     float64 // Maximum simulated distance for real devices
+	BearingMode      string  // How real signals get bearings: "hash", "sector" or "pinned"
+	BearingPinFile   string  // File holding user-pinned bearings (pinned mode)
+	// Real signal tracking configuration
+	TrackTimeout time.Duration // Drop real signals not observed for this long (3 scans if zero)
+	Scanners     []string      // Scanners to run; all registered scanners if empty
+	ActiveSweep  bool          // Ping the local subnets to discover hosts and measure latency
+	// Distance estimation calibration
+	Environment      string  // Path-loss profile: "free-space", "outdoor", "home", "office" or "dense"
+	PathLossExponent float64 // Overrides the profile's path-loss exponent when nonzero
+	ReferencePower   float64 // RSSI 1 m from an access point on 2.4 GHz, dBm; the default if zero
 	// Performance optimization settings
 	EnableVSync          bool    // Enable vertical sync for smoother rendering
 	ReducedMotion        bool    // Reduce animations for better performance
@@ -47,6 +71,16 @@ type Config struct {
 	EnablePan  bool    // Enable pan functionality
 	PanX       float64 // Pan offset X
 	PanY       float64 // Pan offset Y
+	// Theme settings
+	Theme ThemeType // Color theme for the scope and panels
+	// Input settings
+	KeyBindings KeyBindings // Keys bound to display actions
+	// Export settings
+	ExportDir         string   // Directory the export key writes to; the working directory if empty
+	ExportFormats     []string // Formats written by the export key
+	ObserverLatitude  float64  // Observer position used to place signals on maps
+	ObserverLongitude float64
+	MetersPerUnit     float64 // Meters per scope distance unit in map exports
 }
 
 // Signal type filter state
@@ -57,7 +91,8 @@ type FilterState struct {
 	RadioVisible     bool
 	IoTVisible       bool
 	SatelliteVisible bool
-	AllVisible       bool // Quick toggle for all types
+	AllVisible       bool   // Quick toggle for all types
+	Process          string // Show only the connections of this process; all if empty
 }
 
 func NewConfig() Config {
@@ -81,10 +116,19 @@ func NewConfig() Config {
 		HistoryUpdateRate: 0.5, // Faster updates for smoother trails
 		ShowSignalNames:   false,
 		ShowNamesOnHover:  true,
+		ShowTracks:        true,
+		DisplayRange:      40.0,
+		GuardRange:        2.0,
+		AlarmBell:         true,
+		AlarmFlash:        true,
+		AlarmLog:          guard.DefaultLogPath(),
 		EnableRealData:    true,
 		ScanInterval:      8.0, // Faster scanning for more responsive updates
 		UseSimulatedData:  true,
 		MaxScanRange:      1000.0,
+		BearingMode:       "sector",
+		BearingPinFile:    "", // Defaults to ~/.radar_bearings.json
+		Environment:       estimation.DefaultEnvironment,
 		// Performance optimizations
 		EnableVSync:          true,
 		ReducedMotion:        false,
@@ -99,6 +143,13 @@ func NewConfig() Config {
 		EnablePan:  true,
 		PanX:       0.0,
 		PanY:       0.0,
+		// Theme
+		Theme: ThemeModernDark,
+		// Input
+		KeyBindings: DefaultKeyBindings(),
+		// Export
+		ExportFormats: []string{"csv", "geojson", "kml"},
+		MetersPerUnit: 1.0,
 	}
 }
 
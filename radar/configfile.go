@@ -0,0 +1,718 @@
+package radar
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/e6a5/radar/radar/estimation"
+	"github.com/e6a5/radar/radar/export"
+	"github.com/e6a5/radar/radar/guard"
+	"github.com/e6a5/radar/radar/model"
+	"github.com/e6a5/radar/radar/scanner"
+)
+
+// ConfigFile is the on-disk configuration. Every field is optional; fields
+// that are left out keep their built-in defaults.
+type ConfigFile struct {
+	Radar    RadarSettings     `json:"radar"`
+	Scanner  ScannerSettings   `json:"scanner"`
+	Scanners []string          `json:"scanners"` // Scanners to run; all registered scanners if empty
+	Filters  FilterSettings    `json:"filters"`
+	Theme    string            `json:"theme"`
+	Keys     map[string]string `json:"keys"` // Action name -> key
+	Export   ExportSettings    `json:"export"`
+	Zones    []ZoneSettings    `json:"guard_zones"` // Replace the guard zones when present, even if empty
+	Alarms   AlarmSettings     `json:"alarms"`
+}
+
+// RadarSettings configures the display
+type RadarSettings struct {
+	Speed             *float64  `json:"speed"` // Sweep speed multiplier
+	MaxSignals        *int      `json:"max_signals"`
+	RefreshRate       *Duration `json:"refresh_rate"`
+	SignalLifetime    *Duration `json:"signal_lifetime"`
+	Persistence       *Duration `json:"persistence"`
+	EnablePersistence *bool     `json:"enable_persistence"`
+	EnableRipples     *bool     `json:"enable_ripples"`
+	ShowTrails        *bool     `json:"show_trails"`
+	MaxTrailLength    *int      `json:"max_trail_length"`
+	ShowSignalNames   *bool     `json:"show_signal_names"`
+	ShowNamesOnHover  *bool     `json:"show_names_on_hover"`
+	ShowTracks        *bool     `json:"show_tracks"`
+	DisplayRange      *float64  `json:"display_range"`
+	GuardRange        *float64  `json:"guard_range"` // Closest approach alert distance; 0 disables
+	RealData          *bool     `json:"real_data"`
+	ReducedMotion     *bool     `json:"reduced_motion"`
+	AdaptiveRefresh   *bool     `json:"adaptive_refresh"`
+}
+
+// ScannerSettings configures real data collection
+type ScannerSettings struct {
+	ScanInterval     *Duration `json:"scan_interval"`
+	Range            *float64  `json:"range"`
+	TrackTimeout     *Duration `json:"track_timeout"`
+	BearingMode      *string   `json:"bearing_mode"`
+	BearingPinFile   *string   `json:"bearing_pin_file"`
+	Sweep            *bool     `json:"sweep"`       // Ping the local subnets for the lan scanner
+	Environment      *string   `json:"environment"` // Path-loss profile for RSSI distance estimates
+	PathLossExponent *float64  `json:"path_loss_exponent"`
+	ReferencePower   *float64  `json:"reference_power"` // RSSI 1 m from an access point, dBm
+}
+
+// FilterSettings sets which signal types are visible
+type FilterSettings struct {
+	Enabled   *bool   `json:"enabled"`
+	WiFi      *bool   `json:"wifi"`
+	Bluetooth *bool   `json:"bluetooth"`
+	Cellular  *bool   `json:"cellular"`
+	Radio     *bool   `json:"radio"`
+	IoT       *bool   `json:"iot"`
+	Satellite *bool   `json:"satellite"`
+	Process   *string `json:"process"` // Show only this process's connections
+}
+
+// ExportSettings configures the export key and map placement
+type ExportSettings struct {
+	Dir           *string  `json:"dir"`
+	Formats       []string `json:"formats"`
+	Latitude      *float64 `json:"latitude"`
+	Longitude     *float64 `json:"longitude"`
+	MetersPerUnit *float64 `json:"meters_per_unit"`
+}
+
+// ZoneSettings defines a guard zone: an annular sector between two ranges,
+// running clockwise from the start bearing to the end bearing
+type ZoneSettings struct {
+	Name         string    `json:"name"` // "Zone N" if empty
+	MinRange     float64   `json:"min_range"`
+	MaxRange     float64   `json:"max_range"`
+	StartBearing float64   `json:"start_bearing"` // Degrees
+	EndBearing   float64   `json:"end_bearing"`   // Degrees; equal to start_bearing for a full ring
+	Types        []string  `json:"types"`         // Signal categories watched, e.g. "bluetooth"; all if empty
+	Events       []string  `json:"events"`        // "enter", "leave" and "linger"; enter if empty
+	Linger       *Duration `json:"linger"`        // Time inside before a linger alarm; 30s if unset
+}
+
+// AlarmSettings chooses how guard zone alarms are announced
+type AlarmSettings struct {
+	Bell  *bool   `json:"bell"`
+	Flash *bool   `json:"flash"` // Flash the scope's border
+	Log   *string `json:"log"`   // File alarms are appended to; empty to disable
+	Hook  *string `json:"hook"`  // Shell command run for every alarm with RADAR_* variables
+}
+
+// Duration is a time.Duration written as a string such as "8s" or "250ms"
+type Duration time.Duration
+
+// UnmarshalJSON accepts a duration string or a number of seconds
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var text string
+	if err := json.Unmarshal(data, &text); err == nil {
+		parsed, err := time.ParseDuration(text)
+		if err != nil {
+			return fmt.Errorf("invalid duration %q: use a string such as \"8s\"", text)
+		}
+		*d = Duration(parsed)
+		return nil
+	}
+
+	var seconds float64
+	if err := json.Unmarshal(data, &seconds); err != nil {
+		return fmt.Errorf("invalid duration %s: use a string such as \"8s\"", data)
+	}
+	*d = Duration(seconds * float64(time.Second))
+	return nil
+}
+
+// MarshalJSON writes the duration as a string
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+// DefaultConfigPath returns the default location of the config file
+func DefaultConfigPath() string {
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		return "radar.json"
+	}
+	return filepath.Join(configDir, "radar", "config.json")
+}
+
+// ConfigSource builds the config from the built-in defaults, the config file
+// and then the overrides, so a reloaded file never loses command line flags
+type ConfigSource struct {
+	Path      string                     // Config file
+	Required  bool                       // Fail if the file does not exist rather than using the defaults
+	Overrides func(config *Config) error // Applied after the file, e.g. command line flags; may be nil
+}
+
+// Load reads the config file and builds the config and filters from it
+func (s ConfigSource) Load() (Config, FilterState, error) {
+	file, err := LoadConfigFile(s.Path)
+	if err != nil && (s.Required || !errors.Is(err, os.ErrNotExist)) {
+		return NewConfig(), NewFilterState(), err
+	}
+	return s.Build(file)
+}
+
+// Build builds the config and filters from an already loaded file, which may
+// be nil to use only the defaults and overrides
+func (s ConfigSource) Build(file *ConfigFile) (Config, FilterState, error) {
+	config := NewConfig()
+	filters := NewFilterState()
+	if file != nil {
+		file.Apply(&config, &filters)
+	}
+	if s.Overrides == nil {
+		return config, filters, nil
+	}
+	return config, filters, s.Overrides(&config)
+}
+
+// LoadConfigFile reads and validates a config file
+func LoadConfigFile(path string) (*ConfigFile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseConfigFile(path, data)
+}
+
+// ParseConfigFile decodes and validates config file contents; path is only
+// used in error messages
+func ParseConfigFile(path string, data []byte) (*ConfigFile, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+
+	var file ConfigFile
+	if err := decoder.Decode(&file); err != nil {
+		var syntaxErr *json.SyntaxError
+		if errors.As(err, &syntaxErr) {
+			line, col := lineColumn(data, syntaxErr.Offset)
+			return nil, fmt.Errorf("%s:%d:%d: %v", path, line, col, err)
+		}
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+
+	if err := file.Validate(); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return &file, nil
+}
+
+// Validate checks values that decode correctly but make no sense
+func (f *ConfigFile) Validate() error {
+	var errs []error
+	invalid := func(field, format string, args ...interface{}) {
+		errs = append(errs, fmt.Errorf("%s: %s", field, fmt.Sprintf(format, args...)))
+	}
+
+	r := f.Radar
+	if r.Speed != nil && *r.Speed <= 0 {
+		invalid("radar.speed", "must be positive, got %g", *r.Speed)
+	}
+	if r.MaxSignals != nil && *r.MaxSignals <= 0 {
+		invalid("radar.max_signals", "must be positive, got %d", *r.MaxSignals)
+	}
+	if r.RefreshRate != nil && time.Duration(*r.RefreshRate) < time.Millisecond {
+		invalid("radar.refresh_rate", "must be at least 1ms, got %s", time.Duration(*r.RefreshRate))
+	}
+	if r.SignalLifetime != nil && *r.SignalLifetime <= 0 {
+		invalid("radar.signal_lifetime", "must be positive, got %s", time.Duration(*r.SignalLifetime))
+	}
+	if r.Persistence != nil && *r.Persistence < 0 {
+		invalid("radar.persistence", "must not be negative, got %s", time.Duration(*r.Persistence))
+	}
+	if r.MaxTrailLength != nil && *r.MaxTrailLength < 0 {
+		invalid("radar.max_trail_length", "must not be negative, got %d", *r.MaxTrailLength)
+	}
+	if r.DisplayRange != nil && *r.DisplayRange <= 0 {
+		invalid("radar.display_range", "must be positive, got %g", *r.DisplayRange)
+	}
+	if r.GuardRange != nil && *r.GuardRange < 0 {
+		invalid("radar.guard_range", "must not be negative, got %g", *r.GuardRange)
+	}
+
+	s := f.Scanner
+	if s.ScanInterval != nil && time.Duration(*s.ScanInterval) < 100*time.Millisecond {
+		invalid("scanner.scan_interval", "must be at least 100ms, got %s", time.Duration(*s.ScanInterval))
+	}
+	if s.Range != nil && *s.Range <= 0 {
+		invalid("scanner.range", "must be positive, got %g", *s.Range)
+	}
+	if s.TrackTimeout != nil && *s.TrackTimeout < 0 {
+		invalid("scanner.track_timeout", "must not be negative, got %s", time.Duration(*s.TrackTimeout))
+	}
+	if s.BearingMode != nil {
+		switch *s.BearingMode {
+		case scanner.BearingHash, scanner.BearingSector, scanner.BearingPinned:
+		default:
+			invalid("scanner.bearing_mode", "unknown strategy %q (available: hash, sector, pinned)", *s.BearingMode)
+		}
+	}
+	if s.Environment != nil {
+		if _, ok := estimation.LookupEnvironment(*s.Environment); !ok {
+			invalid("scanner.environment", "unknown environment %q (available: %s)", *s.Environment, strings.Join(estimation.EnvironmentNames(), ", "))
+		}
+	}
+	if s.PathLossExponent != nil && (*s.PathLossExponent < 1 || *s.PathLossExponent > 6) {
+		invalid("scanner.path_loss_exponent", "must be between 1 and 6, got %g", *s.PathLossExponent)
+	}
+	if s.ReferencePower != nil && (*s.ReferencePower < -100 || *s.ReferencePower >= 0) {
+		invalid("scanner.reference_power", "must be between -100 and 0 dBm, got %g", *s.ReferencePower)
+	}
+
+	for _, name := range f.Scanners {
+		if !isRegisteredScanner(strings.ToLower(name)) {
+			invalid("scanners", "unknown scanner %q (available: %s)", name, strings.Join(scannerNames(), ", "))
+		}
+	}
+
+	if f.Theme != "" {
+		if _, err := ParseThemeType(f.Theme); err != nil {
+			invalid("theme", "%v", err)
+		}
+	}
+
+	// Every key must end up with one action: the file's keys, plus the
+	// default keys of the actions the file leaves alone
+	owners := make(map[rune]Action)
+	for key, action := range DefaultKeyBindings() {
+		if _, rebound := f.Keys[string(action)]; !rebound {
+			owners[unicode.ToLower(key)] = action
+		}
+	}
+	actions := make([]string, 0, len(f.Keys))
+	for action := range f.Keys {
+		actions = append(actions, action)
+	}
+	sort.Strings(actions)
+	for _, action := range actions {
+		name := f.Keys[action]
+		if !IsAction(action) {
+			invalid("keys", "unknown action %q", action)
+			continue
+		}
+		key, err := ParseKey(name)
+		if err != nil {
+			invalid("keys."+action, "%v", err)
+			continue
+		}
+		if owner, ok := owners[unicode.ToLower(key)]; ok && owner != Action(action) {
+			invalid("keys."+action, "key %q is already bound to %s", name, owner)
+			continue
+		}
+		owners[unicode.ToLower(key)] = Action(action)
+	}
+
+	e := f.Export
+	for _, name := range e.Formats {
+		if _, err := export.ParseFormat(name); err != nil {
+			invalid("export.formats", "%v", err)
+		}
+	}
+	if e.Latitude != nil && (*e.Latitude < -90 || *e.Latitude > 90) {
+		invalid("export.latitude", "must be between -90 and 90, got %g", *e.Latitude)
+	}
+	if e.Longitude != nil && (*e.Longitude < -180 || *e.Longitude > 180) {
+		invalid("export.longitude", "must be between -180 and 180, got %g", *e.Longitude)
+	}
+	if e.MetersPerUnit != nil && *e.MetersPerUnit <= 0 {
+		invalid("export.meters_per_unit", "must be positive, got %g", *e.MetersPerUnit)
+	}
+
+	names := make(map[string]bool, len(f.Zones))
+	for i, z := range f.Zones {
+		field := fmt.Sprintf("guard_zones[%d]", i)
+		if name := z.zoneName(i); names[name] {
+			invalid(field+".name", "duplicate zone name %q", name)
+		} else {
+			names[name] = true
+		}
+		if z.MinRange < 0 {
+			invalid(field+".min_range", "must not be negative, got %g", z.MinRange)
+		}
+		if z.MaxRange <= z.MinRange {
+			invalid(field+".max_range", "must be greater than min_range, got %g", z.MaxRange)
+		}
+		if z.StartBearing < 0 || z.StartBearing > 360 {
+			invalid(field+".start_bearing", "must be between 0 and 360 degrees, got %g", z.StartBearing)
+		}
+		if z.EndBearing < 0 || z.EndBearing > 360 {
+			invalid(field+".end_bearing", "must be between 0 and 360 degrees, got %g", z.EndBearing)
+		}
+		for _, name := range z.Types {
+			if c := model.ParseCategory(strings.ToLower(name)); c == model.CategoryUnknown {
+				invalid(field+".types", "unknown signal type %q", name)
+			}
+		}
+		for _, name := range z.Events {
+			if _, ok := guard.ParseEventKind(strings.ToLower(name)); !ok {
+				invalid(field+".events", "unknown event %q (available: enter, leave, linger)", name)
+			}
+		}
+		if z.Linger != nil && *z.Linger <= 0 {
+			invalid(field+".linger", "must be positive, got %s", time.Duration(*z.Linger))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// Apply overrides config and filters with the values set in the file
+func (f *ConfigFile) Apply(config *Config, filters *FilterState) {
+	defaults := NewConfig()
+
+	r := f.Radar
+	if r.Speed != nil {
+		config.RadarSpeed = defaults.RadarSpeed * *r.Speed
+	}
+	setInt(&config.MaxSignals, r.MaxSignals)
+	setDuration(&config.RefreshRate, r.RefreshRate)
+	setDuration(&config.SignalLifetime, r.SignalLifetime)
+	if r.Persistence != nil {
+		config.PersistenceTime = time.Duration(*r.Persistence).Seconds()
+	}
+	setBool(&config.EnablePersistence, r.EnablePersistence)
+	setBool(&config.EnableRipples, r.EnableRipples)
+	setBool(&config.ShowTrails, r.ShowTrails)
+	setInt(&config.MaxTrailLength, r.MaxTrailLength)
+	setBool(&config.ShowSignalNames, r.ShowSignalNames)
+	setBool(&config.ShowNamesOnHover, r.ShowNamesOnHover)
+	setBool(&config.ShowTracks, r.ShowTracks)
+	setFloat(&config.DisplayRange, r.DisplayRange)
+	setFloat(&config.GuardRange, r.GuardRange)
+	setBool(&config.EnableRealData, r.RealData)
+	setBool(&config.ReducedMotion, r.ReducedMotion)
+	setBool(&config.AdaptiveRefreshRate, r.AdaptiveRefresh)
+
+	s := f.Scanner
+	if s.ScanInterval != nil {
+		config.ScanInterval = time.Duration(*s.ScanInterval).Seconds()
+	}
+	if s.Range != nil {
+		config.MaxScanRange = *s.Range
+	}
+	setDuration(&config.TrackTimeout, s.TrackTimeout)
+	if s.BearingMode != nil {
+		config.BearingMode = *s.BearingMode
+	}
+	if s.BearingPinFile != nil {
+		config.BearingPinFile = *s.BearingPinFile
+	}
+	setBool(&config.ActiveSweep, s.Sweep)
+	if s.Environment != nil {
+		config.Environment = *s.Environment
+	}
+	setFloat(&config.PathLossExponent, s.PathLossExponent)
+	setFloat(&config.ReferencePower, s.ReferencePower)
+	if len(f.Scanners) > 0 {
+		config.Scanners = append([]string(nil), f.Scanners...)
+	}
+
+	if f.Theme != "" {
+		config.Theme, _ = ParseThemeType(f.Theme)
+	}
+
+	if len(f.Keys) > 0 {
+		config.KeyBindings = config.KeyBindings.Clone()
+		for action, name := range f.Keys {
+			if key, err := ParseKey(name); err == nil {
+				config.KeyBindings.Rebind(Action(action), key)
+			}
+		}
+	}
+
+	e := f.Export
+	if e.Dir != nil {
+		config.ExportDir = *e.Dir
+	}
+	if len(e.Formats) > 0 {
+		config.ExportFormats = append([]string(nil), e.Formats...)
+	}
+	setFloat(&config.ObserverLatitude, e.Latitude)
+	setFloat(&config.ObserverLongitude, e.Longitude)
+	setFloat(&config.MetersPerUnit, e.MetersPerUnit)
+
+	if f.Zones != nil {
+		config.GuardZones = make([]guard.Zone, len(f.Zones))
+		for i, z := range f.Zones {
+			config.GuardZones[i] = z.zone(i)
+		}
+	}
+	a := f.Alarms
+	setBool(&config.AlarmBell, a.Bell)
+	setBool(&config.AlarmFlash, a.Flash)
+	if a.Log != nil {
+		config.AlarmLog = *a.Log
+	}
+	if a.Hook != nil {
+		config.AlarmHook = *a.Hook
+	}
+
+	fs := f.Filters
+	setBool(&config.EnableFiltering, fs.Enabled)
+	setBool(&filters.WiFiVisible, fs.WiFi)
+	setBool(&filters.BluetoothVisible, fs.Bluetooth)
+	setBool(&filters.CellularVisible, fs.Cellular)
+	setBool(&filters.RadioVisible, fs.Radio)
+	setBool(&filters.IoTVisible, fs.IoT)
+	setBool(&filters.SatelliteVisible, fs.Satellite)
+	if fs.Process != nil {
+		filters.Process = *fs.Process
+	}
+	filters.AllVisible = filters.WiFiVisible && filters.BluetoothVisible &&
+		filters.CellularVisible && filters.RadioVisible &&
+		filters.IoTVisible && filters.SatelliteVisible
+}
+
+// zoneName returns the zone's name, numbering unnamed zones by position
+func (z ZoneSettings) zoneName(index int) string {
+	if z.Name != "" {
+		return z.Name
+	}
+	return fmt.Sprintf("Zone %d", index+1)
+}
+
+// zone converts the settings of the zone at index into a guard zone
+func (z ZoneSettings) zone(index int) guard.Zone {
+	zone := guard.Zone{
+		Name:         z.zoneName(index),
+		MinRange:     z.MinRange,
+		MaxRange:     z.MaxRange,
+		StartBearing: z.StartBearing * math.Pi / 180,
+		EndBearing:   z.EndBearing * math.Pi / 180,
+	}
+	for _, name := range z.Types {
+		zone.Categories = append(zone.Categories, model.ParseCategory(strings.ToLower(name)))
+	}
+
+	events := z.Events
+	if len(events) == 0 {
+		events = []string{"enter"}
+	}
+	for _, name := range events {
+		switch kind, _ := guard.ParseEventKind(strings.ToLower(name)); kind {
+		case guard.EventEnter:
+			zone.OnEnter = true
+		case guard.EventLeave:
+			zone.OnLeave = true
+		case guard.EventLinger:
+			zone.Linger = guard.DefaultLinger
+			if z.Linger != nil {
+				zone.Linger = time.Duration(*z.Linger)
+			}
+		}
+	}
+	return zone
+}
+
+// ConfigWatcher polls a config file and delivers each valid new version
+type ConfigWatcher struct {
+	path     string
+	interval time.Duration
+	updates  chan *ConfigFile
+	errors   chan error
+	done     chan struct{}
+	modTime  time.Time
+	size     int64
+}
+
+// WatchConfigFile starts polling path for changes. The current contents are
+// not delivered; only changes made after the call are.
+func WatchConfigFile(path string, interval time.Duration) *ConfigWatcher {
+	w := &ConfigWatcher{
+		path:     path,
+		interval: interval,
+		updates:  make(chan *ConfigFile, 1),
+		errors:   make(chan error, 1),
+		done:     make(chan struct{}),
+	}
+	if info, err := os.Stat(path); err == nil {
+		w.modTime = info.ModTime()
+		w.size = info.Size()
+	}
+	go w.run()
+	return w
+}
+
+// Updates delivers config files that changed and passed validation
+func (w *ConfigWatcher) Updates() <-chan *ConfigFile {
+	return w.updates
+}
+
+// Errors delivers load and validation errors for changed files
+func (w *ConfigWatcher) Errors() <-chan error {
+	return w.errors
+}
+
+// Close stops watching
+func (w *ConfigWatcher) Close() {
+	close(w.done)
+}
+
+// run polls the file until the watcher is closed
+func (w *ConfigWatcher) run() {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-w.done:
+			return
+		case <-ticker.C:
+		}
+
+		info, err := os.Stat(w.path)
+		if err != nil {
+			continue // Missing files are picked up once created
+		}
+		if info.ModTime().Equal(w.modTime) && info.Size() == w.size {
+			continue
+		}
+		w.modTime = info.ModTime()
+		w.size = info.Size()
+
+		file, err := LoadConfigFile(w.path)
+		if err != nil {
+			replaceLatest(w.errors, err)
+			continue
+		}
+		replaceLatest(w.updates, file)
+	}
+}
+
+// SetFilters replaces the signal type filters
+func (rd *Display) SetFilters(filters FilterState) {
+	rd.filters = filters
+}
+
+// WatchConfigFile applies changes to the source's config file while the
+// display runs, rebuilding the config from the source on every change
+func (rd *Display) WatchConfigFile(source ConfigSource) {
+	if rd.configWatcher != nil {
+		rd.configWatcher.Close()
+	}
+	rd.configSource = source
+	rd.configWatcher = WatchConfigFile(source.Path, time.Second)
+}
+
+// applyConfigUpdates applies a pending config file change, if any
+func (rd *Display) applyConfigUpdates() {
+	if rd.configWatcher == nil {
+		return
+	}
+
+	select {
+	case file := <-rd.configWatcher.Updates():
+		if err := rd.ApplyConfigFile(file); err != nil {
+			rd.setNotice("Config not reloaded: "+err.Error(), true)
+		} else if err := rd.realDataCollector.Err(); err != nil {
+			rd.setNotice("Config reloaded, but "+err.Error(), true)
+		} else {
+			rd.setNotice("Config reloaded", false)
+		}
+	case err := <-rd.configWatcher.Errors():
+		rd.setNotice("Config not reloaded: "+err.Error(), true)
+	default:
+	}
+}
+
+// ApplyConfigFile rebuilds the config from the defaults, the file and the
+// watched source's overrides, and applies it to the running display. Settings
+// left out of the file return to their defaults; the view's zoom and pan are
+// kept. Scanner settings take effect by restarting real data collection.
+func (rd *Display) ApplyConfigFile(file *ConfigFile) error {
+	config, filters, err := rd.configSource.Build(file)
+	if err != nil {
+		return err
+	}
+	config.ZoomLevel = rd.config.ZoomLevel
+	config.PanX, config.PanY = rd.config.PanX, rd.config.PanY
+	config.EnableZoom, config.EnablePan = rd.config.EnableZoom, rd.config.EnablePan
+
+	previous := rd.config
+	rd.config, rd.filters = config, filters
+
+	if scannerSettingsChanged(previous, rd.config) {
+		rd.replaceCollector()
+	}
+	if previous.RefreshRate != rd.config.RefreshRate {
+		rd.adaptiveRefreshRate = rd.config.RefreshRate
+	}
+	rd.guard.SetZones(rd.config.GuardZones)
+	return nil
+}
+
+// scannerSettingsChanged reports whether real data collection must restart
+// for the new config to take effect
+func scannerSettingsChanged(a, b Config) bool {
+	return a.ScanInterval != b.ScanInterval ||
+		a.MaxSignals != b.MaxSignals ||
+		a.MaxScanRange != b.MaxScanRange ||
+		a.TrackTimeout != b.TrackTimeout ||
+		a.BearingMode != b.BearingMode ||
+		a.BearingPinFile != b.BearingPinFile ||
+		a.ActiveSweep != b.ActiveSweep ||
+		a.Environment != b.Environment ||
+		a.PathLossExponent != b.PathLossExponent ||
+		a.ReferencePower != b.ReferencePower ||
+		strings.Join(a.Scanners, ",") != strings.Join(b.Scanners, ",")
+}
+
+// replaceLatest sends v on a one-slot channel, dropping an unread older value
+func replaceLatest[T any](ch chan T, v T) {
+	select {
+	case <-ch:
+	default:
+	}
+	ch <- v
+}
+
+// lineColumn converts a byte offset into a 1-based line and column
+func lineColumn(data []byte, offset int64) (int, int) {
+	if offset > int64(len(data)) {
+		offset = int64(len(data))
+	}
+	before := data[:offset]
+	line := bytes.Count(before, []byte("\n")) + 1
+	col := int(offset) - bytes.LastIndexByte(before, '\n')
+	return line, col
+}
+
+// setBool overrides dst when v is set
+func setBool(dst *bool, v *bool) {
+	if v != nil {
+		*dst = *v
+	}
+}
+
+// setInt overrides dst when v is set
+func setInt(dst *int, v *int) {
+	if v != nil {
+		*dst = *v
+	}
+}
+
+// setFloat overrides dst when v is set
+func setFloat(dst *float64, v *float64) {
+	if v != nil {
+		*dst = *v
+	}
+}
+
+// setDuration overrides dst when v is set
+func setDuration(dst *time.Duration, v *Duration) {
+	if v != nil {
+		*dst = time.Duration(*v)
+	}
+}
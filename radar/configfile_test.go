@@ -0,0 +1,98 @@
+package radar
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/e6a5/radar/radar/model"
+	"github.com/e6a5/radar/radar/scanner"
+)
+
+// readmeConfigExample returns the config file example documented in the README
+func readmeConfigExample(t *testing.T) []byte {
+	t.Helper()
+	readme, err := os.ReadFile("../README.md")
+	if err != nil {
+		t.Fatalf("reading the README: %v", err)
+	}
+	_, section, ok := strings.Cut(string(readme), "## Configuration File")
+	if !ok {
+		t.Fatal("README has no Configuration File section")
+	}
+	_, example, ok := strings.Cut(section, "```json\n")
+	if !ok {
+		t.Fatal("Configuration File section has no JSON example")
+	}
+	example, _, _ = strings.Cut(example, "```")
+	return []byte(example)
+}
+
+func TestReadmeConfigExample(t *testing.T) {
+	file, err := ParseConfigFile("README.md", readmeConfigExample(t))
+	if err != nil {
+		t.Fatalf("documented example is rejected: %v", err)
+	}
+	if err := file.Validate(); err != nil {
+		t.Errorf("Validate: %v", err)
+	}
+
+	config, filters, err := ConfigSource{}.Build(file)
+	if err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+	if config.KeyBindings['8'] != ActionToggleTrails || config.KeyBindings['?'] != ActionToggleHelp {
+		t.Errorf("documented key bindings not applied: 8 = %q, ? = %q", config.KeyBindings['8'], config.KeyBindings['?'])
+	}
+	if config.DisplayRange != 100 || filters.BluetoothVisible || filters.Process != "firefox" {
+		t.Errorf("display range = %g, filters = %+v; want the documented values", config.DisplayRange, filters)
+	}
+}
+
+func TestDuplicateKeyRejected(t *testing.T) {
+	_, err := ParseConfigFile("config.json", []byte(`{"keys": {"quit": "x"}}`))
+	if err == nil || !strings.Contains(err.Error(), `keys.quit: key "x" is already bound to cursor`) {
+		t.Errorf("error = %v, want quit on the cursor key rejected", err)
+	}
+}
+
+// closingScanner records whether its collector closed it
+type closingScanner struct{ closed bool }
+
+func (c *closingScanner) Scan(ctx context.Context) ([]model.Signal, error) { return nil, nil }
+func (c *closingScanner) Name() string                                     { return "closing" }
+func (c *closingScanner) IsAvailable() bool                                { return true }
+func (c *closingScanner) Close() error {
+	c.closed = true
+	return nil
+}
+
+func TestReloadClosesCollector(t *testing.T) {
+	config := NewConfig()
+	config.ScanInterval = 2
+	rd := NewDisplayWithConfig(80, 24, config)
+	defer rd.Close()
+
+	// Replace the live scanners with one that reports being closed
+	rd.realDataCollector.Close()
+	old := &closingScanner{}
+	coordinator := scanner.NewCoordinator(&scanner.Config{ScanInterval: time.Second, MaxSignals: 10})
+	coordinator.AddScanner(old)
+	rd.realDataCollector = &RealDataCollector{coordinator: coordinator, config: &rd.config}
+
+	file, err := ParseConfigFile("config.json", []byte(`{"scanner": {"scan_interval": "7s"}}`))
+	if err != nil {
+		t.Fatalf("ParseConfigFile: %v", err)
+	}
+	if err := rd.ApplyConfigFile(file); err != nil {
+		t.Fatalf("ApplyConfigFile: %v", err)
+	}
+	if !old.closed {
+		t.Error("previous collector's scanners left open after a scanner setting changed")
+	}
+	if rd.realDataCollector.coordinator == coordinator {
+		t.Error("collector not restarted")
+	}
+}
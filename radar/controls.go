@@ -1,6 +1,8 @@
 package radar
 
 import (
+	"time"
+
 	"github.com/gdamore/tcell/v2"
 )
 
@@ -27,11 +29,15 @@ func (rd *Display) HandleAdvancedInput(screen tcell.Screen) bool {
 
 	switch ev := event.(type) {
 	case *tcell.EventKey:
+		// Arrow keys adjust the active EBL/VRM before they pan
+		if rd.adjustCursor(ev.Key(), ev.Modifiers()) {
+			return true
+		}
 		switch ev.Key() {
 		case tcell.KeyEscape:
 			return false
 		case tcell.KeyEnter:
-			rd.paused = !rd.paused
+			rd.togglePause()
 		case tcell.KeyUp:
 			if rd.config.EnablePan {
 				rd.config.PanY -= 5
@@ -48,6 +54,11 @@ func (rd *Display) HandleAdvancedInput(screen tcell.Screen) bool {
 			if rd.config.EnablePan {
 				rd.config.PanX += 5
 			}
+		case tcell.KeyPgUp:
+			// Scroll the info panel's attribute list
+			rd.infoScroll = max(0, rd.infoScroll-1)
+		case tcell.KeyPgDn:
+			rd.infoScroll++
 		case tcell.KeyHome:
 			// Reset pan to center
 			if rd.config.EnablePan {
@@ -55,95 +66,8 @@ func (rd *Display) HandleAdvancedInput(screen tcell.Screen) bool {
 				rd.config.PanY = 0
 			}
 		default:
-			if ev.Rune() != 0 {
-				switch ev.Rune() {
-				case 'q', 'Q':
-					return false
-				case ' ':
-					rd.paused = !rd.paused
-				case '+', '=':
-					// Zoom in
-					if rd.config.EnableZoom {
-						rd.zoomIn()
-					}
-				case '-', '_':
-					// Zoom out
-					if rd.config.EnableZoom {
-						rd.zoomOut()
-					}
-				case '0':
-					// Reset zoom to 1.0
-					if rd.config.EnableZoom {
-						rd.config.ZoomLevel = 1.0
-					}
-				case 'z', 'Z':
-					// Toggle zoom mode
-					rd.config.EnableZoom = !rd.config.EnableZoom
-				case 'm', 'M':
-					// Toggle pan mode
-					rd.config.EnablePan = !rd.config.EnablePan
-				case 'r', 'R':
-					// Reset both zoom and pan
-					rd.resetViewport()
-				// Keep existing filter controls
-				case '1':
-					rd.filters.WiFiVisible = !rd.filters.WiFiVisible
-					rd.updateAllVisibleFilter()
-				case '2':
-					rd.filters.BluetoothVisible = !rd.filters.BluetoothVisible
-					rd.updateAllVisibleFilter()
-				case '3':
-					rd.filters.CellularVisible = !rd.filters.CellularVisible
-					rd.updateAllVisibleFilter()
-				case '4':
-					rd.filters.RadioVisible = !rd.filters.RadioVisible
-					rd.updateAllVisibleFilter()
-				case '5':
-					rd.filters.IoTVisible = !rd.filters.IoTVisible
-					rd.updateAllVisibleFilter()
-				case '6':
-					rd.filters.SatelliteVisible = !rd.filters.SatelliteVisible
-					rd.updateAllVisibleFilter()
-				case 'a', 'A':
-					// Toggle all signal types
-					rd.filters.AllVisible = !rd.filters.AllVisible
-					rd.filters.WiFiVisible = rd.filters.AllVisible
-					rd.filters.BluetoothVisible = rd.filters.AllVisible
-					rd.filters.CellularVisible = rd.filters.AllVisible
-					rd.filters.RadioVisible = rd.filters.AllVisible
-					rd.filters.IoTVisible = rd.filters.AllVisible
-					rd.filters.SatelliteVisible = rd.filters.AllVisible
-				case 'f', 'F':
-					// Toggle filtering system on/off
-					rd.config.EnableFiltering = !rd.config.EnableFiltering
-				case 't', 'T':
-					// Toggle signal trails
-					rd.config.ShowTrails = !rd.config.ShowTrails
-				case 'i', 'I':
-					// Toggle information panel
-					rd.showInfoPanel = !rd.showInfoPanel
-				case 'n', 'N':
-					// Select next signal
-					rd.selectNextSignal()
-				case 'p', 'P':
-					// Select previous signal
-					rd.selectPreviousSignal()
-				case 'c', 'C':
-					// Clear signal selection
-					rd.selectedSignalIndex = -1
-				case 's', 'S':
-					// Toggle real data collection
-					rd.toggleDataMode()
-				case 'l', 'L':
-					// Toggle signal name labels
-					rd.config.ShowSignalNames = !rd.config.ShowSignalNames
-				case 'v', 'V':
-					// Toggle performance monitor display
-					rd.showPerformanceStats = !rd.showPerformanceStats
-				case 'h', 'H':
-					// Show help screen (handle in main display loop)
-					rd.showHelp = !rd.showHelp
-				}
+			if action, ok := rd.config.KeyBindings[ev.Rune()]; ok {
+				return rd.performAction(action)
 			}
 		}
 	case *tcell.EventResize:
@@ -153,6 +77,141 @@ func (rd *Display) HandleAdvancedInput(screen tcell.Screen) bool {
 	return true
 }
 
+// performAction runs a key-bound action, returning false when the display should exit
+func (rd *Display) performAction(action Action) bool {
+	switch action {
+	case ActionQuit:
+		return false
+	case ActionPause:
+		rd.togglePause()
+	case ActionZoomIn:
+		if rd.config.EnableZoom {
+			rd.zoomIn()
+		}
+	case ActionZoomOut:
+		if rd.config.EnableZoom {
+			rd.zoomOut()
+		}
+	case ActionZoomReset:
+		// Reset zoom to 1.0
+		if rd.config.EnableZoom {
+			rd.config.ZoomLevel = 1.0
+		}
+	case ActionToggleZoom:
+		rd.config.EnableZoom = !rd.config.EnableZoom
+	case ActionTogglePan:
+		rd.config.EnablePan = !rd.config.EnablePan
+	case ActionResetView:
+		// Reset both zoom and pan
+		rd.resetViewport()
+	case ActionToggleWiFi:
+		rd.filters.WiFiVisible = !rd.filters.WiFiVisible
+		rd.updateAllVisibleFilter()
+	case ActionToggleBluetooth:
+		rd.filters.BluetoothVisible = !rd.filters.BluetoothVisible
+		rd.updateAllVisibleFilter()
+	case ActionToggleCellular:
+		rd.filters.CellularVisible = !rd.filters.CellularVisible
+		rd.updateAllVisibleFilter()
+	case ActionToggleRadio:
+		rd.filters.RadioVisible = !rd.filters.RadioVisible
+		rd.updateAllVisibleFilter()
+	case ActionToggleIoT:
+		rd.filters.IoTVisible = !rd.filters.IoTVisible
+		rd.updateAllVisibleFilter()
+	case ActionToggleSatellite:
+		rd.filters.SatelliteVisible = !rd.filters.SatelliteVisible
+		rd.updateAllVisibleFilter()
+	case ActionToggleAll:
+		// Toggle all signal types
+		rd.filters.AllVisible = !rd.filters.AllVisible
+		rd.filters.WiFiVisible = rd.filters.AllVisible
+		rd.filters.BluetoothVisible = rd.filters.AllVisible
+		rd.filters.CellularVisible = rd.filters.AllVisible
+		rd.filters.RadioVisible = rd.filters.AllVisible
+		rd.filters.IoTVisible = rd.filters.AllVisible
+		rd.filters.SatelliteVisible = rd.filters.AllVisible
+	case ActionToggleFiltering:
+		// Toggle filtering system on/off
+		rd.config.EnableFiltering = !rd.config.EnableFiltering
+	case ActionToggleTrails:
+		rd.config.ShowTrails = !rd.config.ShowTrails
+	case ActionToggleInfo:
+		rd.showInfoPanel = !rd.showInfoPanel
+	case ActionSelectNext:
+		rd.selectNextSignal()
+	case ActionSelectPrevious:
+		rd.selectPreviousSignal()
+	case ActionClearSelection:
+		rd.selectedSignalIndex = -1
+	case ActionToggleDataMode:
+		// Toggle real data collection
+		rd.toggleDataMode()
+	case ActionToggleLabels:
+		rd.config.ShowSignalNames = !rd.config.ShowSignalNames
+	case ActionToggleTracks:
+		rd.config.ShowTracks = !rd.config.ShowTracks
+	case ActionTogglePerformance:
+		rd.showPerformanceStats = !rd.showPerformanceStats
+	case ActionToggleHelp:
+		// Show help screen (handle in main display loop)
+		rd.showHelp = !rd.showHelp
+	case ActionExport:
+		rd.exportSignals()
+	case ActionFilterProcess:
+		rd.cycleProcessFilter()
+	case ActionCursor:
+		rd.cycleCursor()
+	case ActionAnchorCursor:
+		rd.anchorCursor()
+	case ActionRemoveCursor:
+		rd.removeCursor()
+	case ActionPinBearing:
+		rd.pinBearing()
+	case ActionAddZone:
+		rd.addZone()
+	case ActionRemoveZone:
+		rd.removeZone()
+	default:
+		rd.performReplayAction(action)
+	}
+	return true
+}
+
+// performReplayAction runs a replay control; it does nothing outside replay
+func (rd *Display) performReplayAction(action Action) {
+	if rd.player == nil {
+		return
+	}
+
+	switch action {
+	case ActionReplaySlower:
+		rd.player.Slower()
+	case ActionReplayFaster:
+		rd.player.Faster()
+	case ActionReplayBack:
+		rd.player.SeekBy(-10 * time.Second)
+	case ActionReplayForward:
+		rd.player.SeekBy(10 * time.Second)
+	case ActionReplayBackLong:
+		rd.player.SeekBy(-time.Minute)
+	case ActionReplayForwardLong:
+		rd.player.SeekBy(time.Minute)
+	case ActionReplayRestart:
+		rd.player.Seek(0)
+	case ActionReplayToggleLoop:
+		rd.player.SetLoop(!rd.player.Loop())
+	}
+}
+
+// togglePause pauses or resumes the display, and the replay with it
+func (rd *Display) togglePause() {
+	rd.paused = !rd.paused
+	if rd.player != nil {
+		rd.player.SetPaused(rd.paused)
+	}
+}
+
 // zoomIn increases the zoom level
 func (rd *Display) zoomIn() {
 	newZoom := rd.config.ZoomLevel * 1.25
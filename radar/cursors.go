@@ -0,0 +1,277 @@
+package radar
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// maxCursors is the number of EBL/VRM pairs, as on a marine radar
+const maxCursors = 2
+
+// Cursor adjustment steps; shifted arrow keys use the coarse steps
+const (
+	cursorBearingStep       = math.Pi / 180 // One degree
+	cursorBearingStepCoarse = math.Pi / 18
+	cursorRangeStep         = 0.01 // Fraction of the scope's range
+	cursorRangeStepCoarse   = 0.1
+)
+
+// Cursor is an electronic bearing line (EBL) and variable range marker (VRM)
+// drawn from the scope's center, or from a signal it is anchored to
+type Cursor struct {
+	Bearing float64 // EBL angle in radians
+	Range   float64 // VRM radius in meters
+	Anchor  string  // Key of the signal the cursor is drawn from; the center if empty
+}
+
+// cursorColors tells the cursors apart
+var cursorColors = [maxCursors]tcell.Color{tcell.ColorYellow, tcell.ColorFuchsia}
+
+// cycleCursor makes the next cursor the one the arrow keys adjust. Past the
+// last cursor a new one is added while there is room; otherwise the arrow
+// keys go back to panning.
+func (rd *Display) cycleCursor() {
+	next := rd.activeCursor + 1
+	if next < len(rd.cursors) {
+		rd.activeCursor = next
+		return
+	}
+	if len(rd.cursors) < maxCursors && rd.activeCursor == len(rd.cursors)-1 {
+		rd.cursors = append(rd.cursors, rd.newCursor())
+		rd.activeCursor = len(rd.cursors) - 1
+		return
+	}
+	rd.activeCursor = -1
+}
+
+// newCursor creates a cursor on the selected signal, or at a quarter of the
+// scope's range if none is selected
+func (rd *Display) newCursor() Cursor {
+	if s := rd.getSelectedSignal(); s != nil {
+		return Cursor{Bearing: s.Angle, Range: s.Distance}
+	}
+	return Cursor{Range: rd.scopeRange() / 4}
+}
+
+// removeCursor deletes the active cursor, or the last one if none is active
+func (rd *Display) removeCursor() {
+	if rd.activeCursor < 0 {
+		if len(rd.cursors) == 0 {
+			return
+		}
+		rd.activeCursor = len(rd.cursors) - 1
+	}
+	rd.cursors = append(rd.cursors[:rd.activeCursor], rd.cursors[rd.activeCursor+1:]...)
+	rd.activeCursor = -1
+}
+
+// anchorCursor draws the active cursor from the selected signal, or from the
+// center again if it is already anchored there or nothing is selected
+func (rd *Display) anchorCursor() {
+	if rd.activeCursor < 0 {
+		rd.setNotice("No active cursor: press "+rd.keyFor(ActionCursor)+" to add one", true)
+		return
+	}
+	cursor := &rd.cursors[rd.activeCursor]
+
+	selected := rd.getSelectedSignal()
+	if selected == nil || cursor.Anchor == selected.Key() {
+		cursor.Anchor = ""
+		rd.setNotice(fmt.Sprintf("EBL/VRM %d drawn from the center", rd.activeCursor+1), false)
+		return
+	}
+	cursor.Anchor = selected.Key()
+	rd.setNotice(fmt.Sprintf("EBL/VRM %d anchored to %s", rd.activeCursor+1, selected.Name), false)
+}
+
+// pinBearing moves the selected signal to the active bearing line, or keeps
+// it where it is if no cursor is active, and remembers that bearing for its
+// identity; in pinned bearing mode it is kept across restarts
+func (rd *Display) pinBearing() {
+	selected := rd.getSelectedSignal()
+	if selected == nil {
+		rd.setNotice("Select a signal to pin its bearing", true)
+		return
+	}
+	if rd.player != nil {
+		rd.setNotice("Bearings cannot be pinned while replaying", true)
+		return
+	}
+
+	angle := selected.Angle
+	if rd.activeCursor >= 0 {
+		cursor := rd.cursors[rd.activeCursor]
+		if cursor.Anchor != "" {
+			rd.setNotice("Bearings are pinned from the center: unanchor the cursor first", true)
+			return
+		}
+		angle = cursor.Bearing
+	}
+
+	persistent, err := rd.realDataCollector.PinBearing(selected, angle)
+	if err != nil {
+		rd.setNotice("Bearing not pinned: "+err.Error(), true)
+		return
+	}
+	selected.Angle = angle
+
+	message := fmt.Sprintf("Pinned %s at %05.1f°", selected.Name, angle*180/math.Pi)
+	if !persistent {
+		message += " until exit (bearing mode is not pinned)"
+	}
+	rd.setNotice(message, false)
+}
+
+// adjustCursor rotates or resizes the active cursor with the arrow keys,
+// reporting whether the key was used
+func (rd *Display) adjustCursor(key tcell.Key, modifiers tcell.ModMask) bool {
+	if rd.activeCursor < 0 || rd.activeCursor >= len(rd.cursors) {
+		return false
+	}
+	cursor := &rd.cursors[rd.activeCursor]
+
+	bearingStep, rangeStep := cursorBearingStep, cursorRangeStep*rd.scopeRange()
+	if modifiers&tcell.ModShift != 0 {
+		bearingStep, rangeStep = cursorBearingStepCoarse, cursorRangeStepCoarse*rd.scopeRange()
+	}
+
+	switch key {
+	case tcell.KeyLeft:
+		cursor.Bearing = math.Mod(cursor.Bearing-bearingStep+2*math.Pi, 2*math.Pi)
+	case tcell.KeyRight:
+		cursor.Bearing = math.Mod(cursor.Bearing+bearingStep, 2*math.Pi)
+	case tcell.KeyUp:
+		cursor.Range = math.Min(cursor.Range+rangeStep, rd.scopeRange())
+	case tcell.KeyDown:
+		cursor.Range = math.Max(cursor.Range-rangeStep, 0)
+	default:
+		return false
+	}
+	return true
+}
+
+// findSignal returns the signal with the given key
+func (rd *Display) findSignal(key string) (*Signal, bool) {
+	for i := range rd.signals {
+		if rd.signals[i].Key() == key {
+			return &rd.signals[i], true
+		}
+	}
+	return nil, false
+}
+
+// cursorOrigin returns the cursor's origin in cartesian meters and the
+// signal it is anchored to, if that signal is still on the scope
+func (rd *Display) cursorOrigin(c Cursor) (x, y float64, anchor *Signal) {
+	if c.Anchor == "" {
+		return 0, 0, nil
+	}
+	s, ok := rd.findSignal(c.Anchor)
+	if !ok {
+		return 0, 0, nil
+	}
+	x, y = s.Position()
+	return x, y, s
+}
+
+// drawCursors draws every cursor's bearing line to the edge of the scope and
+// its range ring, both dashed; the active cursor is drawn bright
+func (rd *Display) drawCursors(screen tcell.Screen, scaleFactor float64) {
+	toScreen := func(x, y float64) (int, int) {
+		return rd.centerX + int(math.Round(x*scaleFactor)), rd.centerY + int(math.Round(y*scaleFactor*0.5))
+	}
+	onScope := func(x, y int) bool {
+		return x >= 0 && x < rd.width && y >= 3 && y < rd.height-3
+	}
+
+	for i, c := range rd.cursors {
+		style := tcell.StyleDefault.Foreground(cursorColors[i])
+		if i == rd.activeCursor {
+			style = style.Bold(true)
+		} else {
+			style = style.Dim(true)
+		}
+		ox, oy, _ := rd.cursorOrigin(c)
+		dx, dy := math.Cos(c.Bearing), math.Sin(c.Bearing)
+
+		// Bearing line: every other cell, out to the scope's range from the origin
+		lineChar := vectorRune(dx, dy*0.5)
+		step, length := 1/scaleFactor, rd.scopeRange()
+		for n, t := 1, step; t <= length; n, t = n+1, t+step {
+			if n%2 == 0 {
+				continue
+			}
+			x, y := toScreen(ox+dx*t, oy+dy*t)
+			if !onScope(x, y) {
+				break
+			}
+			screen.SetContent(x, y, lineChar, nil, style)
+		}
+
+		// Range ring: every other cell around the origin
+		radius := c.Range * scaleFactor
+		if radius < 1 {
+			continue
+		}
+		angleStep := 1 / radius
+		for n, angle := 0, 0.0; angle < 2*math.Pi; n, angle = n+1, angle+angleStep {
+			if n%2 == 1 {
+				continue
+			}
+			x, y := toScreen(ox+c.Range*math.Cos(angle), oy+c.Range*math.Sin(angle))
+			if onScope(x, y) {
+				screen.SetContent(x, y, '◦', nil, style)
+			}
+		}
+	}
+}
+
+// cursorReadout describes each cursor's bearing and range, and the range and
+// bearing from an anchored cursor's signal to the selected signal
+func (rd *Display) cursorReadout() string {
+	parts := make([]string, 0, len(rd.cursors)+1)
+	var relative string
+	for i, c := range rd.cursors {
+		marker := " "
+		if i == rd.activeCursor {
+			marker = "▸"
+		}
+		part := fmt.Sprintf("%sEBL%d %05.1f° VRM%d %.2fm", marker, i+1, c.Bearing*180/math.Pi, i+1, c.Range)
+
+		_, _, anchor := rd.cursorOrigin(c)
+		if anchor == nil {
+			parts = append(parts, part)
+			continue
+		}
+		part += " @" + anchor.Name
+		parts = append(parts, part)
+
+		if selected := rd.getSelectedSignal(); selected != nil && selected.Key() != anchor.Key() && relative == "" {
+			ax, ay := anchor.Position()
+			sx, sy := selected.Position()
+			bearing := math.Atan2(sy-ay, sx-ax)
+			if bearing < 0 {
+				bearing += 2 * math.Pi
+			}
+			relative = fmt.Sprintf("%s→%s %.2fm %05.1f°", anchor.Name, selected.Name, math.Hypot(sx-ax, sy-ay), bearing*180/math.Pi)
+		}
+	}
+	if relative != "" {
+		parts = append(parts, relative)
+	}
+	return strings.Join(parts, " │ ")
+}
+
+// keyFor returns the first key bound to an action, for prompts
+func (rd *Display) keyFor(action Action) string {
+	best := ""
+	for r, bound := range rd.config.KeyBindings {
+		if bound == action && (best == "" || string(r) < best) {
+			best = string(r)
+		}
+	}
+	return strings.ToUpper(best)
+}
@@ -3,8 +3,15 @@ package radar
 import (
 	"math"
 	"math/rand"
+	"sort"
+	"strings"
 	"time"
 
+	"github.com/e6a5/radar/radar/guard"
+	"github.com/e6a5/radar/radar/model"
+	"github.com/e6a5/radar/radar/scanner"
+	"github.com/e6a5/radar/radar/session"
+	"github.com/e6a5/radar/radar/tracker"
 	"github.com/gdamore/tcell/v2"
 )
 
@@ -23,7 +30,22 @@ type Display struct {
 	// Signal selection and information panel
 	selectedSignalIndex int                // Index of currently selected signal (-1 if none)
 	showInfoPanel       bool               // Whether to show detailed info panel
+	infoScroll          int                // Scroll offset of the info panel's attribute list
 	realDataCollector   *RealDataCollector // Add real data collector
+	scanObservers       []scanner.Observer // Observers kept across collector restarts
+	history             *session.TrackSet  // Position history of every real signal, for export
+	player              *session.Player    // Recorded session being replayed (nil for live data)
+	// Multi-target tracking, one scan per sweep revolution
+	tracker        *tracker.Tracker // Associates the signals on the scope into numbered tracks
+	trackedSignals map[int]Signal   // Last signal associated with each track, kept while it coasts
+	// Electronic bearing lines and variable range markers
+	cursors      []Cursor
+	activeCursor int // Cursor the arrow keys adjust; -1 when they pan
+	// Guard zones and their alarms
+	guard       *guard.Monitor // Follows which signals are inside each guard zone
+	alarmErrors chan error     // Failures of alarm hooks, which run in the background
+	alarmUntil  time.Time      // When the alarm border stops flashing
+	bellPending bool           // Whether to ring the terminal bell on the next frame
 	// Performance optimization components
 	performanceMonitor   *PerformanceMonitor // Performance tracking
 	spatialCache         *SpatialCache       // Spatial calculation cache
@@ -31,10 +53,20 @@ type Display struct {
 	lastPerformanceCheck time.Time           // Last performance evaluation
 	showPerformanceStats bool                // Whether to show performance statistics
 	showHelp             bool                // Whether to show help screen
+	// Config file hot reload and transient messages
+	configWatcher *ConfigWatcher // Delivers config file changes (nil if not watching)
+	configSource  ConfigSource   // Rebuilds the config when the file changes
+	notice        string         // Message shown in the top panel
+	noticeIsError bool           // Whether the notice reports a failure
+	noticeUntil   time.Time      // When the notice disappears
 }
 
 func NewDisplay(width, height int) *Display {
-	config := NewConfig()
+	return NewDisplayWithConfig(width, height, NewConfig())
+}
+
+// NewDisplayWithConfig creates a display using the given configuration
+func NewDisplayWithConfig(width, height int, config Config) *Display {
 	display := &Display{
 		width:               width,
 		height:              height,
@@ -46,6 +78,11 @@ func NewDisplay(width, height int) *Display {
 		lastHistoryUpdate:   time.Now(),
 		selectedSignalIndex: -1, // No signal selected initially
 		showInfoPanel:       false,
+		tracker:             tracker.NewTracker(tracker.DefaultConfig()),
+		trackedSignals:      make(map[int]Signal),
+		activeCursor:        -1,
+		guard:               guard.NewMonitor(config.GuardZones),
+		alarmErrors:         make(chan error, 1),
 		// Performance optimization components
 		performanceMonitor:   NewPerformanceMonitor(),
 		spatialCache:         NewSpatialCache(500), // Cache up to 500 entries
@@ -53,8 +90,12 @@ func NewDisplay(width, height int) *Display {
 		lastPerformanceCheck: time.Now(),
 	}
 
+	// Keep every real signal's history for export, beyond the scope's trail
+	display.history = &session.TrackSet{MaxHistory: exportHistoryLimit}
+	display.scanObservers = []scanner.Observer{display.history}
+
 	// Initialize real data collector with pointer to config
-	display.realDataCollector = NewRealDataCollector(&display.config)
+	display.realDataCollector = display.newCollector()
 
 	// Generate initial signals based on configuration
 	if config.EnableRealData {
@@ -68,7 +109,65 @@ func NewDisplay(width, height int) *Display {
 	return display
 }
 
+// StartReplay drives the display from a recorded session instead of live scanners
+func (rd *Display) StartReplay(player *session.Player) {
+	rd.player = player
+	rd.config.EnableRealData = true
+	rd.replaceCollector()
+	rd.signals = rd.realDataCollector.CollectRealSignals()
+}
+
+// replaceCollector closes the running collector and starts a new one for the
+// current config and data source
+func (rd *Display) replaceCollector() {
+	if rd.realDataCollector != nil {
+		rd.realDataCollector.Close()
+	}
+	rd.realDataCollector = rd.newCollector()
+}
+
+// Close stops real data collection and the config file watcher
+func (rd *Display) Close() error {
+	if rd.configWatcher != nil {
+		rd.configWatcher.Close()
+		rd.configWatcher = nil
+	}
+	if rd.realDataCollector == nil {
+		return nil
+	}
+	return rd.realDataCollector.Close()
+}
+
+// newCollector creates the collector for the current data source and attaches
+// the registered scan observers
+func (rd *Display) newCollector() *RealDataCollector {
+	var collector *RealDataCollector
+	if rd.player != nil {
+		collector = NewReplayCollector(&rd.config, rd.player)
+	} else {
+		collector = NewRealDataCollector(&rd.config)
+		if err := collector.Err(); err != nil {
+			rd.setNotice(err.Error(), true)
+		}
+	}
+	for _, observer := range rd.scanObservers {
+		collector.AddObserver(observer)
+	}
+	return collector
+}
+
+// AddScanObserver registers an observer for every real data scan result
+func (rd *Display) AddScanObserver(observer scanner.Observer) {
+	rd.scanObservers = append(rd.scanObservers, observer)
+	if rd.realDataCollector != nil {
+		rd.realDataCollector.AddObserver(observer)
+	}
+}
+
 func (rd *Display) UpdatePhases() {
+	rd.applyConfigUpdates()
+	rd.checkAlarmErrors()
+
 	if rd.paused {
 		return
 	}
@@ -91,6 +190,7 @@ func (rd *Display) UpdatePhases() {
 			// Signal is being swept - refresh it
 			rd.signals[i].LastSeen = now
 			rd.signals[i].Persistence = 1.0
+			rd.observeZones(&rd.signals[i], now)
 
 			// Randomly change signal strength for realism when refreshed
 			if rand.Float64() < 0.1 {
@@ -109,6 +209,9 @@ func (rd *Display) UpdatePhases() {
 	rd.radarAngle += rd.config.RadarSpeed
 	if rd.radarAngle > 2*math.Pi {
 		rd.radarAngle -= 2 * math.Pi
+		// Each revolution of the sweep is one tracker scan
+		rd.updateTracks(now)
+		rd.expireZones(now)
 	}
 
 	// Remove old signals and add new ones occasionally
@@ -119,6 +222,8 @@ func (rd *Display) UpdatePhases() {
 }
 
 func (rd *Display) manageSignals(now time.Time) {
+	selectedID := rd.selectedSignalID()
+
 	// Remove expired signals (both by lifetime and persistence)
 	activeSignals := []Signal{}
 	for _, s := range rd.signals {
@@ -133,32 +238,28 @@ func (rd *Display) manageSignals(now time.Time) {
 	if rd.config.EnableRealData && rd.realDataCollector != nil {
 		realSignals := rd.realDataCollector.CollectRealSignals()
 		if len(realSignals) > 0 {
-			// Replace or merge with real signals
-			rd.signals = append(rd.signals, realSignals...)
-			// Remove duplicates and limit to max signals
-			if len(rd.signals) > rd.config.MaxSignals {
-				rd.signals = rd.signals[len(rd.signals)-rd.config.MaxSignals:]
-			}
+			rd.signals = rd.mergeRealSignals(realSignals)
 		}
 	}
 
-	// Add new simulated signals occasionally if needed
-	if len(rd.signals) < rd.config.MaxSignals && rand.Float64() < 0.3 {
+	rd.restoreSelection(selectedID)
+
+	// Add new simulated signals occasionally if needed (never into a replay)
+	if rd.player == nil && len(rd.signals) < rd.config.MaxSignals && rand.Float64() < 0.3 {
 		types := []struct {
 			typeName string
 			icon     string
-			color    tcell.Color
 		}{
-			{"WiFi", "≋", tcell.ColorBlue},
-			{"Bluetooth", "β", tcell.ColorNavy},
-			{"Cellular", "▲", tcell.ColorGreen},
-			{"Radio", "◈", tcell.ColorPurple},
-			{"IoT", "◇", tcell.ColorOrange},
-			{"Satellite", "★", tcell.ColorYellow},
+			{"WiFi", "≋"},
+			{"Bluetooth", "β"},
+			{"Cellular", "▲"},
+			{"Radio", "◈"},
+			{"IoT", "◇"},
+			{"Satellite", "★"},
 		}
 
 		t := types[rand.Intn(len(types))]
-		distance := rand.Float64()*4 + 2
+		distance := (rand.Float64()*4 + 2) * simulatedScale
 		angle := rand.Float64() * 2 * math.Pi
 		strength := rand.Intn(51) + 50
 
@@ -166,7 +267,7 @@ func (rd *Display) manageSignals(now time.Time) {
 			Type:        t.typeName,
 			Icon:        t.icon,
 			Name:        "SIM-" + t.typeName, // Simple name for simulated signals
-			Color:       t.color,
+			Category:    model.CategoryForType(t.typeName),
 			Strength:    strength,
 			Distance:    distance,
 			Angle:       angle,
@@ -179,11 +280,115 @@ func (rd *Display) manageSignals(now time.Time) {
 		}
 
 		// Add initial position to history
-		newSignal.addToHistory(distance, angle, strength, true, now)
+		newSignal.AddToHistory(distance, angle, strength, true, now)
 		rd.signals = append(rd.signals, newSignal)
 	}
 }
 
+// mergeRealSignals replaces the real signals on the scope with the collector's
+// current tracks. Tracks already on the scope keep their animation and sweep
+// state; the collector owns their lifetime and history, so identities that are
+// no longer tracked disappear. Simulated signals are kept after the real ones.
+func (rd *Display) mergeRealSignals(realSignals []Signal) []Signal {
+	previous := make(map[string]Signal, len(rd.signals))
+	simulated := make([]Signal, 0)
+	for _, s := range rd.signals {
+		if s.ID == "" {
+			simulated = append(simulated, s)
+		} else {
+			previous[s.ID] = s
+		}
+	}
+
+	merged := make([]Signal, 0, len(realSignals)+len(simulated))
+	for _, s := range realSignals {
+		if old, ok := previous[s.ID]; ok {
+			s.Phase = old.Phase
+			s.LastSeen = old.LastSeen
+			s.Persistence = old.Persistence
+			s.Track = old.Track
+		}
+		merged = append(merged, s)
+	}
+	merged = append(merged, simulated...)
+
+	if len(merged) > rd.config.MaxSignals {
+		merged = merged[:rd.config.MaxSignals]
+	}
+	return merged
+}
+
+// updateTracks feeds the tracker one plot per signal on the scope and records
+// the track each signal joined. Simulated signals have no identity, so they
+// are associated by position alone.
+func (rd *Display) updateTracks(now time.Time) {
+	plots := make([]tracker.Plot, len(rd.signals))
+	for i, s := range rd.signals {
+		plots[i] = tracker.Plot{
+			Key:      s.ID,
+			Category: s.Category,
+			Distance: s.Distance,
+			Angle:    s.Angle,
+		}
+	}
+
+	numbers := rd.tracker.Scan(plots, now)
+	for i, number := range numbers {
+		rd.signals[i].Track = number
+		s := rd.signals[i]
+		rd.trackedSignals[number] = Signal{
+			ID:         s.ID,
+			Type:       s.Type,
+			Icon:       s.Icon,
+			Name:       s.Name,
+			Category:   s.Category,
+			Severity:   s.Severity,
+			Attributes: s.Attributes,
+			Track:      number,
+		}
+	}
+
+	// Forget the signals of dropped tracks
+	for number := range rd.trackedSignals {
+		if _, ok := rd.tracker.Track(number); !ok {
+			delete(rd.trackedSignals, number)
+		}
+	}
+}
+
+// scanPeriod returns how long one revolution of the sweep takes
+func (rd *Display) scanPeriod() time.Duration {
+	revolution := 2 * math.Pi / rd.config.RadarSpeed
+	return time.Duration(revolution * float64(rd.config.RefreshRate))
+}
+
+// selectedSignalID returns the identity of the selected real signal, if any
+func (rd *Display) selectedSignalID() string {
+	if s := rd.getSelectedSignal(); s != nil {
+		return s.ID
+	}
+	return ""
+}
+
+// restoreSelection keeps the selection on the same real signal after the
+// signal list has been rebuilt
+func (rd *Display) restoreSelection(id string) {
+	if id == "" {
+		if rd.selectedSignalIndex >= len(rd.signals) {
+			rd.selectedSignalIndex = -1
+		}
+		return
+	}
+
+	rd.selectedSignalIndex = -1
+	for i, s := range rd.signals {
+		if s.ID == id {
+			rd.selectedSignalIndex = i
+			return
+		}
+	}
+}
+
 func (rd *Display) angleWithinRadar(angle float64) bool {
 	delta := math.Abs(angle - rd.radarAngle)
 	if delta > math.Pi {
@@ -299,6 +504,10 @@ func (rd *Display) isSignalVisible(signal Signal) bool {
 	if !rd.config.EnableFiltering {
 		return true
 	}
+	// The process filter narrows the connections; other signals have no process
+	if rd.filters.Process != "" && isConnection(signal) && signal.Attributes.Get(model.AttrProcess) != rd.filters.Process {
+		return false
+	}
 
 	switch signal.Type {
 	case "WiFi":
@@ -318,6 +527,60 @@ func (rd *Display) isSignalVisible(signal Signal) bool {
 	}
 }
 
+// isConnection reports whether a signal is a socket connection
+func isConnection(signal Signal) bool {
+	return strings.HasPrefix(signal.ID, "conn:")
+}
+
+// cycleProcessFilter shows only the connections of the selected signal's
+// process, or steps through the processes on the scope in name order, ending
+// with all connections shown again
+func (rd *Display) cycleProcessFilter() {
+	seen := make(map[string]bool)
+	processes := make([]string, 0)
+	for _, s := range rd.signals {
+		if name := s.Attributes.Get(model.AttrProcess); name != "" && !seen[name] {
+			seen[name] = true
+			processes = append(processes, name)
+		}
+	}
+	sort.Strings(processes)
+
+	if len(processes) == 0 && rd.filters.Process == "" {
+		rd.setNotice("No connections with a known process", true)
+		return
+	}
+
+	selectedProcess := ""
+	if selected := rd.getSelectedSignal(); selected != nil {
+		selectedProcess = selected.Attributes.Get(model.AttrProcess)
+	}
+
+	next := ""
+	if selectedProcess != "" && selectedProcess != rd.filters.Process {
+		next = selectedProcess
+	} else {
+		current := -1
+		for i, name := range processes {
+			if name == rd.filters.Process {
+				current = i
+			}
+		}
+		if current+1 < len(processes) {
+			next = processes[current+1]
+		}
+	}
+
+	rd.filters.Process = next
+	rd.selectedSignalIndex = -1
+	if next == "" {
+		rd.setNotice("Showing all connections", false)
+		return
+	}
+	rd.config.EnableFiltering = true
+	rd.setNotice("Showing connections of "+next, false)
+}
+
 func (rd *Display) RefreshRate() time.Duration {
 	return rd.config.RefreshRate
 }
@@ -370,6 +633,7 @@ func (rd *Display) getSignalCountsByType() map[string]int {
 
 // Signal selection methods
 func (rd *Display) selectNextSignal() {
+	rd.infoScroll = 0
 	visibleSignals := rd.getVisibleSignalIndices()
 	if len(visibleSignals) == 0 {
 		rd.selectedSignalIndex = -1
@@ -397,6 +661,7 @@ func (rd *Display) selectNextSignal() {
 }
 
 func (rd *Display) selectPreviousSignal() {
+	rd.infoScroll = 0
 	visibleSignals := rd.getVisibleSignalIndices()
 	if len(visibleSignals) == 0 {
 		rd.selectedSignalIndex = -1
@@ -443,12 +708,17 @@ func (rd *Display) getSelectedSignal() *Signal {
 // Update signal positions and track in history
 func (rd *Display) updateSignalHistory(now time.Time) {
 	for i := range rd.signals {
+		// Real signals get their history from the scanner coordinator
+		if rd.signals[i].ID != "" {
+			continue
+		}
+
 		// Update signal position (simulate movement)
-		rd.signals[i].updatePosition(now)
+		updateSimulatedPosition(&rd.signals[i])
 
 		// Add current position to history
 		isBeingSwept := rd.angleWithinRadar(rd.signals[i].Angle)
-		rd.signals[i].addToHistory(
+		rd.signals[i].AddToHistory(
 			rd.signals[i].Distance,
 			rd.signals[i].Angle,
 			rd.signals[i].Strength,
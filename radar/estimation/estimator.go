@@ -0,0 +1,98 @@
+package estimation
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// filterTimeout is how long a signal's filter is kept without readings
+const filterTimeout = 10 * time.Minute
+
+// Estimate is a distance derived from a signal's smoothed RSSI
+type Estimate struct {
+	RSSI     float64 // Smoothed RSSI, dBm
+	Distance float64 // Meters
+	Low      float64 // One-sigma confidence interval around Distance, meters
+	High     float64
+}
+
+// Clamp limits the distance and its interval to [min, max]
+func (e Estimate) Clamp(min, max float64) Estimate {
+	clamp := func(v float64) float64 {
+		return math.Max(min, math.Min(max, v))
+	}
+	e.Distance = clamp(e.Distance)
+	e.Low = clamp(e.Low)
+	e.High = clamp(e.High)
+	return e
+}
+
+// Estimator keeps a Kalman filter per signal and converts the smoothed RSSI
+// into distance estimates. It is safe for concurrent use.
+type Estimator struct {
+	calibration Calibration
+	filters     map[string]*Kalman
+	pruned      time.Time
+	mutex       sync.Mutex
+}
+
+// NewEstimator creates an estimator with the given calibration
+func NewEstimator(calibration Calibration) *Estimator {
+	return &Estimator{
+		calibration: calibration,
+		filters:     make(map[string]*Kalman),
+	}
+}
+
+// Calibration returns the estimator's calibration
+func (e *Estimator) Calibration() Calibration {
+	return e.calibration
+}
+
+// Update folds a reading of the signal identified by key into its filter and
+// returns the resulting estimate
+func (e *Estimator) Update(key string, rssi float64, source Source, now time.Time) Estimate {
+	e.mutex.Lock()
+	filter, ok := e.filters[key]
+	if !ok {
+		filter = NewKalman()
+		e.filters[key] = filter
+	}
+	smoothed := filter.Update(rssi, now)
+	_, variance := filter.Estimate()
+	e.prune(now)
+	e.mutex.Unlock()
+
+	return estimate(e.calibration.Model(source), smoothed, variance)
+}
+
+// prune drops the filters of signals that have gone quiet. Callers must hold
+// the lock.
+func (e *Estimator) prune(now time.Time) {
+	if now.Sub(e.pruned) < time.Minute {
+		return
+	}
+	e.pruned = now
+	for key, filter := range e.filters {
+		if now.Sub(filter.Updated()) > filterTimeout {
+			delete(e.filters, key)
+		}
+	}
+}
+
+// Single converts one unsmoothed reading into an estimate
+func (c Calibration) Single(rssi float64, source Source) Estimate {
+	return estimate(c.Model(source), rssi, DefaultMeasurementNoise)
+}
+
+// estimate applies the model to an RSSI estimate and its variance
+func estimate(model PathLoss, rssi, variance float64) Estimate {
+	low, high := model.Interval(rssi, variance)
+	return Estimate{
+		RSSI:     rssi,
+		Distance: model.Distance(rssi),
+		Low:      low,
+		High:     high,
+	}
+}
@@ -0,0 +1,63 @@
+package estimation
+
+import (
+	"testing"
+	"time"
+)
+
+func TestEstimateClamp(t *testing.T) {
+	tests := []struct {
+		name     string
+		estimate Estimate
+		want     Estimate
+	}{
+		{
+			name:     "within range",
+			estimate: Estimate{RSSI: -60, Distance: 10, Low: 5, High: 20},
+			want:     Estimate{RSSI: -60, Distance: 10, Low: 5, High: 20},
+		},
+		{
+			name:     "interval past the edge",
+			estimate: Estimate{RSSI: -90, Distance: 80, Low: 40, High: 160},
+			want:     Estimate{RSSI: -90, Distance: 80, Low: 40, High: 100},
+		},
+		{
+			name:     "closer than the minimum",
+			estimate: Estimate{RSSI: -30, Distance: 0.3, Low: 0.1, High: 0.9},
+			want:     Estimate{RSSI: -30, Distance: 0.5, Low: 0.5, High: 0.9},
+		},
+		{
+			name:     "entirely out of range",
+			estimate: Estimate{RSSI: -120, Distance: 500, Low: 200, High: 1000},
+			want:     Estimate{RSSI: -120, Distance: 100, Low: 100, High: 100},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.estimate.Clamp(0.5, 100); got != tt.want {
+				t.Errorf("Clamp = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEstimatorNarrowsInterval(t *testing.T) {
+	e := NewEstimator(Calibration{})
+	now := time.Unix(0, 0)
+	first := e.Update("wifi:a", -60, Source{}, now)
+	var last Estimate
+	for i := 1; i <= 20; i++ {
+		last = e.Update("wifi:a", -60, Source{}, now.Add(time.Duration(i)*time.Second))
+	}
+	if last.High-last.Low >= first.High-first.Low {
+		t.Errorf("interval widened from [%g, %g] to [%g, %g]", first.Low, first.High, last.Low, last.High)
+	}
+	if !(last.Low < last.Distance && last.Distance < last.High) {
+		t.Errorf("interval [%g, %g] does not contain %g", last.Low, last.High, last.Distance)
+	}
+
+	// Other signals have filters of their own
+	if other := e.Update("wifi:b", -60, Source{}, now); other != first {
+		t.Errorf("new signal = %+v, want %+v", other, first)
+	}
+}
@@ -0,0 +1,68 @@
+package estimation
+
+import "time"
+
+// Default noise levels for RSSI smoothing
+const (
+	DefaultProcessNoise     = 0.5  // dB² per second: how fast a moving source's RSSI drifts
+	DefaultMeasurementNoise = 16.0 // dB²: a 4 dB spread between readings of a still source
+)
+
+// Kalman smooths a noisy RSSI series with a one-dimensional Kalman filter.
+// The true RSSI is modelled as a random walk, so the longer it has been since
+// the last reading, the more a new one is trusted.
+type Kalman struct {
+	ProcessNoise     float64 // Variance the true RSSI gains per second, dB²
+	MeasurementNoise float64 // Variance of a single reading, dB²
+
+	estimate    float64
+	variance    float64
+	updated     time.Time
+	initialized bool
+}
+
+// NewKalman creates a filter with the default noise levels
+func NewKalman() *Kalman {
+	return &Kalman{
+		ProcessNoise:     DefaultProcessNoise,
+		MeasurementNoise: DefaultMeasurementNoise,
+	}
+}
+
+// Update folds in a reading taken at now and returns the new estimate.
+// Readings no newer than the last one are ignored, so a cached reading that
+// is reported again counts once.
+func (k *Kalman) Update(rssi float64, now time.Time) float64 {
+	if k.initialized && !now.After(k.updated) {
+		return k.estimate
+	}
+	if !k.initialized {
+		k.estimate = rssi
+		k.variance = k.MeasurementNoise
+		k.updated = now
+		k.initialized = true
+		return k.estimate
+	}
+
+	// Predict: the estimate keeps its value but grows less certain with time
+	if elapsed := now.Sub(k.updated).Seconds(); elapsed > 0 {
+		k.variance += k.ProcessNoise * elapsed
+	}
+	k.updated = now
+
+	// Correct towards the reading in proportion to the relative uncertainties
+	gain := k.variance / (k.variance + k.MeasurementNoise)
+	k.estimate += gain * (rssi - k.estimate)
+	k.variance *= 1 - gain
+	return k.estimate
+}
+
+// Estimate returns the smoothed RSSI and its variance
+func (k *Kalman) Estimate() (rssi, variance float64) {
+	return k.estimate, k.variance
+}
+
+// Updated returns when the last reading was folded in
+func (k *Kalman) Updated() time.Time {
+	return k.updated
+}
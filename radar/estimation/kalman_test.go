@@ -0,0 +1,85 @@
+package estimation
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestKalmanConverges(t *testing.T) {
+	tests := []struct {
+		name  string
+		first float64 // First reading, far from the truth
+		truth float64
+		noise []float64 // Repeating offsets added to the truth
+	}{
+		{"still source", -80, -60, []float64{0}},
+		{"noisy still source", -40, -70, []float64{4, -4, 2, -2, 0}},
+		{"biased start", -95, -50, []float64{3, -1, -3, 1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			k := NewKalman()
+			now := time.Unix(0, 0)
+			k.Update(tt.first, now)
+
+			var previous float64
+			for i := 0; i < 200; i++ {
+				now = now.Add(time.Second)
+				k.Update(tt.truth+tt.noise[i%len(tt.noise)], now)
+				if i == 0 {
+					_, previous = k.Estimate()
+				}
+			}
+
+			rssi, variance := k.Estimate()
+			if math.Abs(rssi-tt.truth) > 1.5 {
+				t.Errorf("estimate = %.2f after 200 readings, want about %g", rssi, tt.truth)
+			}
+			if variance >= previous {
+				t.Errorf("variance grew from %g to %g", previous, variance)
+			}
+		})
+	}
+}
+
+func TestKalmanSmooths(t *testing.T) {
+	k := NewKalman()
+	now := time.Unix(0, 0)
+	k.Update(-60, now)
+
+	// A single outlier moves the estimate only part of the way
+	if got := k.Update(-80, now.Add(time.Second)); got <= -80 || got >= -60 {
+		t.Errorf("estimate after an outlier = %g, want between -80 and -60", got)
+	}
+}
+
+func TestKalmanIgnoresStaleReadings(t *testing.T) {
+	k := NewKalman()
+	now := time.Unix(100, 0)
+	k.Update(-60, now)
+	k.Update(-62, now.Add(time.Second))
+	before, variance := k.Estimate()
+
+	for _, stale := range []time.Time{now, now.Add(time.Second)} {
+		if got := k.Update(-90, stale); got != before {
+			t.Errorf("Update at %v = %g, want the reading ignored (%g)", stale, got, before)
+		}
+	}
+	if _, got := k.Estimate(); got != variance {
+		t.Errorf("variance = %g after stale readings, want %g", got, variance)
+	}
+}
+
+func TestKalmanTrustsReadingsAfterSilence(t *testing.T) {
+	gain := func(gap time.Duration) float64 {
+		k := NewKalman()
+		now := time.Unix(0, 0)
+		k.Update(-60, now)
+		got := k.Update(-70, now.Add(gap))
+		return (got + 60) / -10
+	}
+	if soon, late := gain(time.Second), gain(time.Minute); late <= soon {
+		t.Errorf("gain after a minute = %g, want more than after a second (%g)", late, soon)
+	}
+}
@@ -0,0 +1,142 @@
+// Package estimation turns received signal strength into distance: a
+// log-distance path-loss model calibrated per environment, Kalman smoothing
+// of each signal's RSSI, and a confidence interval around the distance.
+package estimation
+
+import (
+	"math"
+	"sort"
+)
+
+// ReferenceFrequency is the frequency in MHz that reference powers are
+// given for, the middle of the 2.4 GHz band
+const ReferenceFrequency = 2437
+
+// Default reference powers: the RSSI one meter from the transmitter
+const (
+	DefaultWiFiReference      = -40.0 // A typical access point
+	DefaultBluetoothReference = -59.0 // A typical BLE advertiser, as iBeacon assumes
+)
+
+// Environment describes how quickly signals fade in a kind of surroundings
+type Environment struct {
+	Name      string
+	Exponent  float64 // Path-loss exponent: 2 in free space, higher with walls and clutter
+	Shadowing float64 // Standard deviation of readings around the model, dB
+}
+
+// environments lists the built-in profiles
+var environments = []Environment{
+	{"free-space", 2.0, 2.0},
+	{"outdoor", 2.7, 4.0},
+	{"home", 3.0, 5.0},
+	{"office", 3.3, 6.0},
+	{"dense", 4.0, 7.0}, // Concrete walls, many floors or heavy clutter
+}
+
+// DefaultEnvironment is used when none is configured
+const DefaultEnvironment = "home"
+
+// LookupEnvironment returns the built-in profile with the given name
+func LookupEnvironment(name string) (Environment, bool) {
+	for _, env := range environments {
+		if env.Name == name {
+			return env, true
+		}
+	}
+	return Environment{}, false
+}
+
+// EnvironmentNames returns the names of the built-in profiles, sorted
+func EnvironmentNames() []string {
+	names := make([]string, len(environments))
+	for i, env := range environments {
+		names[i] = env.Name
+	}
+	sort.Strings(names)
+	return names
+}
+
+// PathLoss is a log-distance path-loss model:
+// RSSI = ReferencePower - 10 * Exponent * log10(distance)
+type PathLoss struct {
+	ReferencePower float64 // RSSI at 1 m, dBm
+	Exponent       float64
+	Shadowing      float64 // Standard deviation of readings around the model, dB
+}
+
+// Distance returns the distance in meters at which the model predicts rssi
+func (m PathLoss) Distance(rssi float64) float64 {
+	return math.Pow(10, (m.ReferencePower-rssi)/(10*m.Exponent))
+}
+
+// RSSI returns the RSSI the model predicts at a distance in meters
+func (m PathLoss) RSSI(distance float64) float64 {
+	return m.ReferencePower - 10*m.Exponent*math.Log10(distance)
+}
+
+// Interval returns the one-sigma distance interval for an RSSI estimate with
+// the given variance, adding the model's shadowing
+func (m PathLoss) Interval(rssi, variance float64) (low, high float64) {
+	sigma := math.Sqrt(m.Shadowing*m.Shadowing + variance)
+	return m.Distance(rssi + sigma), m.Distance(rssi - sigma)
+}
+
+// FreeSpaceLoss returns the free-space path loss in dB over a distance in
+// meters at a frequency in MHz
+func FreeSpaceLoss(distance float64, frequency int) float64 {
+	return 20*math.Log10(distance) + 20*math.Log10(float64(frequency)) - 27.55
+}
+
+// ReferenceFromTxPower returns the RSSI expected 1 m from a transmitter
+// sending at txPower dBm
+func ReferenceFromTxPower(txPower float64, frequency int) float64 {
+	return txPower - FreeSpaceLoss(1, frequency)
+}
+
+// Source describes the transmitter behind a reading
+type Source struct {
+	Frequency      int     // Carrier frequency in MHz; ReferenceFrequency if zero
+	ReferencePower float64 // RSSI at 1 m if the source is known to differ from the calibration, dBm; zero otherwise
+}
+
+// Calibration adapts the model to the surroundings. Zero values select the
+// defaults.
+type Calibration struct {
+	Environment    string  // Profile name; DefaultEnvironment if empty or unknown
+	Exponent       float64 // Overrides the profile's path-loss exponent
+	ReferencePower float64 // RSSI 1 m from an access point on 2.4 GHz; DefaultWiFiReference if zero
+}
+
+// Model returns the path-loss model for a source. The calibrated reference
+// power is moved to the source's frequency, since free-space loss grows by
+// 20 dB per decade of frequency; a 5 GHz signal is about 6.5 dB weaker.
+func (c Calibration) Model(source Source) PathLoss {
+	env, ok := LookupEnvironment(c.Environment)
+	if !ok {
+		env, _ = LookupEnvironment(DefaultEnvironment)
+	}
+	exponent := env.Exponent
+	if c.Exponent > 0 {
+		exponent = c.Exponent
+	}
+
+	frequency := source.Frequency
+	if frequency <= 0 {
+		frequency = ReferenceFrequency
+	}
+	reference := source.ReferencePower
+	if reference == 0 {
+		reference = c.ReferencePower
+		if reference == 0 {
+			reference = DefaultWiFiReference
+		}
+		reference -= FreeSpaceLoss(1, frequency) - FreeSpaceLoss(1, ReferenceFrequency)
+	}
+
+	return PathLoss{
+		ReferencePower: reference,
+		Exponent:       exponent,
+		Shadowing:      env.Shadowing,
+	}
+}
@@ -0,0 +1,109 @@
+package estimation
+
+import (
+	"math"
+	"testing"
+)
+
+func TestPathLossInversion(t *testing.T) {
+	tests := []struct {
+		name     string
+		model    PathLoss
+		rssi     float64
+		distance float64
+	}{
+		{"at the reference distance", PathLoss{ReferencePower: -40, Exponent: 3}, -40, 1},
+		{"free space, ten meters", PathLoss{ReferencePower: -40, Exponent: 2}, -60, 10},
+		{"home, ten meters", PathLoss{ReferencePower: -40, Exponent: 3}, -70, 10},
+		{"dense, hundred meters", PathLoss{ReferencePower: -59, Exponent: 4}, -139, 100},
+		{"closer than a meter", PathLoss{ReferencePower: -40, Exponent: 2}, -34, math.Pow(10, -0.3)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.model.Distance(tt.rssi); math.Abs(got-tt.distance) > 1e-9 {
+				t.Errorf("Distance(%g) = %g, want %g", tt.rssi, got, tt.distance)
+			}
+			if got := tt.model.RSSI(tt.distance); math.Abs(got-tt.rssi) > 1e-9 {
+				t.Errorf("RSSI(%g) = %g, want %g", tt.distance, got, tt.rssi)
+			}
+			if got := tt.model.RSSI(tt.model.Distance(tt.rssi)); math.Abs(got-tt.rssi) > 1e-9 {
+				t.Errorf("RSSI(Distance(%g)) = %g", tt.rssi, got)
+			}
+		})
+	}
+}
+
+func TestPathLossInterval(t *testing.T) {
+	model := PathLoss{ReferencePower: -40, Exponent: 2, Shadowing: 3}
+	low, high := model.Interval(-60, 16) // Sigma of 5 dB
+	if want := model.Distance(-55); math.Abs(low-want) > 1e-9 {
+		t.Errorf("low = %g, want %g", low, want)
+	}
+	if want := model.Distance(-65); math.Abs(high-want) > 1e-9 {
+		t.Errorf("high = %g, want %g", high, want)
+	}
+	if distance := model.Distance(-60); !(low < distance && distance < high) {
+		t.Errorf("interval [%g, %g] does not contain %g", low, high, distance)
+	}
+}
+
+func TestCalibrationModel(t *testing.T) {
+	shift5GHz := 20 * math.Log10(5180.0/ReferenceFrequency) // About 6.5 dB
+
+	tests := []struct {
+		name        string
+		calibration Calibration
+		source      Source
+		want        PathLoss
+	}{
+		{
+			name: "defaults",
+			want: PathLoss{ReferencePower: DefaultWiFiReference, Exponent: 3.0, Shadowing: 5.0},
+		},
+		{
+			name:        "unknown environment falls back to the default",
+			calibration: Calibration{Environment: "underwater"},
+			want:        PathLoss{ReferencePower: DefaultWiFiReference, Exponent: 3.0, Shadowing: 5.0},
+		},
+		{
+			name:        "environment and exponent override",
+			calibration: Calibration{Environment: "office", Exponent: 2.5},
+			source:      Source{Frequency: ReferenceFrequency},
+			want:        PathLoss{ReferencePower: DefaultWiFiReference, Exponent: 2.5, Shadowing: 6.0},
+		},
+		{
+			name:        "5 GHz is weaker at a meter",
+			calibration: Calibration{Environment: "free-space", ReferencePower: -35},
+			source:      Source{Frequency: 5180},
+			want:        PathLoss{ReferencePower: -35 - shift5GHz, Exponent: 2.0, Shadowing: 2.0},
+		},
+		{
+			name:        "lower frequencies are stronger",
+			calibration: Calibration{Environment: "outdoor"},
+			source:      Source{Frequency: 900},
+			want:        PathLoss{ReferencePower: DefaultWiFiReference - 20*math.Log10(900.0/ReferenceFrequency), Exponent: 2.7, Shadowing: 4.0},
+		},
+		{
+			name:        "a known source power is not shifted",
+			calibration: Calibration{ReferencePower: -35},
+			source:      Source{Frequency: 5180, ReferencePower: DefaultBluetoothReference},
+			want:        PathLoss{ReferencePower: DefaultBluetoothReference, Exponent: 3.0, Shadowing: 5.0},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.calibration.Model(tt.source)
+			if math.Abs(got.ReferencePower-tt.want.ReferencePower) > 1e-9 ||
+				got.Exponent != tt.want.Exponent || got.Shadowing != tt.want.Shadowing {
+				t.Errorf("Model(%+v) = %+v, want %+v", tt.source, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReferenceFromTxPower(t *testing.T) {
+	// Free-space loss over a meter at 2.4 GHz is about 40 dB
+	if got := ReferenceFromTxPower(0, 2400); math.Abs(got+40.05) > 0.01 {
+		t.Errorf("ReferenceFromTxPower(0, 2400) = %g, want about -40", got)
+	}
+}
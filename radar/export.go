@@ -0,0 +1,90 @@
+package radar
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/e6a5/radar/radar/export"
+	"github.com/e6a5/radar/radar/model"
+)
+
+// exportHistoryLimit caps the positions kept per signal for exports from the
+// display, about a day of scans at the default interval
+const exportHistoryLimit = 10800
+
+// ExportOptions returns the map placement settings for exports
+func (c Config) ExportOptions(now time.Time) export.Options {
+	return export.Options{
+		Latitude:      c.ObserverLatitude,
+		Longitude:     c.ObserverLongitude,
+		MetersPerUnit: c.MetersPerUnit,
+		Now:           now,
+	}
+}
+
+// ExportFile writes signals to path in the given format
+func ExportFile(path string, format export.Format, signals []model.Signal, options export.Options) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := export.Write(file, format, signals, options); err != nil {
+		file.Close()
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return file.Close()
+}
+
+// exportSignals writes the real signals that pass the filters, with their
+// history since the display started, in every configured export format and
+// reports the result as a notice
+func (rd *Display) exportSignals() {
+	if len(rd.config.ExportFormats) == 0 {
+		rd.setNotice("Export failed: no export formats configured", true)
+		return
+	}
+	signals := rd.exportableSignals()
+	if len(signals) == 0 {
+		rd.setNotice("Nothing to export: no real signals are visible", true)
+		return
+	}
+
+	now := time.Now()
+	base := filepath.Join(rd.config.ExportDir, "radar-"+now.Format("20060102-150405"))
+	options := rd.config.ExportOptions(now)
+
+	for _, name := range rd.config.ExportFormats {
+		format, err := export.ParseFormat(name)
+		if err != nil {
+			rd.setNotice("Export failed: "+err.Error(), true)
+			return
+		}
+		if err := ExportFile(base+format.Extension(), format, signals, options); err != nil {
+			rd.setNotice("Export failed: "+err.Error(), true)
+			return
+		}
+	}
+	rd.setNotice(fmt.Sprintf("Exported %d signals to %s.*", len(signals), base), false)
+}
+
+// exportableSignals returns the visible signals that came from a scanner,
+// each with its recorded history in place of the scope's short trail.
+// Simulated and placeholder signals are left out.
+func (rd *Display) exportableSignals() []model.Signal {
+	signals := make([]model.Signal, 0, len(rd.signals))
+	for _, s := range rd.signals {
+		if s.ID == "" || strings.HasPrefix(s.ID, "fallback:") || !rd.isSignalVisible(s) {
+			continue
+		}
+		signal := s.Clone()
+		if track, ok := rd.history.Track(s.ID); ok {
+			signal.History = track.History
+			signal.MaxHistory = track.MaxHistory
+		}
+		signals = append(signals, signal)
+	}
+	return signals
+}
@@ -0,0 +1,72 @@
+package export
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/e6a5/radar/radar/model"
+)
+
+// csvHeader names the CSV columns. Each signal contributes one "current" row
+// followed by one "history" row per recorded position.
+var csvHeader = []string{
+	"row", "id", "type", "name", "category", "severity", "time",
+	"strength", "distance", "bearing", "latitude", "longitude",
+	"detected", "first_seen", "attributes",
+}
+
+// WriteCSV writes the signal table and position history as CSV
+func WriteCSV(w io.Writer, signals []model.Signal, options Options) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(csvHeader); err != nil {
+		return err
+	}
+
+	for _, s := range signals {
+		current := currentPoint(s, options)
+		row := csvRow("current", s, current)
+		row = append(row, formatTime(s.Lifetime), formatAttributes(s.Attributes))
+		if err := writer.Write(row); err != nil {
+			return err
+		}
+
+		for _, p := range historyPoints(s, options) {
+			row := append(csvRow("history", s, p), "", "")
+			if err := writer.Write(row); err != nil {
+				return err
+			}
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// csvRow formats the columns shared by current and history rows
+func csvRow(kind string, s model.Signal, p point) []string {
+	return []string{
+		kind,
+		s.Key(),
+		s.Type,
+		s.Name,
+		s.Category.String(),
+		s.Severity.String(),
+		formatTime(p.Time),
+		strconv.Itoa(p.Strength),
+		strconv.FormatFloat(p.Distance, 'f', 2, 64),
+		strconv.FormatFloat(p.Bearing, 'f', 1, 64),
+		strconv.FormatFloat(p.Latitude, 'f', 7, 64),
+		strconv.FormatFloat(p.Longitude, 'f', 7, 64),
+		strconv.FormatBool(p.Detected),
+	}
+}
+
+// formatTime formats a timestamp as RFC 3339, leaving zero times empty
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339Nano)
+}
@@ -0,0 +1,158 @@
+// Package export writes signals and their position history to CSV, GeoJSON
+// and KML. Positions on the scope are relative to the observer; for the map
+// formats they are projected from the observer's latitude and longitude using
+// each point's distance and bearing.
+package export
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/e6a5/radar/radar/model"
+)
+
+// Format is an export file format
+type Format string
+
+const (
+	FormatCSV     Format = "csv"
+	FormatGeoJSON Format = "geojson"
+	FormatKML     Format = "kml"
+)
+
+// Formats lists every supported format
+var Formats = []Format{FormatCSV, FormatGeoJSON, FormatKML}
+
+// ParseFormat returns the format with the given name
+func ParseFormat(name string) (Format, error) {
+	switch strings.ToLower(name) {
+	case "csv":
+		return FormatCSV, nil
+	case "geojson", "json":
+		return FormatGeoJSON, nil
+	case "kml":
+		return FormatKML, nil
+	default:
+		return "", fmt.Errorf("unknown export format %q (available: csv, geojson, kml)", name)
+	}
+}
+
+// FormatForPath picks the format from a file extension
+func FormatForPath(path string) (Format, error) {
+	return ParseFormat(strings.TrimPrefix(filepath.Ext(path), "."))
+}
+
+// Extension returns the file extension used for the format
+func (f Format) Extension() string {
+	return "." + string(f)
+}
+
+// Options sets where the observer is and how scope distances map to meters
+type Options struct {
+	Latitude      float64 // Observer latitude in degrees
+	Longitude     float64 // Observer longitude in degrees
+	MetersPerUnit float64 // Meters per scope distance unit (1 if zero)
+	Now           time.Time
+}
+
+// Write exports signals in the given format
+func Write(w io.Writer, format Format, signals []model.Signal, options Options) error {
+	if options.MetersPerUnit == 0 {
+		options.MetersPerUnit = 1
+	}
+	if options.Now.IsZero() {
+		options.Now = time.Now()
+	}
+
+	switch format {
+	case FormatCSV:
+		return WriteCSV(w, signals, options)
+	case FormatGeoJSON:
+		return WriteGeoJSON(w, signals, options)
+	case FormatKML:
+		return WriteKML(w, signals, options)
+	default:
+		return fmt.Errorf("unknown export format %q", format)
+	}
+}
+
+// point is one exported position of a signal
+type point struct {
+	Time      time.Time
+	Strength  int
+	Distance  float64 // Scope units
+	Bearing   float64 // Compass degrees, clockwise from north
+	Latitude  float64
+	Longitude float64
+	Detected  bool
+}
+
+// currentPoint returns the signal's current position
+func currentPoint(s model.Signal, options Options) point {
+	return newPoint(s.LastSeen, s.Strength, s.Distance, s.Angle, true, options)
+}
+
+// historyPoints returns the signal's recorded positions, oldest first
+func historyPoints(s model.Signal, options Options) []point {
+	points := make([]point, 0, len(s.History))
+	for _, h := range s.History {
+		points = append(points, newPoint(h.Timestamp, h.Strength, h.Distance, h.Angle, h.WasDetected, options))
+	}
+	return points
+}
+
+// newPoint projects a scope position onto the map
+func newPoint(t time.Time, strength int, distance, angle float64, detected bool, options Options) point {
+	bearing := CompassBearing(angle)
+	lat, lon := Destination(options.Latitude, options.Longitude, bearing, distance*options.MetersPerUnit)
+	return point{
+		Time:      t,
+		Strength:  strength,
+		Distance:  distance,
+		Bearing:   bearing,
+		Latitude:  lat,
+		Longitude: lon,
+		Detected:  detected,
+	}
+}
+
+// CompassBearing converts a scope angle (radians, 0 = east, increasing
+// clockwise on screen) to a compass bearing in degrees (0 = north, clockwise)
+func CompassBearing(angle float64) float64 {
+	bearing := math.Mod(90+angle*180/math.Pi, 360)
+	if bearing < 0 {
+		bearing += 360
+	}
+	return bearing
+}
+
+// earthRadius is the mean Earth radius in meters
+const earthRadius = 6371008.8
+
+// Destination returns the point reached by travelling meters from lat/lon
+// along a compass bearing on a great circle
+func Destination(lat, lon, bearing, meters float64) (float64, float64) {
+	φ1 := lat * math.Pi / 180
+	λ1 := lon * math.Pi / 180
+	θ := bearing * math.Pi / 180
+	δ := meters / earthRadius
+
+	φ2 := math.Asin(math.Sin(φ1)*math.Cos(δ) + math.Cos(φ1)*math.Sin(δ)*math.Cos(θ))
+	λ2 := λ1 + math.Atan2(math.Sin(θ)*math.Sin(δ)*math.Cos(φ1), math.Cos(δ)-math.Sin(φ1)*math.Sin(φ2))
+
+	lon2 := math.Mod(λ2*180/math.Pi+540, 360) - 180
+	return φ2 * 180 / math.Pi, lon2
+}
+
+// formatAttributes flattens attributes into "key=value; key=value"
+func formatAttributes(attrs model.Attributes) string {
+	parts := make([]string, 0, len(attrs))
+	for _, attr := range attrs.Sorted() {
+		parts = append(parts, attr.Key+"="+attrs.Get(attr.Key))
+	}
+	return strings.Join(parts, "; ")
+}
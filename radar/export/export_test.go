@@ -0,0 +1,165 @@
+package export
+
+import (
+	"bytes"
+	"flag"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/e6a5/radar/radar/model"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+func TestCompassBearing(t *testing.T) {
+	tests := []struct {
+		name  string
+		angle float64 // Scope radians
+		want  float64 // Compass degrees
+	}{
+		{"east", 0, 90},
+		{"south", math.Pi / 2, 180},
+		{"west", math.Pi, 270},
+		{"north", 3 * math.Pi / 2, 0},
+		{"north, negative", -math.Pi / 2, 0},
+		{"north-west, negative", -3 * math.Pi / 4, 315},
+		{"more than a turn", 2*math.Pi + math.Pi/4, 135},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := CompassBearing(tt.angle)
+			if math.Abs(got-tt.want) > 1e-9 && math.Abs(got-tt.want-360) > 1e-9 {
+				t.Errorf("CompassBearing(%g) = %g, want %g", tt.angle, got, tt.want)
+			}
+			if got < 0 || got >= 360 {
+				t.Errorf("CompassBearing(%g) = %g, want within [0, 360)", tt.angle, got)
+			}
+		})
+	}
+}
+
+func TestDestination(t *testing.T) {
+	degree := 2 * math.Pi * earthRadius / 360 // Meters per degree of arc
+	tests := []struct {
+		name             string
+		lat, lon         float64
+		bearing, meters  float64
+		wantLat, wantLon float64
+		tolerance        float64 // Degrees
+	}{
+		{"north along the meridian", 0, 0, 0, degree, 1, 0, 1e-9},
+		{"east along the equator", 0, 0, 90, degree, 0, 1, 1e-9},
+		{"south", 0, 0, 180, degree, -1, 0, 1e-9},
+		{"west", 0, 0, 270, degree, 0, -1, 1e-9},
+		{"standing still", 51.5, -0.1, 123, 0, 51.5, -0.1, 1e-12},
+		{"across the antimeridian", 0, 179.5, 90, degree, 0, -179.5, 1e-9},
+		{"over the pole", 89, 0, 0, 2 * degree, 89, 180, 1e-9},
+		// 53°19′14″N 1°43′47″W on 96°01′18″ for 124.8 km reaches 53°11′18″N 0°08′00″E
+		{"worked example", 53.32056, -1.72972, 96.02167, 124800, 53.18833, 0.13333, 5e-4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lat, lon := Destination(tt.lat, tt.lon, tt.bearing, tt.meters)
+			dLon := math.Mod(lon-tt.wantLon+540, 360) - 180
+			if math.Abs(lat-tt.wantLat) > tt.tolerance || math.Abs(dLon) > tt.tolerance {
+				t.Errorf("Destination = %.7f, %.7f, want %.7f, %.7f", lat, lon, tt.wantLat, tt.wantLon)
+			}
+			if lon < -180 || lon >= 180 {
+				t.Errorf("longitude %g outside [-180, 180)", lon)
+			}
+		})
+	}
+}
+
+// testSignals returns an access point that moved toward the observer over
+// three scans and a keyboard seen once, north-west of the observer
+func testSignals() []model.Signal {
+	start := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	ap := model.Signal{
+		ID:       "wifi:aa:bb:cc:dd:ee:01",
+		Type:     "WiFi",
+		Name:     "HomeNet",
+		Category: model.CategoryWiFi,
+		Strength: 72,
+		Distance: 10,
+		Angle:    0, // East
+		Lifetime: start,
+		LastSeen: start.Add(2 * time.Second),
+		History: []model.PositionHistory{
+			{Distance: 30, Angle: 0, Timestamp: start, Strength: 60, WasDetected: true},
+			{Distance: 20, Angle: 0, Timestamp: start.Add(time.Second), Strength: 66, WasDetected: true},
+			{Distance: 10, Angle: 0, Timestamp: start.Add(2 * time.Second), Strength: 72, WasDetected: true},
+		},
+		Attributes: model.Attributes{model.AttrSSID: "HomeNet", model.AttrChannel: "6"},
+	}
+	keyboard := model.Signal{
+		ID:       "bt:AA:BB:CC:DD:EE:02",
+		Type:     "Bluetooth",
+		Name:     "MX Keys <desk>",
+		Category: model.CategoryBluetooth,
+		Severity: model.SeverityNotice,
+		Strength: 40,
+		Distance: 5,
+		Angle:    -3 * math.Pi / 4, // North-west
+		Lifetime: start.Add(time.Second),
+		LastSeen: start.Add(time.Second),
+		History: []model.PositionHistory{
+			{Distance: 5, Angle: -3 * math.Pi / 4, Timestamp: start.Add(time.Second), Strength: 40, WasDetected: true},
+		},
+	}
+	return []model.Signal{ap, keyboard}
+}
+
+func TestWriteGolden(t *testing.T) {
+	options := Options{
+		Latitude:  51.5,
+		Longitude: -0.1,
+		Now:       time.Date(2024, 5, 1, 12, 0, 5, 0, time.UTC),
+	}
+	for _, format := range Formats {
+		t.Run(string(format), func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := Write(&buf, format, testSignals(), options); err != nil {
+				t.Fatalf("Write: %v", err)
+			}
+
+			golden := filepath.Join("testdata", "export"+format.Extension())
+			if *update {
+				if err := os.WriteFile(golden, buf.Bytes(), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatalf("reading golden file (run with -update to create it): %v", err)
+			}
+			if !bytes.Equal(buf.Bytes(), want) {
+				t.Errorf("%s output differs from %s:\n%s", format, golden, buf.String())
+			}
+		})
+	}
+}
+
+func TestFormatForPath(t *testing.T) {
+	tests := []struct {
+		path    string
+		want    Format
+		wantErr bool
+	}{
+		{"signals.csv", FormatCSV, false},
+		{"signals.GeoJSON", FormatGeoJSON, false},
+		{"signals.json", FormatGeoJSON, false},
+		{"/tmp/signals.kml", FormatKML, false},
+		{"signals.gpx", "", true},
+		{"signals", "", true},
+	}
+	for _, tt := range tests {
+		got, err := FormatForPath(tt.path)
+		if got != tt.want || (err != nil) != tt.wantErr {
+			t.Errorf("FormatForPath(%q) = %q, %v", tt.path, got, err)
+		}
+	}
+}
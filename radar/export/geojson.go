@@ -0,0 +1,112 @@
+package export
+
+import (
+	"encoding/json"
+	"io"
+
+	"github.com/e6a5/radar/radar/model"
+)
+
+// geoJSONFeatureCollection is the top-level GeoJSON object
+type geoJSONFeatureCollection struct {
+	Type     string           `json:"type"`
+	Features []geoJSONFeature `json:"features"`
+}
+
+// geoJSONFeature is a single GeoJSON feature
+type geoJSONFeature struct {
+	Type       string          `json:"type"`
+	ID         string          `json:"id,omitempty"`
+	Geometry   geoJSONGeometry `json:"geometry"`
+	Properties map[string]any  `json:"properties"`
+}
+
+// geoJSONGeometry is a Point or LineString geometry
+type geoJSONGeometry struct {
+	Type        string `json:"type"`
+	Coordinates any    `json:"coordinates"`
+}
+
+// WriteGeoJSON writes a FeatureCollection holding the observer, a Point for
+// each signal's current position and a LineString for each signal's track
+func WriteGeoJSON(w io.Writer, signals []model.Signal, options Options) error {
+	collection := geoJSONFeatureCollection{
+		Type:     "FeatureCollection",
+		Features: make([]geoJSONFeature, 0, 1+2*len(signals)),
+	}
+
+	collection.Features = append(collection.Features, geoJSONFeature{
+		Type: "Feature",
+		ID:   "observer",
+		Geometry: geoJSONGeometry{
+			Type:        "Point",
+			Coordinates: coordinates(options.Latitude, options.Longitude),
+		},
+		Properties: map[string]any{
+			"kind":      "observer",
+			"name":      "Observer",
+			"timestamp": formatTime(options.Now),
+		},
+	})
+
+	for _, s := range signals {
+		current := currentPoint(s, options)
+		properties := map[string]any{
+			"kind":       "signal",
+			"id":         s.Key(),
+			"type":       s.Type,
+			"name":       s.Name,
+			"category":   s.Category.String(),
+			"severity":   s.Severity.String(),
+			"strength":   s.Strength,
+			"distance":   model.Round(s.Distance, 2),
+			"bearing":    model.Round(current.Bearing, 1),
+			"first_seen": formatTime(s.Lifetime),
+			"last_seen":  formatTime(s.LastSeen),
+		}
+		for _, attr := range s.Attributes.Sorted() {
+			properties["attr_"+attr.Key] = attr.Value
+		}
+		collection.Features = append(collection.Features, geoJSONFeature{
+			Type:       "Feature",
+			ID:         s.Key(),
+			Geometry:   geoJSONGeometry{Type: "Point", Coordinates: coordinates(current.Latitude, current.Longitude)},
+			Properties: properties,
+		})
+
+		history := historyPoints(s, options)
+		if len(history) < 2 {
+			continue
+		}
+		line := make([][]float64, 0, len(history))
+		times := make([]string, 0, len(history))
+		strengths := make([]int, 0, len(history))
+		for _, p := range history {
+			line = append(line, coordinates(p.Latitude, p.Longitude))
+			times = append(times, formatTime(p.Time))
+			strengths = append(strengths, p.Strength)
+		}
+		collection.Features = append(collection.Features, geoJSONFeature{
+			Type:     "Feature",
+			ID:       s.Key() + "/track",
+			Geometry: geoJSONGeometry{Type: "LineString", Coordinates: line},
+			Properties: map[string]any{
+				"kind":      "track",
+				"id":        s.Key(),
+				"name":      s.Name,
+				"category":  s.Category.String(),
+				"times":     times,
+				"strengths": strengths,
+			},
+		})
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(collection)
+}
+
+// coordinates returns a GeoJSON position, which is longitude first
+func coordinates(lat, lon float64) []float64 {
+	return []float64{model.Round(lon, 7), model.Round(lat, 7)}
+}
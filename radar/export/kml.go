@@ -0,0 +1,132 @@
+package export
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/e6a5/radar/radar/model"
+)
+
+// kmlDocument is the root of a KML file
+type kmlDocument struct {
+	XMLName  xml.Name `xml:"kml"`
+	XMLNS    string   `xml:"xmlns,attr"`
+	Document struct {
+		Name    string      `xml:"name"`
+		Folders []kmlFolder `xml:"Folder"`
+	} `xml:"Document"`
+}
+
+// kmlFolder groups placemarks
+type kmlFolder struct {
+	Name       string         `xml:"name"`
+	Placemarks []kmlPlacemark `xml:"Placemark"`
+}
+
+// kmlPlacemark is a point or a track line
+type kmlPlacemark struct {
+	Name         string           `xml:"name"`
+	Description  string           `xml:"description,omitempty"`
+	TimeStamp    *kmlTimeStamp    `xml:"TimeStamp,omitempty"`
+	ExtendedData *kmlExtendedData `xml:"ExtendedData,omitempty"`
+	Point        *kmlGeometry     `xml:"Point,omitempty"`
+	LineString   *kmlGeometry     `xml:"LineString,omitempty"`
+}
+
+// kmlTimeStamp marks when a placemark was observed
+type kmlTimeStamp struct {
+	When string `xml:"when"`
+}
+
+// kmlGeometry holds the coordinates of a Point or LineString
+type kmlGeometry struct {
+	Coordinates string `xml:"coordinates"`
+}
+
+// kmlExtendedData carries the signal's fields as name/value pairs
+type kmlExtendedData struct {
+	Data []kmlData `xml:"Data"`
+}
+
+// kmlData is one name/value pair
+type kmlData struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value"`
+}
+
+// WriteKML writes the observer and signals as placemarks, with each signal's
+// history as a track line
+func WriteKML(w io.Writer, signals []model.Signal, options Options) error {
+	var doc kmlDocument
+	doc.XMLNS = "http://www.opengis.net/kml/2.2"
+	doc.Document.Name = "Radar export " + formatTime(options.Now)
+
+	observer := kmlFolder{Name: "Observer"}
+	observer.Placemarks = append(observer.Placemarks, kmlPlacemark{
+		Name:  "Observer",
+		Point: &kmlGeometry{Coordinates: kmlCoordinate(options.Latitude, options.Longitude)},
+	})
+
+	current := kmlFolder{Name: "Signals"}
+	tracks := kmlFolder{Name: "Tracks"}
+	for _, s := range signals {
+		p := currentPoint(s, options)
+		data := []kmlData{
+			{Name: "id", Value: s.Key()},
+			{Name: "type", Value: s.Type},
+			{Name: "category", Value: s.Category.String()},
+			{Name: "severity", Value: s.Severity.String()},
+			{Name: "strength", Value: fmt.Sprint(s.Strength)},
+			{Name: "distance", Value: fmt.Sprintf("%.2f", s.Distance)},
+			{Name: "bearing", Value: fmt.Sprintf("%.1f", p.Bearing)},
+			{Name: "first_seen", Value: formatTime(s.Lifetime)},
+			{Name: "last_seen", Value: formatTime(s.LastSeen)},
+		}
+		for _, attr := range s.Attributes.Sorted() {
+			data = append(data, kmlData{Name: attr.Key, Value: attr.Value})
+		}
+
+		placemark := kmlPlacemark{
+			Name:         s.Name,
+			Description:  fmt.Sprintf("%s, %d%% at %.1f°", s.Type, s.Strength, p.Bearing),
+			ExtendedData: &kmlExtendedData{Data: data},
+			Point:        &kmlGeometry{Coordinates: kmlCoordinate(p.Latitude, p.Longitude)},
+		}
+		if when := formatTime(s.LastSeen); when != "" {
+			placemark.TimeStamp = &kmlTimeStamp{When: when}
+		}
+		current.Placemarks = append(current.Placemarks, placemark)
+
+		history := historyPoints(s, options)
+		if len(history) < 2 {
+			continue
+		}
+		line := make([]string, 0, len(history))
+		for _, h := range history {
+			line = append(line, kmlCoordinate(h.Latitude, h.Longitude))
+		}
+		tracks.Placemarks = append(tracks.Placemarks, kmlPlacemark{
+			Name:       s.Name + " track",
+			LineString: &kmlGeometry{Coordinates: strings.Join(line, " ")},
+		})
+	}
+	doc.Document.Folders = []kmlFolder{observer, current, tracks}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(doc); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// kmlCoordinate formats a KML "lon,lat" coordinate
+func kmlCoordinate(lat, lon float64) string {
+	return fmt.Sprintf("%.7f,%.7f", lon, lat)
+}
@@ -0,0 +1,95 @@
+package guard
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"time"
+)
+
+// HookTimeout bounds how long an alarm hook command may run
+const HookTimeout = 30 * time.Second
+
+// DefaultLogPath returns where alarms are logged by default: alarms.log in
+// the radar config directory
+func DefaultLogPath() string {
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(configDir, "radar", "alarms.log")
+}
+
+// LogLine formats an event as one line of the alarm log
+func (e Event) LogLine() string {
+	return fmt.Sprintf("%s %s zone=%q signal=%q type=%s id=%q distance=%.1f bearing=%.0f\n",
+		e.Time.Format(time.RFC3339), e.Kind, e.Zone, e.Name, e.Type, e.ID, e.Distance, e.Bearing())
+}
+
+// AppendLog appends an event to the alarm log at path, creating the file and
+// its directory if needed
+func AppendLog(path string, event Event) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := file.WriteString(event.LogLine()); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// Environment returns the RADAR_* variables describing an event to a hook
+func (e Event) Environment() []string {
+	return []string{
+		"RADAR_EVENT=" + e.Kind.String(),
+		"RADAR_ZONE=" + e.Zone,
+		"RADAR_SIGNAL=" + e.Name,
+		"RADAR_SIGNAL_ID=" + e.ID,
+		"RADAR_SIGNAL_TYPE=" + e.Type,
+		"RADAR_DISTANCE=" + strconv.FormatFloat(e.Distance, 'f', 1, 64),
+		"RADAR_BEARING=" + strconv.FormatFloat(e.Bearing(), 'f', 0, 64),
+		"RADAR_TIME=" + e.Time.Format(time.RFC3339),
+		"RADAR_MESSAGE=" + e.String(),
+	}
+}
+
+// RunHook runs a shell command for an event with the event's details in its
+// environment, waiting at most HookTimeout
+func RunHook(ctx context.Context, command string, event Event) error {
+	ctx, cancel := context.WithTimeout(ctx, HookTimeout)
+	defer cancel()
+
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(ctx, "cmd", "/C", command)
+	} else {
+		cmd = exec.CommandContext(ctx, "sh", "-c", command)
+	}
+	cmd.Env = append(os.Environ(), event.Environment()...)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		if len(output) > 0 {
+			return fmt.Errorf("alarm hook: %v: %s", err, firstLine(output))
+		}
+		return fmt.Errorf("alarm hook: %v", err)
+	}
+	return nil
+}
+
+// firstLine returns the first line of command output
+func firstLine(output []byte) string {
+	for i, b := range output {
+		if b == '\n' || b == '\r' {
+			return string(output[:i])
+		}
+	}
+	return string(output)
+}
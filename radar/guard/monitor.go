@@ -0,0 +1,206 @@
+package guard
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/e6a5/radar/radar/model"
+)
+
+// EventKind is what a signal did in a zone
+type EventKind int
+
+const (
+	EventEnter EventKind = iota
+	EventLeave
+	EventLinger
+)
+
+// String returns the event name
+func (k EventKind) String() string {
+	switch k {
+	case EventLeave:
+		return "leave"
+	case EventLinger:
+		return "linger"
+	default:
+		return "enter"
+	}
+}
+
+// ParseEventKind returns the event kind with the given name
+func ParseEventKind(name string) (EventKind, bool) {
+	for k := EventEnter; k <= EventLinger; k++ {
+		if k.String() == name {
+			return k, true
+		}
+	}
+	return EventEnter, false
+}
+
+// Event is an alarm raised by a signal in a zone
+type Event struct {
+	Kind     EventKind
+	Zone     string
+	Time     time.Time
+	Since    time.Time // When the signal entered the zone
+	ID       string    // Signal identity; empty for simulated signals
+	Name     string
+	Type     string
+	Distance float64
+	Angle    float64 // Radians
+}
+
+// String describes the event for display
+func (e Event) String() string {
+	switch e.Kind {
+	case EventLeave:
+		return fmt.Sprintf("%s left %s", e.Name, e.Zone)
+	case EventLinger:
+		return fmt.Sprintf("%s in %s for %s", e.Name, e.Zone, e.Time.Sub(e.Since).Round(time.Second))
+	default:
+		return fmt.Sprintf("%s entered %s", e.Name, e.Zone)
+	}
+}
+
+// Bearing returns the event position's bearing in degrees within [0, 360)
+func (e Event) Bearing() float64 {
+	return normalizeAngle(e.Angle) * 180 / math.Pi
+}
+
+// presence is a signal's stay inside a zone
+type presence struct {
+	entered  time.Time
+	lingered bool // A linger alarm was raised for this stay
+	last     Event
+}
+
+// Monitor follows which signals are inside each zone. It is not safe for
+// concurrent use.
+type Monitor struct {
+	zones  []Zone
+	inside map[string]map[string]*presence // Zone name -> presence key -> stay
+}
+
+// PresenceKey returns the identity a signal's stays in zones are kept under:
+// its ID, or for signals without one, such as simulated signals that share
+// names, the display track it belongs to. It is empty for a signal with
+// neither, which cannot be followed through a zone.
+func PresenceKey(s *model.Signal) string {
+	switch {
+	case s.ID != "":
+		return s.ID
+	case s.Track != 0:
+		return fmt.Sprintf("track:%d", s.Track)
+	default:
+		return ""
+	}
+}
+
+// NewMonitor creates a monitor for the given zones
+func NewMonitor(zones []Zone) *Monitor {
+	m := &Monitor{inside: make(map[string]map[string]*presence)}
+	m.SetZones(zones)
+	return m
+}
+
+// SetZones replaces the zones. Signals stay inside zones whose name is kept
+// until they are next observed.
+func (m *Monitor) SetZones(zones []Zone) {
+	m.zones = append([]Zone(nil), zones...)
+	kept := make(map[string]map[string]*presence, len(zones))
+	for _, zone := range zones {
+		if stays, ok := m.inside[zone.Name]; ok {
+			kept[zone.Name] = stays
+		} else {
+			kept[zone.Name] = make(map[string]*presence)
+		}
+	}
+	m.inside = kept
+}
+
+// Zones returns the watched zones
+func (m *Monitor) Zones() []Zone {
+	return append([]Zone(nil), m.zones...)
+}
+
+// Occupied returns how many signals are inside the named zone
+func (m *Monitor) Occupied(zone string) int {
+	return len(m.inside[zone])
+}
+
+// Observe checks a detection of a signal against every zone and returns the
+// alarms it raises. Signals without a presence key are ignored.
+func (m *Monitor) Observe(s *model.Signal, now time.Time) []Event {
+	var events []Event
+	key := PresenceKey(s)
+	if key == "" {
+		return nil
+	}
+	for _, zone := range m.zones {
+		if !zone.Watches(s.Category) {
+			continue
+		}
+		stays := m.inside[zone.Name]
+		stay, wasInside := stays[key]
+		event := Event{
+			Zone:     zone.Name,
+			Time:     now,
+			ID:       s.ID,
+			Name:     s.Name,
+			Type:     s.Type,
+			Distance: s.Distance,
+			Angle:    s.Angle,
+		}
+
+		switch isInside := zone.Contains(s.Distance, s.Angle); {
+		case isInside && !wasInside:
+			event.Since = now
+			stays[key] = &presence{entered: now, last: event}
+			if zone.OnEnter {
+				event.Kind = EventEnter
+				events = append(events, event)
+			}
+		case !isInside && wasInside:
+			delete(stays, key)
+			if zone.OnLeave {
+				event.Kind = EventLeave
+				event.Since = stay.entered
+				events = append(events, event)
+			}
+		case isInside:
+			event.Since = stay.entered
+			stay.last = event
+			if zone.Linger > 0 && !stay.lingered && now.Sub(stay.entered) >= zone.Linger {
+				stay.lingered = true
+				event.Kind = EventLinger
+				events = append(events, event)
+			}
+		}
+	}
+	return events
+}
+
+// Expire treats signals that are no longer on the scope as having left their
+// zones, at their last observed position. present reports whether a
+// presence key is still on the scope.
+func (m *Monitor) Expire(present func(key string) bool, now time.Time) []Event {
+	var events []Event
+	for _, zone := range m.zones {
+		stays := m.inside[zone.Name]
+		for key, stay := range stays {
+			if present(key) {
+				continue
+			}
+			delete(stays, key)
+			if zone.OnLeave {
+				event := stay.last
+				event.Kind = EventLeave
+				event.Time = now
+				events = append(events, event)
+			}
+		}
+	}
+	return events
+}
@@ -0,0 +1,84 @@
+package guard
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/e6a5/radar/radar/model"
+)
+
+// door is a quarter disc out to 5 m around bearing zero
+var door = Zone{
+	Name:         "Door",
+	MaxRange:     5,
+	StartBearing: -math.Pi / 4,
+	EndBearing:   math.Pi / 4,
+	OnEnter:      true,
+	OnLeave:      true,
+}
+
+func kinds(events []Event) []EventKind {
+	list := make([]EventKind, len(events))
+	for i, e := range events {
+		list[i] = e.Kind
+	}
+	return list
+}
+
+func TestSameNamedSignals(t *testing.T) {
+	m := NewMonitor([]Zone{door})
+	now := time.Unix(1700000000, 0)
+
+	// Two simulated signals share a name; one sits in the zone, one outside
+	inside := &model.Signal{Type: "Bluetooth", Name: "SIM-Bluetooth", Distance: 2, Track: 1}
+	outside := &model.Signal{Type: "Bluetooth", Name: "SIM-Bluetooth", Distance: 2, Angle: math.Pi, Track: 2}
+	if inside.Key() != outside.Key() {
+		t.Fatal("the signals should share a display key")
+	}
+
+	var events []Event
+	for sweep := 0; sweep < 5; sweep++ {
+		at := now.Add(time.Duration(sweep) * time.Second)
+		events = append(events, m.Observe(inside, at)...)
+		events = append(events, m.Observe(outside, at)...)
+	}
+	if len(events) != 1 || events[0].Kind != EventEnter {
+		t.Errorf("events over 5 sweeps = %v, want a single enter", kinds(events))
+	}
+	if n := m.Occupied("Door"); n != 1 {
+		t.Errorf("occupied by %d, want 1", n)
+	}
+
+	// The one inside disappears while its namesake is still on the scope
+	present := map[string]bool{PresenceKey(outside): true}
+	events = m.Expire(func(key string) bool { return present[key] }, now.Add(10*time.Second))
+	if len(events) != 1 || events[0].Kind != EventLeave || events[0].Distance != inside.Distance {
+		t.Errorf("expire events = %+v, want the signal inside to leave", events)
+	}
+	if n := m.Occupied("Door"); n != 0 {
+		t.Errorf("occupied by %d after it left, want 0", n)
+	}
+}
+
+func TestPresenceKey(t *testing.T) {
+	tests := []struct {
+		signal model.Signal
+		want   string
+	}{
+		{model.Signal{ID: "bt:AA", Name: "Keys", Track: 4}, "bt:AA"},
+		{model.Signal{Name: "SIM-WiFi", Track: 4}, "track:4"},
+		{model.Signal{Name: "SIM-WiFi"}, ""},
+	}
+	for _, tt := range tests {
+		if got := PresenceKey(&tt.signal); got != tt.want {
+			t.Errorf("PresenceKey(%+v) = %q, want %q", tt.signal, got, tt.want)
+		}
+	}
+
+	// Untracked signals without an ID never enter a zone
+	m := NewMonitor([]Zone{door})
+	if events := m.Observe(&model.Signal{Name: "SIM-WiFi", Distance: 1}, time.Now()); len(events) != 0 {
+		t.Errorf("events = %v, want none", kinds(events))
+	}
+}
@@ -0,0 +1,68 @@
+// Package guard watches guard zones, annular sectors of the scope, and
+// reports signals that enter, leave or linger inside them.
+package guard
+
+import (
+	"math"
+	"time"
+
+	"github.com/e6a5/radar/radar/model"
+)
+
+// DefaultLinger is how long a signal stays in a zone before a linger alarm
+// when a zone does not set its own time
+const DefaultLinger = 30 * time.Second
+
+// Zone is an annular sector between two ranges, running clockwise on the
+// scope (in the direction of increasing angle) from StartBearing to
+// EndBearing. Equal bearings make a full ring.
+type Zone struct {
+	Name         string
+	MinRange     float64          // Meters
+	MaxRange     float64          // Meters
+	StartBearing float64          // Radians
+	EndBearing   float64          // Radians
+	Categories   []model.Category // Signal categories watched; all if empty
+	OnEnter      bool             // Alarm when a signal enters
+	OnLeave      bool             // Alarm when a signal leaves
+	Linger       time.Duration    // Alarm when a signal stays this long; never if zero
+}
+
+// Span returns the angular width of the zone in radians
+func (z Zone) Span() float64 {
+	span := normalizeAngle(z.EndBearing - z.StartBearing)
+	if span == 0 {
+		return 2 * math.Pi
+	}
+	return span
+}
+
+// Contains reports whether a position lies inside the zone
+func (z Zone) Contains(distance, angle float64) bool {
+	if distance < z.MinRange || distance > z.MaxRange {
+		return false
+	}
+	return normalizeAngle(angle-z.StartBearing) <= z.Span()
+}
+
+// Watches reports whether the zone raises alarms for a signal category
+func (z Zone) Watches(category model.Category) bool {
+	if len(z.Categories) == 0 {
+		return true
+	}
+	for _, c := range z.Categories {
+		if c == category {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeAngle returns an angle within [0, 2π)
+func normalizeAngle(angle float64) float64 {
+	angle = math.Mod(angle, 2*math.Pi)
+	if angle < 0 {
+		angle += 2 * math.Pi
+	}
+	return angle
+}
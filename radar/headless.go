@@ -0,0 +1,75 @@
+package radar
+
+import (
+	"context"
+	"errors"
+	"io"
+	"time"
+
+	"github.com/e6a5/radar/radar/scanner"
+	"github.com/e6a5/radar/radar/stream"
+)
+
+// HeadlessOptions configures a scan run without the display
+type HeadlessOptions struct {
+	Scanners  []string           // Scanner names to run; the config's scanners if empty
+	Once      bool               // Run a single scan and return
+	Observers []scanner.Observer // Receive every scan result, e.g. a session recorder
+}
+
+// RunHeadless scans every config.ScanInterval and writes every observation to
+// w as a JSON line until ctx is cancelled, or after the first scan in
+// single-shot mode. config.MaxSignals limits the signals of each scanner.
+func RunHeadless(ctx context.Context, config Config, options HeadlessOptions, w io.Writer) error {
+	scannerConfig, err := newScannerConfig(&config)
+	if err != nil {
+		return err
+	}
+	names := options.Scanners
+	if len(names) == 0 {
+		names = config.Scanners
+	}
+	scanners, err := NewScanners(names, scannerConfig)
+	if err != nil {
+		return err
+	}
+
+	coordinator := scanner.NewCoordinator(scannerConfig)
+	defer coordinator.Close()
+	for _, s := range scanners {
+		coordinator.AddScanner(s)
+	}
+	for _, observer := range options.Observers {
+		coordinator.AddObserver(observer)
+	}
+	if len(coordinator.GetScanners()) == 0 {
+		return errors.New("no scanners available on this system")
+	}
+
+	encoder := stream.NewEncoder(w)
+	ticker := time.NewTicker(scannerConfig.ScanInterval)
+	defer ticker.Stop()
+
+	for {
+		signals, err := coordinator.ScanNow(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		if err := encoder.Encode(signals, time.Now()); err != nil {
+			return err
+		}
+
+		if options.Once {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
@@ -26,8 +26,10 @@ func (rd *Display) showHelpScreen(screen tcell.Screen) {
 		"  1-6        - Toggle signal types (WiFi, Bluetooth, etc.)",
 		"  A          - Toggle all signal types",
 		"  F          - Toggle filtering system",
+		"  U          - Show only one process's connections (cycles)",
 		"  T          - Toggle signal trails",
 		"  L          - Toggle signal labels",
+		"  K          - Toggle track numbers and predictions",
 		"  S          - Switch real/simulated data",
 		"",
 		"INFORMATION & SELECTION:",
@@ -35,7 +37,22 @@ func (rd *Display) showHelpScreen(screen tcell.Screen) {
 		"  P          - Select previous signal",
 		"  C          - Clear signal selection",
 		"  I          - Toggle information panel",
+		"  PgUp/PgDn  - Scroll information panel details",
+		"  X          - Add/switch EBL/VRM cursor (arrows adjust)",
+		"  G          - Anchor cursor to selected signal",
+		"  W          - Remove cursor",
+		"  D          - Pin selected signal's bearing (to the active EBL)",
+		"  J          - Add guard zone outlined by the cursors",
+		"  Y          - Remove last guard zone",
 		"  V          - Toggle performance stats",
+		"  E          - Export signals to CSV, GeoJSON and KML",
+		"",
+		"REPLAY (radar replay FILE):",
+		"  [ / ]      - Halve/double playback speed (0.25x-16x)",
+		"  , / .      - Seek back/forward 10 seconds",
+		"  < / >      - Seek back/forward 1 minute",
+		"  B          - Restart from the beginning",
+		"  O          - Toggle looping",
 		"",
 		"ADVANCED:",
 		"  H          - Show/hide this help",
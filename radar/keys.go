@@ -0,0 +1,170 @@
+package radar
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Action is a display command that can be bound to a key
+type Action string
+
+const (
+	ActionQuit              Action = "quit"
+	ActionPause             Action = "pause"
+	ActionZoomIn            Action = "zoom-in"
+	ActionZoomOut           Action = "zoom-out"
+	ActionZoomReset         Action = "zoom-reset"
+	ActionToggleZoom        Action = "toggle-zoom"
+	ActionTogglePan         Action = "toggle-pan"
+	ActionResetView         Action = "reset-view"
+	ActionToggleWiFi        Action = "toggle-wifi"
+	ActionToggleBluetooth   Action = "toggle-bluetooth"
+	ActionToggleCellular    Action = "toggle-cellular"
+	ActionToggleRadio       Action = "toggle-radio"
+	ActionToggleIoT         Action = "toggle-iot"
+	ActionToggleSatellite   Action = "toggle-satellite"
+	ActionToggleAll         Action = "toggle-all"
+	ActionToggleFiltering   Action = "toggle-filtering"
+	ActionToggleTrails      Action = "toggle-trails"
+	ActionToggleInfo        Action = "toggle-info"
+	ActionSelectNext        Action = "select-next"
+	ActionSelectPrevious    Action = "select-previous"
+	ActionClearSelection    Action = "clear-selection"
+	ActionToggleDataMode    Action = "toggle-data-mode"
+	ActionToggleLabels      Action = "toggle-labels"
+	ActionToggleTracks      Action = "toggle-tracks"
+	ActionTogglePerformance Action = "toggle-performance"
+	ActionToggleHelp        Action = "toggle-help"
+	ActionExport            Action = "export"
+	ActionFilterProcess     Action = "filter-process"
+	ActionCursor            Action = "cursor"
+	ActionAnchorCursor      Action = "anchor-cursor"
+	ActionRemoveCursor      Action = "remove-cursor"
+	ActionPinBearing        Action = "pin-bearing"
+	ActionAddZone           Action = "add-zone"
+	ActionRemoveZone        Action = "remove-zone"
+	// Replay controls, active while replaying a recorded session
+	ActionReplaySlower      Action = "replay-slower"
+	ActionReplayFaster      Action = "replay-faster"
+	ActionReplayBack        Action = "replay-back"
+	ActionReplayForward     Action = "replay-forward"
+	ActionReplayBackLong    Action = "replay-back-long"
+	ActionReplayForwardLong Action = "replay-forward-long"
+	ActionReplayRestart     Action = "replay-restart"
+	ActionReplayToggleLoop  Action = "replay-loop"
+)
+
+// KeyBindings maps keys to the actions they trigger
+type KeyBindings map[rune]Action
+
+// defaultKeys lists the default key(s) of every action; letters match either case
+var defaultKeys = []struct {
+	action Action
+	keys   string
+}{
+	{ActionQuit, "q"},
+	{ActionPause, " "},
+	{ActionZoomIn, "+="},
+	{ActionZoomOut, "-_"},
+	{ActionZoomReset, "0"},
+	{ActionToggleZoom, "z"},
+	{ActionTogglePan, "m"},
+	{ActionResetView, "r"},
+	{ActionToggleWiFi, "1"},
+	{ActionToggleBluetooth, "2"},
+	{ActionToggleCellular, "3"},
+	{ActionToggleRadio, "4"},
+	{ActionToggleIoT, "5"},
+	{ActionToggleSatellite, "6"},
+	{ActionToggleAll, "a"},
+	{ActionToggleFiltering, "f"},
+	{ActionToggleTrails, "t"},
+	{ActionToggleInfo, "i"},
+	{ActionSelectNext, "n"},
+	{ActionSelectPrevious, "p"},
+	{ActionClearSelection, "c"},
+	{ActionToggleDataMode, "s"},
+	{ActionToggleLabels, "l"},
+	{ActionToggleTracks, "k"},
+	{ActionTogglePerformance, "v"},
+	{ActionToggleHelp, "h"},
+	{ActionExport, "e"},
+	{ActionFilterProcess, "u"},
+	{ActionCursor, "x"},
+	{ActionAnchorCursor, "g"},
+	{ActionRemoveCursor, "w"},
+	{ActionPinBearing, "d"},
+	{ActionAddZone, "j"},
+	{ActionRemoveZone, "y"},
+	{ActionReplaySlower, "["},
+	{ActionReplayFaster, "]"},
+	{ActionReplayBack, ","},
+	{ActionReplayForward, "."},
+	{ActionReplayBackLong, "<"},
+	{ActionReplayForwardLong, ">"},
+	{ActionReplayRestart, "b"},
+	{ActionReplayToggleLoop, "o"},
+}
+
+// DefaultKeyBindings returns the built-in key bindings
+func DefaultKeyBindings() KeyBindings {
+	bindings := make(KeyBindings)
+	for _, d := range defaultKeys {
+		for _, r := range d.keys {
+			bindings.bind(r, d.action)
+		}
+	}
+	return bindings
+}
+
+// IsAction reports whether name is a known action
+func IsAction(name string) bool {
+	for _, d := range defaultKeys {
+		if string(d.action) == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Clone returns a copy of the bindings
+func (k KeyBindings) Clone() KeyBindings {
+	clone := make(KeyBindings, len(k))
+	for r, action := range k {
+		clone[r] = action
+	}
+	return clone
+}
+
+// Rebind replaces every key of action with the given key
+func (k KeyBindings) Rebind(action Action, key rune) {
+	for r, bound := range k {
+		if bound == action {
+			delete(k, r)
+		}
+	}
+	k.bind(key, action)
+}
+
+// bind maps key to action, binding both cases of a letter
+func (k KeyBindings) bind(key rune, action Action) {
+	k[key] = action
+	if unicode.IsLetter(key) {
+		k[unicode.ToLower(key)] = action
+		k[unicode.ToUpper(key)] = action
+	}
+}
+
+// ParseKey parses a key name: a single character or "space"
+func ParseKey(name string) (rune, error) {
+	if strings.EqualFold(name, "space") {
+		return ' ', nil
+	}
+	if utf8.RuneCountInString(name) != 1 {
+		return 0, fmt.Errorf("invalid key %q: use a single character or \"space\"", name)
+	}
+	r, _ := utf8.DecodeRuneInString(name)
+	return r, nil
+}
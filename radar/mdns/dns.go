@@ -0,0 +1,260 @@
+package mdns
+
+import (
+	"encoding/binary"
+	"errors"
+	"net"
+	"strings"
+)
+
+// DNS record types used by DNS-SD
+const (
+	typeA    uint16 = 1
+	typePTR  uint16 = 12
+	typeTXT  uint16 = 16
+	typeAAAA uint16 = 28
+	typeSRV  uint16 = 33
+)
+
+const (
+	classIN         uint16 = 1
+	classMask       uint16 = 0x7fff // The top bit is the mDNS unicast-response or cache-flush flag
+	unicastResponse uint16 = 0x8000
+	flagResponse    uint16 = 0x8000
+	maxPointerHops         = 16
+)
+
+var errMalformed = errors.New("mdns: malformed message")
+
+// question is one entry of a query's question section
+type question struct {
+	name  string
+	qtype uint16
+	class uint16
+}
+
+// record is a resource record with its data decoded for the types DNS-SD uses
+type record struct {
+	name   string
+	rtype  uint16
+	flush  bool // Cache-flush bit: this record replaces earlier ones of the same name and type
+	ttl    uint32
+	target string   // PTR and SRV target name
+	port   int      // SRV port
+	txt    []string // TXT strings
+	ip     net.IP   // A and AAAA address
+}
+
+// message is a parsed DNS message. Answers, authority and additional records
+// are kept together since mDNS treats them alike.
+type message struct {
+	id        uint16
+	flags     uint16
+	questions []question
+	records   []record
+}
+
+// isResponse reports whether the message is a response
+func (m message) isResponse() bool {
+	return m.flags&flagResponse != 0
+}
+
+// encodeQuery builds a query message for the questions
+func encodeQuery(questions []question) []byte {
+	buf := make([]byte, 12, 512)
+	binary.BigEndian.PutUint16(buf[4:6], uint16(len(questions)))
+	for _, q := range questions {
+		buf = appendName(buf, q.name)
+		buf = binary.BigEndian.AppendUint16(buf, q.qtype)
+		buf = binary.BigEndian.AppendUint16(buf, q.class)
+	}
+	return buf
+}
+
+// appendName appends a name in uncompressed wire format
+func appendName(buf []byte, name string) []byte {
+	for _, label := range splitName(name) {
+		if len(label) > 63 {
+			label = label[:63]
+		}
+		buf = append(buf, byte(len(label)))
+		buf = append(buf, label...)
+	}
+	return append(buf, 0)
+}
+
+// parseMessage decodes a DNS message
+func parseMessage(data []byte) (message, error) {
+	if len(data) < 12 {
+		return message{}, errMalformed
+	}
+	m := message{
+		id:    binary.BigEndian.Uint16(data[0:2]),
+		flags: binary.BigEndian.Uint16(data[2:4]),
+	}
+	qdcount := int(binary.BigEndian.Uint16(data[4:6]))
+	rrcount := int(binary.BigEndian.Uint16(data[6:8])) +
+		int(binary.BigEndian.Uint16(data[8:10])) +
+		int(binary.BigEndian.Uint16(data[10:12]))
+
+	offset := 12
+	for i := 0; i < qdcount; i++ {
+		name, next, err := readName(data, offset)
+		if err != nil || next+4 > len(data) {
+			return message{}, errMalformed
+		}
+		m.questions = append(m.questions, question{
+			name:  name,
+			qtype: binary.BigEndian.Uint16(data[next : next+2]),
+			class: binary.BigEndian.Uint16(data[next+2 : next+4]),
+		})
+		offset = next + 4
+	}
+
+	for i := 0; i < rrcount; i++ {
+		rr, next, err := readRecord(data, offset)
+		if err != nil {
+			return message{}, err
+		}
+		m.records = append(m.records, rr)
+		offset = next
+	}
+	return m, nil
+}
+
+// readRecord decodes the resource record at offset and returns the offset
+// after it
+func readRecord(data []byte, offset int) (record, int, error) {
+	name, next, err := readName(data, offset)
+	if err != nil || next+10 > len(data) {
+		return record{}, 0, errMalformed
+	}
+	class := binary.BigEndian.Uint16(data[next+2 : next+4])
+	rr := record{
+		name:  name,
+		rtype: binary.BigEndian.Uint16(data[next : next+2]),
+		flush: class&^classMask != 0,
+		ttl:   binary.BigEndian.Uint32(data[next+4 : next+8]),
+	}
+	length := int(binary.BigEndian.Uint16(data[next+8 : next+10]))
+	start := next + 10
+	end := start + length
+	if end > len(data) {
+		return record{}, 0, errMalformed
+	}
+	rdata := data[start:end]
+
+	switch rr.rtype {
+	case typeA:
+		if len(rdata) == net.IPv4len {
+			rr.ip = append(net.IP(nil), rdata...)
+		}
+	case typeAAAA:
+		if len(rdata) == net.IPv6len {
+			rr.ip = append(net.IP(nil), rdata...)
+		}
+	case typePTR:
+		// Names inside record data may point anywhere in the message
+		rr.target, _, err = readName(data, start)
+		if err != nil {
+			return record{}, 0, err
+		}
+	case typeSRV:
+		if len(rdata) < 7 {
+			return record{}, 0, errMalformed
+		}
+		rr.port = int(binary.BigEndian.Uint16(rdata[4:6]))
+		rr.target, _, err = readName(data, start+6)
+		if err != nil {
+			return record{}, 0, err
+		}
+	case typeTXT:
+		for i := 0; i < len(rdata); {
+			n := int(rdata[i])
+			if i+1+n > len(rdata) {
+				return record{}, 0, errMalformed
+			}
+			if n > 0 {
+				rr.txt = append(rr.txt, string(rdata[i+1:i+1+n]))
+			}
+			i += 1 + n
+		}
+	}
+	return rr, end, nil
+}
+
+// readName decodes a possibly compressed name at offset and returns it with
+// a trailing dot, along with the offset after the name's own bytes
+func readName(data []byte, offset int) (string, int, error) {
+	var labels []string
+	end := -1
+	for hops := 0; ; {
+		if offset >= len(data) {
+			return "", 0, errMalformed
+		}
+		length := int(data[offset])
+		switch {
+		case length == 0:
+			if end < 0 {
+				end = offset + 1
+			}
+			return strings.Join(labels, ".") + ".", end, nil
+		case length&0xc0 == 0xc0:
+			if offset+1 >= len(data) {
+				return "", 0, errMalformed
+			}
+			if end < 0 {
+				end = offset + 2
+			}
+			hops++
+			if hops > maxPointerHops {
+				return "", 0, errMalformed
+			}
+			offset = int(binary.BigEndian.Uint16(data[offset:offset+2]) & 0x3fff)
+		case length&0xc0 != 0:
+			return "", 0, errMalformed
+		default:
+			if offset+1+length > len(data) {
+				return "", 0, errMalformed
+			}
+			labels = append(labels, escapeLabel(string(data[offset+1:offset+1+length])))
+			offset += 1 + length
+		}
+	}
+}
+
+// escapeLabel escapes the dots and backslashes inside a label, which DNS-SD
+// instance names may contain
+func escapeLabel(label string) string {
+	if !strings.ContainsAny(label, `.\`) {
+		return label
+	}
+	return strings.NewReplacer(`\`, `\\`, ".", `\.`).Replace(label)
+}
+
+// splitName splits a name into its unescaped labels
+func splitName(name string) []string {
+	labels := make([]string, 0, 4)
+	var label strings.Builder
+	escaped := false
+	for _, r := range name {
+		switch {
+		case escaped:
+			label.WriteRune(r)
+			escaped = false
+		case r == '\\':
+			escaped = true
+		case r == '.':
+			if label.Len() > 0 {
+				labels = append(labels, label.String())
+			}
+			label.Reset()
+		default:
+			label.WriteRune(r)
+		}
+	}
+	if label.Len() > 0 {
+		labels = append(labels, label.String())
+	}
+	return labels
+}
@@ -0,0 +1,215 @@
+package mdns
+
+import (
+	"encoding/binary"
+	"net"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// responseBuilder assembles a response message, compressing names against
+// the ones already written as responders do
+type responseBuilder struct {
+	buf     []byte
+	count   int
+	offsets map[string]int // Offset of each name suffix written so far
+}
+
+func newResponseBuilder() *responseBuilder {
+	return &responseBuilder{buf: make([]byte, 12), offsets: make(map[string]int)}
+}
+
+// name appends a name, ending in a pointer to the longest suffix already written
+func (b *responseBuilder) name(name string) {
+	labels := splitName(name)
+	for i := range labels {
+		suffix := strings.ToLower(strings.Join(labels[i:], "."))
+		if offset, ok := b.offsets[suffix]; ok {
+			b.buf = binary.BigEndian.AppendUint16(b.buf, 0xc000|uint16(offset))
+			return
+		}
+		if len(b.buf) < 0x3fff {
+			b.offsets[suffix] = len(b.buf)
+		}
+		b.buf = append(b.buf, byte(len(labels[i])))
+		b.buf = append(b.buf, labels[i]...)
+	}
+	b.buf = append(b.buf, 0)
+}
+
+// record appends a resource record; rdata writes the record data
+func (b *responseBuilder) record(name string, rtype uint16, flush bool, ttl uint32, rdata func()) {
+	b.name(name)
+	class := classIN
+	if flush {
+		class |= 0x8000
+	}
+	b.buf = binary.BigEndian.AppendUint16(b.buf, rtype)
+	b.buf = binary.BigEndian.AppendUint16(b.buf, class)
+	b.buf = binary.BigEndian.AppendUint32(b.buf, ttl)
+	lengthAt := len(b.buf)
+	b.buf = append(b.buf, 0, 0)
+	rdata()
+	binary.BigEndian.PutUint16(b.buf[lengthAt:], uint16(len(b.buf)-lengthAt-2))
+	b.count++
+}
+
+func (b *responseBuilder) ptr(name, target string, ttl uint32) {
+	b.record(name, typePTR, false, ttl, func() { b.name(target) })
+}
+
+func (b *responseBuilder) srv(instance, target string, port int, ttl uint32) {
+	b.record(instance, typeSRV, true, ttl, func() {
+		b.buf = append(b.buf, 0, 0, 0, 0) // Priority and weight
+		b.buf = binary.BigEndian.AppendUint16(b.buf, uint16(port))
+		b.name(target)
+	})
+}
+
+func (b *responseBuilder) txt(instance string, ttl uint32, entries ...string) {
+	b.record(instance, typeTXT, true, ttl, func() {
+		for _, entry := range entries {
+			b.buf = append(b.buf, byte(len(entry)))
+			b.buf = append(b.buf, entry...)
+		}
+	})
+}
+
+func (b *responseBuilder) address(host string, ip net.IP, ttl uint32) {
+	rtype := typeAAAA
+	if ip4 := ip.To4(); ip4 != nil {
+		rtype, ip = typeA, ip4
+	}
+	b.record(host, rtype, true, ttl, func() { b.buf = append(b.buf, ip...) })
+}
+
+// bytes returns the message with its header filled in
+func (b *responseBuilder) bytes() []byte {
+	binary.BigEndian.PutUint16(b.buf[2:4], flagResponse|0x0400) // Authoritative answer
+	binary.BigEndian.PutUint16(b.buf[6:8], uint16(b.count))
+	return b.buf
+}
+
+func TestReadName(t *testing.T) {
+	// "local." at 0, "printer.local." at 7 pointing back to it, then names
+	// built from pointers
+	data := []byte{
+		5, 'l', 'o', 'c', 'a', 'l', 0, // 0: local.
+		7, 'p', 'r', 'i', 'n', 't', 'e', 'r', 0xc0, 0, // 7: printer.local.
+		0xc0, 7, // 17: pointer to printer.local.
+		3, 'a', '.', 'b', 0xc0, 0, // 19: a label with a dot, then local.
+		2, '_', 'x', 0xc0, 17, // 25: a pointer to a pointer
+	}
+	tests := []struct {
+		name   string
+		offset int
+		want   string
+		end    int
+	}{
+		{"uncompressed", 0, "local.", 7},
+		{"labels then pointer", 7, "printer.local.", 17},
+		{"pointer only", 17, "printer.local.", 19},
+		{"escaped dot", 19, `a\.b.local.`, 25},
+		{"chained pointers", 25, "_x.printer.local.", 30},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, end, err := readName(data, tt.offset)
+			if err != nil {
+				t.Fatalf("readName: %v", err)
+			}
+			if got != tt.want || end != tt.end {
+				t.Errorf("readName = %q ending at %d, want %q ending at %d", got, end, tt.want, tt.end)
+			}
+		})
+	}
+}
+
+func TestReadNameRejects(t *testing.T) {
+	// A chain of maxPointerHops pointers, each to the one before, ending in the root
+	chain := []byte{0}
+	for previous := 0; len(chain) < 1+2*maxPointerHops; {
+		next := len(chain)
+		chain = binary.BigEndian.AppendUint16(chain, 0xc000|uint16(previous))
+		previous = next
+	}
+
+	tests := []struct {
+		name   string
+		data   []byte
+		offset int
+	}{
+		{"pointer to itself", []byte{0xc0, 0}, 0},
+		{"pointer loop", []byte{1, 'a', 0xc0, 4, 1, 'b', 0xc0, 0}, 0},
+		{"pointer past the end", []byte{1, 'a', 0xc0, 0x10}, 0},
+		{"pointer far past the end", []byte{0xff, 0xff}, 0},
+		{"truncated pointer", []byte{1, 'a', 0xc0}, 0},
+		{"truncated label", []byte{5, 'l', 'o', 'c'}, 0},
+		{"missing root", []byte{1, 'a'}, 0},
+		{"reserved label type", []byte{0x40, 'a', 0}, 0},
+		{"offset past the end", []byte{0}, 1},
+		{"too many pointers", binary.BigEndian.AppendUint16(chain, 0xc000|uint16(len(chain)-2)), len(chain)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if name, _, err := readName(tt.data, tt.offset); err == nil {
+				t.Errorf("readName = %q, want an error", name)
+			}
+		})
+	}
+
+	// The longest allowed chain still resolves
+	if name, _, err := readName(chain, len(chain)-2); err != nil || name != "." {
+		t.Errorf("readName of %d pointers = %q, %v", maxPointerHops, name, err)
+	}
+}
+
+func TestParseMessage(t *testing.T) {
+	b := newResponseBuilder()
+	b.ptr("_ipp._tcp.local.", "Office._ipp._tcp.local.", 4500)
+	b.srv("Office._ipp._tcp.local.", "printer.local.", 631, 120)
+	b.txt("Office._ipp._tcp.local.", 4500, "ty=LaserJet", "", "rp=ipp/print")
+	b.address("printer.local.", net.ParseIP("192.0.2.7"), 120)
+	b.address("printer.local.", net.ParseIP("fe80::7"), 0)
+
+	msg, err := parseMessage(b.bytes())
+	if err != nil {
+		t.Fatalf("parseMessage: %v", err)
+	}
+	if !msg.isResponse() {
+		t.Error("message is not a response")
+	}
+	want := []record{
+		{name: "_ipp._tcp.local.", rtype: typePTR, ttl: 4500, target: "Office._ipp._tcp.local."},
+		{name: "Office._ipp._tcp.local.", rtype: typeSRV, flush: true, ttl: 120, target: "printer.local.", port: 631},
+		{name: "Office._ipp._tcp.local.", rtype: typeTXT, flush: true, ttl: 4500, txt: []string{"ty=LaserJet", "rp=ipp/print"}},
+		{name: "printer.local.", rtype: typeA, flush: true, ttl: 120, ip: net.ParseIP("192.0.2.7").To4()},
+		{name: "printer.local.", rtype: typeAAAA, flush: true, ip: net.ParseIP("fe80::7")},
+	}
+	if !reflect.DeepEqual(msg.records, want) {
+		t.Errorf("records = %+v\nwant %+v", msg.records, want)
+	}
+
+	// Every truncation of the message is rejected
+	data := b.bytes()
+	for n := 12; n < len(data); n++ {
+		if _, err := parseMessage(data[:n]); err == nil {
+			t.Errorf("parsing %d of %d bytes succeeded", n, len(data))
+		}
+	}
+}
+
+func TestEncodeQuery(t *testing.T) {
+	questions := []question{
+		{servicesName, typePTR, classIN | unicastResponse},
+		{`My\.Printer._ipp._tcp.local.`, typeSRV, classIN},
+	}
+	msg, err := parseMessage(encodeQuery(questions))
+	if err != nil {
+		t.Fatalf("parseMessage: %v", err)
+	}
+	if msg.isResponse() || !reflect.DeepEqual(msg.questions, questions) {
+		t.Errorf("questions = %+v, want %+v", msg.questions, questions)
+	}
+}
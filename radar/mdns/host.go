@@ -0,0 +1,204 @@
+package mdns
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/e6a5/radar/radar/model"
+	"github.com/e6a5/radar/radar/scanner"
+)
+
+// Host is a device on the link and the services it offers
+type Host struct {
+	Name      string // Host name, e.g. "office-printer.local."
+	Addresses []string
+	Services  []*Service
+}
+
+// serviceNames maps service types to readable names
+var serviceNames = map[string]string{
+	"_airplay._tcp":         "AirPlay",
+	"_raop._tcp":            "AirPlay Audio",
+	"_googlecast._tcp":      "Google Cast",
+	"_spotify-connect._tcp": "Spotify Connect",
+	"_hap._tcp":             "HomeKit",
+	"_homekit._tcp":         "HomeKit",
+	"_matter._tcp":          "Matter",
+	"_ipp._tcp":             "IPP Printer",
+	"_ipps._tcp":            "IPP Printer",
+	"_printer._tcp":         "LPD Printer",
+	"_pdl-datastream._tcp":  "Raw Printer",
+	"_scanner._tcp":         "Scanner",
+	"_uscan._tcp":           "eSCL Scanner",
+	"_http._tcp":            "Web",
+	"_smb._tcp":             "SMB",
+	"_afpovertcp._tcp":      "AFP",
+	"_ssh._tcp":             "SSH",
+	"_sonos._tcp":           "Sonos",
+	"_companion-link._tcp":  "Apple Device",
+	"_device-info._tcp":     "Device Info",
+}
+
+// friendlyNameKeys are TXT keys that hold a device's display name
+var friendlyNameKeys = []string{"fn", "n", "name"}
+
+// modelKeys are TXT keys that hold a device's model
+var modelKeys = []string{"md", "model", "ty", "product", "usb_MDL"}
+
+// ServiceName returns a readable name for a service type
+func ServiceName(serviceType string) string {
+	if name, ok := serviceNames[serviceType]; ok {
+		return name
+	}
+	return serviceType
+}
+
+// sort orders addresses with IPv4 first and services by type
+func (h *Host) sort() {
+	sort.Slice(h.Addresses, func(i, j int) bool {
+		a, b := net.ParseIP(h.Addresses[i]), net.ParseIP(h.Addresses[j])
+		if (a.To4() != nil) != (b.To4() != nil) {
+			return a.To4() != nil
+		}
+		return h.Addresses[i] < h.Addresses[j]
+	})
+	sort.Slice(h.Services, func(i, j int) bool {
+		if h.Services[i].Type != h.Services[j].Type {
+			return h.Services[i].Type < h.Services[j].Type
+		}
+		return h.Services[i].Instance < h.Services[j].Instance
+	})
+}
+
+// LastSeen returns when any of the host's services was last heard
+func (h *Host) LastSeen() time.Time {
+	var last time.Time
+	for _, s := range h.Services {
+		if s.LastSeen.After(last) {
+			last = s.LastSeen
+		}
+	}
+	return last
+}
+
+// DisplayName returns a TXT friendly name, else the instance name of the
+// host's first service, else the host name
+func (h *Host) DisplayName() string {
+	for _, key := range friendlyNameKeys {
+		for _, s := range h.Services {
+			if v := s.TXTValue(key); v != "" {
+				return v
+			}
+		}
+	}
+	for _, s := range h.Services {
+		if label := s.Label(); label != "" {
+			return label
+		}
+	}
+	return h.shortName()
+}
+
+// Model returns the device model from the services' TXT records
+func (h *Host) Model() string {
+	for _, key := range modelKeys {
+		for _, s := range h.Services {
+			if v := s.TXTValue(key); v != "" {
+				return v
+			}
+		}
+	}
+	return ""
+}
+
+// shortName returns the host name without the trailing dot
+func (h *Host) shortName() string {
+	return strings.TrimSuffix(h.Name, ".")
+}
+
+// icon picks a symbol for the host's most specific service
+func (h *Host) icon() string {
+	for _, s := range h.Services {
+		switch s.Type {
+		case "_ipp._tcp", "_ipps._tcp", "_printer._tcp", "_pdl-datastream._tcp", "_scanner._tcp", "_uscan._tcp":
+			return "⎙"
+		case "_googlecast._tcp", "_airplay._tcp", "_raop._tcp", "_spotify-connect._tcp", "_sonos._tcp":
+			return "▶"
+		case "_hap._tcp", "_homekit._tcp", "_matter._tcp":
+			return "⌂"
+		}
+	}
+	return "◇"
+}
+
+// strength fades from 90 for a host heard this scan to 30 for one silent
+// for five minutes
+func (h *Host) strength(now time.Time) int {
+	age := now.Sub(h.LastSeen()).Minutes() / 5
+	if age > 1 {
+		age = 1
+	}
+	if age < 0 {
+		age = 0
+	}
+	return int(90 - 60*age)
+}
+
+// hostSignal converts a host and its services into a signal
+func hostSignal(h *Host, config *scanner.Config, now time.Time) model.Signal {
+	key := h.shortName()
+
+	addresses := h.Addresses
+	if len(addresses) == 0 {
+		// Fall back to where the service records came from
+		for _, s := range h.Services {
+			if s.Source != nil {
+				addresses = []string{s.Source.String()}
+				break
+			}
+		}
+	}
+
+	services := make([]string, 0, len(h.Services))
+	types := make([]string, 0, len(h.Services))
+	attrs := model.Attributes{}
+	for _, s := range h.Services {
+		services = append(services, fmt.Sprintf("%s (%d)", ServiceName(s.Type), s.Port))
+		types = append(types, s.Type)
+		if len(s.TXT) > 0 {
+			attrs.Set("txt "+s.Type, strings.Join(s.TXT, ", "))
+		}
+	}
+
+	attrs.Set("hostname", key)
+	attrs.Set(model.AttrIP, strings.Join(addresses, ", "))
+	attrs.Set(model.AttrServices, strings.Join(services, ", "))
+	attrs.Set("service_types", strings.Join(types, ","))
+	attrs.Set("model", h.Model())
+
+	signal := model.Signal{
+		ID:       "mdns:" + key,
+		Type:     "IoT",
+		Icon:     h.icon(),
+		Name:     h.DisplayName(),
+		Category: model.CategoryIoT,
+		Severity: model.SeverityNormal,
+		Strength: h.strength(now),
+		// Where the lan scanner places hosts of unknown latency
+		Distance:    config.LatencyDistance(0),
+		Angle:       config.Bearing("IoT", "mdns:"+key),
+		Phase:       0,
+		Lifetime:    now,
+		LastSeen:    now,
+		Persistence: 1.0,
+		History:     make([]model.PositionHistory, 0, 20),
+		MaxHistory:  20,
+		Attributes:  attrs,
+	}
+
+	signal.AddToHistory(signal.Distance, signal.Angle, signal.Strength, true, now)
+	return signal
+}
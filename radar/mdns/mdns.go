@@ -0,0 +1,593 @@
+// Package mdns discovers services on the local network with multicast DNS
+// and DNS-SD (RFC 6762 and RFC 6763).
+package mdns
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/e6a5/radar/radar/model"
+	"github.com/e6a5/radar/radar/scanner"
+)
+
+// Default multicast groups
+const (
+	IPv4Group = "224.0.0.251:5353"
+	IPv6Group = "[ff02::fb]:5353"
+)
+
+// servicesName enumerates the service types on the link (RFC 6763 section 9)
+const servicesName = "_services._dns-sd._udp.local."
+
+const (
+	browseInterval = time.Minute // Time between browse queries
+	queryWindow    = time.Second // Time the first scan waits for answers
+	maxQuerySize   = 1200        // Queries are split to stay under this many bytes
+	goodbyeDelay   = time.Second // Records with TTL 0 expire after this (RFC 6762 section 10.1)
+)
+
+// DefaultServiceTypes are browsed directly, since not every responder
+// answers service type enumeration
+var DefaultServiceTypes = []string{
+	"_airplay._tcp",
+	"_raop._tcp",
+	"_googlecast._tcp",
+	"_spotify-connect._tcp",
+	"_hap._tcp",
+	"_homekit._tcp",
+	"_matter._tcp",
+	"_ipp._tcp",
+	"_ipps._tcp",
+	"_printer._tcp",
+	"_pdl-datastream._tcp",
+	"_scanner._tcp",
+	"_uscan._tcp",
+	"_http._tcp",
+	"_smb._tcp",
+	"_afpovertcp._tcp",
+	"_ssh._tcp",
+	"_sonos._tcp",
+	"_companion-link._tcp",
+	"_device-info._tcp",
+}
+
+// Options selects where the scanner listens and what it browses
+type Options struct {
+	Interface    string   // Interface to join the groups on; the system default if empty
+	IPv4Group    string   // IPv4 group and port; IPv4 is skipped if empty
+	IPv6Group    string   // IPv6 group and port; IPv6 is skipped if empty
+	ServiceTypes []string // Service types browsed in addition to enumerated ones
+}
+
+// DefaultOptions returns the standard mDNS groups on the default interface
+func DefaultOptions() Options {
+	return Options{
+		IPv4Group:    IPv4Group,
+		IPv6Group:    IPv6Group,
+		ServiceTypes: DefaultServiceTypes,
+	}
+}
+
+// Service is a resolved DNS-SD service instance
+type Service struct {
+	Instance string   // Full instance name, e.g. "Office._ipp._tcp.local."
+	Type     string   // Service type, e.g. "_ipp._tcp"
+	Host     string   // Target host name
+	Port     int      // Service port
+	TXT      []string // TXT key=value strings
+	Source   net.IP   // Address the SRV record came from
+	LastSeen time.Time
+}
+
+// Label returns the user-visible instance name
+func (s *Service) Label() string {
+	labels := splitName(s.Instance)
+	if len(labels) == 0 {
+		return s.Instance
+	}
+	return labels[0]
+}
+
+// TXTValue returns the value of a TXT key
+func (s *Service) TXTValue(key string) string {
+	for _, entry := range s.TXT {
+		k, v, _ := strings.Cut(entry, "=")
+		if strings.EqualFold(k, key) {
+			return v
+		}
+	}
+	return ""
+}
+
+// expiring is a cached value with its expiry
+type expiring struct {
+	expires time.Time
+	seen    time.Time
+}
+
+// cache holds the records heard on the link, by name
+type cache struct {
+	types     map[string]expiring            // Service types from enumeration
+	instances map[string]map[string]expiring // Instances by service type
+	services  map[string]*Service            // Resolved instances by lowercase name
+	srvExpiry map[string]time.Time
+	addresses map[string]map[string]expiring // Addresses by host name
+}
+
+func newCache() *cache {
+	return &cache{
+		types:     make(map[string]expiring),
+		instances: make(map[string]map[string]expiring),
+		services:  make(map[string]*Service),
+		srvExpiry: make(map[string]time.Time),
+		addresses: make(map[string]map[string]expiring),
+	}
+}
+
+// MDNSScanner browses DNS-SD services over multicast DNS and listens to the
+// announcements of other queriers and responders
+type MDNSScanner struct {
+	config   *scanner.Config
+	options  Options
+	conns    []*multicastConn
+	cache    *cache
+	started  bool
+	closed   bool
+	lastSent time.Time
+	browsed  map[string]bool // Service types browsed at least once
+	mutex    sync.Mutex
+}
+
+// multicastConn is a socket joined to one group
+type multicastConn struct {
+	conn  *net.UDPConn
+	group *net.UDPAddr
+}
+
+// NewMDNSScanner creates a new mDNS scanner
+func NewMDNSScanner(config *scanner.Config, options Options) *MDNSScanner {
+	return &MDNSScanner{
+		config:  config,
+		options: options,
+		cache:   newCache(),
+		browsed: make(map[string]bool),
+	}
+}
+
+// Name returns the scanner name
+func (m *MDNSScanner) Name() string {
+	return "mDNS Service Scanner"
+}
+
+// IsAvailable checks for an interface that can send multicast
+func (m *MDNSScanner) IsAvailable() bool {
+	if m.options.Interface != "" {
+		_, err := net.InterfaceByName(m.options.Interface)
+		return err == nil
+	}
+	interfaces, err := net.Interfaces()
+	if err != nil {
+		return false
+	}
+	for _, iface := range interfaces {
+		if iface.Flags&net.FlagUp != 0 && iface.Flags&net.FlagMulticast != 0 && iface.Flags&net.FlagLoopback == 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// Scan browses for services and returns one signal per responding host
+func (m *MDNSScanner) Scan(ctx context.Context) ([]model.Signal, error) {
+	first, err := m.start()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	m.query(now)
+
+	// Give responders a moment to answer the first browse
+	if first {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(queryWindow):
+		}
+		m.query(time.Now())
+		now = time.Now()
+	}
+
+	return m.signals(now), nil
+}
+
+// Services returns the resolved service instances, sorted by name
+func (m *MDNSScanner) Services() []Service {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.expire(time.Now())
+
+	services := make([]Service, 0, len(m.cache.services))
+	for _, s := range m.cache.services {
+		copied := *s
+		copied.TXT = append([]string(nil), s.TXT...)
+		services = append(services, copied)
+	}
+	sort.Slice(services, func(i, j int) bool {
+		return services[i].Instance < services[j].Instance
+	})
+	return services
+}
+
+// start joins the multicast groups once, reporting whether this call did
+func (m *MDNSScanner) start() (bool, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if m.closed {
+		return false, net.ErrClosed
+	}
+	if m.started {
+		return false, nil
+	}
+
+	var iface *net.Interface
+	if m.options.Interface != "" {
+		found, err := net.InterfaceByName(m.options.Interface)
+		if err != nil {
+			return false, err
+		}
+		iface = found
+	}
+
+	var errs []error
+	for _, group := range []struct{ network, address string }{
+		{"udp4", m.options.IPv4Group},
+		{"udp6", m.options.IPv6Group},
+	} {
+		if group.address == "" {
+			continue
+		}
+		addr, err := net.ResolveUDPAddr(group.network, group.address)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if iface != nil && addr.IP.IsLinkLocalMulticast() && addr.IP.To4() == nil {
+			addr.Zone = iface.Name
+		}
+		conn, err := net.ListenMulticastUDP(group.network, iface, addr)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		mc := &multicastConn{conn: conn, group: addr}
+		m.conns = append(m.conns, mc)
+		go m.listen(mc)
+	}
+
+	if len(m.conns) == 0 {
+		if len(errs) == 0 {
+			return false, errors.New("mdns: no multicast groups configured")
+		}
+		return false, errors.Join(errs...)
+	}
+	m.started = true
+	return true, nil
+}
+
+// Close leaves the multicast groups, which stops the listeners. A closed
+// scanner fails every later scan.
+func (m *MDNSScanner) Close() error {
+	m.mutex.Lock()
+	conns := m.conns
+	m.conns = nil
+	m.closed = true
+	m.mutex.Unlock()
+
+	var errs []error
+	for _, mc := range conns {
+		if err := mc.conn.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// listen reads messages from a group until the socket fails
+func (m *MDNSScanner) listen(mc *multicastConn) {
+	buf := make([]byte, 9000)
+	for {
+		n, src, err := mc.conn.ReadFromUDP(buf)
+		if err != nil {
+			return
+		}
+		msg, err := parseMessage(buf[:n])
+		if err != nil || !msg.isResponse() {
+			continue
+		}
+		m.mutex.Lock()
+		m.apply(msg, src.IP, time.Now())
+		m.mutex.Unlock()
+	}
+}
+
+// query sends the browse questions every browseInterval, browses newly
+// enumerated service types and asks for the records still missing from
+// discovered instances on every scan
+func (m *MDNSScanner) query(now time.Time) {
+	m.mutex.Lock()
+	m.expire(now)
+
+	questions := make([]question, 0)
+	browse := now.Sub(m.lastSent) >= browseInterval
+	// Ask for unicast answers on the first browse, as RFC 6762 section 5.4 suggests
+	class := classIN
+	if m.lastSent.IsZero() {
+		class |= unicastResponse
+	}
+	if browse {
+		m.lastSent = now
+		questions = append(questions, question{servicesName, typePTR, class})
+	}
+	// Types found by enumeration since the last browse are browsed right away
+	for _, serviceType := range m.serviceTypes() {
+		if browse || !m.browsed[serviceType] {
+			m.browsed[serviceType] = true
+			questions = append(questions, question{serviceType, typePTR, class})
+		}
+	}
+	questions = append(questions, m.unresolved()...)
+	conns := m.conns
+	m.mutex.Unlock()
+
+	for _, batch := range batchQuestions(questions) {
+		payload := encodeQuery(batch)
+		for _, mc := range conns {
+			mc.conn.WriteToUDP(payload, mc.group)
+		}
+	}
+}
+
+// serviceTypes returns the configured and enumerated service types as fully
+// qualified names. Callers must hold the lock.
+func (m *MDNSScanner) serviceTypes() []string {
+	seen := make(map[string]bool)
+	types := make([]string, 0, len(m.options.ServiceTypes)+len(m.cache.types))
+	add := func(serviceType string) {
+		name := qualify(serviceType)
+		if !seen[name] {
+			seen[name] = true
+			types = append(types, name)
+		}
+	}
+	for _, serviceType := range m.options.ServiceTypes {
+		add(serviceType)
+	}
+	enumerated := make([]string, 0, len(m.cache.types))
+	for serviceType := range m.cache.types {
+		enumerated = append(enumerated, serviceType)
+	}
+	sort.Strings(enumerated)
+	for _, serviceType := range enumerated {
+		add(serviceType)
+	}
+	return types
+}
+
+// unresolved returns questions for the SRV, TXT and address records that
+// discovered instances still lack. Callers must hold the lock.
+func (m *MDNSScanner) unresolved() []question {
+	questions := make([]question, 0)
+	asked := make(map[string]bool)
+	for _, instances := range m.cache.instances {
+		for instance := range instances {
+			service, ok := m.cache.services[instance]
+			if !ok || service.Host == "" {
+				questions = append(questions, question{instance, typeSRV, classIN})
+			}
+			if !ok || service.TXT == nil {
+				questions = append(questions, question{instance, typeTXT, classIN})
+			}
+			if ok && service.Host != "" && len(m.cache.addresses[service.Host]) == 0 && !asked[service.Host] {
+				asked[service.Host] = true
+				questions = append(questions, question{service.Host, typeA, classIN}, question{service.Host, typeAAAA, classIN})
+			}
+		}
+	}
+	sort.Slice(questions, func(i, j int) bool {
+		if questions[i].name != questions[j].name {
+			return questions[i].name < questions[j].name
+		}
+		return questions[i].qtype < questions[j].qtype
+	})
+	return questions
+}
+
+// batchQuestions splits questions into messages that fit maxQuerySize
+func batchQuestions(questions []question) [][]question {
+	batches := make([][]question, 0)
+	var batch []question
+	size := 12
+	for _, q := range questions {
+		qsize := len(q.name) + 6
+		if len(batch) > 0 && size+qsize > maxQuerySize {
+			batches = append(batches, batch)
+			batch, size = nil, 12
+		}
+		batch = append(batch, q)
+		size += qsize
+	}
+	if len(batch) > 0 {
+		batches = append(batches, batch)
+	}
+	return batches
+}
+
+// apply caches the records of a response. Callers must hold the lock.
+func (m *MDNSScanner) apply(msg message, src net.IP, now time.Time) {
+	c := m.cache
+	for _, rr := range msg.records {
+		name := strings.ToLower(rr.name)
+		expires := now.Add(time.Duration(rr.ttl) * time.Second)
+		if rr.ttl == 0 {
+			expires = now.Add(goodbyeDelay)
+		}
+		entry := expiring{expires: expires, seen: now}
+
+		switch rr.rtype {
+		case typePTR:
+			if name == servicesName {
+				c.types[strings.ToLower(rr.target)] = entry
+				continue
+			}
+			if c.instances[name] == nil {
+				c.instances[name] = make(map[string]expiring)
+			}
+			c.instances[name][strings.ToLower(rr.target)] = entry
+		case typeSRV:
+			service := m.service(rr.name, now)
+			service.Host = strings.ToLower(rr.target)
+			service.Port = rr.port
+			service.Source = src
+			c.srvExpiry[name] = expires
+		case typeTXT:
+			service := m.service(rr.name, now)
+			service.TXT = append([]string{}, rr.txt...)
+		case typeA, typeAAAA:
+			if rr.ip == nil {
+				continue
+			}
+			addresses := c.addresses[name]
+			if addresses == nil {
+				addresses = make(map[string]expiring)
+				c.addresses[name] = addresses
+			}
+			// A cache-flush record replaces addresses not refreshed within the last second
+			if rr.flush {
+				for ip, cached := range addresses {
+					if now.Sub(cached.seen) > time.Second {
+						delete(addresses, ip)
+					}
+				}
+			}
+			addresses[rr.ip.String()] = entry
+		}
+	}
+}
+
+// service returns the cached instance, creating it. Callers must hold the lock.
+func (m *MDNSScanner) service(instance string, now time.Time) *Service {
+	key := strings.ToLower(instance)
+	service, ok := m.cache.services[key]
+	if !ok {
+		service = &Service{Instance: instance, Type: serviceType(instance)}
+		m.cache.services[key] = service
+	}
+	service.LastSeen = now
+	return service
+}
+
+// expire drops records whose TTL ran out. Callers must hold the lock.
+func (m *MDNSScanner) expire(now time.Time) {
+	c := m.cache
+	for name, entry := range c.types {
+		if now.After(entry.expires) {
+			delete(c.types, name)
+		}
+	}
+	for serviceType, instances := range c.instances {
+		for instance, entry := range instances {
+			if now.After(entry.expires) {
+				delete(instances, instance)
+			}
+		}
+		if len(instances) == 0 {
+			delete(c.instances, serviceType)
+		}
+	}
+	for instance, expires := range c.srvExpiry {
+		if now.After(expires) {
+			delete(c.srvExpiry, instance)
+			delete(c.services, instance)
+		}
+	}
+	for host, addresses := range c.addresses {
+		for ip, entry := range addresses {
+			if now.After(entry.expires) {
+				delete(addresses, ip)
+			}
+		}
+		if len(addresses) == 0 {
+			delete(c.addresses, host)
+		}
+	}
+}
+
+// signals groups the resolved services by host. Callers must not hold the lock.
+func (m *MDNSScanner) signals(now time.Time) []model.Signal {
+	m.mutex.Lock()
+	m.expire(now)
+	hosts := make(map[string]*Host)
+	for _, service := range m.cache.services {
+		if service.Host == "" {
+			continue
+		}
+		host, ok := hosts[service.Host]
+		if !ok {
+			host = &Host{Name: service.Host}
+			for ip := range m.cache.addresses[service.Host] {
+				host.Addresses = append(host.Addresses, ip)
+			}
+			hosts[service.Host] = host
+		}
+		copied := *service
+		host.Services = append(host.Services, &copied)
+	}
+	m.mutex.Unlock()
+
+	list := make([]*Host, 0, len(hosts))
+	for _, host := range hosts {
+		host.sort()
+		list = append(list, host)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if !list[i].LastSeen().Equal(list[j].LastSeen()) {
+			return list[i].LastSeen().After(list[j].LastSeen())
+		}
+		return list[i].Name < list[j].Name
+	})
+
+	signals := make([]model.Signal, 0, len(list))
+	for _, host := range list {
+		signals = append(signals, hostSignal(host, m.config, now))
+		if len(signals) >= m.config.MaxSignals {
+			break
+		}
+	}
+	return signals
+}
+
+// qualify returns a service type as a fully qualified name in .local
+func qualify(serviceType string) string {
+	name := strings.ToLower(strings.TrimSuffix(serviceType, "."))
+	if !strings.HasSuffix(name, ".local") {
+		name += ".local"
+	}
+	return name + "."
+}
+
+// serviceType returns the "_name._proto" part of an instance name
+func serviceType(instance string) string {
+	labels := splitName(instance)
+	for i := 0; i+1 < len(labels); i++ {
+		if strings.HasPrefix(labels[i], "_") && (labels[i+1] == "_tcp" || labels[i+1] == "_udp") {
+			return strings.ToLower(labels[i] + "." + labels[i+1])
+		}
+	}
+	return ""
+}
@@ -0,0 +1,245 @@
+package mdns
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/e6a5/radar/radar/model"
+	"github.com/e6a5/radar/radar/scanner"
+)
+
+// fakeResponder answers mDNS questions on a loopback group from a fixed set
+// of records, one response per question as a responder that has only what
+// was asked for would
+type fakeResponder struct {
+	t     *testing.T
+	conn  *net.UDPConn
+	group *net.UDPAddr
+
+	mutex sync.Mutex
+	asked map[uint16][]string // Names asked for, by record type
+}
+
+const (
+	testHost     = "office-printer.local."
+	testIPP      = "Office Printer._ipp._tcp.local."
+	testLPD      = "Office Printer._printer._tcp.local."
+	testIPv4     = "192.0.2.10"
+	testIPv6     = "fe80::10"
+	testIPPTXT   = "ty=LaserJet 4000"
+	testIPPNote  = "note=Room 2.1"
+	testLPDQueue = "rp=queue"
+)
+
+// loopbackGroup returns an IPv4 mDNS group on a free port and the loopback
+// interface to join it on
+func loopbackGroup(t *testing.T) (*net.Interface, *net.UDPAddr) {
+	t.Helper()
+	lo, err := loopbackInterface()
+	if err != nil {
+		t.Skipf("no loopback interface: %v", err)
+	}
+	probe, err := net.ListenUDP("udp4", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Skipf("no IPv4 loopback: %v", err)
+	}
+	port := probe.LocalAddr().(*net.UDPAddr).Port
+	probe.Close()
+	return lo, &net.UDPAddr{IP: net.IPv4(224, 0, 0, 251), Port: port}
+}
+
+func loopbackInterface() (*net.Interface, error) {
+	interfaces, err := net.Interfaces()
+	if err != nil {
+		return nil, err
+	}
+	for i := range interfaces {
+		if interfaces[i].Flags&net.FlagLoopback != 0 && interfaces[i].Flags&net.FlagUp != 0 {
+			return &interfaces[i], nil
+		}
+	}
+	return nil, net.UnknownNetworkError("loopback")
+}
+
+// newFakeResponder joins the group and answers until the test ends
+func newFakeResponder(t *testing.T, lo *net.Interface, group *net.UDPAddr) *fakeResponder {
+	t.Helper()
+	conn, err := net.ListenMulticastUDP("udp4", lo, group)
+	if err != nil {
+		t.Skipf("cannot join %s on %s: %v", group, lo.Name, err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	r := &fakeResponder{t: t, conn: conn, group: group, asked: make(map[uint16][]string)}
+	go r.serve()
+	return r
+}
+
+func (r *fakeResponder) serve() {
+	buf := make([]byte, 9000)
+	for {
+		n, _, err := r.conn.ReadFromUDP(buf)
+		if err != nil {
+			return
+		}
+		msg, err := parseMessage(buf[:n])
+		if err != nil || msg.isResponse() {
+			continue // Our own answers come back on the group
+		}
+		for _, q := range msg.questions {
+			r.mutex.Lock()
+			r.asked[q.qtype] = append(r.asked[q.qtype], q.name)
+			r.mutex.Unlock()
+			if response := answer(q); response != nil {
+				r.conn.WriteToUDP(response, r.group)
+			}
+		}
+	}
+}
+
+// answer returns the response to a question, or nil if it is not ours
+func answer(q question) []byte {
+	b := newResponseBuilder()
+	switch name := strings.ToLower(q.name); {
+	case q.qtype == typePTR && name == servicesName:
+		// _printer._tcp is only found by enumeration
+		b.ptr(servicesName, "_printer._tcp.local.", 4500)
+	case q.qtype == typePTR && name == "_ipp._tcp.local.":
+		b.ptr("_ipp._tcp.local.", testIPP, 4500)
+	case q.qtype == typePTR && name == "_printer._tcp.local.":
+		b.ptr("_printer._tcp.local.", testLPD, 4500)
+	case q.qtype == typeSRV && name == strings.ToLower(testIPP):
+		b.srv(testIPP, testHost, 631, 120)
+	case q.qtype == typeSRV && name == strings.ToLower(testLPD):
+		b.srv(testLPD, testHost, 515, 120)
+	case q.qtype == typeTXT && name == strings.ToLower(testIPP):
+		b.txt(testIPP, 4500, "txtvers=1", testIPPTXT, testIPPNote)
+	case q.qtype == typeTXT && name == strings.ToLower(testLPD):
+		b.txt(testLPD, 4500, testLPDQueue)
+	case q.qtype == typeA && name == testHost:
+		b.address(testHost, net.ParseIP(testIPv4), 120)
+	case q.qtype == typeAAAA && name == testHost:
+		b.address(testHost, net.ParseIP(testIPv6), 120)
+	default:
+		return nil
+	}
+	return b.bytes()
+}
+
+// Asked returns the names asked for with a record type
+func (r *fakeResponder) Asked(qtype uint16) []string {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return append([]string(nil), r.asked[qtype]...)
+}
+
+func TestMDNSScannerLoopback(t *testing.T) {
+	lo, group := loopbackGroup(t)
+	responder := newFakeResponder(t, lo, group)
+
+	s := NewMDNSScanner(&scanner.Config{MaxSignals: 10, MaxScanRange: 1000}, Options{
+		Interface:    lo.Name,
+		IPv4Group:    group.String(),
+		ServiceTypes: []string{"_ipp._tcp"},
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// The first scan browses; later ones ask for what is still missing
+	var signal model.Signal
+	for resolved := false; !resolved; {
+		if ctx.Err() != nil {
+			t.Fatalf("not resolved in time: %+v", signal)
+		}
+		signals, err := s.Scan(ctx)
+		if err != nil {
+			t.Fatalf("Scan: %v (asked for PTR %q, SRV %q)", err, responder.Asked(typePTR), responder.Asked(typeSRV))
+		}
+		if len(signals) == 1 {
+			signal = signals[0]
+			resolved = signal.Attributes.Get(model.AttrIP) == testIPv4+", "+testIPv6 &&
+				signal.Attributes.Get("service_types") == "_ipp._tcp,_printer._tcp" &&
+				signal.Attributes.Get("txt _printer._tcp") != ""
+		}
+		if !resolved {
+			time.Sleep(20 * time.Millisecond)
+		}
+	}
+
+	attributes := map[string]string{
+		"hostname":          "office-printer.local",
+		model.AttrIP:        testIPv4 + ", " + testIPv6,
+		model.AttrServices:  "IPP Printer (631), LPD Printer (515)",
+		"service_types":     "_ipp._tcp,_printer._tcp",
+		"txt _ipp._tcp":     "txtvers=1, " + testIPPTXT + ", " + testIPPNote,
+		"txt _printer._tcp": testLPDQueue,
+		"model":             "LaserJet 4000",
+	}
+	for key, want := range attributes {
+		if got := signal.Attributes.Get(key); got != want {
+			t.Errorf("%s = %q, want %q", key, got, want)
+		}
+	}
+	if signal.ID != "mdns:office-printer.local" || signal.Name != "Office Printer" || signal.Icon != "⎙" {
+		t.Errorf("signal = %s %q %s, want the office printer", signal.ID, signal.Name, signal.Icon)
+	}
+	if want := s.config.LatencyDistance(0); signal.Distance != want {
+		t.Errorf("distance = %g, want %g like a LAN host of unknown latency", signal.Distance, want)
+	}
+
+	// Each record was asked for by name, the addresses once per host
+	for qtype, want := range map[uint16]string{
+		typeSRV:  strings.ToLower(testIPP),
+		typeTXT:  strings.ToLower(testLPD),
+		typeA:    testHost,
+		typeAAAA: testHost,
+	} {
+		if asked := responder.Asked(qtype); !contains(asked, want) {
+			t.Errorf("questions of type %d = %q, want %q", qtype, asked, want)
+		}
+	}
+
+	services := s.Services()
+	if len(services) != 2 || services[0].Instance != testIPP || services[0].Port != 631 ||
+		services[0].TXTValue("ty") != "LaserJet 4000" || services[1].Type != "_printer._tcp" {
+		t.Errorf("services = %+v", services)
+	}
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+func TestMDNSScannerClose(t *testing.T) {
+	lo, group := loopbackGroup(t)
+	s := NewMDNSScanner(&scanner.Config{MaxSignals: 10, MaxScanRange: 1000}, Options{
+		Interface: lo.Name,
+		IPv4Group: group.String(),
+	})
+	if _, err := s.start(); err != nil {
+		t.Fatalf("start: %v", err)
+	}
+	conns := s.conns
+
+	if err := s.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	for _, mc := range conns {
+		if err := mc.conn.SetReadDeadline(time.Now()); !errors.Is(err, net.ErrClosed) {
+			t.Errorf("socket for %s still open after Close: %v", mc.group, err)
+		}
+	}
+	if _, err := s.Scan(context.Background()); !errors.Is(err, net.ErrClosed) {
+		t.Errorf("Scan after Close = %v, want net.ErrClosed", err)
+	}
+}
@@ -0,0 +1,272 @@
+package model
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Well-known attribute keys shared by scanners and frontends
+const (
+	AttrBSSID        = "bssid"
+	AttrSSID         = "ssid"
+	AttrChannel      = "channel"
+	AttrFrequency    = "frequency"
+	AttrChannelWidth = "channel_width"
+	AttrRate         = "rate"
+	AttrSecurity     = "security"
+	AttrRSSI         = "rssi"
+	AttrConnected    = "connected"
+	AttrVendor       = "vendor"
+	AttrRandomMAC    = "random_mac" // Locally administered, usually randomized, address
+	AttrMAC          = "mac"
+	AttrIP           = "ip"
+	AttrPort         = "port"
+	AttrProtocol     = "protocol"
+	AttrInterface    = "interface"
+	AttrProcess      = "process"
+	AttrPID          = "pid"
+	AttrUser         = "user"
+	AttrAddressType  = "address_type"
+	AttrTxPower      = "tx_power"
+	AttrManufacturer = "manufacturer_data"
+	AttrServices     = "services"
+	AttrPaired       = "paired"
+	AttrRxRate       = "rx_rate"      // Bytes received per second
+	AttrTxRate       = "tx_rate"      // Bytes sent per second
+	AttrRxPackets    = "rx_pps"       // Packets received per second
+	AttrTxPackets    = "tx_pps"       // Packets sent per second
+	AttrErrors       = "errors"       // Receive and transmit errors since the last scan
+	AttrDrops        = "drops"        // Dropped packets since the last scan
+	AttrLinkSpeed    = "link_speed"   // Negotiated link speed in Mbit/s
+	AttrRateHistory  = "rate_history" // Comma-separated recent throughput in bytes per second
+)
+
+// AttrKind is the value type of a well-known attribute
+type AttrKind int
+
+const (
+	AttrString AttrKind = iota
+	AttrInt
+	AttrBool
+	AttrByteRate  // Bytes per second, scaled to kB/s, MB/s or GB/s
+	AttrSparkline // Comma-separated samples drawn as a bar sparkline
+)
+
+// AttrSpec describes how a well-known attribute is typed and displayed
+type AttrSpec struct {
+	Key   string
+	Label string
+	Unit  string
+	Kind  AttrKind
+}
+
+// wellKnownAttrs lists the well-known attributes in display order
+var wellKnownAttrs = []AttrSpec{
+	{AttrSSID, "SSID", "", AttrString},
+	{AttrBSSID, "BSSID", "", AttrString},
+	{AttrMAC, "MAC", "", AttrString},
+	{AttrVendor, "Vendor", "", AttrString},
+	{AttrRandomMAC, "Random MAC", "", AttrBool},
+	{AttrConnected, "Connected", "", AttrBool},
+	{AttrAddressType, "Address Type", "", AttrString},
+	{AttrPaired, "Paired", "", AttrBool},
+	{AttrRSSI, "RSSI", "dBm", AttrInt},
+	{AttrTxPower, "TX Power", "dBm", AttrInt},
+	{AttrChannel, "Channel", "", AttrInt},
+	{AttrFrequency, "Frequency", "MHz", AttrInt},
+	{AttrChannelWidth, "Width", "MHz", AttrInt},
+	{AttrRate, "Rate", "Mbit/s", AttrInt},
+	{AttrSecurity, "Security", "", AttrString},
+	{AttrServices, "Services", "", AttrString},
+	{AttrManufacturer, "Mfr Data", "", AttrString},
+	{AttrInterface, "Interface", "", AttrString},
+	{AttrIP, "IP", "", AttrString},
+	{AttrPort, "Port", "", AttrInt},
+	{AttrProtocol, "Protocol", "", AttrString},
+	{AttrProcess, "Process", "", AttrString},
+	{AttrPID, "PID", "", AttrInt},
+	{AttrUser, "User", "", AttrString},
+	{AttrLinkSpeed, "Link Speed", "Mbit/s", AttrInt},
+	{AttrRxRate, "RX", "", AttrByteRate},
+	{AttrTxRate, "TX", "", AttrByteRate},
+	{AttrRxPackets, "RX Packets", "pkt/s", AttrInt},
+	{AttrTxPackets, "TX Packets", "pkt/s", AttrInt},
+	{AttrErrors, "Errors", "", AttrInt},
+	{AttrDrops, "Drops", "", AttrInt},
+	{AttrRateHistory, "Throughput", "", AttrSparkline},
+}
+
+// LookupAttr returns the spec of a well-known attribute
+func LookupAttr(key string) (AttrSpec, bool) {
+	for _, spec := range wellKnownAttrs {
+		if spec.Key == key {
+			return spec, true
+		}
+	}
+	return AttrSpec{}, false
+}
+
+// Attributes holds the well-known and free-form details a scanner reports for
+// a signal. Values are stored as strings; the typed accessors convert them.
+type Attributes map[string]string
+
+// Attribute is one formatted key/value pair ready for display
+type Attribute struct {
+	Key   string
+	Label string
+	Value string
+}
+
+// Set stores a string value, ignoring empty values
+func (a Attributes) Set(key, value string) {
+	if value == "" {
+		return
+	}
+	a[key] = value
+}
+
+// SetInt stores an integer value
+func (a Attributes) SetInt(key string, value int) {
+	a[key] = strconv.Itoa(value)
+}
+
+// SetBool stores a boolean value
+func (a Attributes) SetBool(key string, value bool) {
+	a[key] = strconv.FormatBool(value)
+}
+
+// Get returns the raw value of key
+func (a Attributes) Get(key string) string {
+	return a[key]
+}
+
+// Int returns the value of key as an integer
+func (a Attributes) Int(key string) (int, bool) {
+	value, ok := a[key]
+	if !ok {
+		return 0, false
+	}
+	n, err := strconv.Atoi(value)
+	return n, err == nil
+}
+
+// Bool returns the value of key as a boolean
+func (a Attributes) Bool(key string) bool {
+	b, _ := strconv.ParseBool(a[key])
+	return b
+}
+
+// Clone returns an independent copy
+func (a Attributes) Clone() Attributes {
+	if a == nil {
+		return nil
+	}
+	clone := make(Attributes, len(a))
+	for k, v := range a {
+		clone[k] = v
+	}
+	return clone
+}
+
+// Sorted returns the attributes formatted for display: well-known keys first
+// in their canonical order, followed by free-form keys alphabetically
+func (a Attributes) Sorted() []Attribute {
+	attrs := make([]Attribute, 0, len(a))
+	seen := make(map[string]bool, len(a))
+
+	for _, spec := range wellKnownAttrs {
+		value, ok := a[spec.Key]
+		if !ok {
+			continue
+		}
+		seen[spec.Key] = true
+		attrs = append(attrs, Attribute{Key: spec.Key, Label: spec.Label, Value: formatAttr(spec, value)})
+	}
+
+	extra := make([]string, 0)
+	for key := range a {
+		if !seen[key] {
+			extra = append(extra, key)
+		}
+	}
+	sort.Strings(extra)
+	for _, key := range extra {
+		attrs = append(attrs, Attribute{Key: key, Label: key, Value: a[key]})
+	}
+
+	return attrs
+}
+
+// formatAttr renders a well-known value with its unit
+func formatAttr(spec AttrSpec, value string) string {
+	switch spec.Kind {
+	case AttrByteRate:
+		if rate, err := strconv.ParseFloat(value, 64); err == nil {
+			return FormatByteRate(rate)
+		}
+	case AttrSparkline:
+		return Sparkline(value)
+	case AttrBool:
+		if b, err := strconv.ParseBool(value); err == nil {
+			if b {
+				return "yes"
+			}
+			return "no"
+		}
+	}
+	if spec.Unit != "" {
+		return value + " " + spec.Unit
+	}
+	return value
+}
+
+// Round rounds value to the given number of decimal places, for frontends
+// that write distances and bearings without float noise
+func Round(value float64, places int) float64 {
+	scale := math.Pow(10, float64(places))
+	return math.Round(value*scale) / scale
+}
+
+// FormatByteRate formats bytes per second with an SI prefix, e.g. "1.5 MB/s"
+func FormatByteRate(rate float64) string {
+	units := []string{"B/s", "kB/s", "MB/s", "GB/s"}
+	unit := 0
+	for rate >= 1000 && unit < len(units)-1 {
+		rate /= 1000
+		unit++
+	}
+	if unit == 0 {
+		return fmt.Sprintf("%.0f %s", rate, units[unit])
+	}
+	return fmt.Sprintf("%.1f %s", rate, units[unit])
+}
+
+// sparkBars are the sparkline levels from lowest to highest
+var sparkBars = []rune("▁▂▃▄▅▆▇█")
+
+// Sparkline draws comma-separated samples as bars scaled to the largest one
+func Sparkline(samples string) string {
+	values := make([]float64, 0)
+	peak := 0.0
+	for _, field := range strings.Split(samples, ",") {
+		v, err := strconv.ParseFloat(strings.TrimSpace(field), 64)
+		if err != nil || v < 0 {
+			v = 0
+		}
+		values = append(values, v)
+		peak = math.Max(peak, v)
+	}
+
+	bars := make([]rune, len(values))
+	for i, v := range values {
+		level := 0
+		if peak > 0 {
+			level = int(math.Round(v / peak * float64(len(sparkBars)-1)))
+		}
+		bars[i] = sparkBars[level]
+	}
+	return string(bars)
+}
@@ -0,0 +1,90 @@
+package model
+
+import (
+	"math"
+	"time"
+)
+
+// Motion is a signal's velocity and its closest point of approach (CPA) to
+// the observer at the scope's center
+type Motion struct {
+	VX, VY float64       // Velocity in distance units per second, along the axes of Position
+	CPA    float64       // Closest distance the signal will pass the center at
+	TCPA   time.Duration // Time until the closest point; zero if the signal is not closing
+}
+
+// Speed returns the speed in distance units per second
+func (m Motion) Speed() float64 {
+	return math.Hypot(m.VX, m.VY)
+}
+
+// Course returns the direction of travel as a scope angle in radians
+func (m Motion) Course() float64 {
+	course := math.Atan2(m.VY, m.VX)
+	if course < 0 {
+		course += 2 * math.Pi
+	}
+	return course
+}
+
+// Closing reports whether the closest point of approach lies ahead
+func (m Motion) Closing() bool {
+	return m.TCPA > 0
+}
+
+// Position returns the signal's cartesian position: x along the scope's east
+// axis, y in the direction of increasing angle
+func (s *Signal) Position() (x, y float64) {
+	return s.Distance * math.Cos(s.Angle), s.Distance * math.Sin(s.Angle)
+}
+
+// EstimateMotion fits a constant velocity by least squares to the positions
+// recorded within window of the latest one, and projects it from the current
+// position to the closest point of approach. It reports false if fewer than
+// three positions span the window.
+func (s *Signal) EstimateMotion(window time.Duration) (Motion, bool) {
+	if len(s.History) < 3 {
+		return Motion{}, false
+	}
+	latest := s.History[len(s.History)-1].Timestamp
+
+	// Regress x and y against time, in seconds before the latest position
+	var n, sumT, sumTT, sumX, sumTX, sumY, sumTY float64
+	for _, pos := range s.History {
+		age := latest.Sub(pos.Timestamp)
+		if age > window || age < 0 {
+			continue
+		}
+		t := -age.Seconds()
+		x, y := pos.Distance*math.Cos(pos.Angle), pos.Distance*math.Sin(pos.Angle)
+		n++
+		sumT += t
+		sumTT += t * t
+		sumX += x
+		sumTX += t * x
+		sumY += y
+		sumTY += t * y
+	}
+	denominator := n*sumTT - sumT*sumT
+	if n < 3 || denominator <= 0 {
+		return Motion{}, false
+	}
+
+	motion := Motion{
+		VX:  (n*sumTX - sumT*sumX) / denominator,
+		VY:  (n*sumTY - sumT*sumY) / denominator,
+		CPA: s.Distance,
+	}
+
+	// The center is closest where the relative position is perpendicular to the velocity
+	x, y := s.Position()
+	speed2 := motion.VX*motion.VX + motion.VY*motion.VY
+	if speed2 == 0 {
+		return motion, true
+	}
+	if tcpa := -(x*motion.VX + y*motion.VY) / speed2; tcpa > 0 {
+		motion.CPA = math.Hypot(x+motion.VX*tcpa, y+motion.VY*tcpa)
+		motion.TCPA = time.Duration(tcpa * float64(time.Second))
+	}
+	return motion, true
+}
@@ -0,0 +1,181 @@
+// Package model defines the signal model shared by scanners and frontends.
+// It has no UI dependencies: frontends map a signal's Category and Severity
+// to their own colors.
+package model
+
+import "time"
+
+// Category is the semantic class of a signal, used by frontends to pick colors
+type Category int
+
+const (
+	CategoryUnknown Category = iota
+	CategoryWiFi
+	CategoryBluetooth
+	CategoryCellular
+	CategoryRadio
+	CategoryIoT
+	CategorySatellite
+	CategoryNetwork
+	CategoryInterface
+)
+
+// String returns the category name
+func (c Category) String() string {
+	switch c {
+	case CategoryWiFi:
+		return "wifi"
+	case CategoryBluetooth:
+		return "bluetooth"
+	case CategoryCellular:
+		return "cellular"
+	case CategoryRadio:
+		return "radio"
+	case CategoryIoT:
+		return "iot"
+	case CategorySatellite:
+		return "satellite"
+	case CategoryNetwork:
+		return "network"
+	case CategoryInterface:
+		return "interface"
+	default:
+		return "unknown"
+	}
+}
+
+// ParseCategory returns the category with the given name, or CategoryUnknown
+func ParseCategory(name string) Category {
+	for c := CategoryUnknown; c <= CategoryInterface; c++ {
+		if c.String() == name {
+			return c
+		}
+	}
+	return CategoryUnknown
+}
+
+// CategoryForType returns the category of a signal type name such as "WiFi"
+func CategoryForType(signalType string) Category {
+	switch signalType {
+	case "WiFi":
+		return CategoryWiFi
+	case "Bluetooth":
+		return CategoryBluetooth
+	case "Cellular":
+		return CategoryCellular
+	case "Radio":
+		return CategoryRadio
+	case "IoT":
+		return CategoryIoT
+	case "Satellite":
+		return CategorySatellite
+	case "Network":
+		return CategoryNetwork
+	case "Ethernet":
+		return CategoryInterface
+	default:
+		return CategoryUnknown
+	}
+}
+
+// Severity tells frontends how much attention a signal deserves
+type Severity int
+
+const (
+	SeverityNormal Severity = iota
+	SeverityActive          // In use by this device, e.g. the connected network
+	SeverityNotice          // Worth a closer look, e.g. remote shell sessions
+	SeverityAlert           // Needs attention
+)
+
+// String returns the severity name
+func (s Severity) String() string {
+	switch s {
+	case SeverityActive:
+		return "active"
+	case SeverityNotice:
+		return "notice"
+	case SeverityAlert:
+		return "alert"
+	default:
+		return "normal"
+	}
+}
+
+// ParseSeverity returns the severity with the given name, or SeverityNormal
+func ParseSeverity(name string) Severity {
+	for s := SeverityNormal; s <= SeverityAlert; s++ {
+		if s.String() == name {
+			return s
+		}
+	}
+	return SeverityNormal
+}
+
+// PositionHistory is one recorded position of a signal
+type PositionHistory struct {
+	Distance    float64
+	Angle       float64
+	Timestamp   time.Time
+	Strength    int
+	WasDetected bool // Was the signal actually detected at this position
+}
+
+// Signal represents a detected or simulated signal on the radar
+type Signal struct {
+	ID           string   // Stable identity (BSSID, MAC, interface name, 5-tuple); empty for simulated signals
+	Type         string   // Signal type name, e.g. "WiFi"
+	Icon         string   // Icon drawn on the scope
+	Name         string   // Signal identifier/name (e.g., WiFi SSID, device name)
+	Category     Category // Semantic class used for coloring
+	Severity     Severity // Attention level used for coloring
+	Strength     int      // 0–100
+	Distance     float64  // radar units
+	DistanceLow  float64  // Confidence interval around Distance; both zero when unknown
+	DistanceHigh float64
+	Angle        float64   // radians
+	Phase        int       // for animation (wave ring phase)
+	Lifetime     time.Time // when signal was first seen
+	LastSeen     time.Time // when signal was last detected
+	Persistence  float64   // how long signal stays visible after last sweep (0.0-1.0)
+	History      []PositionHistory
+	MaxHistory   int        // Maximum number of history points to keep
+	Attributes   Attributes // Well-known and free-form details (BSSID, channel, security, ...)
+	Track        int        // Number of the display track the signal was last associated with; zero if none
+}
+
+// Key returns the signal identity, deriving one from type and name if no ID was set
+func (s *Signal) Key() string {
+	if s.ID != "" {
+		return s.ID
+	}
+	return s.Type + ":" + s.Name
+}
+
+// AddToHistory adds a position entry to signal history
+func (s *Signal) AddToHistory(distance, angle float64, strength int, wasDetected bool, timestamp time.Time) {
+	s.History = append(s.History, PositionHistory{
+		Distance:    distance,
+		Angle:       angle,
+		Timestamp:   timestamp,
+		Strength:    strength,
+		WasDetected: wasDetected,
+	})
+
+	// Keep only recent history
+	if len(s.History) > s.MaxHistory {
+		s.History = s.History[1:]
+	}
+}
+
+// IsVisible reports whether the signal is still bright enough to draw
+func (s *Signal) IsVisible() bool {
+	return s.Persistence > 0.1
+}
+
+// Clone returns a copy that shares no history or attributes with s
+func (s Signal) Clone() Signal {
+	s.History = append([]PositionHistory(nil), s.History...)
+	s.Attributes = s.Attributes.Clone()
+	return s
+}
@@ -0,0 +1,178 @@
+package radar
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/e6a5/radar/radar/model"
+	"github.com/gdamore/tcell/v2"
+)
+
+// Motion vector and closest point of approach settings
+const (
+	motionWindow   = 30 * time.Second // History used to estimate a signal's velocity
+	vectorTime     = 30 * time.Second // Motion vectors end where the signal will be after this long
+	minMotionSpeed = 0.05             // Slower signals are treated as stationary, units per second
+	cpaHorizon     = time.Minute      // Closest approaches further ahead do not raise alerts
+)
+
+// cpaAlert is a signal predicted to pass within the guard range
+type cpaAlert struct {
+	signal Signal
+	motion model.Motion
+}
+
+// signalMotion estimates a signal's motion, reporting false for signals
+// without enough history or too slow to be considered moving
+func signalMotion(s *Signal) (model.Motion, bool) {
+	motion, ok := s.EstimateMotion(motionWindow)
+	if !ok || motion.Speed() < minMotionSpeed {
+		return model.Motion{}, false
+	}
+	return motion, true
+}
+
+// isCPAAlert reports whether a motion brings its signal within the guard range
+// within the alert horizon
+func (rd *Display) isCPAAlert(motion model.Motion) bool {
+	return rd.config.GuardRange > 0 && motion.Closing() &&
+		motion.TCPA <= cpaHorizon && motion.CPA <= rd.config.GuardRange
+}
+
+// cpaAlerts returns the visible signals closing within the guard range,
+// soonest first
+func (rd *Display) cpaAlerts() []cpaAlert {
+	alerts := make([]cpaAlert, 0)
+	for i := range rd.signals {
+		s := &rd.signals[i]
+		if !s.IsVisible() || !rd.isSignalVisible(*s) {
+			continue
+		}
+		if motion, ok := signalMotion(s); ok && rd.isCPAAlert(motion) {
+			alerts = append(alerts, cpaAlert{signal: *s, motion: motion})
+		}
+	}
+	sort.Slice(alerts, func(i, j int) bool {
+		return alerts[i].motion.TCPA < alerts[j].motion.TCPA
+	})
+	return alerts
+}
+
+// drawMotionVector draws a line from a signal along its velocity to where it
+// will be after vectorTime, in red if its closest approach raises an alert
+func (rd *Display) drawMotionVector(screen tcell.Screen, s *Signal, scaleFactor float64) {
+	motion, ok := signalMotion(s)
+	if !ok {
+		return
+	}
+
+	color := rd.categoryColor(s.Category, s.Severity)
+	if rd.isCPAAlert(motion) {
+		color = tcell.ColorRed
+	}
+	style := tcell.StyleDefault.Foreground(color)
+
+	x0, y0 := s.Position()
+	x1 := x0 + motion.VX*vectorTime.Seconds()
+	y1 := y0 + motion.VY*vectorTime.Seconds()
+
+	// Screen cells of both ends, rows squeezed for the terminal aspect ratio
+	sx0, sy0 := float64(rd.centerX)+x0*scaleFactor, float64(rd.centerY)+y0*scaleFactor*0.5
+	sx1, sy1 := float64(rd.centerX)+x1*scaleFactor, float64(rd.centerY)+y1*scaleFactor*0.5
+	dx, dy := sx1-sx0, sy1-sy0
+	steps := int(math.Round(math.Max(math.Abs(dx), math.Abs(dy))))
+	if steps < 1 {
+		return
+	}
+	char := vectorRune(dx, dy)
+
+	// Start one cell out so the blip itself stays visible
+	for step := 1; step <= steps; step++ {
+		t := float64(step) / float64(steps)
+		x := int(math.Round(sx0 + dx*t))
+		y := int(math.Round(sy0 + dy*t))
+		if x >= 0 && x < rd.width && y >= 3 && y < rd.height-3 {
+			screen.SetContent(x, y, char, nil, style)
+		}
+	}
+}
+
+// vectorRune picks the line character closest to a screen direction
+func vectorRune(dx, dy float64) rune {
+	angle := math.Atan2(dy, dx)
+	if angle < 0 {
+		angle += math.Pi
+	}
+	switch octant := int(math.Round(angle / (math.Pi / 4))); octant {
+	case 1:
+		return '╲'
+	case 2:
+		return '│'
+	case 3:
+		return '╱'
+	default:
+		return '─'
+	}
+}
+
+// drawGuardRange draws the guard range ring used for closest approach alerts
+func (rd *Display) drawGuardRange(screen tcell.Screen, scaleFactor float64) {
+	radius := rd.config.GuardRange * scaleFactor
+	if radius < 1 {
+		return
+	}
+	style := tcell.StyleDefault.Foreground(tcell.ColorDarkRed)
+	step := 1 / radius // About one cell per step
+	for angle := 0.0; angle < 2*math.Pi; angle += step {
+		x := rd.centerX + int(math.Round(radius*math.Cos(angle)))
+		y := rd.centerY + int(math.Round(radius*math.Sin(angle)*0.5))
+		if x >= 0 && x < rd.width && y >= 3 && y < rd.height-3 {
+			screen.SetContent(x, y, '∙', nil, style)
+		}
+	}
+}
+
+// drawCPAAlert shows the soonest closest approach alert over the top panel's
+// lower border
+func (rd *Display) drawCPAAlert(screen tcell.Screen) {
+	alerts := rd.cpaAlerts()
+	if len(alerts) == 0 {
+		return
+	}
+
+	first := alerts[0]
+	message := fmt.Sprintf("⚠ CPA %s: %.1fm in %.0fs", first.signal.Name, first.motion.CPA, first.motion.TCPA.Seconds())
+	if len(alerts) > 1 {
+		message += fmt.Sprintf(" (+%d more)", len(alerts)-1)
+	}
+
+	text := []rune(" " + message + " ")
+	if len(text) > rd.width-2 {
+		text = text[:max(0, rd.width-2)]
+	}
+	style := tcell.StyleDefault.Foreground(tcell.ColorWhite).Background(tcell.ColorRed).Bold(true)
+	startX := (rd.width - len(text)) / 2
+	for i, r := range text {
+		screen.SetContent(startX+i, 2, r, nil, style)
+	}
+}
+
+// describeMotion returns the info panel lines for a signal's motion
+func describeMotion(s *Signal) []string {
+	motion, ok := signalMotion(s)
+	if !ok {
+		return []string{"Motion:   stationary"}
+	}
+
+	lines := []string{
+		fmt.Sprintf("Motion:   %.2fm/s, course %.0f°", motion.Speed(), motion.Course()*180/math.Pi),
+	}
+	if motion.Closing() {
+		lines = append(lines, fmt.Sprintf("CPA:      %.1fm in %.0fs", motion.CPA, motion.TCPA.Seconds()))
+	} else {
+		lines = append(lines, "CPA:      opening")
+	}
+	return lines
+}
@@ -3,26 +3,31 @@ package network
 import (
 	"context"
 	"fmt"
-	"math/rand"
-	"os/exec"
+	"math"
 	"strconv"
 	"strings"
 	"time"
 
+	"github.com/e6a5/radar/radar/model"
 	"github.com/e6a5/radar/radar/scanner"
-	"github.com/gdamore/tcell/v2"
 )
 
+// rateHistoryLength is the number of throughput samples kept per interface
+const rateHistoryLength = 20
+
 // InterfaceScanner monitors network interfaces and active connections
 type InterfaceScanner struct {
-	lastScan time.Time
-	config   *scanner.Config
+	config      *scanner.Config
+	samples     map[string]interfaceSample // Counters from the previous scan
+	rateHistory map[string][]float64       // Recent throughput per interface in bytes/s
 }
 
 // NewInterfaceScanner creates a new network interface scanner
 func NewInterfaceScanner(config *scanner.Config) *InterfaceScanner {
 	return &InterfaceScanner{
-		config: config,
+		config:      config,
+		samples:     make(map[string]interfaceSample),
+		rateHistory: make(map[string][]float64),
 	}
 }
 
@@ -31,23 +36,16 @@ func (n *InterfaceScanner) Name() string {
 	return "Network Interface Scanner"
 }
 
-// IsAvailable checks if netstat is available
+// IsAvailable checks for the kernel socket tables or netstat
 func (n *InterfaceScanner) IsAvailable() bool {
-	_, err := exec.LookPath("netstat")
-	return err == nil
+	return socketsAvailable()
 }
 
 // Scan scans for active network connections and interfaces
-func (n *InterfaceScanner) Scan(ctx context.Context) ([]scanner.Signal, error) {
-	signals := make([]scanner.Signal, 0)
+func (n *InterfaceScanner) Scan(ctx context.Context) ([]model.Signal, error) {
+	signals := make([]model.Signal, 0)
 	now := time.Now()
 
-	// Rate limiting
-	if now.Sub(n.lastScan) < n.config.ScanInterval {
-		return signals, nil
-	}
-	n.lastScan = now
-
 	// Scan active connections
 	connectionSignals, err := n.scanConnections(ctx, now)
 	if err == nil {
@@ -68,161 +66,123 @@ func (n *InterfaceScanner) Scan(ctx context.Context) ([]scanner.Signal, error) {
 	return signals, nil
 }
 
-// scanConnections scans for active network connections
-func (n *InterfaceScanner) scanConnections(ctx context.Context, now time.Time) ([]scanner.Signal, error) {
-	signals := make([]scanner.Signal, 0)
+// scanConnections reports every remote endpoint with an active connection
+func (n *InterfaceScanner) scanConnections(ctx context.Context, now time.Time) ([]model.Signal, error) {
+	sockets, err := readSockets(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return connectionSignals(sockets, socketProcesses(), n.config, now), nil
+}
 
-	cmd := exec.CommandContext(ctx, "netstat", "-n")
-	output, err := cmd.Output()
+// scanInterfaces reports each active interface with its throughput since the
+// previous scan
+func (n *InterfaceScanner) scanInterfaces(ctx context.Context, now time.Time) ([]model.Signal, error) {
+	stats, err := readInterfaceStats(ctx)
 	if err != nil {
-		return signals, err
+		return nil, err
 	}
 
-	connectionCounts := make(map[string]int)
-	lines := strings.Split(string(output), "\n")
-
-	for _, line := range lines {
-		if strings.Contains(line, "ESTABLISHED") {
-			if strings.Contains(line, ":80") || strings.Contains(line, ":443") {
-				connectionCounts["HTTP"]++
-			} else if strings.Contains(line, ":22") {
-				connectionCounts["SSH"]++
-			} else if strings.Contains(line, ":53") {
-				connectionCounts["DNS"]++
-			} else {
-				connectionCounts["Other"]++
-			}
+	signals := make([]model.Signal, 0)
+	samples := make(map[string]interfaceSample, len(stats))
+	for _, st := range stats {
+		current := interfaceSample{stats: st, at: now}
+		samples[st.Name] = current
+
+		// Skip loopback, down and never-used interfaces
+		if st.Loopback || !st.Up || st.RxPackets+st.TxPackets == 0 {
+			continue
 		}
-	}
 
-	// Create signals for different connection types
-	connectionTypes := []struct {
-		name  string
-		icon  string
-		color tcell.Color
-	}{
-		{"HTTP", "⚡", tcell.ColorGreen},
-		{"SSH", "🔐", tcell.ColorYellow},
-		{"DNS", "🌐", tcell.ColorBlue},
-		{"Other", "▲", tcell.ColorWhite},
+		var rates interfaceRates
+		if previous, ok := n.samples[st.Name]; ok {
+			rates = computeRates(previous, current)
+		}
+		throughput := rates.RxBytes + rates.TxBytes
+
+		history := append(n.rateHistory[st.Name], throughput)
+		if len(history) > rateHistoryLength {
+			history = history[len(history)-rateHistoryLength:]
+		}
+		n.rateHistory[st.Name] = history
+
+		signals = append(signals, n.interfaceSignal(st, rates, history, now))
 	}
 
-	for _, connType := range connectionTypes {
-		count := connectionCounts[connType.name]
-		if count > 0 {
-			signal := scanner.Signal{
-				Type:        "Network",
-				Icon:        connType.icon,
-				Name:        fmt.Sprintf("%s (%d)", connType.name, count),
-				Color:       connType.color,
-				Strength:    min(100, count*20),
-				Distance:    rand.Float64()*3 + 1,
-				Angle:       rand.Float64() * 2 * 3.14159,
-				Phase:       0,
-				Lifetime:    now,
-				LastSeen:    now,
-				Persistence: 1.0,
-				History:     make([]scanner.PositionHistory, 0, 20),
-				MaxHistory:  20,
-			}
-
-			signal.AddToHistory(signal.Distance, signal.Angle, signal.Strength, true, now)
-			signals = append(signals, signal)
+	// Forget interfaces that went away
+	for name := range n.rateHistory {
+		if _, ok := samples[name]; !ok {
+			delete(n.rateHistory, name)
 		}
 	}
+	n.samples = samples
 
 	return signals, nil
 }
 
-// scanInterfaces scans network interfaces for activity
-func (n *InterfaceScanner) scanInterfaces(ctx context.Context, now time.Time) ([]scanner.Signal, error) {
-	signals := make([]scanner.Signal, 0)
+// interfaceSignal converts an interface and its rates into a signal
+func (n *InterfaceScanner) interfaceSignal(st InterfaceStats, rates interfaceRates, history []float64, now time.Time) model.Signal {
+	// Determine interface type
+	icon := "▲"
+	signalType := "Network"
+	if st.Wireless || strings.HasPrefix(st.Name, "wl") || strings.HasPrefix(st.Name, "wifi") {
+		icon = "≋"
+		signalType = "WiFi"
+	} else if strings.HasPrefix(st.Name, "en") || strings.HasPrefix(st.Name, "eth") {
+		icon = "≋"
+		signalType = "Ethernet"
+	}
 
-	cmd := exec.CommandContext(ctx, "netstat", "-i")
-	output, err := cmd.Output()
-	if err != nil {
-		return signals, err
+	// Busier interfaces sit closer to the center
+	strength := max(5, utilizationStrength(rates.RxBytes+rates.TxBytes, st.Speed))
+
+	samples := make([]string, len(history))
+	for i, rate := range history {
+		samples[i] = strconv.FormatFloat(rate, 'f', 0, 64)
 	}
 
-	lines := strings.Split(string(output), "\n")
-	for i, line := range lines {
-		if i == 0 || strings.TrimSpace(line) == "" {
-			continue
-		}
+	attrs := model.Attributes{
+		model.AttrInterface: st.Name,
+		"rx_packets":        strconv.FormatUint(st.RxPackets, 10),
+		"tx_packets":        strconv.FormatUint(st.TxPackets, 10),
+	}
+	attrs.SetInt(model.AttrRxRate, int(rates.RxBytes))
+	attrs.SetInt(model.AttrTxRate, int(rates.TxBytes))
+	attrs.SetInt(model.AttrRxPackets, int(math.Round(rates.RxPackets)))
+	attrs.SetInt(model.AttrTxPackets, int(math.Round(rates.TxPackets)))
+	attrs.SetInt(model.AttrErrors, int(rates.Errors))
+	attrs.SetInt(model.AttrDrops, int(rates.Drops))
+	if st.Speed > 0 {
+		attrs.SetInt(model.AttrLinkSpeed, st.Speed)
+	}
+	attrs.Set(model.AttrRateHistory, strings.Join(samples, ","))
 
-		fields := strings.Fields(line)
-		if len(fields) >= 4 {
-			interfaceName := fields[0]
-
-			// Skip loopback and down interfaces
-			if strings.HasPrefix(interfaceName, "lo") ||
-				strings.Contains(interfaceName, "*") {
-				continue
-			}
-
-			// Try to get packet counts
-			var rxPackets, txPackets int
-			if len(fields) >= 7 {
-				if rx, err := strconv.Atoi(fields[3]); err == nil {
-					rxPackets = rx
-				}
-				if tx, err := strconv.Atoi(fields[7]); err == nil {
-					txPackets = tx
-				}
-			}
-
-			// Calculate activity level
-			totalPackets := rxPackets + txPackets
-			if totalPackets > 0 {
-				// Determine interface type
-				var icon string
-				var color tcell.Color
-				signalType := "Network"
-
-				if strings.HasPrefix(interfaceName, "en") ||
-					strings.HasPrefix(interfaceName, "eth") {
-					icon = "≋"
-					color = tcell.ColorBlue
-					signalType = "Ethernet"
-				} else if strings.HasPrefix(interfaceName, "wl") ||
-					strings.HasPrefix(interfaceName, "wifi") {
-					icon = "≋"
-					color = tcell.ColorGreen
-					signalType = "WiFi"
-				} else {
-					icon = "▲"
-					color = tcell.ColorWhite
-				}
-
-				// Normalize activity to strength percentage
-				strength := min(100, totalPackets/1000)
-				if strength < 10 {
-					strength = 10
-				}
-
-				signal := scanner.Signal{
-					Type:        signalType,
-					Icon:        icon,
-					Name:        fmt.Sprintf("%s Interface", interfaceName),
-					Color:       color,
-					Strength:    strength,
-					Distance:    rand.Float64()*2 + 0.5,
-					Angle:       rand.Float64() * 2 * 3.14159,
-					Phase:       0,
-					Lifetime:    now,
-					LastSeen:    now,
-					Persistence: 1.0,
-					History:     make([]scanner.PositionHistory, 0, 20),
-					MaxHistory:  20,
-				}
-
-				signal.AddToHistory(signal.Distance, signal.Angle, signal.Strength, true, now)
-				signals = append(signals, signal)
-			}
-		}
+	severity := model.SeverityNormal
+	if rates.Errors > 0 {
+		severity = model.SeverityNotice
 	}
 
-	return signals, nil
+	signal := model.Signal{
+		ID:          "iface:" + st.Name,
+		Type:        signalType,
+		Icon:        icon,
+		Name:        fmt.Sprintf("%s Interface", st.Name),
+		Category:    model.CategoryInterface,
+		Severity:    severity,
+		Strength:    strength,
+		Distance:    2.5 - 2*float64(strength)/100,
+		Angle:       n.config.Bearing(signalType, "iface:"+st.Name),
+		Phase:       0,
+		Lifetime:    now,
+		LastSeen:    now,
+		Persistence: 1.0,
+		History:     make([]model.PositionHistory, 0, 20),
+		MaxHistory:  20,
+		Attributes:  attrs,
+	}
+
+	signal.AddToHistory(signal.Distance, signal.Angle, signal.Strength, true, now)
+	return signal
 }
 
 // min returns the smaller of two integers
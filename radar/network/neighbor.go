@@ -0,0 +1,457 @@
+package network
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/e6a5/radar/radar/model"
+	"github.com/e6a5/radar/radar/scanner"
+	"github.com/e6a5/radar/radar/wifi"
+)
+
+// Neighbor states, following the kernel's NUD_* names
+const (
+	StateReachable  = "reachable"
+	StateStale      = "stale"
+	StateDelay      = "delay"
+	StateProbe      = "probe"
+	StateFailed     = "failed"
+	StateIncomplete = "incomplete"
+	StatePermanent  = "permanent"
+	StateNoARP      = "noarp"
+)
+
+// sweepInterval is how often the whole subnet is probed. Scans in between
+// only probe hosts already in the neighbor table.
+const sweepInterval = 5 * time.Minute
+
+// maxSweepHosts bounds the subnet size that is swept (a /22)
+const maxSweepHosts = 1022
+
+// Neighbor is one host from the ARP/neighbor table
+type Neighbor struct {
+	IP        net.IP
+	MAC       net.HardwareAddr
+	Interface string
+	State     string
+	RTT       time.Duration // Round trip of the last probe, zero if unknown
+}
+
+// NeighborScanner lists hosts on the local network from the neighbor table,
+// optionally probing the local subnets to find hosts and measure latency
+type NeighborScanner struct {
+	lastSweep time.Time
+	rtts      map[string]time.Duration // Last round trip by IP
+	config    *scanner.Config
+	mutex     sync.Mutex
+}
+
+// NewNeighborScanner creates a new neighbor table scanner
+func NewNeighborScanner(config *scanner.Config) *NeighborScanner {
+	return &NeighborScanner{
+		config: config,
+		rtts:   make(map[string]time.Duration),
+	}
+}
+
+// Name returns the scanner name
+func (n *NeighborScanner) Name() string {
+	return "LAN Neighbor Scanner"
+}
+
+// IsAvailable checks if the neighbor table can be read
+func (n *NeighborScanner) IsAvailable() bool {
+	return neighborTableAvailable()
+}
+
+// Scan lists the hosts in the neighbor table
+func (n *NeighborScanner) Scan(ctx context.Context) ([]model.Signal, error) {
+	signals := make([]model.Signal, 0)
+	now := time.Now()
+
+	if n.config.ActiveSweep {
+		n.probe(ctx, now)
+	}
+
+	neighbors, err := readNeighbors(ctx)
+	if err != nil {
+		return signals, err
+	}
+
+	n.mutex.Lock()
+	for i := range neighbors {
+		neighbors[i].RTT = n.rtts[neighbors[i].IP.String()]
+	}
+	n.mutex.Unlock()
+	for _, host := range groupNeighbors(neighbors) {
+		signals = append(signals, neighborSignal(host, n.config, now))
+	}
+
+	sort.Slice(signals, func(i, j int) bool {
+		return signals[i].Strength > signals[j].Strength
+	})
+	if len(signals) > n.config.MaxSignals {
+		signals = signals[:n.config.MaxSignals]
+	}
+	return signals, nil
+}
+
+// probe pings the local subnets, or only the known neighbors between full
+// sweeps, and records the round trips. The lock is not held while pinging,
+// so a slow sweep does not block other scans.
+func (n *NeighborScanner) probe(ctx context.Context, now time.Time) {
+	var targets []net.IP
+	n.mutex.Lock()
+	sweep := now.Sub(n.lastSweep) >= sweepInterval
+	if sweep {
+		n.lastSweep = now
+	}
+	n.mutex.Unlock()
+
+	if sweep {
+		targets = sweepTargets()
+	} else if neighbors, err := readNeighbors(ctx); err == nil {
+		for _, neighbor := range neighbors {
+			if neighbor.IP.To4() != nil && neighbor.State != StateFailed {
+				targets = append(targets, neighbor.IP)
+			}
+		}
+	}
+	if len(targets) == 0 {
+		return
+	}
+
+	rtts := ping(ctx, targets, time.Second)
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+	for _, ip := range targets {
+		delete(n.rtts, ip.String())
+	}
+	for ip, rtt := range rtts {
+		n.rtts[ip] = rtt
+	}
+}
+
+// usableNeighbor reports whether a neighbor entry is a host that answered
+func usableNeighbor(neighbor *Neighbor) bool {
+	if neighbor.IP.IsMulticast() || neighbor.IP.IsLoopback() || neighbor.IP.IsUnspecified() {
+		return false
+	}
+	if neighbor.State == StateFailed || neighbor.State == StateIncomplete {
+		return neighbor.RTT > 0
+	}
+	return len(neighbor.MAC) > 0 && !isZeroMAC(neighbor.MAC)
+}
+
+// groupNeighbors groups entries by hardware address, since a host usually has
+// both IPv4 and IPv6 entries. Entries without a usable address are dropped.
+func groupNeighbors(neighbors []Neighbor) [][]*Neighbor {
+	groups := make(map[string][]*Neighbor)
+	keys := make([]string, 0)
+	for i := range neighbors {
+		neighbor := &neighbors[i]
+		if !usableNeighbor(neighbor) {
+			continue
+		}
+		key := neighbor.MAC.String()
+		if key == "" {
+			key = neighbor.IP.String()
+		}
+		if _, ok := groups[key]; !ok {
+			keys = append(keys, key)
+		}
+		groups[key] = append(groups[key], neighbor)
+	}
+
+	hosts := make([][]*Neighbor, 0, len(keys))
+	for _, key := range keys {
+		host := groups[key]
+		// IPv4 addresses first, as they are what users recognise
+		sort.SliceStable(host, func(i, j int) bool {
+			return host[i].IP.To4() != nil && host[j].IP.To4() == nil
+		})
+		hosts = append(hosts, host)
+	}
+	return hosts
+}
+
+// neighborSignal converts the entries of one host into a radar signal
+func neighborSignal(host []*Neighbor, config *scanner.Config, now time.Time) model.Signal {
+	primary := host[0]
+	key := primary.MAC.String()
+	if key == "" {
+		key = primary.IP.String()
+	}
+
+	// The best round trip and state across the host's addresses
+	var rtt time.Duration
+	state := primary.State
+	addresses := make([]string, 0, len(host))
+	for _, neighbor := range host {
+		addresses = append(addresses, neighbor.IP.String())
+		if neighbor.RTT > 0 && (rtt == 0 || neighbor.RTT < rtt) {
+			rtt = neighbor.RTT
+		}
+		if stateRank(neighbor.State) > stateRank(state) {
+			state = neighbor.State
+		}
+	}
+
+	vendor := LookupVendor(primary.MAC)
+	name := primary.IP.String()
+	if vendor != "" {
+		name = fmt.Sprintf("%s (%s)", vendor, name)
+	}
+
+	attrs := model.Attributes{}
+	attrs.Set(model.AttrIP, strings.Join(addresses, ", "))
+	attrs.Set(model.AttrMAC, primary.MAC.String())
+	attrs.Set(model.AttrVendor, vendor)
+	if wifi.IsLocallyAdministered(primary.MAC) {
+		attrs.SetBool(model.AttrRandomMAC, true)
+	}
+	attrs.Set(model.AttrInterface, primary.Interface)
+	attrs.Set("state", state)
+	if rtt > 0 {
+		attrs.Set("rtt", rtt.Round(10*time.Microsecond).String())
+	}
+
+	signal := model.Signal{
+		ID:          "lan:" + key,
+		Type:        "IoT",
+		Icon:        "◇",
+		Name:        name,
+		Category:    model.CategoryIoT,
+		Severity:    model.SeverityNormal,
+		Strength:    neighborStrength(state, rtt),
+		Distance:    config.LatencyDistance(rtt),
+		Angle:       config.Bearing("IoT", "lan:"+key),
+		Phase:       0,
+		Lifetime:    now,
+		LastSeen:    now,
+		Persistence: 1.0,
+		History:     make([]model.PositionHistory, 0, 20),
+		MaxHistory:  20,
+		Attributes:  attrs,
+	}
+
+	signal.AddToHistory(signal.Distance, signal.Angle, signal.Strength, true, now)
+	return signal
+}
+
+// stateRank orders states from least to most certain reachability
+func stateRank(state string) int {
+	switch state {
+	case StateReachable, StatePermanent:
+		return 4
+	case StateDelay, StateProbe, StateNoARP:
+		return 3
+	case StateStale:
+		return 2
+	case StateIncomplete, StateFailed:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// neighborStrength scores a host by how recently it was confirmed reachable
+// and how fast it answers
+func neighborStrength(state string, rtt time.Duration) int {
+	if rtt > 0 {
+		// 100% under 1 ms, falling 2% per further millisecond
+		ms := float64(rtt) / float64(time.Millisecond)
+		return max(10, min(100, int(102-2*ms)))
+	}
+	return stateRank(state) * 20
+}
+
+// parseProcARP parses the kernel's /proc/net/arp table
+func parseProcARP(r io.Reader) []Neighbor {
+	neighbors := make([]Neighbor, 0)
+	lines := bufio.NewScanner(r)
+	for lines.Scan() {
+		// IP address  HW type  Flags  HW address  Mask  Device
+		fields := strings.Fields(lines.Text())
+		if len(fields) < 6 || fields[0] == "IP" {
+			continue
+		}
+		ip := net.ParseIP(fields[0])
+		if ip == nil {
+			continue
+		}
+		mac, _ := net.ParseMAC(fields[3])
+
+		var flags int
+		fmt.Sscanf(fields[2], "0x%x", &flags)
+		state := StateIncomplete
+		switch {
+		case flags&0x4 != 0: // ATF_PERM
+			state = StatePermanent
+		case flags&0x2 != 0: // ATF_COM
+			state = StateReachable
+		}
+
+		neighbors = append(neighbors, Neighbor{
+			IP:        ip,
+			MAC:       mac,
+			Interface: fields[5],
+			State:     state,
+		})
+	}
+	return neighbors
+}
+
+// parseARPCommand parses the output of `arp -an` as printed by BSD and macOS:
+// "? (192.168.1.1) at aa:bb:cc:dd:ee:ff on en0 ifscope [ethernet]"
+func parseARPCommand(r io.Reader) []Neighbor {
+	neighbors := make([]Neighbor, 0)
+	lines := bufio.NewScanner(r)
+	for lines.Scan() {
+		fields := strings.Fields(lines.Text())
+		if len(fields) < 4 || fields[2] != "at" {
+			continue
+		}
+		ip := net.ParseIP(strings.Trim(fields[1], "()"))
+		if ip == nil {
+			continue
+		}
+
+		neighbor := Neighbor{IP: ip, State: StateIncomplete}
+		if mac, err := parseLooseMAC(fields[3]); err == nil {
+			neighbor.MAC = mac
+			neighbor.State = StateReachable
+		}
+		for i := 4; i+1 < len(fields); i++ {
+			if fields[i] == "on" {
+				neighbor.Interface = fields[i+1]
+			}
+		}
+		if strings.Contains(lines.Text(), "permanent") {
+			neighbor.State = StatePermanent
+		}
+		neighbors = append(neighbors, neighbor)
+	}
+	return neighbors
+}
+
+// parseLooseMAC parses a MAC address whose octets may lack leading zeros, as
+// BSD arp prints them (e.g. "0:1b:63:a:b:c")
+func parseLooseMAC(s string) (net.HardwareAddr, error) {
+	parts := strings.Split(s, ":")
+	if len(parts) != 6 {
+		return nil, fmt.Errorf("invalid MAC address %q", s)
+	}
+	for i, part := range parts {
+		if len(part) == 1 {
+			parts[i] = "0" + part
+		}
+	}
+	return net.ParseMAC(strings.Join(parts, ":"))
+}
+
+// touchUDP sends an empty datagram to the discard port of each target, so
+// the kernel resolves their hardware addresses without an ICMP socket
+func touchUDP(targets []net.IP) {
+	for _, ip := range targets {
+		conn, err := net.DialUDP("udp4", nil, &net.UDPAddr{IP: ip, Port: 9})
+		if err != nil {
+			continue
+		}
+		conn.Write(nil)
+		conn.Close()
+	}
+}
+
+// echoRequest builds an ICMP echo request
+func echoRequest(id, seq uint16) []byte {
+	msg := []byte{8, 0, 0, 0, byte(id >> 8), byte(id), byte(seq >> 8), byte(seq), 'r', 'a', 'd', 'a', 'r'}
+	sum := checksum(msg)
+	msg[2] = byte(sum >> 8)
+	msg[3] = byte(sum)
+	return msg
+}
+
+// checksum computes the Internet checksum of data
+func checksum(data []byte) uint16 {
+	var sum uint32
+	for i := 0; i+1 < len(data); i += 2 {
+		sum += uint32(data[i])<<8 | uint32(data[i+1])
+	}
+	if len(data)%2 == 1 {
+		sum += uint32(data[len(data)-1]) << 8
+	}
+	for sum>>16 != 0 {
+		sum = sum&0xffff + sum>>16
+	}
+	return ^uint16(sum)
+}
+
+// isZeroMAC reports whether every octet of mac is zero
+func isZeroMAC(mac net.HardwareAddr) bool {
+	for _, b := range mac {
+		if b != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// sweepTargets returns every host address on the IPv4 subnets of the up,
+// non-loopback interfaces, skipping subnets larger than maxSweepHosts
+func sweepTargets() []net.IP {
+	targets := make([]net.IP, 0)
+	interfaces, err := net.Interfaces()
+	if err != nil {
+		return targets
+	}
+
+	for _, iface := range interfaces {
+		if iface.Flags&net.FlagUp == 0 || iface.Flags&net.FlagLoopback != 0 {
+			continue
+		}
+		addrs, err := iface.Addrs()
+		if err != nil {
+			continue
+		}
+		for _, addr := range addrs {
+			ipnet, ok := addr.(*net.IPNet)
+			if !ok || ipnet.IP.To4() == nil {
+				continue
+			}
+			targets = append(targets, subnetHosts(ipnet)...)
+		}
+	}
+	return targets
+}
+
+// subnetHosts lists the host addresses of an IPv4 subnet other than the
+// interface's own address
+func subnetHosts(ipnet *net.IPNet) []net.IP {
+	ones, bits := ipnet.Mask.Size()
+	size := 1 << (bits - ones)
+	if bits != 32 || size-2 > maxSweepHosts || size < 4 {
+		return nil
+	}
+
+	self := ipnet.IP.To4()
+	network := self.Mask(ipnet.Mask)
+	base := uint32(network[0])<<24 | uint32(network[1])<<16 | uint32(network[2])<<8 | uint32(network[3])
+
+	hosts := make([]net.IP, 0, size-2)
+	for i := 1; i < size-1; i++ {
+		v := base + uint32(i)
+		ip := net.IPv4(byte(v>>24), byte(v>>16), byte(v>>8), byte(v)).To4()
+		if !ip.Equal(self) {
+			hosts = append(hosts, ip)
+		}
+	}
+	return hosts
+}
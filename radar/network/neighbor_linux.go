@@ -0,0 +1,247 @@
+//go:build linux
+// +build linux
+
+package network
+
+import (
+	"context"
+	"encoding/binary"
+	"net"
+	"os"
+	"sync"
+	"syscall"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+// neighborTableAvailable checks for the rtnetlink or /proc neighbor table
+func neighborTableAvailable() bool {
+	if _, err := os.Stat("/proc/net/arp"); err == nil {
+		return true
+	}
+	_, err := readNetlinkNeighbors()
+	return err == nil
+}
+
+// readNeighbors reads the neighbor table over rtnetlink, which includes IPv6
+// neighbors and reachability states, falling back to /proc/net/arp
+func readNeighbors(ctx context.Context) ([]Neighbor, error) {
+	if neighbors, err := readNetlinkNeighbors(); err == nil {
+		return neighbors, nil
+	}
+
+	file, err := os.Open("/proc/net/arp")
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return parseProcARP(file), nil
+}
+
+// readNetlinkNeighbors dumps the kernel neighbor table with RTM_GETNEIGH
+func readNetlinkNeighbors() ([]Neighbor, error) {
+	data, err := syscall.NetlinkRIB(unix.RTM_GETNEIGH, unix.AF_UNSPEC)
+	if err != nil {
+		return nil, err
+	}
+	messages, err := syscall.ParseNetlinkMessage(data)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make(map[int]string)
+	if interfaces, err := net.Interfaces(); err == nil {
+		for _, iface := range interfaces {
+			names[iface.Index] = iface.Name
+		}
+	}
+
+	neighbors := make([]Neighbor, 0, len(messages))
+	for _, msg := range messages {
+		if msg.Header.Type != unix.RTM_NEWNEIGH {
+			continue
+		}
+		if neighbor, ok := parseNeighborMessage(msg.Data, names); ok {
+			neighbors = append(neighbors, neighbor)
+		}
+	}
+	return neighbors, nil
+}
+
+// parseNeighborMessage decodes an ndmsg header and its NDA_* attributes
+func parseNeighborMessage(data []byte, names map[int]string) (Neighbor, bool) {
+	if len(data) < unix.SizeofNdMsg {
+		return Neighbor{}, false
+	}
+	ifindex := int(int32(binary.NativeEndian.Uint32(data[4:8])))
+	state := binary.NativeEndian.Uint16(data[8:10])
+
+	neighbor := Neighbor{
+		Interface: names[ifindex],
+		State:     neighborState(state),
+	}
+
+	attrs := data[unix.SizeofNdMsg:]
+	for len(attrs) >= 4 {
+		length := int(binary.NativeEndian.Uint16(attrs[0:2]))
+		kind := binary.NativeEndian.Uint16(attrs[2:4])
+		if length < 4 || length > len(attrs) {
+			break
+		}
+		payload := attrs[4:length]
+		switch kind {
+		case unix.NDA_DST:
+			neighbor.IP = append(net.IP(nil), payload...)
+		case unix.NDA_LLADDR:
+			neighbor.MAC = append(net.HardwareAddr(nil), payload...)
+		}
+
+		aligned := (length + 3) &^ 3
+		if aligned > len(attrs) {
+			break
+		}
+		attrs = attrs[aligned:]
+	}
+
+	if len(neighbor.IP) != net.IPv4len && len(neighbor.IP) != net.IPv6len {
+		return Neighbor{}, false
+	}
+	return neighbor, true
+}
+
+// neighborState names a NUD_* state bitmask by its most significant state
+func neighborState(state uint16) string {
+	switch {
+	case state&unix.NUD_PERMANENT != 0:
+		return StatePermanent
+	case state&unix.NUD_NOARP != 0:
+		return StateNoARP
+	case state&unix.NUD_REACHABLE != 0:
+		return StateReachable
+	case state&unix.NUD_DELAY != 0:
+		return StateDelay
+	case state&unix.NUD_PROBE != 0:
+		return StateProbe
+	case state&unix.NUD_STALE != 0:
+		return StateStale
+	case state&unix.NUD_FAILED != 0:
+		return StateFailed
+	default:
+		return StateIncomplete
+	}
+}
+
+// ping sends one ICMP echo request to each target and returns the round trip
+// of every reply by IP. It uses an unprivileged ping socket where the system
+// allows one, then a raw socket. Without either, targets are only touched
+// with a UDP datagram, which still fills the neighbor table.
+func ping(ctx context.Context, targets []net.IP, timeout time.Duration) map[string]time.Duration {
+	conn, raw, err := listenICMP()
+	if err != nil {
+		touchUDP(targets)
+		return nil
+	}
+	defer conn.Close()
+
+	id := uint16(os.Getpid())
+	sent := make([]time.Time, len(targets))
+	rtts := make(map[string]time.Duration)
+	var mutex sync.Mutex
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		buf := make([]byte, 1500)
+		for {
+			n, addr, err := conn.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			received := time.Now()
+
+			reply := buf[:n]
+			if len(reply) < 8 || reply[0] != 0 || reply[1] != 0 {
+				continue // Not an echo reply
+			}
+			// Ping sockets rewrite the identifier and filter replies themselves
+			if raw && binary.BigEndian.Uint16(reply[4:6]) != id {
+				continue
+			}
+			seq := int(binary.BigEndian.Uint16(reply[6:8]))
+
+			mutex.Lock()
+			if seq < len(targets) && addrIP(addr).Equal(targets[seq]) && !sent[seq].IsZero() {
+				rtts[targets[seq].String()] = received.Sub(sent[seq])
+			}
+			complete := len(rtts) == len(targets)
+			mutex.Unlock()
+			if complete {
+				return
+			}
+		}
+	}()
+
+	for seq, ip := range targets {
+		if ctx.Err() != nil {
+			break
+		}
+		var addr net.Addr = &net.UDPAddr{IP: ip}
+		if raw {
+			addr = &net.IPAddr{IP: ip}
+		}
+
+		mutex.Lock()
+		sent[seq] = time.Now()
+		mutex.Unlock()
+		conn.WriteTo(echoRequest(id, uint16(seq)), addr)
+
+		// Pace the sweep so it does not flood the link
+		if seq%64 == 63 {
+			time.Sleep(5 * time.Millisecond)
+		}
+	}
+
+	conn.SetReadDeadline(time.Now().Add(timeout))
+	select {
+	case <-done:
+	case <-ctx.Done():
+		conn.SetReadDeadline(time.Now())
+		<-done
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+	return rtts
+}
+
+// listenICMP opens an ICMP socket, reporting whether it is a raw socket
+func listenICMP() (net.PacketConn, bool, error) {
+	fd, err := unix.Socket(unix.AF_INET, unix.SOCK_DGRAM|unix.SOCK_CLOEXEC, unix.IPPROTO_ICMP)
+	if err == nil {
+		file := os.NewFile(uintptr(fd), "icmp")
+		conn, err := net.FilePacketConn(file)
+		file.Close()
+		if err == nil {
+			return conn, false, nil
+		}
+	}
+
+	conn, err := net.ListenPacket("ip4:icmp", "0.0.0.0")
+	if err != nil {
+		return nil, false, err
+	}
+	return conn, true, nil
+}
+
+// addrIP returns the IP of a packet source address
+func addrIP(addr net.Addr) net.IP {
+	switch a := addr.(type) {
+	case *net.UDPAddr:
+		return a.IP
+	case *net.IPAddr:
+		return a.IP
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,16 @@
+package network
+
+import (
+	"net"
+
+	"github.com/e6a5/radar/radar/wifi"
+)
+
+// LookupVendor returns the vendor owning a MAC address's OUI. Randomised,
+// locally administered addresses are reported as private.
+func LookupVendor(mac net.HardwareAddr) string {
+	if wifi.IsLocallyAdministered(mac) {
+		return "Private"
+	}
+	return wifi.LookupVendor(mac)
+}
@@ -0,0 +1,33 @@
+package network
+
+import (
+	"os/user"
+	"strconv"
+	"sync"
+)
+
+// Process is the program that owns a socket
+type Process struct {
+	PID  int
+	Name string
+}
+
+// userNames caches user names by UID
+var userNames sync.Map
+
+// userName returns the login name of a UID, or the UID itself if the user
+// cannot be looked up
+func userName(uid int) string {
+	if uid < 0 {
+		return ""
+	}
+	if name, ok := userNames.Load(uid); ok {
+		return name.(string)
+	}
+	name := strconv.Itoa(uid)
+	if u, err := user.LookupId(name); err == nil {
+		name = u.Username
+	}
+	userNames.Store(uid, name)
+	return name
+}
@@ -0,0 +1,64 @@
+//go:build linux
+// +build linux
+
+package network
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// socketProcesses maps socket inodes to the processes holding them by reading
+// the /proc/<pid>/fd links. Processes of other users are only visible with
+// enough privileges. A socket shared by several processes, such as a forked
+// server's, is attributed to the lowest PID.
+func socketProcesses() map[uint64]Process {
+	processes := make(map[uint64]Process)
+	entries, err := os.ReadDir("/proc")
+	if err != nil {
+		return processes
+	}
+
+	for _, entry := range entries {
+		pid, err := strconv.Atoi(entry.Name())
+		if err != nil {
+			continue
+		}
+		dir := filepath.Join("/proc", entry.Name())
+		fds, err := os.ReadDir(filepath.Join(dir, "fd"))
+		if err != nil {
+			continue
+		}
+
+		name := ""
+		for _, fd := range fds {
+			link, err := os.Readlink(filepath.Join(dir, "fd", fd.Name()))
+			if err != nil || !strings.HasPrefix(link, "socket:[") {
+				continue
+			}
+			inode, err := strconv.ParseUint(strings.TrimSuffix(strings.TrimPrefix(link, "socket:["), "]"), 10, 64)
+			if err != nil {
+				continue
+			}
+			if owner, ok := processes[inode]; ok && owner.PID < pid {
+				continue
+			}
+			if name == "" {
+				name = processName(dir)
+			}
+			processes[inode] = Process{PID: pid, Name: name}
+		}
+	}
+	return processes
+}
+
+// processName reads a process's command name
+func processName(dir string) string {
+	comm, err := os.ReadFile(filepath.Join(dir, "comm"))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(comm))
+}
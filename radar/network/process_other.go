@@ -0,0 +1,10 @@
+//go:build !linux
+// +build !linux
+
+package network
+
+// socketProcesses is not supported without /proc; connections are reported
+// without their owning process
+func socketProcesses() map[uint64]Process {
+	return nil
+}
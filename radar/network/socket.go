@@ -0,0 +1,417 @@
+package network
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net"
+	"os/exec"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/e6a5/radar/radar/model"
+	"github.com/e6a5/radar/radar/scanner"
+)
+
+// TCP states, named as netstat prints them
+const (
+	TCPEstablished = "ESTABLISHED"
+	TCPSynSent     = "SYN_SENT"
+	TCPSynRecv     = "SYN_RECV"
+	TCPFinWait1    = "FIN_WAIT1"
+	TCPFinWait2    = "FIN_WAIT2"
+	TCPTimeWait    = "TIME_WAIT"
+	TCPClose       = "CLOSE"
+	TCPCloseWait   = "CLOSE_WAIT"
+	TCPLastAck     = "LAST_ACK"
+	TCPListen      = "LISTEN"
+	TCPClosing     = "CLOSING"
+)
+
+// tcpStates maps the kernel's TCP state numbers to names
+var tcpStates = map[int]string{
+	1:  TCPEstablished,
+	2:  TCPSynSent,
+	3:  TCPSynRecv,
+	4:  TCPFinWait1,
+	5:  TCPFinWait2,
+	6:  TCPTimeWait,
+	7:  TCPClose,
+	8:  TCPCloseWait,
+	9:  TCPLastAck,
+	10: TCPListen,
+	11: TCPClosing,
+}
+
+// Socket is one TCP or UDP socket from the kernel's socket tables
+type Socket struct {
+	Protocol   string // "tcp" or "udp"
+	LocalIP    net.IP
+	LocalPort  int
+	RemoteIP   net.IP
+	RemotePort int
+	State      string
+	UID        int // -1 if unknown
+	Inode      uint64
+}
+
+// service describes a well-known port
+type service struct {
+	name     string
+	icon     string
+	severity model.Severity
+}
+
+// services maps well-known ports to the service they carry
+var services = map[int]service{
+	21:    {"FTP", "▲", model.SeverityNotice},
+	22:    {"SSH", "🔐", model.SeverityNotice},
+	23:    {"Telnet", "▲", model.SeverityAlert},
+	25:    {"SMTP", "✉", model.SeverityNormal},
+	53:    {"DNS", "🌐", model.SeverityNormal},
+	80:    {"HTTP", "⚡", model.SeverityNormal},
+	110:   {"POP3", "✉", model.SeverityNormal},
+	123:   {"NTP", "◷", model.SeverityNormal},
+	143:   {"IMAP", "✉", model.SeverityNormal},
+	443:   {"HTTPS", "⚡", model.SeverityNormal},
+	465:   {"SMTPS", "✉", model.SeverityNormal},
+	587:   {"Submission", "✉", model.SeverityNormal},
+	853:   {"DNS over TLS", "🌐", model.SeverityNormal},
+	993:   {"IMAPS", "✉", model.SeverityNormal},
+	995:   {"POP3S", "✉", model.SeverityNormal},
+	1883:  {"MQTT", "◇", model.SeverityNormal},
+	3306:  {"MySQL", "▣", model.SeverityNormal},
+	3389:  {"RDP", "🔐", model.SeverityNotice},
+	5222:  {"XMPP", "✉", model.SeverityNormal},
+	5353:  {"mDNS", "🌐", model.SeverityNormal},
+	5432:  {"PostgreSQL", "▣", model.SeverityNormal},
+	5900:  {"VNC", "🔐", model.SeverityNotice},
+	6379:  {"Redis", "▣", model.SeverityNormal},
+	8080:  {"HTTP", "⚡", model.SeverityNormal},
+	8443:  {"HTTPS", "⚡", model.SeverityNormal},
+	8883:  {"MQTTS", "◇", model.SeverityNormal},
+	27017: {"MongoDB", "▣", model.SeverityNormal},
+}
+
+// otherService describes connections on ports without a known service
+var otherService = service{"Other", "▲", model.SeverityNormal}
+
+// isActive reports whether a socket is a live connection to a remote peer
+func (s Socket) isActive() bool {
+	if s.RemotePort == 0 || s.RemoteIP == nil || s.RemoteIP.IsUnspecified() || s.RemoteIP.IsLoopback() {
+		return false
+	}
+	if s.Protocol == "udp" {
+		return true
+	}
+	switch s.State {
+	case TCPEstablished, TCPSynSent, TCPSynRecv:
+		return true
+	}
+	return false
+}
+
+// endpoint is a remote peer, the service port it is reached on and the
+// process talking to it
+type endpoint struct {
+	protocol string
+	ip       string
+	port     int  // Service port: the remote port, or the local one for inbound connections
+	inbound  bool // The peer connected to a local service
+	pid      int  // Owning process; 0 if unknown
+}
+
+// ephemeralPorts is the start of the range systems pick client ports from
+const ephemeralPorts = 32768
+
+// socketEndpoint classifies a socket by exact port. A connection is inbound
+// when its remote port is not a known service but its local port is, or when
+// the peer is on an ephemeral port and the local port is not.
+func socketEndpoint(s Socket, process Process) endpoint {
+	ep := endpoint{protocol: s.Protocol, ip: s.RemoteIP.String(), port: s.RemotePort, pid: process.PID}
+	if _, known := services[s.RemotePort]; known {
+		return ep
+	}
+	_, local := services[s.LocalPort]
+	if local || s.State == TCPSynRecv || (s.RemotePort >= ephemeralPorts && s.LocalPort < ephemeralPorts) {
+		ep.port = s.LocalPort
+		ep.inbound = true
+	}
+	return ep
+}
+
+// key returns the signal identity of the endpoint
+func (ep endpoint) key() string {
+	direction := ""
+	if ep.inbound {
+		direction = "in:"
+	}
+	key := "conn:" + ep.protocol + ":" + direction + net.JoinHostPort(ep.ip, strconv.Itoa(ep.port))
+	if ep.pid > 0 {
+		key += "@" + strconv.Itoa(ep.pid)
+	}
+	return key
+}
+
+// connectionSignals groups active sockets by remote endpoint and owning
+// process, and converts each group into a signal
+func connectionSignals(sockets []Socket, processes map[uint64]Process, config *scanner.Config, now time.Time) []model.Signal {
+	groups := make(map[endpoint][]Socket)
+	owners := make(map[endpoint]Process)
+	order := make([]endpoint, 0)
+	for _, s := range sockets {
+		if !s.isActive() {
+			continue
+		}
+		process := processes[s.Inode]
+		ep := socketEndpoint(s, process)
+		if _, ok := groups[ep]; !ok {
+			order = append(order, ep)
+			owners[ep] = process
+		}
+		groups[ep] = append(groups[ep], s)
+	}
+
+	signals := make([]model.Signal, 0, len(order))
+	for _, ep := range order {
+		signals = append(signals, endpointSignal(ep, groups[ep], owners[ep], config, now))
+	}
+	sort.SliceStable(signals, func(i, j int) bool {
+		return signals[i].Strength > signals[j].Strength
+	})
+	return signals
+}
+
+// endpointSignal converts the sockets connected to one endpoint into a signal
+func endpointSignal(ep endpoint, sockets []Socket, process Process, config *scanner.Config, now time.Time) model.Signal {
+	svc, ok := services[ep.port]
+	if !ok {
+		svc = otherService
+	}
+
+	name := fmt.Sprintf("%s %s", svc.name, ep.ip)
+	if !ok {
+		name = net.JoinHostPort(ep.ip, strconv.Itoa(ep.port))
+	}
+	if ep.inbound {
+		name = fmt.Sprintf("%s from %s", svc.name, ep.ip)
+	}
+	if len(sockets) > 1 {
+		name = fmt.Sprintf("%s (%d)", name, len(sockets))
+	}
+
+	// Prefix the owner, e.g. "firefox[1234] alice: HTTPS 93.184.216.34"
+	owner := userName(sockets[0].UID)
+	if process.Name != "" {
+		owner = strings.TrimSpace(fmt.Sprintf("%s[%d] %s", process.Name, process.PID, owner))
+	}
+	if owner != "" {
+		name = owner + ": " + name
+	}
+
+	// Peers on the local network sit closer than ones across the internet
+	distance := 3.5
+	if ip := net.ParseIP(ep.ip); ip != nil && (ip.IsPrivate() || ip.IsLinkLocalUnicast()) {
+		distance = 1.5
+	}
+
+	direction := "outbound"
+	if ep.inbound {
+		direction = "inbound"
+	}
+
+	attrs := model.Attributes{}
+	attrs.Set(model.AttrIP, ep.ip)
+	attrs.SetInt(model.AttrPort, ep.port)
+	attrs.Set(model.AttrProtocol, strings.ToUpper(ep.protocol))
+	attrs.Set("service", svc.name)
+	attrs.Set("direction", direction)
+	attrs.SetInt("connections", len(sockets))
+	if sockets[0].State != "" {
+		attrs.Set("state", sockets[0].State)
+	}
+	if process.Name != "" {
+		attrs.Set(model.AttrProcess, process.Name)
+		attrs.SetInt(model.AttrPID, process.PID)
+	}
+	if user := userName(sockets[0].UID); user != "" {
+		attrs.Set(model.AttrUser, user)
+	}
+
+	key := ep.key()
+	signal := model.Signal{
+		ID:          key,
+		Type:        "Network",
+		Icon:        svc.icon,
+		Name:        name,
+		Category:    model.CategoryNetwork,
+		Severity:    svc.severity,
+		Strength:    min(100, 40+20*len(sockets)),
+		Distance:    distance,
+		Angle:       config.Bearing("Network", key),
+		Phase:       0,
+		Lifetime:    now,
+		LastSeen:    now,
+		Persistence: 1.0,
+		History:     make([]model.PositionHistory, 0, 20),
+		MaxHistory:  20,
+		Attributes:  attrs,
+	}
+
+	signal.AddToHistory(signal.Distance, signal.Angle, signal.Strength, true, now)
+	return signal
+}
+
+// parseProcNet parses a /proc/net/{tcp,tcp6,udp,udp6} table
+func parseProcNet(r io.Reader, protocol string) ([]Socket, error) {
+	sockets := make([]Socket, 0)
+	lines := bufio.NewScanner(r)
+	for lines.Scan() {
+		// sl local_address rem_address st tx_queue:rx_queue tr:tm->when retrnsmt uid timeout inode
+		fields := strings.Fields(lines.Text())
+		if len(fields) < 10 || fields[0] == "sl" {
+			continue
+		}
+
+		localIP, localPort, err := parseProcAddress(fields[1])
+		if err != nil {
+			return nil, err
+		}
+		remoteIP, remotePort, err := parseProcAddress(fields[2])
+		if err != nil {
+			return nil, err
+		}
+		state, _ := strconv.ParseInt(fields[3], 16, 32)
+		uid, err := strconv.Atoi(fields[7])
+		if err != nil {
+			uid = -1
+		}
+		inode, _ := strconv.ParseUint(fields[9], 10, 64)
+
+		socket := Socket{
+			Protocol:   protocol,
+			LocalIP:    localIP,
+			LocalPort:  localPort,
+			RemoteIP:   remoteIP,
+			RemotePort: remotePort,
+			UID:        uid,
+			Inode:      inode,
+		}
+		if protocol == "tcp" {
+			socket.State = tcpStates[int(state)]
+		}
+		sockets = append(sockets, socket)
+	}
+	return sockets, lines.Err()
+}
+
+// parseProcAddress parses "0100007F:0277". The address is printed as the
+// host-order words of the network-order bytes; the port is host order.
+func parseProcAddress(s string) (net.IP, int, error) {
+	addr, portHex, ok := strings.Cut(s, ":")
+	if !ok {
+		return nil, 0, fmt.Errorf("invalid socket address %q", s)
+	}
+	port, err := strconv.ParseUint(portHex, 16, 16)
+	if err != nil {
+		return nil, 0, fmt.Errorf("invalid socket address %q", s)
+	}
+	raw, err := hex.DecodeString(addr)
+	if err != nil || (len(raw) != net.IPv4len && len(raw) != net.IPv6len) {
+		return nil, 0, fmt.Errorf("invalid socket address %q", s)
+	}
+
+	ip := make(net.IP, len(raw))
+	for i := 0; i < len(raw); i += 4 {
+		binary.NativeEndian.PutUint32(ip[i:], binary.BigEndian.Uint32(raw[i:]))
+	}
+	if v4 := ip.To4(); v4 != nil {
+		ip = v4
+	}
+	return ip, int(port), nil
+}
+
+// netstatAvailable checks if netstat is available
+func netstatAvailable() bool {
+	_, err := exec.LookPath("netstat")
+	return err == nil
+}
+
+// readNetstatSockets lists sockets from `netstat -an`
+func readNetstatSockets(ctx context.Context) ([]Socket, error) {
+	output, err := exec.CommandContext(ctx, "netstat", "-an").Output()
+	if err != nil {
+		return nil, err
+	}
+	return parseNetstat(bytes.NewReader(output)), nil
+}
+
+// parseNetstat parses `netstat -an` output in either the Linux form
+// ("tcp 0 0 10.0.0.2:51234 1.2.3.4:443 ESTABLISHED") or the BSD form
+// ("tcp4 0 0 10.0.0.2.51234 1.2.3.4.443 ESTABLISHED")
+func parseNetstat(r io.Reader) []Socket {
+	sockets := make([]Socket, 0)
+	lines := bufio.NewScanner(r)
+	for lines.Scan() {
+		fields := strings.Fields(lines.Text())
+		if len(fields) < 5 {
+			continue
+		}
+		protocol := strings.TrimRight(fields[0], "46")
+		if protocol != "tcp" && protocol != "udp" {
+			continue
+		}
+
+		localIP, localPort, ok1 := splitNetstatAddress(fields[3])
+		remoteIP, remotePort, ok2 := splitNetstatAddress(fields[4])
+		if !ok1 || !ok2 {
+			continue
+		}
+
+		socket := Socket{
+			Protocol:   protocol,
+			LocalIP:    localIP,
+			LocalPort:  localPort,
+			RemoteIP:   remoteIP,
+			RemotePort: remotePort,
+			UID:        -1,
+		}
+		if protocol == "tcp" && len(fields) >= 6 {
+			socket.State = strings.ReplaceAll(fields[5], "FIN_WAIT_", "FIN_WAIT")
+		}
+		sockets = append(sockets, socket)
+	}
+	return sockets
+}
+
+// splitNetstatAddress splits an address at its last '.' or ':', whichever
+// separates the port
+func splitNetstatAddress(s string) (net.IP, int, bool) {
+	sep := max(strings.LastIndex(s, "."), strings.LastIndex(s, ":"))
+	if sep <= 0 {
+		return nil, 0, false
+	}
+	host, portText := s[:sep], s[sep+1:]
+	if i := strings.Index(host, "%"); i >= 0 {
+		host = host[:i] // Drop IPv6 zones
+	}
+	if host == "*" {
+		host = "0.0.0.0"
+	}
+
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return nil, 0, false
+	}
+	port, err := strconv.Atoi(portText)
+	if err != nil {
+		port = 0 // "*" for unbound ports
+	}
+	return ip, port, true
+}
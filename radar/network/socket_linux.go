@@ -0,0 +1,187 @@
+//go:build linux
+// +build linux
+
+package network
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"net"
+	"os"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// Sizes of the sock_diag structures, which x/sys/unix does not define
+const (
+	sizeofInetDiagReqV2 = 56
+	sizeofInetDiagMsg   = 72
+)
+
+// procNetTables lists the /proc socket tables and their protocols
+var procNetTables = []struct {
+	path     string
+	protocol string
+}{
+	{"/proc/net/tcp", "tcp"},
+	{"/proc/net/tcp6", "tcp"},
+	{"/proc/net/udp", "udp"},
+	{"/proc/net/udp6", "udp"},
+}
+
+// socketsAvailable checks for the kernel socket tables
+func socketsAvailable() bool {
+	_, err := os.Stat("/proc/net/tcp")
+	return err == nil || netstatAvailable()
+}
+
+// readSockets lists TCP and UDP sockets over sock_diag netlink, falling back
+// to the /proc/net tables and then to netstat
+func readSockets(ctx context.Context) ([]Socket, error) {
+	if sockets, err := readDiagSockets(); err == nil {
+		return sockets, nil
+	}
+	if sockets, err := readProcSockets(); err == nil {
+		return sockets, nil
+	}
+	return readNetstatSockets(ctx)
+}
+
+// readProcSockets parses every /proc/net socket table that exists
+func readProcSockets() ([]Socket, error) {
+	sockets := make([]Socket, 0)
+	found := false
+	for _, table := range procNetTables {
+		file, err := os.Open(table.path)
+		if err != nil {
+			continue // IPv6 may be disabled
+		}
+		parsed, err := parseProcNet(file, table.protocol)
+		file.Close()
+		if err != nil {
+			return nil, err
+		}
+		sockets = append(sockets, parsed...)
+		found = true
+	}
+	if !found {
+		return nil, errors.New("no /proc/net socket tables")
+	}
+	return sockets, nil
+}
+
+// readDiagSockets dumps TCP and UDP sockets of both families with
+// SOCK_DIAG_BY_FAMILY requests
+func readDiagSockets() ([]Socket, error) {
+	fd, err := unix.Socket(unix.AF_NETLINK, unix.SOCK_RAW|unix.SOCK_CLOEXEC, unix.NETLINK_SOCK_DIAG)
+	if err != nil {
+		return nil, err
+	}
+	defer unix.Close(fd)
+	if err := unix.Bind(fd, &unix.SockaddrNetlink{Family: unix.AF_NETLINK}); err != nil {
+		return nil, err
+	}
+
+	sockets := make([]Socket, 0)
+	for _, protocol := range []uint8{unix.IPPROTO_TCP, unix.IPPROTO_UDP} {
+		for _, family := range []uint8{unix.AF_INET, unix.AF_INET6} {
+			dumped, err := diagDump(fd, family, protocol)
+			if err != nil {
+				return nil, err
+			}
+			sockets = append(sockets, dumped...)
+		}
+	}
+	return sockets, nil
+}
+
+// diagDump sends one inet_diag_req_v2 for every socket state and reads the
+// multipart reply
+func diagDump(fd int, family, protocol uint8) ([]Socket, error) {
+	request := make([]byte, unix.NLMSG_HDRLEN+sizeofInetDiagReqV2)
+	binary.NativeEndian.PutUint32(request[0:4], uint32(len(request)))
+	binary.NativeEndian.PutUint16(request[4:6], unix.SOCK_DIAG_BY_FAMILY)
+	binary.NativeEndian.PutUint16(request[6:8], unix.NLM_F_REQUEST|unix.NLM_F_DUMP)
+	binary.NativeEndian.PutUint32(request[8:12], 1)
+	req := request[unix.NLMSG_HDRLEN:]
+	req[0] = family
+	req[1] = protocol
+	binary.NativeEndian.PutUint32(req[4:8], 0xffffffff) // All states
+
+	if err := unix.Sendto(fd, request, 0, &unix.SockaddrNetlink{Family: unix.AF_NETLINK}); err != nil {
+		return nil, err
+	}
+
+	name := "tcp"
+	if protocol == unix.IPPROTO_UDP {
+		name = "udp"
+	}
+
+	sockets := make([]Socket, 0)
+	buf := make([]byte, 32*1024)
+	for {
+		n, _, err := unix.Recvfrom(fd, buf, 0)
+		if err != nil {
+			return nil, err
+		}
+		messages, err := syscall.ParseNetlinkMessage(buf[:n])
+		if err != nil {
+			return nil, err
+		}
+		for _, msg := range messages {
+			switch msg.Header.Type {
+			case unix.NLMSG_DONE:
+				return sockets, nil
+			case unix.NLMSG_ERROR:
+				if len(msg.Data) >= 4 {
+					if errno := int32(binary.NativeEndian.Uint32(msg.Data[0:4])); errno != 0 {
+						return nil, syscall.Errno(-errno)
+					}
+				}
+				return sockets, nil
+			}
+			if socket, ok := parseDiagMessage(msg.Data, name); ok {
+				sockets = append(sockets, socket)
+			}
+		}
+	}
+}
+
+// parseDiagMessage decodes an inet_diag_msg. Ports and addresses are in
+// network order; the rest is host order.
+func parseDiagMessage(data []byte, protocol string) (Socket, bool) {
+	if len(data) < sizeofInetDiagMsg {
+		return Socket{}, false
+	}
+	family := data[0]
+	id := data[4:52]
+
+	addrLen := net.IPv4len
+	if family == unix.AF_INET6 {
+		addrLen = net.IPv6len
+	}
+	localIP := append(net.IP(nil), id[4:4+addrLen]...)
+	remoteIP := append(net.IP(nil), id[20:20+addrLen]...)
+	if v4 := localIP.To4(); v4 != nil {
+		localIP = v4
+	}
+	if v4 := remoteIP.To4(); v4 != nil {
+		remoteIP = v4
+	}
+
+	socket := Socket{
+		Protocol:   protocol,
+		LocalIP:    localIP,
+		LocalPort:  int(binary.BigEndian.Uint16(id[0:2])),
+		RemoteIP:   remoteIP,
+		RemotePort: int(binary.BigEndian.Uint16(id[2:4])),
+		UID:        int(binary.NativeEndian.Uint32(data[64:68])),
+		Inode:      uint64(binary.NativeEndian.Uint32(data[68:72])),
+	}
+	if protocol == "tcp" {
+		socket.State = tcpStates[int(data[1])]
+	}
+	return socket, true
+}
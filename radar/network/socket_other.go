@@ -0,0 +1,16 @@
+//go:build !linux
+// +build !linux
+
+package network
+
+import "context"
+
+// socketsAvailable checks if netstat is available
+func socketsAvailable() bool {
+	return netstatAvailable()
+}
+
+// readSockets lists TCP and UDP sockets from netstat
+func readSockets(ctx context.Context) ([]Socket, error) {
+	return readNetstatSockets(ctx)
+}
@@ -0,0 +1,242 @@
+package network
+
+import (
+	"encoding/binary"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// socketString formats a socket compactly for comparison
+func socketString(s Socket) string {
+	return fmt.Sprintf("%s %s:%d %s:%d %s uid=%d inode=%d", s.Protocol, s.LocalIP, s.LocalPort, s.RemoteIP, s.RemotePort, s.State, s.UID, s.Inode)
+}
+
+// skipBigEndian skips tests whose /proc fixtures were taken on a
+// little-endian machine; the kernel prints addresses in host order
+func skipBigEndian(t *testing.T) {
+	t.Helper()
+	if binary.NativeEndian.Uint16([]byte{1, 0}) != 1 {
+		t.Skip("fixtures are little-endian /proc tables")
+	}
+}
+
+func TestParseProcAddress(t *testing.T) {
+	skipBigEndian(t)
+	tests := []struct {
+		name    string
+		address string
+		ip      string
+		port    int
+		wantErr bool
+	}{
+		{"loopback", "0100007F:0277", "127.0.0.1", 631, false},
+		{"IPv4", "0A0200C0:01BB", "192.0.2.10", 443, false},
+		{"unspecified", "00000000:0000", "0.0.0.0", 0, false},
+		{"IPv6 loopback", "00000000000000000000000001000000:0016", "::1", 22, false},
+		{"IPv6", "B80D0120000000000000000001000000:1F90", "2001:db8::1", 8080, false},
+		{"v4-mapped", "0000000000000000FFFF00000A0200C0:0050", "192.0.2.10", 80, false},
+		{"no port", "0100007F", "", 0, true},
+		{"bad port", "0100007F:XYZ", "", 0, true},
+		{"odd length", "0100007:0050", "", 0, true},
+		{"neither IPv4 nor IPv6", "0100007F00:0050", "", 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ip, port, err := parseProcAddress(tt.address)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("parseProcAddress(%q) = %s:%d, want an error", tt.address, ip, port)
+				}
+				return
+			}
+			if err != nil || ip.String() != tt.ip || port != tt.port {
+				t.Errorf("parseProcAddress(%q) = %s:%d, %v, want %s:%d", tt.address, ip, port, err, tt.ip, tt.port)
+			}
+		})
+	}
+}
+
+func TestParseProcNet(t *testing.T) {
+	skipBigEndian(t)
+	tests := []struct {
+		name     string
+		protocol string
+		table    string
+		want     []string
+	}{
+		{
+			name:     "tcp",
+			protocol: "tcp",
+			table: `  sl  local_address rem_address   st tx_queue rx_queue tr tm->when retrnsmt   uid  timeout inode
+   0: 0100007F:0277 00000000:0000 0A 00000000:00000000 00:00000000 00000000     0        0 21342 1 0000000000000000 100 0 0 10 0
+   1: 0200000A:C822 0A0200C0:01BB 01 00000000:00000000 02:00000A1E 00000000  1000        0 88410 2 0000000000000000 20 4 30 10 -1
+   2: 0200000A:0016 0B0200C0:E1C4 03 00000000:00000000 00:00000000 00000000     0        0 0 1 0000000000000000 100 0 0 10 0
+`,
+			want: []string{
+				"tcp 127.0.0.1:631 0.0.0.0:0 LISTEN uid=0 inode=21342",
+				"tcp 10.0.0.2:51234 192.0.2.10:443 ESTABLISHED uid=1000 inode=88410",
+				"tcp 10.0.0.2:22 192.0.2.11:57796 SYN_RECV uid=0 inode=0",
+			},
+		},
+		{
+			name:     "tcp6 with IPv6 and v4-mapped peers",
+			protocol: "tcp",
+			table: `  sl  local_address                         remote_address                        st tx_queue rx_queue tr tm->when retrnsmt   uid  timeout inode
+   0: 00000000000000000000000000000000:0016 00000000000000000000000000000000:0000 0A 00000000:00000000 00:00000000 00000000     0        0 19876 1 0000000000000000 100 0 0 10 0
+   1: B80D0120000000000000000002000000:9C40 B80D0120000000000000000001000000:1F90 01 00000000:00000000 00:00000000 00000000  1000        0 90211 1 0000000000000000 20 4 29 10 -1
+   2: 0000000000000000FFFF00000200000A:0050 0000000000000000FFFF00000A0200C0:D431 06 00000000:00000000 03:00000F3C 00000000     0        0 0 3 0000000000000000
+`,
+			want: []string{
+				"tcp :::22 :::0 LISTEN uid=0 inode=19876",
+				"tcp 2001:db8::2:40000 2001:db8::1:8080 ESTABLISHED uid=1000 inode=90211",
+				"tcp 10.0.0.2:80 192.0.2.10:54321 TIME_WAIT uid=0 inode=0",
+			},
+		},
+		{
+			name:     "udp has no state",
+			protocol: "udp",
+			table: `   sl  local_address rem_address   st tx_queue rx_queue tr tm->when retrnsmt   uid  timeout inode ref pointer drops
+  412: 0200000A:0044 0100000A:0043 01 00000000:00000000 00:00000000 00000000     0        0 30112 2 0000000000000000 0
+`,
+			want: []string{"udp 10.0.0.2:68 10.0.0.1:67  uid=0 inode=30112"},
+		},
+		{
+			name:     "short lines are skipped",
+			protocol: "tcp",
+			table:    "   0: 0100007F:0277 00000000:0000 0A\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sockets, err := parseProcNet(strings.NewReader(tt.table), tt.protocol)
+			if err != nil {
+				t.Fatalf("parseProcNet: %v", err)
+			}
+			got := make([]string, len(sockets))
+			for i, s := range sockets {
+				got[i] = socketString(s)
+			}
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("sockets:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+			}
+		})
+	}
+}
+
+func TestParseProcNetBadAddress(t *testing.T) {
+	table := "   0: 0100007F:0277 0000ZZ00:0000 0A 00000000:00000000 00:00000000 00000000     0        0 21342\n"
+	if _, err := parseProcNet(strings.NewReader(table), "tcp"); err == nil {
+		t.Error("parseProcNet accepted a malformed address")
+	}
+}
+
+func TestParseNetstat(t *testing.T) {
+	tests := []struct {
+		name   string
+		output string
+		want   []string
+	}{
+		{
+			name: "Linux",
+			output: `Active Internet connections (servers and established)
+Proto Recv-Q Send-Q Local Address           Foreign Address         State
+tcp        0      0 0.0.0.0:22              0.0.0.0:*               LISTEN
+tcp        0      0 10.0.0.2:51234          192.0.2.10:443          ESTABLISHED
+tcp6       0      0 :::631                  :::*                    LISTEN
+tcp6       0      0 2001:db8::2:40000       2001:db8::1:8080        FIN_WAIT2
+udp        0      0 10.0.0.2:68             10.0.0.1:67             ESTABLISHED
+Active UNIX domain sockets (servers and established)
+Proto RefCnt Flags       Type       State         I-Node   Path
+unix  2      [ ACC ]     STREAM     LISTENING     21343    /run/cups/cups.sock
+`,
+			want: []string{
+				"tcp 0.0.0.0:22 0.0.0.0:0 LISTEN uid=-1 inode=0",
+				"tcp 10.0.0.2:51234 192.0.2.10:443 ESTABLISHED uid=-1 inode=0",
+				"tcp :::631 :::0 LISTEN uid=-1 inode=0",
+				"tcp 2001:db8::2:40000 2001:db8::1:8080 FIN_WAIT2 uid=-1 inode=0",
+				"udp 10.0.0.2:68 10.0.0.1:67  uid=-1 inode=0",
+			},
+		},
+		{
+			name: "BSD",
+			output: `Active Internet connections (including servers)
+Proto Recv-Q Send-Q  Local Address          Foreign Address        (state)
+tcp4       0      0  10.0.0.2.51234         192.0.2.10.443         ESTABLISHED
+tcp4       0      0  10.0.0.2.50000         192.0.2.20.80          FIN_WAIT_2
+tcp6       0      0  fe80::1%lo0.631        *.*                    LISTEN
+tcp6       0      0  2001:db8::2.40000      2001:db8::1.8080       SYN_SENT
+tcp4       0      0  *.22                   *.*                    LISTEN
+udp4       0      0  *.5353                 *.*
+`,
+			want: []string{
+				"tcp 10.0.0.2:51234 192.0.2.10:443 ESTABLISHED uid=-1 inode=0",
+				"tcp 10.0.0.2:50000 192.0.2.20:80 FIN_WAIT2 uid=-1 inode=0",
+				"tcp fe80::1:631 0.0.0.0:0 LISTEN uid=-1 inode=0",
+				"tcp 2001:db8::2:40000 2001:db8::1:8080 SYN_SENT uid=-1 inode=0",
+				"tcp 0.0.0.0:22 0.0.0.0:0 LISTEN uid=-1 inode=0",
+				"udp 0.0.0.0:5353 0.0.0.0:0  uid=-1 inode=0",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sockets := parseNetstat(strings.NewReader(tt.output))
+			got := make([]string, len(sockets))
+			for i, s := range sockets {
+				got[i] = socketString(s)
+			}
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("sockets:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+			}
+		})
+	}
+}
+
+func TestSocketEndpoint(t *testing.T) {
+	tests := []struct {
+		name        string
+		local       int
+		remote      int
+		state       string
+		wantPort    int
+		wantIn      bool
+		wantService string
+	}{
+		{"HTTP", 51234, 80, TCPEstablished, 80, false, "HTTP"},
+		{"8080 is not 80", 51234, 8080, TCPEstablished, 8080, false, "HTTP"},
+		{"HTTPS", 51234, 443, TCPEstablished, 443, false, "HTTPS"},
+		{"4430 is not HTTPS", 51234, 4430, TCPEstablished, 4430, false, "Other"},
+		{"8443 is HTTPS", 51234, 8443, TCPEstablished, 8443, false, "HTTPS"},
+		{"inbound to a known service", 443, 50123, TCPEstablished, 443, true, "HTTPS"},
+		{"inbound from an ephemeral port", 4430, 50123, TCPEstablished, 4430, true, "Other"},
+		{"outbound from a low port", 4430, 8081, TCPEstablished, 8081, false, "Other"},
+		{"half-open inbound", 9000, 1234, TCPSynRecv, 9000, true, "Other"},
+		{"known remote wins over known local", 8080, 80, TCPEstablished, 80, false, "HTTP"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := Socket{Protocol: "tcp", LocalPort: tt.local, RemoteIP: []byte{192, 0, 2, 10}, RemotePort: tt.remote, State: tt.state}
+			ep := socketEndpoint(s, Process{PID: 42})
+			if ep.port != tt.wantPort || ep.inbound != tt.wantIn {
+				t.Errorf("endpoint port %d inbound %v, want port %d inbound %v", ep.port, ep.inbound, tt.wantPort, tt.wantIn)
+			}
+			svc, ok := services[ep.port]
+			if !ok {
+				svc = otherService
+			}
+			if svc.name != tt.wantService {
+				t.Errorf("service = %s, want %s", svc.name, tt.wantService)
+			}
+		})
+	}
+
+	inbound := socketEndpoint(Socket{Protocol: "tcp", LocalPort: 22, RemoteIP: []byte{192, 0, 2, 10}, RemotePort: 50123, State: TCPEstablished}, Process{PID: 42})
+	if got, want := inbound.key(), "conn:tcp:in:192.0.2.10:22@42"; got != want {
+		t.Errorf("inbound key = %s, want %s", got, want)
+	}
+	outbound := socketEndpoint(Socket{Protocol: "udp", LocalPort: 50123, RemoteIP: []byte{192, 0, 2, 53}, RemotePort: 53}, Process{})
+	if got, want := outbound.key(), "conn:udp:192.0.2.53:53"; got != want {
+		t.Errorf("outbound key = %s, want %s", got, want)
+	}
+}
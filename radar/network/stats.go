@@ -0,0 +1,173 @@
+package network
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"io"
+	"math"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// InterfaceStats holds the cumulative counters of a network interface
+type InterfaceStats struct {
+	Name      string
+	RxBytes   uint64
+	TxBytes   uint64
+	RxPackets uint64
+	TxPackets uint64
+	RxErrors  uint64
+	TxErrors  uint64
+	RxDropped uint64
+	TxDropped uint64
+	Speed     int // Link speed in Mbit/s; 0 if unknown
+	Up        bool
+	Loopback  bool
+	Wireless  bool
+}
+
+// interfaceSample is the counters of an interface at one point in time
+type interfaceSample struct {
+	stats InterfaceStats
+	at    time.Time
+}
+
+// interfaceRates is the activity of an interface between two samples
+type interfaceRates struct {
+	RxBytes   float64 // Per second
+	TxBytes   float64
+	RxPackets float64
+	TxPackets float64
+	Errors    uint64 // Since the previous sample
+	Drops     uint64
+}
+
+// defaultLinkSpeed is assumed for links that do not report a speed, such as
+// most wireless and virtual interfaces, in Mbit/s
+const defaultLinkSpeed = 100
+
+// computeRates returns the rates between two samples. A counter that went
+// backwards, such as after a driver reload, counts as no activity.
+func computeRates(previous, current interfaceSample) interfaceRates {
+	seconds := current.at.Sub(previous.at).Seconds()
+	if seconds <= 0 {
+		return interfaceRates{}
+	}
+	delta := func(before, after uint64) uint64 {
+		if after < before {
+			return 0
+		}
+		return after - before
+	}
+
+	p, c := previous.stats, current.stats
+	return interfaceRates{
+		RxBytes:   float64(delta(p.RxBytes, c.RxBytes)) / seconds,
+		TxBytes:   float64(delta(p.TxBytes, c.TxBytes)) / seconds,
+		RxPackets: float64(delta(p.RxPackets, c.RxPackets)) / seconds,
+		TxPackets: float64(delta(p.TxPackets, c.TxPackets)) / seconds,
+		Errors:    delta(p.RxErrors+p.TxErrors, c.RxErrors+c.TxErrors),
+		Drops:     delta(p.RxDropped+p.TxDropped, c.RxDropped+c.TxDropped),
+	}
+}
+
+// utilizationStrength maps throughput relative to link speed to a strength
+// on a log scale, so light traffic still registers: 0.1% of the link is 10,
+// 1% is 33, 10% is 67 and a saturated link is 100
+func utilizationStrength(bytesPerSecond float64, speed int) int {
+	if speed <= 0 {
+		speed = defaultLinkSpeed
+	}
+	utilization := math.Min(1, bytesPerSecond*8/(float64(speed)*1e6))
+	return int(math.Round(100 * math.Log10(1+utilization*999) / 3))
+}
+
+// readNetstatInterfaces reads interface counters from netstat, with byte
+// counts where the platform's netstat reports them
+func readNetstatInterfaces(ctx context.Context) ([]InterfaceStats, error) {
+	args := []string{"-ibn"}
+	if runtime.GOOS == "linux" {
+		args = []string{"-i"}
+	}
+	output, err := exec.CommandContext(ctx, "netstat", args...).Output()
+	if err != nil {
+		return nil, err
+	}
+	return parseNetstatInterfaces(bytes.NewReader(output)), nil
+}
+
+// parseNetstatInterfaces parses `netstat -i` tables by their column names:
+// the Linux form (Iface, RX-OK, RX-ERR, RX-DRP, TX-OK, ...) and the BSD form
+// of `netstat -ibn` (Name, Ipkts, Ierrs, Ibytes, Opkts, ...). BSD lists an
+// interface once per address; only its first row is used.
+func parseNetstatInterfaces(r io.Reader) []InterfaceStats {
+	stats := make([]InterfaceStats, 0)
+	seen := make(map[string]bool)
+	var columns map[string]int
+	var width int
+
+	lines := bufio.NewScanner(r)
+	for lines.Scan() {
+		fields := strings.Fields(lines.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		if fields[0] == "Iface" || fields[0] == "Name" {
+			columns = make(map[string]int, len(fields))
+			for i, name := range fields {
+				columns[name] = i
+			}
+			width = len(fields)
+			continue
+		}
+		if columns == nil {
+			continue
+		}
+		// BSD leaves the address empty for interfaces without one
+		if i, ok := columns["Address"]; ok && len(fields) == width-1 {
+			fields = append(fields[:i], append([]string{""}, fields[i:]...)...)
+		}
+		if len(fields) != width {
+			continue
+		}
+
+		name := strings.TrimSuffix(fields[0], "*")
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+
+		counter := func(names ...string) uint64 {
+			for _, column := range names {
+				if i, ok := columns[column]; ok {
+					n, _ := strconv.ParseUint(fields[i], 10, 64)
+					return n
+				}
+			}
+			return 0
+		}
+		flags := ""
+		if i, ok := columns["Flg"]; ok {
+			flags = fields[i]
+		}
+
+		stats = append(stats, InterfaceStats{
+			Name:      name,
+			RxBytes:   counter("Ibytes"),
+			TxBytes:   counter("Obytes"),
+			RxPackets: counter("RX-OK", "Ipkts"),
+			TxPackets: counter("TX-OK", "Opkts"),
+			RxErrors:  counter("RX-ERR", "Ierrs"),
+			TxErrors:  counter("TX-ERR", "Oerrs"),
+			RxDropped: counter("RX-DRP", "Idrop"),
+			TxDropped: counter("TX-DRP", "Drop"),
+			Up:        !strings.HasSuffix(fields[0], "*") && (flags == "" || strings.Contains(flags, "U")),
+			Loopback:  strings.HasPrefix(name, "lo") || strings.Contains(flags, "L"),
+		})
+	}
+	return stats
+}
@@ -0,0 +1,68 @@
+//go:build linux
+// +build linux
+
+package network
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"golang.org/x/sys/unix"
+)
+
+// sysClassNet is where the kernel lists network interfaces
+const sysClassNet = "/sys/class/net"
+
+// readInterfaceStats reads the counters of every interface from
+// /sys/class/net, falling back to netstat
+func readInterfaceStats(ctx context.Context) ([]InterfaceStats, error) {
+	entries, err := os.ReadDir(sysClassNet)
+	if err != nil {
+		return readNetstatInterfaces(ctx)
+	}
+
+	stats := make([]InterfaceStats, 0, len(entries))
+	for _, entry := range entries {
+		dir := filepath.Join(sysClassNet, entry.Name())
+		counter := func(name string) uint64 {
+			n, _ := strconv.ParseUint(readSysFile(filepath.Join(dir, "statistics", name)), 10, 64)
+			return n
+		}
+
+		// speed is -1 or unreadable while the link is down or unknown
+		speed, _ := strconv.Atoi(readSysFile(filepath.Join(dir, "speed")))
+		flags, _ := strconv.ParseUint(strings.TrimPrefix(readSysFile(filepath.Join(dir, "flags")), "0x"), 16, 32)
+		operstate := readSysFile(filepath.Join(dir, "operstate"))
+		_, wirelessErr := os.Stat(filepath.Join(dir, "wireless"))
+
+		stats = append(stats, InterfaceStats{
+			Name:      entry.Name(),
+			RxBytes:   counter("rx_bytes"),
+			TxBytes:   counter("tx_bytes"),
+			RxPackets: counter("rx_packets"),
+			TxPackets: counter("tx_packets"),
+			RxErrors:  counter("rx_errors"),
+			TxErrors:  counter("tx_errors"),
+			RxDropped: counter("rx_dropped"),
+			TxDropped: counter("tx_dropped"),
+			Speed:     max(0, speed),
+			Up:        operstate == "up" || (operstate == "unknown" && flags&unix.IFF_UP != 0),
+			Loopback:  flags&unix.IFF_LOOPBACK != 0,
+			Wireless:  wirelessErr == nil,
+		})
+	}
+	return stats, nil
+}
+
+// readSysFile returns the trimmed contents of a sysfs attribute, or "" if it
+// cannot be read
+func readSysFile(path string) string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
@@ -0,0 +1,11 @@
+//go:build !linux
+// +build !linux
+
+package network
+
+import "context"
+
+// readInterfaceStats reads the counters of every interface from netstat
+func readInterfaceStats(ctx context.Context) ([]InterfaceStats, error) {
+	return readNetstatInterfaces(ctx)
+}
@@ -2,44 +2,95 @@ package radar
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"time"
 
-	"github.com/e6a5/radar/radar/network"
+	"github.com/e6a5/radar/radar/estimation"
+	"github.com/e6a5/radar/radar/model"
 	"github.com/e6a5/radar/radar/scanner"
-	"github.com/gdamore/tcell/v2"
 )
 
 // RealDataCollector coordinates multiple focused scanners
 type RealDataCollector struct {
 	coordinator *scanner.Coordinator
 	config      *Config
+	bearings    *scanner.BearingAssigner
+	fallback    bool  // Show placeholder signals when scanners report nothing
+	err         error // Why a configured setting was replaced by its fallback
 }
 
 // NewRealDataCollector creates a new real data collector using modular scanners
 func NewRealDataCollector(config *Config) *RealDataCollector {
-	// Convert radar config to scanner config
-	scannerConfig := &scanner.Config{
-		ScanInterval:  time.Duration(config.ScanInterval * float64(time.Second)),
-		MaxSignals:    config.MaxSignals,
-		MaxScanRange:  config.MaxScanRange,
-		UseRealData:   config.EnableRealData,
-		EnableConsent: true,
-	}
-
+	scannerConfig, configErr := newScannerConfig(config)
 	coordinator := scanner.NewCoordinator(scannerConfig)
 
-	// Add WiFi scanner (platform-specific implementation will be selected at compile time)
-	if wifiScanner := createWiFiScanner(scannerConfig); wifiScanner != nil {
-		coordinator.AddScanner(wifiScanner)
+	// Add every registered scanner; unavailable ones are skipped by the coordinator
+	scanners, err := NewScanners(config.Scanners, scannerConfig)
+	if err != nil {
+		scanners, _ = NewScanners(nil, scannerConfig)
+	}
+	for _, s := range scanners {
+		coordinator.AddScanner(s)
+	}
+
+	return &RealDataCollector{
+		coordinator: coordinator,
+		config:      config,
+		bearings:    scannerConfig.Bearings,
+		fallback:    true,
+		err:         configErr,
 	}
+}
+
+// NewReplayCollector creates a collector fed only by a recorded session player
+func NewReplayCollector(config *Config, player scanner.Scanner) *RealDataCollector {
+	scannerConfig, _ := newScannerConfig(config)
+	// Poll the player often so playback looks continuous at any speed
+	scannerConfig.ScanInterval = 500 * time.Millisecond
+	scannerConfig.TrackTimeout = 0
 
-	// Add network interface scanner (cross-platform)
-	coordinator.AddScanner(network.NewInterfaceScanner(scannerConfig))
+	coordinator := scanner.NewCoordinator(scannerConfig)
+	coordinator.AddScanner(player)
 
 	return &RealDataCollector{
 		coordinator: coordinator,
 		config:      config,
+		bearings:    scannerConfig.Bearings,
+	}
+}
+
+// newScannerConfig converts radar config to scanner config. The config is
+// usable even with an error, which reports a setting that fell back to its
+// default.
+func newScannerConfig(config *Config) (*scanner.Config, error) {
+	bearings, err := newBearingAssigner(config)
+	return &scanner.Config{
+		ScanInterval:  time.Duration(config.ScanInterval * float64(time.Second)),
+		MaxSignals:    config.MaxSignals,
+		MaxScanRange:  config.MaxScanRange,
+		DisplayRange:  config.DisplayRange,
+		UseRealData:   config.EnableRealData,
+		EnableConsent: true,
+		Bearings:      bearings,
+		TrackTimeout:  config.TrackTimeout,
+		ActiveSweep:   config.ActiveSweep,
+		Estimator: estimation.NewEstimator(estimation.Calibration{
+			Environment:    config.Environment,
+			Exponent:       config.PathLossExponent,
+			ReferencePower: config.ReferencePower,
+		}),
+	}, err
+}
+
+// newBearingAssigner creates the bearing assigner selected in config, falling
+// back to hash-based bearings if the configured strategy cannot be loaded
+func newBearingAssigner(config *Config) (*scanner.BearingAssigner, error) {
+	strategy, err := scanner.NewBearingStrategy(config.BearingMode, config.BearingPinFile)
+	if err != nil {
+		return scanner.NewBearingAssigner(scanner.HashBearing{}), fmt.Errorf("%w; using hash bearings", err)
 	}
+	return scanner.NewBearingAssigner(strategy), nil
 }
 
 // CollectRealSignals gathers signals from all available scanners
@@ -48,40 +99,41 @@ func (rdc *RealDataCollector) CollectRealSignals() []Signal {
 
 	// Get signals from coordinator
 	scannerSignals, err := rdc.coordinator.Scan(ctx)
-	if err != nil || len(scannerSignals) == 0 {
+	if (err != nil || len(scannerSignals) == 0) && rdc.fallback {
 		// Return basic fallback signals if real scanning fails
 		return rdc.generateBasicSignals()
 	}
 
-	// Convert scanner.Signal to radar.Signal
-	signals := make([]Signal, len(scannerSignals))
-	for i, s := range scannerSignals {
-		// Handle color conversion safely
-		var color tcell.Color
-		if c, ok := s.Color.(tcell.Color); ok {
-			color = c
-		} else {
-			color = tcell.ColorWhite // default
-		}
+	return scannerSignals
+}
 
-		signals[i] = Signal{
-			Type:        s.Type,
-			Icon:        s.Icon,
-			Name:        s.Name,
-			Color:       color,
-			Strength:    s.Strength,
-			Distance:    s.Distance,
-			Angle:       s.Angle,
-			Phase:       s.Phase,
-			Lifetime:    s.Lifetime,
-			LastSeen:    s.LastSeen,
-			Persistence: s.Persistence,
-			History:     convertHistory(s.History),
-			MaxHistory:  s.MaxHistory,
-		}
+// Err returns why a configured setting could not be used, if any. The
+// collector still runs, with that setting's fallback.
+func (rdc *RealDataCollector) Err() error {
+	return rdc.err
+}
+
+// PinBearing fixes the bearing of a real signal, persisting it in pinned
+// bearing mode. It reports whether the pin outlives the process.
+func (rdc *RealDataCollector) PinBearing(s *Signal, angle float64) (persistent bool, err error) {
+	if s.ID == "" {
+		return false, errors.New("simulated signals cannot be pinned")
+	}
+	if err := rdc.bearings.Pin(s.Type, s.ID, angle); err != nil {
+		return false, err
 	}
+	return rdc.bearings.Persistent(), nil
+}
 
-	return signals
+// AddObserver registers an observer for every scan result
+func (rdc *RealDataCollector) AddObserver(observer scanner.Observer) {
+	rdc.coordinator.AddObserver(observer)
+}
+
+// Close stops the collector's scanners and releases their sockets and
+// connections
+func (rdc *RealDataCollector) Close() error {
+	return rdc.coordinator.Close()
 }
 
 // GetAvailableScanners returns the names of available scanners
@@ -89,20 +141,6 @@ func (rdc *RealDataCollector) GetAvailableScanners() []string {
 	return rdc.coordinator.GetScanners()
 }
 
-// convertHistory converts scanner position history to radar position history
-func convertHistory(scannerHistory []scanner.PositionHistory) []PositionHistory {
-	history := make([]PositionHistory, len(scannerHistory))
-	for i, h := range scannerHistory {
-		history[i] = PositionHistory{
-			Distance:  h.Distance,
-			Angle:     h.Angle,
-			Strength:  h.Strength,
-			Timestamp: h.Timestamp,
-		}
-	}
-	return history
-}
-
 // generateBasicSignals creates fallback signals when real scanning fails
 func (rdc *RealDataCollector) generateBasicSignals() []Signal {
 	signals := make([]Signal, 0)
@@ -113,20 +151,21 @@ func (rdc *RealDataCollector) generateBasicSignals() []Signal {
 		name       string
 		icon       string
 		signalType string
-		color      tcell.Color
+		category   model.Category
 	}{
-		{"WiFi-Network", "≋", "WiFi", tcell.ColorBlue},
-		{"Network-Activity", "▲", "Network", tcell.ColorGreen},
+		{"WiFi-Network", "≋", "WiFi", model.CategoryWiFi},
+		{"Network-Activity", "▲", "Network", model.CategoryNetwork},
 	}
 
 	for i, basic := range basicSignals {
 		signal := Signal{
+			ID:          "fallback:" + basic.name,
 			Type:        basic.signalType,
 			Icon:        basic.icon,
 			Name:        basic.name,
-			Color:       basic.color,
+			Category:    basic.category,
 			Strength:    50 + i*10,
-			Distance:    float64(i + 2),
+			Distance:    float64(i+2) * simulatedScale,
 			Angle:       float64(i) * 1.57, // 90 degrees apart
 			Phase:       0,
 			Lifetime:    now,
@@ -136,7 +175,7 @@ func (rdc *RealDataCollector) generateBasicSignals() []Signal {
 			MaxHistory:  20,
 		}
 
-		signal.addToHistory(signal.Distance, signal.Angle, signal.Strength, false, now)
+		signal.AddToHistory(signal.Distance, signal.Angle, signal.Strength, false, now)
 		signals = append(signals, signal)
 	}
 
@@ -6,6 +6,8 @@ import (
 	"strings"
 	"time"
 
+	"github.com/e6a5/radar/radar/model"
+	"github.com/e6a5/radar/radar/tracker"
 	"github.com/gdamore/tcell/v2"
 )
 
@@ -47,6 +49,10 @@ func (rd *Display) Render(screen tcell.Screen) {
 	// }
 
 	screen.Show()
+	if rd.bellPending {
+		screen.Beep()
+		rd.bellPending = false
+	}
 
 	// Temporarily disable performance monitoring for debugging
 	// rd.performanceMonitor.EndFrame()
@@ -85,31 +91,55 @@ func (rd *Display) drawRangeRings(screen tcell.Screen) {
 		rd.drawCircle(screen, rd.centerX, rd.centerY, radius, ringChar, ringColor)
 
 		// Add range labels with better positioning
-		if ring*10 <= 40 {
-			label := fmt.Sprintf("%dm", ring*10)
-			labelX := rd.centerX + int(radius) - len(label)/2
-			labelY := rd.centerY - 1
-
-			// Try multiple label positions for better visibility
-			if labelX > 0 && labelX < rd.width-len(label) && labelY > 2 {
+		label := rangeLabel(rd.scopeRange() * float64(ring) / 4)
+		labelX := rd.centerX + int(radius) - len(label)/2
+		labelY := rd.centerY - 1
+
+		// Try multiple label positions for better visibility
+		if labelX > 0 && labelX < rd.width-len(label) && labelY > 2 {
+			for i, r := range label {
+				screen.SetContent(labelX+i, labelY, r, nil,
+					tcell.StyleDefault.Foreground(tcell.ColorGreen).Bold(true))
+			}
+		} else {
+			// Alternative position: bottom of ring
+			labelY = rd.centerY + int(radius*0.5) + 1
+			if labelY < rd.height-3 && labelX > 0 && labelX < rd.width-len(label) {
 				for i, r := range label {
 					screen.SetContent(labelX+i, labelY, r, nil,
 						tcell.StyleDefault.Foreground(tcell.ColorGreen).Bold(true))
 				}
-			} else {
-				// Alternative position: bottom of ring
-				labelY = rd.centerY + int(radius*0.5) + 1
-				if labelY < rd.height-3 && labelX > 0 && labelX < rd.width-len(label) {
-					for i, r := range label {
-						screen.SetContent(labelX+i, labelY, r, nil,
-							tcell.StyleDefault.Foreground(tcell.ColorGreen).Bold(true))
-					}
-				}
 			}
 		}
 	}
 }
 
+// scopeRange returns the distance at the scope's edge in meters: the display
+// range, narrowed by the zoom level when zoom is enabled
+func (rd *Display) scopeRange() float64 {
+	if rd.config.EnableZoom && rd.config.ZoomLevel > 0 {
+		return rd.config.DisplayRange / rd.config.ZoomLevel
+	}
+	return rd.config.DisplayRange
+}
+
+// scopeScale returns the screen columns per meter on a scope of maxRadius
+func (rd *Display) scopeScale(maxRadius float64) float64 {
+	return maxRadius / rd.scopeRange()
+}
+
+// rangeLabel formats the distance of a range ring
+func rangeLabel(meters float64) string {
+	switch {
+	case meters >= 1000:
+		return fmt.Sprintf("%gkm", math.Round(meters/100)/10)
+	case meters >= 10:
+		return fmt.Sprintf("%.0fm", meters)
+	default:
+		return fmt.Sprintf("%gm", math.Round(meters*10)/10)
+	}
+}
+
 func (rd *Display) drawCircle(screen tcell.Screen, centerX, centerY int, radius float64, char rune, color tcell.Color) {
 	// Use smaller angle increment for smoother, more visible circles
 	for angle := 0.0; angle < 2*math.Pi; angle += 0.05 {
@@ -184,9 +214,9 @@ func (rd *Display) hasSignalNear(x, y, radius int) bool {
 			continue
 		}
 
-		phaseOffset := float64(s.Phase) * 0.3
+		phaseOffset := float64(s.Phase) * 0.03 * rd.scopeRange()
 		distance := s.Distance + phaseOffset
-		scaleFactor := maxRadiusDisplay / 10.0
+		scaleFactor := rd.scopeScale(maxRadiusDisplay)
 
 		signalX := rd.centerX + int(math.Round(math.Cos(s.Angle)*distance*scaleFactor))
 		signalY := rd.centerY + int(math.Round(math.Sin(s.Angle)*distance*scaleFactor*0.5))
@@ -231,6 +261,15 @@ func (rd *Display) drawSignals(screen tcell.Screen) {
 		rd.drawSignalTrails(screen, maxRadius)
 	}
 
+	// Guard range and motion vectors go under the blips
+	rd.drawGuardRange(screen, rd.scopeScale(maxRadius))
+	rd.drawZones(screen, rd.scopeScale(maxRadius))
+	for i := range rd.signals {
+		if s := &rd.signals[i]; s.IsVisible() && rd.isSignalVisible(*s) {
+			rd.drawMotionVector(screen, s, rd.scopeScale(maxRadius))
+		}
+	}
+
 	// Then draw all visible signals (including persistent ones)
 	for i, s := range rd.signals {
 		if !s.IsVisible() {
@@ -242,11 +281,11 @@ func (rd *Display) drawSignals(screen tcell.Screen) {
 			continue // Skip filtered out signals
 		}
 
-		phaseOffset := float64(s.Phase) * 0.3
+		phaseOffset := float64(s.Phase) * 0.03 * rd.scopeRange()
 		distance := s.Distance + phaseOffset
 
 		// Scale distance based on terminal size
-		scaleFactor := maxRadius / 10.0
+		scaleFactor := rd.scopeScale(maxRadius)
 		dx := int(math.Round(math.Cos(s.Angle) * distance * scaleFactor))
 		dy := int(math.Round(math.Sin(s.Angle) * distance * scaleFactor * 0.5))
 		x := rd.centerX + dx
@@ -254,14 +293,14 @@ func (rd *Display) drawSignals(screen tcell.Screen) {
 
 		if x >= 0 && x < rd.width && y >= 3 && y < rd.height-3 {
 			// Use enhanced signal color that combines type, strength and persistence
-			color := s.GetEnhancedColor()
+			color := rd.signalColor(&s)
 
 			// Use the signal's predefined icon
 			icon := []rune(s.Icon)[0]
 
 			// Create base style with persistence-based styling
 			baseStyle := tcell.StyleDefault.Foreground(color)
-			style := s.GetVisualStyle(baseStyle)
+			style := signalStyle(&s, baseStyle)
 
 			// Check if this signal is selected
 			isSelected := (i == rd.selectedSignalIndex)
@@ -279,7 +318,8 @@ func (rd *Display) drawSignals(screen tcell.Screen) {
 
 			// Highlight selected signal
 			if isSelected {
-				// Draw selection indicator around signal
+				// Show how far off the distance estimate may be, then the selection box
+				rd.drawRangeBand(screen, s, scaleFactor)
 				rd.drawSelectionIndicator(screen, x, y)
 				// Make selected signal more prominent
 				style = style.Bold(true).Background(tcell.ColorDarkBlue)
@@ -299,12 +339,86 @@ func (rd *Display) drawSignals(screen tcell.Screen) {
 			}
 		}
 	}
+
+	// Bearing lines and range markers over the blips
+	rd.drawCursors(screen, rd.scopeScale(maxRadius))
+
+	// Finally overlay track numbers and predictions
+	if rd.config.ShowTracks {
+		rd.drawTracks(screen, rd.scopeScale(maxRadius))
+	}
+}
+
+// drawTracks labels confirmed and coasting tracks with their numbers, marks
+// where each moving track is predicted to be on the next scan, and keeps
+// coasting tracks on the scope at their extrapolated position
+func (rd *Display) drawTracks(screen tcell.Screen, scaleFactor float64) {
+	now := time.Now()
+	lead := now.Add(rd.scanPeriod())
+
+	toScreen := func(distance, angle float64) (int, int) {
+		x := rd.centerX + int(math.Round(math.Cos(angle)*distance*scaleFactor))
+		y := rd.centerY + int(math.Round(math.Sin(angle)*distance*scaleFactor*0.5))
+		return x, y
+	}
+	onScope := func(x, y int) bool {
+		return x >= 0 && x < rd.width && y >= 3 && y < rd.height-3
+	}
+
+	for _, track := range rd.tracker.Tracks() {
+		if track.State == tracker.StateTentative {
+			continue
+		}
+		signal, ok := rd.trackedSignals[track.Number]
+		if ok && !rd.isSignalVisible(signal) {
+			continue
+		}
+
+		color := rd.categoryColor(track.Category, signal.Severity)
+		if track.State == tracker.StateCoasting {
+			color = tcell.ColorGray
+		}
+		style := tcell.StyleDefault.Foreground(color).Dim(true)
+
+		x, y := toScreen(track.Predict(now))
+
+		// Predicted position on the next scan, if it is a cell or more away
+		if px, py := toScreen(track.Predict(lead)); (px != x || py != y) && onScope(px, py) {
+			screen.SetContent(px, py, '+', nil, style)
+		}
+
+		if !onScope(x, y) {
+			continue
+		}
+		if track.State == tracker.StateCoasting {
+			screen.SetContent(x, y, '◌', nil, style)
+		}
+
+		// Track number to the right of the blip
+		label := fmt.Sprintf("%03d", track.Number)
+		for i, r := range label {
+			if lx := x + 2 + i; lx < rd.width {
+				screen.SetContent(lx, y, r, nil, style)
+			}
+		}
+	}
+}
+
+// trackCount returns the number of confirmed and coasting tracks
+func (rd *Display) trackCount() int {
+	count := 0
+	for _, track := range rd.tracker.Tracks() {
+		if track.State != tracker.StateTentative {
+			count++
+		}
+	}
+	return count
 }
 
 // Draw signal trails showing movement history
 func (rd *Display) drawSignalTrails(screen tcell.Screen, maxRadius float64) {
 	now := time.Now()
-	scaleFactor := maxRadius / 10.0
+	scaleFactor := rd.scopeScale(maxRadius)
 
 	for _, s := range rd.signals {
 		// Skip if signal is filtered out or not visible
@@ -347,7 +461,7 @@ func (rd *Display) drawSignalTrails(screen tcell.Screen, maxRadius float64) {
 
 				if pos.WasDetected {
 					// Detected positions use signal type color but faded
-					color = s.Color
+					color = rd.categoryColor(s.Category, s.Severity)
 					char = '•'
 				} else {
 					// Undetected/estimated positions are gray
@@ -436,8 +550,11 @@ func (rd *Display) drawSignalRipples(screen tcell.Screen, centerX, centerY, phas
 
 func (rd *Display) drawUI(screen tcell.Screen) {
 	rd.drawTopPanel(screen)
+	rd.drawCPAAlert(screen)
+	rd.drawNotice(screen)
 	rd.drawBottomPanel(screen)
 	rd.drawSidePanel(screen)
+	rd.drawAlarmBorder(screen)
 }
 
 func (rd *Display) drawTopPanel(screen tcell.Screen) {
@@ -474,19 +591,27 @@ func (rd *Display) drawTopPanel(screen tcell.Screen) {
 		labelStatus = " | LABELS"
 	}
 
+	trackStatus := ""
+	if rd.config.ShowTracks {
+		trackStatus = fmt.Sprintf(" | TRK:%d", rd.trackCount())
+	}
+
 	dataStatus := ""
 	if rd.config.EnableRealData {
 		dataStatus = " | REAL"
 	} else {
 		dataStatus = " | SIM"
 	}
+	if rd.player != nil && rd.config.EnableRealData {
+		dataStatus = " | " + rd.replayStatus()
+	}
 
 	selectionStatus := ""
 	if rd.selectedSignalIndex >= 0 {
 		selectionStatus = fmt.Sprintf(" | SEL:%d", rd.selectedSignalIndex+1)
 	}
 
-	info := fmt.Sprintf("Signals: %d | Speed: %.1fx%s%s%s%s", rd.getVisibleSignalCount(), rd.config.RadarSpeed/(math.Pi/30), trailStatus, labelStatus, dataStatus, selectionStatus)
+	info := fmt.Sprintf("Signals: %d | Speed: %.1fx%s%s%s%s%s", rd.getVisibleSignalCount(), rd.config.RadarSpeed/(math.Pi/30), trailStatus, labelStatus, trackStatus, dataStatus, selectionStatus)
 	startX := rd.width - len(info)
 	if startX > len(title)+2 {
 		for i, r := range info {
@@ -499,6 +624,53 @@ func (rd *Display) drawTopPanel(screen tcell.Screen) {
 	}
 }
 
+// replayStatus describes the replay position, speed and loop state
+func (rd *Display) replayStatus() string {
+	status := fmt.Sprintf("REPLAY %s/%s %gx",
+		formatOffset(rd.player.Position()), formatOffset(rd.player.Duration()), rd.player.Speed())
+	if rd.player.Loop() {
+		status += " LOOP"
+	}
+	return status
+}
+
+// formatOffset formats a replay offset as m:ss, or h:mm:ss past an hour
+func formatOffset(d time.Duration) string {
+	total := int(d.Seconds())
+	if total >= 3600 {
+		return fmt.Sprintf("%d:%02d:%02d", total/3600, total/60%60, total%60)
+	}
+	return fmt.Sprintf("%d:%02d", total/60, total%60)
+}
+
+// setNotice shows a short message in the top panel for a few seconds
+func (rd *Display) setNotice(message string, isError bool) {
+	rd.notice = message
+	rd.noticeIsError = isError
+	rd.noticeUntil = time.Now().Add(5 * time.Second)
+}
+
+// drawNotice draws the current notice over the top panel's lower border
+func (rd *Display) drawNotice(screen tcell.Screen) {
+	if rd.notice == "" || time.Now().After(rd.noticeUntil) {
+		return
+	}
+
+	style := tcell.StyleDefault.Foreground(tcell.ColorBlack).Background(tcell.ColorGreen)
+	if rd.noticeIsError {
+		style = tcell.StyleDefault.Foreground(tcell.ColorWhite).Background(tcell.ColorRed)
+	}
+
+	text := []rune(" " + rd.notice + " ")
+	if len(text) > rd.width-2 {
+		text = text[:max(0, rd.width-2)]
+	}
+	startX := (rd.width - len(text)) / 2
+	for i, r := range text {
+		screen.SetContent(startX+i, 2, r, nil, style)
+	}
+}
+
 func (rd *Display) drawBottomPanel(screen tcell.Screen) {
 	bottomY := rd.height - 3
 
@@ -508,6 +680,23 @@ func (rd *Display) drawBottomPanel(screen tcell.Screen) {
 		screen.SetContent(x, rd.height-1, '═', nil, tcell.StyleDefault.Foreground(tcell.ColorWhite))
 	}
 
+	// Cursor readout replaces the controls while any cursor is shown
+	if len(rd.cursors) > 0 {
+		readout := []rune(rd.cursorReadout())
+		if len(readout) > rd.width-2 {
+			readout = readout[:max(0, rd.width-2)]
+		}
+		startX := (rd.width - len(readout)) / 2
+		for i, r := range readout {
+			color := tcell.ColorYellow
+			if r == '│' {
+				color = tcell.ColorDarkGray
+			}
+			screen.SetContent(startX+i, rd.height-2, r, nil, tcell.StyleDefault.Foreground(color))
+		}
+		return
+	}
+
 	// Controls
 	controls := []string{
 		"ESC/Q:Quit",
@@ -613,6 +802,18 @@ func (rd *Display) drawSidePanel(screen tcell.Screen) {
 		}
 	}
 
+	// Process filter
+	if processY := legendY + 8; rd.filters.Process != "" && processY < rd.height-4 {
+		label := []rune("Process: " + rd.filters.Process)
+		if len(label) > 22 {
+			label = append(label[:21], '…')
+		}
+		screen.SetContent(panelX, processY, 'U', nil, tcell.StyleDefault.Foreground(tcell.ColorWhite).Bold(true))
+		for j, r := range label {
+			screen.SetContent(panelX+2+j, processY, r, nil, tcell.StyleDefault.Foreground(tcell.ColorYellow))
+		}
+	}
+
 	// Signal strength legend
 	strengthY := legendY + 10
 	if strengthY < rd.height-8 {
@@ -661,6 +862,32 @@ func (rd *Display) drawSelectionIndicator(screen tcell.Screen, centerX, centerY
 	}
 }
 
+// drawRangeBand draws arcs at both ends of a signal's distance confidence
+// interval, spanning a few degrees either side of its bearing
+func (rd *Display) drawRangeBand(screen tcell.Screen, s Signal, scaleFactor float64) {
+	if s.DistanceHigh <= s.DistanceLow {
+		return
+	}
+	style := tcell.StyleDefault.Foreground(rd.categoryColor(s.Category, s.Severity)).Dim(true)
+	const spread = 0.2 // Radians either side of the bearing
+
+	for _, distance := range []float64{s.DistanceLow, s.DistanceHigh} {
+		radius := distance * scaleFactor
+		if radius < 1 {
+			continue
+		}
+		// About one cell per step along the arc
+		step := 1 / radius
+		for angle := s.Angle - spread; angle <= s.Angle+spread; angle += step {
+			x := rd.centerX + int(math.Round(math.Cos(angle)*radius))
+			y := rd.centerY + int(math.Round(math.Sin(angle)*radius*0.5))
+			if x >= 0 && x < rd.width && y >= 3 && y < rd.height-3 {
+				screen.SetContent(x, y, '·', nil, style)
+			}
+		}
+	}
+}
+
 // Draw detailed information panel for selected signal
 func (rd *Display) drawInfoPanel(screen tcell.Screen) {
 	signal := rd.getSelectedSignal()
@@ -668,14 +895,59 @@ func (rd *Display) drawInfoPanel(screen tcell.Screen) {
 		return
 	}
 
+	distance := fmt.Sprintf("Distance: %.1fm", signal.Distance)
+	if signal.DistanceHigh > signal.DistanceLow {
+		distance += fmt.Sprintf(" (%.1f-%.1fm)", signal.DistanceLow, signal.DistanceHigh)
+	}
+
+	// Signal details
+	details := []string{
+		fmt.Sprintf("Name:     %s", signal.Name),
+		fmt.Sprintf("Type:     %s %s", signal.Type, signal.Icon),
+		fmt.Sprintf("Strength: %d%% (%s)", signal.Strength, rd.getStrengthLabel(signal.Strength)),
+		distance,
+		fmt.Sprintf("Bearing:  %.0f°", signal.Angle*180/math.Pi),
+		fmt.Sprintf("Age:      %.0fs", time.Since(signal.Lifetime).Seconds()),
+		fmt.Sprintf("Last Seen: %.1fs ago", time.Since(signal.LastSeen).Seconds()),
+		fmt.Sprintf("Persist:  %.0f%%", signal.Persistence*100),
+		"",
+		"HISTORY:",
+		fmt.Sprintf("Positions: %d/%d", len(signal.History), signal.MaxHistory),
+	}
+
+	// Add velocity and closest approach
+	details = append(details, describeMotion(signal)...)
+
+	// Add the track the signal belongs to
+	if track, ok := rd.tracker.Track(signal.Track); ok {
+		details = append(details,
+			"",
+			"TRACK:",
+			fmt.Sprintf("Number:   %03d (%s)", track.Number, track.State),
+			fmt.Sprintf("Hits:     %d of last 8 scans", track.Hits(8)),
+			fmt.Sprintf("Course:   %.0f° at %.2fm/s", track.Course()*180/math.Pi, track.Speed()),
+		)
+	}
+
+	attrs := signal.Attributes.Sorted()
+
 	// Panel dimensions and position
 	panelWidth := 40
-	panelHeight := 15
+	panelHeight := max(15, len(details)+3)
+	if len(attrs) > 0 {
+		// Room for the details, a blank line, the section title and the attributes
+		panelHeight = len(details) + len(attrs) + 6
+	}
 	startX := rd.width - panelWidth - 2
 	startY := 4
 
+	// Shrink to the available space; the attribute section scrolls
+	if maxHeight := rd.height - 3 - startY - 1; panelHeight > maxHeight {
+		panelHeight = maxHeight
+	}
+
 	// Skip if not enough space
-	if startX < 0 || startY+panelHeight >= rd.height-3 {
+	if startX < 0 || panelHeight < 15 {
 		return
 	}
 
@@ -701,45 +973,69 @@ func (rd *Display) drawInfoPanel(screen tcell.Screen) {
 			tcell.StyleDefault.Foreground(tcell.ColorAqua).Bold(true))
 	}
 
-	// Signal details
-	details := []string{
-		fmt.Sprintf("Name:     %s", signal.Name),
-		fmt.Sprintf("Type:     %s %s", signal.Type, signal.Icon),
-		fmt.Sprintf("Strength: %d%% (%s)", signal.Strength, rd.getStrengthLabel(signal.Strength)),
-		fmt.Sprintf("Distance: %.1fm", signal.Distance),
-		fmt.Sprintf("Bearing:  %.0f°", signal.Angle*180/math.Pi),
-		fmt.Sprintf("Age:      %.0fs", time.Since(signal.Lifetime).Seconds()),
-		fmt.Sprintf("Last Seen: %.1fs ago", time.Since(signal.LastSeen).Seconds()),
-		fmt.Sprintf("Persist:  %.0f%%", signal.Persistence*100),
-		"",
-		"HISTORY:",
-		fmt.Sprintf("Positions: %d/%d", len(signal.History), signal.MaxHistory),
-	}
-
-	// Add movement analysis
-	if len(signal.History) >= 2 {
-		recent := signal.History[len(signal.History)-1]
-		older := signal.History[max(0, len(signal.History)-5)]
-		distanceMoved := math.Sqrt(math.Pow(recent.Distance-older.Distance, 2) +
-			math.Pow(recent.Angle-older.Angle, 2))
-		details = append(details, fmt.Sprintf("Movement: %.2f units", distanceMoved))
-	}
-
 	// Draw details
 	for i, detail := range details {
 		if i+2 < panelHeight-1 {
-			y := startY + 2 + i
-			for j, r := range detail {
-				if j+2 < panelWidth-2 {
-					color := tcell.ColorWhite
-					if strings.Contains(detail, "HISTORY:") {
-						color = tcell.ColorYellow
-					}
-					screen.SetContent(startX+2+j, y, r, nil,
-						tcell.StyleDefault.Foreground(color))
-				}
+			color := tcell.ColorWhite
+			if strings.Contains(detail, "HISTORY:") || detail == "TRACK:" {
+				color = tcell.ColorYellow
 			}
+			rd.drawPanelText(screen, startX+2, startY+2+i, panelWidth-4, detail, color)
+		}
+	}
+
+	if len(attrs) > 0 {
+		rd.drawAttributeSection(screen, attrs, startX, startY+len(details)+3, panelWidth, startY+panelHeight-1)
+	}
+}
+
+// drawAttributeSection draws the scrollable key/value list of signal attributes
+// between y and bottomY (exclusive)
+func (rd *Display) drawAttributeSection(screen tcell.Screen, attrs []model.Attribute, startX, y, panelWidth, bottomY int) {
+	if y >= bottomY {
+		return
+	}
+
+	visibleRows := bottomY - y - 1
+	if visibleRows <= 0 {
+		return
+	}
+
+	// Clamp the scroll offset to the attribute list
+	maxScroll := max(0, len(attrs)-visibleRows)
+	rd.infoScroll = max(0, min(rd.infoScroll, maxScroll))
+
+	title := "DETAILS:"
+	if maxScroll > 0 {
+		title = fmt.Sprintf("DETAILS: %d-%d/%d (PgUp/PgDn)", rd.infoScroll+1, rd.infoScroll+min(visibleRows, len(attrs)), len(attrs))
+	}
+	rd.drawPanelText(screen, startX+2, y, panelWidth-4, title, tcell.ColorYellow)
+
+	for row := 0; row < visibleRows && rd.infoScroll+row < len(attrs); row++ {
+		attr := attrs[rd.infoScroll+row]
+		line := fmt.Sprintf("%-10s%s", attr.Label+":", attr.Value)
+		rd.drawPanelText(screen, startX+2, y+1+row, panelWidth-4, line, tcell.ColorWhite)
+	}
+
+	// Scroll indicators on the right edge
+	if rd.infoScroll > 0 {
+		screen.SetContent(startX+panelWidth-2, y+1, '▲', nil,
+			tcell.StyleDefault.Foreground(tcell.ColorAqua).Background(tcell.ColorDarkSlateGray))
+	}
+	if rd.infoScroll < maxScroll {
+		screen.SetContent(startX+panelWidth-2, bottomY-1, '▼', nil,
+			tcell.StyleDefault.Foreground(tcell.ColorAqua).Background(tcell.ColorDarkSlateGray))
+	}
+}
+
+// drawPanelText draws a single line of panel text, truncated to width
+func (rd *Display) drawPanelText(screen tcell.Screen, x, y, width int, text string, color tcell.Color) {
+	for j, r := range []rune(text) {
+		if j >= width {
+			break
 		}
+		screen.SetContent(x+j, y, r, nil,
+			tcell.StyleDefault.Foreground(color))
 	}
 }
 
@@ -825,16 +1121,14 @@ func (rd *Display) drawOptimizedRangeRings(screen tcell.Screen) {
 		}
 
 		// Add range labels (optimized positioning)
-		if ring*10 <= 40 {
-			label := fmt.Sprintf("%dm", ring*10)
-			labelX := rd.centerX + int(radius) - len(label)/2
-			labelY := rd.centerY - 1
-
-			if labelX > 0 && labelX < rd.width-len(label) && labelY > 2 {
-				for i, r := range label {
-					screen.SetContent(labelX+i, labelY, r, nil,
-						tcell.StyleDefault.Foreground(tcell.ColorGreen).Bold(true))
-				}
+		label := rangeLabel(rd.scopeRange() * float64(ring) / 4)
+		labelX := rd.centerX + int(radius) - len(label)/2
+		labelY := rd.centerY - 1
+
+		if labelX > 0 && labelX < rd.width-len(label) && labelY > 2 {
+			for i, r := range label {
+				screen.SetContent(labelX+i, labelY, r, nil,
+					tcell.StyleDefault.Foreground(tcell.ColorGreen).Bold(true))
 			}
 		}
 	}
@@ -0,0 +1,249 @@
+package scanner
+
+import (
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// Bearing strategy names accepted by NewBearingStrategy
+const (
+	BearingHash   = "hash"
+	BearingSector = "sector"
+	BearingPinned = "pinned"
+)
+
+// BearingStrategy decides where on the scope a signal identity is drawn
+type BearingStrategy interface {
+	// Bearing returns an angle in radians within [0, 2π) for a signal identity
+	Bearing(signalType, key string) float64
+
+	// Name returns the strategy name used in configuration
+	Name() string
+}
+
+// NewBearingStrategy creates a bearing strategy by name
+func NewBearingStrategy(name, pinFile string) (BearingStrategy, error) {
+	switch name {
+	case "", BearingHash:
+		return HashBearing{}, nil
+	case BearingSector:
+		return NewSectorBearing(), nil
+	case BearingPinned:
+		return NewPinnedBearing(pinFile, NewSectorBearing())
+	default:
+		return nil, fmt.Errorf("unknown bearing strategy %q", name)
+	}
+}
+
+// HashBearing spreads identities around the full scope using a hash of the key
+type HashBearing struct{}
+
+// Name returns the strategy name
+func (HashBearing) Name() string {
+	return BearingHash
+}
+
+// Bearing returns a stable angle derived from the signal type and key
+func (HashBearing) Bearing(signalType, key string) float64 {
+	return hashFraction(identity(signalType, key)) * 2 * math.Pi
+}
+
+// SectorBearing groups signals of the same type into a fixed sector of the scope
+type SectorBearing struct {
+	sectors map[string]int
+	count   int
+}
+
+// NewSectorBearing creates a sector strategy with one sector per known signal type
+func NewSectorBearing() *SectorBearing {
+	types := []string{"WiFi", "Bluetooth", "Cellular", "Radio", "IoT", "Satellite", "Network", "Ethernet"}
+	sectors := make(map[string]int, len(types))
+	for i, t := range types {
+		sectors[t] = i
+	}
+	return &SectorBearing{
+		sectors: sectors,
+		count:   len(types),
+	}
+}
+
+// Name returns the strategy name
+func (s *SectorBearing) Name() string {
+	return BearingSector
+}
+
+// Bearing places the key inside its type's sector, leaving a margin between sectors
+func (s *SectorBearing) Bearing(signalType, key string) float64 {
+	sector, ok := s.sectors[signalType]
+	if !ok {
+		// Unknown types still get a stable sector of their own
+		sector = int(hashFraction(signalType) * float64(s.count))
+	}
+
+	width := 2 * math.Pi / float64(s.count)
+	margin := width * 0.1
+	offset := margin + hashFraction(key)*(width-2*margin)
+	return float64(sector)*width + offset
+}
+
+// PinnedBearing serves user-pinned bearings persisted to disk and defers to a
+// fallback strategy for identities that have not been pinned
+type PinnedBearing struct {
+	path     string
+	fallback BearingStrategy
+	pins     map[string]float64 // "type|key" -> degrees
+	mutex    sync.RWMutex
+}
+
+// NewPinnedBearing loads pinned bearings from path (a JSON object of
+// "type|key" to degrees, such as "WiFi|wifi:aa:bb:cc:dd:ee:ff": 90)
+func NewPinnedBearing(path string, fallback BearingStrategy) (*PinnedBearing, error) {
+	if path == "" {
+		path = DefaultBearingPinFile()
+	}
+	if fallback == nil {
+		fallback = HashBearing{}
+	}
+
+	p := &PinnedBearing{
+		path:     path,
+		fallback: fallback,
+		pins:     make(map[string]float64),
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return p, nil
+		}
+		return nil, fmt.Errorf("reading bearing pins: %w", err)
+	}
+	if err := json.Unmarshal(data, &p.pins); err != nil {
+		return nil, fmt.Errorf("parsing bearing pins %s: %w", path, err)
+	}
+	return p, nil
+}
+
+// DefaultBearingPinFile returns the default location of the pinned bearings file
+func DefaultBearingPinFile() string {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return ".radar_bearings.json"
+	}
+	return filepath.Join(homeDir, ".radar_bearings.json")
+}
+
+// Name returns the strategy name
+func (p *PinnedBearing) Name() string {
+	return BearingPinned
+}
+
+// Bearing returns the pinned bearing for key, or the fallback bearing
+func (p *PinnedBearing) Bearing(signalType, key string) float64 {
+	p.mutex.RLock()
+	degrees, ok := p.pins[identity(signalType, key)]
+	p.mutex.RUnlock()
+
+	if ok {
+		return normalizeAngle(degrees * math.Pi / 180)
+	}
+	return p.fallback.Bearing(signalType, key)
+}
+
+// Pin fixes the bearing of a signal identity (in degrees) and persists all
+// pins to disk
+func (p *PinnedBearing) Pin(signalType, key string, degrees float64) error {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.pins[identity(signalType, key)] = degrees
+	data, err := json.MarshalIndent(p.pins, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(p.path, data, 0644)
+}
+
+// BearingAssigner remembers the bearing given to each identity so that a
+// signal keeps its place on the scope across scans
+type BearingAssigner struct {
+	strategy BearingStrategy
+	bearings map[string]float64
+	mutex    sync.Mutex
+}
+
+// NewBearingAssigner creates an assigner backed by the given strategy
+func NewBearingAssigner(strategy BearingStrategy) *BearingAssigner {
+	if strategy == nil {
+		strategy = HashBearing{}
+	}
+	return &BearingAssigner{
+		strategy: strategy,
+		bearings: make(map[string]float64),
+	}
+}
+
+// Assign returns the bearing for a signal identity, assigning one on first sight
+func (b *BearingAssigner) Assign(signalType, key string) float64 {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	cacheKey := identity(signalType, key)
+	if angle, ok := b.bearings[cacheKey]; ok {
+		return angle
+	}
+
+	angle := normalizeAngle(b.strategy.Bearing(signalType, key))
+	b.bearings[cacheKey] = angle
+	return angle
+}
+
+// Pin fixes the bearing of an identity, persisting it when the strategy supports pins
+func (b *BearingAssigner) Pin(signalType, key string, angle float64) error {
+	b.mutex.Lock()
+	b.bearings[identity(signalType, key)] = normalizeAngle(angle)
+	b.mutex.Unlock()
+
+	if pinned, ok := b.strategy.(*PinnedBearing); ok {
+		return pinned.Pin(signalType, key, angle*180/math.Pi)
+	}
+	return nil
+}
+
+// Persistent reports whether pinned bearings outlive the process
+func (b *BearingAssigner) Persistent() bool {
+	_, ok := b.strategy.(*PinnedBearing)
+	return ok
+}
+
+// Strategy returns the strategy used for new identities
+func (b *BearingAssigner) Strategy() BearingStrategy {
+	return b.strategy
+}
+
+// identity combines a signal type and key, so that identities of different
+// types never share a bearing
+func identity(signalType, key string) string {
+	return signalType + "|" + key
+}
+
+// hashFraction maps a string to a stable value in [0, 1)
+func hashFraction(s string) float64 {
+	h := fnv.New64a()
+	h.Write([]byte(s))
+	return float64(h.Sum64()>>11) / float64(1<<53)
+}
+
+// normalizeAngle wraps an angle into [0, 2π)
+func normalizeAngle(angle float64) float64 {
+	angle = math.Mod(angle, 2*math.Pi)
+	if angle < 0 {
+		angle += 2 * math.Pi
+	}
+	return angle
+}
@@ -2,16 +2,23 @@ package scanner
 
 import (
 	"context"
+	"errors"
+	"io"
+	"sort"
 	"sync"
 	"time"
+
+	"github.com/e6a5/radar/radar/model"
 )
 
 // Coordinator manages multiple scanners and aggregates their results
 type Coordinator struct {
 	scanners      []Scanner
+	observers     []Observer
 	config        *Config
 	lastScan      time.Time
-	cachedSignals []Signal
+	cachedSignals []model.Signal
+	tracks        map[string]*model.Signal // Merged observations keyed by signal identity
 	mutex         sync.RWMutex
 	isScanning    bool
 }
@@ -21,7 +28,8 @@ func NewCoordinator(config *Config) *Coordinator {
 	return &Coordinator{
 		scanners:      make([]Scanner, 0),
 		config:        config,
-		cachedSignals: make([]Signal, 0),
+		cachedSignals: make([]model.Signal, 0),
+		tracks:        make(map[string]*model.Signal),
 	}
 }
 
@@ -34,6 +42,13 @@ func (c *Coordinator) AddScanner(scanner Scanner) {
 	}
 }
 
+// AddObserver registers an observer for the results of every scanner run
+func (c *Coordinator) AddObserver(observer Observer) {
+	c.mutex.Lock()
+	c.observers = append(c.observers, observer)
+	c.mutex.Unlock()
+}
+
 // GetScanners returns the list of available scanners
 func (c *Coordinator) GetScanners() []string {
 	c.mutex.RLock()
@@ -46,14 +61,34 @@ func (c *Coordinator) GetScanners() []string {
 	return names
 }
 
+// Close closes the scanners that hold resources between scans and removes
+// every scanner, so later scans find nothing. A scan already running finishes
+// with the errors of its closed scanners.
+func (c *Coordinator) Close() error {
+	c.mutex.Lock()
+	scanners := c.scanners
+	c.scanners = nil
+	c.mutex.Unlock()
+
+	var errs []error
+	for _, s := range scanners {
+		if closer, ok := s.(io.Closer); ok {
+			if err := closer.Close(); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
 // Scan runs all scanners and aggregates results
-func (c *Coordinator) Scan(ctx context.Context) ([]Signal, error) {
+func (c *Coordinator) Scan(ctx context.Context) ([]model.Signal, error) {
 	c.mutex.RLock()
 	now := time.Now()
 
 	// Rate limiting
 	if now.Sub(c.lastScan) < c.config.ScanInterval {
-		signals := make([]Signal, len(c.cachedSignals))
+		signals := make([]model.Signal, len(c.cachedSignals))
 		copy(signals, c.cachedSignals)
 		c.mutex.RUnlock()
 		return signals, nil
@@ -68,13 +103,46 @@ func (c *Coordinator) Scan(ctx context.Context) ([]Signal, error) {
 	}
 
 	// Return cached signals immediately
-	signals := make([]Signal, len(c.cachedSignals))
+	signals := make([]model.Signal, len(c.cachedSignals))
 	copy(signals, c.cachedSignals)
 	c.mutex.Unlock()
 
 	return signals, nil
 }
 
+// ScanNow runs all scanners immediately, waits for them to finish and returns
+// the tracks observed by this scan. Unlike Scan it ignores the scan interval,
+// which suits callers that drive their own schedule.
+func (c *Coordinator) ScanNow(ctx context.Context) ([]model.Signal, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	observations := c.runScanners(ctx)
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	now := time.Now()
+	c.mergeObservations(observations, now)
+	c.cachedSignals = c.snapshotTracks()
+	c.lastScan = now
+
+	signals := make([]model.Signal, 0, len(observations))
+	seen := make(map[string]bool, len(observations))
+	for _, obs := range observations {
+		key := obs.Key()
+		track, ok := c.tracks[key]
+		if !ok || seen[key] {
+			continue
+		}
+		seen[key] = true
+		signals = append(signals, track.Clone())
+	}
+	sortSignals(signals)
+	return signals, nil
+}
+
 // performBackgroundScan runs all scanners in parallel
 func (c *Coordinator) performBackgroundScan(ctx context.Context) {
 	defer func() {
@@ -84,9 +152,23 @@ func (c *Coordinator) performBackgroundScan(ctx context.Context) {
 		c.mutex.Unlock()
 	}()
 
+	allSignals := c.runScanners(ctx)
+
+	// Merge observations into the track table and publish the strongest tracks
+	c.mutex.Lock()
+	c.mergeObservations(allSignals, time.Now())
+	c.cachedSignals = c.snapshotTracks()
+	c.mutex.Unlock()
+}
+
+// runScanners runs every scanner in parallel and collects their observations,
+// giving up on scanners that have not answered within the scan timeout
+func (c *Coordinator) runScanners(ctx context.Context) []model.Signal {
 	c.mutex.RLock()
 	scanners := make([]Scanner, len(c.scanners))
 	copy(scanners, c.scanners)
+	observers := make([]Observer, len(c.observers))
+	copy(observers, c.observers)
 	c.mutex.RUnlock()
 
 	// Create timeout context
@@ -95,7 +177,7 @@ func (c *Coordinator) performBackgroundScan(ctx context.Context) {
 
 	// Run all scanners in parallel
 	type scanResult struct {
-		signals []Signal
+		signals []model.Signal
 		err     error
 		scanner string
 	}
@@ -114,36 +196,108 @@ func (c *Coordinator) performBackgroundScan(ctx context.Context) {
 	}
 
 	// Collect results
-	allSignals := make([]Signal, 0)
+	allSignals := make([]model.Signal, 0)
 	for i := 0; i < len(scanners); i++ {
 		select {
 		case result := <-resultChan:
+			for _, observer := range observers {
+				observer.ObserveScan(ScanResult{
+					Scanner: result.scanner,
+					Time:    time.Now(),
+					Signals: result.signals,
+					Err:     result.err,
+				})
+			}
 			if result.err == nil {
 				allSignals = append(allSignals, result.signals...)
 			}
 		case <-scanCtx.Done():
 			// Timeout - continue with what we have
-			break
+			return allSignals
 		}
 	}
+	return allSignals
+}
+
+// mergeObservations folds a scan's signals into the track table. An observation
+// of a known identity updates the existing track and extends its history, while
+// tracks that have not been observed within the track timeout are dropped.
+// Callers must hold the write lock.
+func (c *Coordinator) mergeObservations(observations []model.Signal, now time.Time) {
+	for _, obs := range observations {
+		key := obs.Key()
 
-	// Limit total signals
-	if len(allSignals) > c.config.MaxSignals {
-		allSignals = allSignals[:c.config.MaxSignals]
+		track, exists := c.tracks[key]
+		if !exists {
+			merged := obs.Clone()
+			merged.ID = key
+			if merged.MaxHistory == 0 {
+				merged.MaxHistory = 20
+			}
+			c.tracks[key] = &merged
+			continue
+		}
+
+		track.Type = obs.Type
+		track.Icon = obs.Icon
+		track.Name = obs.Name
+		track.Category = obs.Category
+		track.Severity = obs.Severity
+		track.Strength = obs.Strength
+		track.Distance = obs.Distance
+		track.DistanceLow = obs.DistanceLow
+		track.DistanceHigh = obs.DistanceHigh
+		track.Angle = obs.Angle
+		track.LastSeen = obs.LastSeen
+		track.Persistence = 1.0
+		track.Attributes = obs.Attributes.Clone()
+		track.AddToHistory(obs.Distance, obs.Angle, obs.Strength, true, obs.LastSeen)
 	}
 
-	// Update cached signals
-	c.mutex.Lock()
-	c.cachedSignals = allSignals
-	c.mutex.Unlock()
+	timeout := c.config.TrackTimeout
+	if timeout == 0 {
+		timeout = 3 * c.config.ScanInterval
+	}
+	for key, track := range c.tracks {
+		if now.Sub(track.LastSeen) > timeout {
+			delete(c.tracks, key)
+		}
+	}
+}
+
+// snapshotTracks copies the strongest tracks, limited to MaxSignals. Callers
+// must hold the lock.
+func (c *Coordinator) snapshotTracks() []model.Signal {
+	signals := make([]model.Signal, 0, len(c.tracks))
+	for _, track := range c.tracks {
+		signals = append(signals, track.Clone())
+	}
+
+	sortSignals(signals)
+
+	if len(signals) > c.config.MaxSignals {
+		signals = signals[:c.config.MaxSignals]
+	}
+	return signals
+}
+
+// sortSignals orders signals strongest first, breaking ties by identity so the
+// order is stable
+func sortSignals(signals []model.Signal) {
+	sort.Slice(signals, func(i, j int) bool {
+		if signals[i].Strength != signals[j].Strength {
+			return signals[i].Strength > signals[j].Strength
+		}
+		return signals[i].ID < signals[j].ID
+	})
 }
 
 // GetCachedSignals returns the last cached signals
-func (c *Coordinator) GetCachedSignals() []Signal {
+func (c *Coordinator) GetCachedSignals() []model.Signal {
 	c.mutex.RLock()
 	defer c.mutex.RUnlock()
 
-	signals := make([]Signal, len(c.cachedSignals))
+	signals := make([]model.Signal, len(c.cachedSignals))
 	copy(signals, c.cachedSignals)
 	return signals
 }
@@ -0,0 +1,48 @@
+package scanner
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/e6a5/radar/radar/model"
+)
+
+// fakeScanner returns no signals and records whether it was closed
+type fakeScanner struct {
+	name   string
+	closed bool
+}
+
+func (f *fakeScanner) Scan(ctx context.Context) ([]model.Signal, error) { return nil, nil }
+func (f *fakeScanner) Name() string                                     { return f.name }
+func (f *fakeScanner) IsAvailable() bool                                { return true }
+
+// closingScanner is a fakeScanner that holds resources between scans
+type closingScanner struct{ fakeScanner }
+
+func (c *closingScanner) Close() error {
+	c.closed = true
+	return nil
+}
+
+func TestCoordinatorClose(t *testing.T) {
+	c := NewCoordinator(&Config{ScanInterval: time.Second, MaxSignals: 10})
+	plain := &fakeScanner{name: "plain"}
+	holding := &closingScanner{fakeScanner{name: "holding"}}
+	c.AddScanner(plain)
+	c.AddScanner(holding)
+
+	if err := c.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if !holding.closed {
+		t.Error("scanner with Close was not closed")
+	}
+	if names := c.GetScanners(); len(names) != 0 {
+		t.Errorf("scanners after Close = %q, want none", names)
+	}
+	if signals, err := c.ScanNow(context.Background()); err != nil || len(signals) != 0 {
+		t.Errorf("ScanNow after Close = %v, %v, want nothing", signals, err)
+	}
+}
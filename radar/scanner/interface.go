@@ -2,39 +2,18 @@ package scanner
 
 import (
 	"context"
+	"math"
 	"time"
-)
-
-// Signal represents a detected signal on the radar
-type Signal struct {
-	Type        string
-	Icon        string
-	Name        string
-	Color       interface{} // tcell.Color
-	Strength    int         // 0-100%
-	Distance    float64     // radar units
-	Angle       float64     // radians
-	Phase       int
-	Lifetime    time.Time
-	LastSeen    time.Time
-	Persistence float64
-	History     []PositionHistory
-	MaxHistory  int
-}
 
-// PositionHistory tracks signal movement over time
-type PositionHistory struct {
-	Distance  float64
-	Angle     float64
-	Strength  int
-	Timestamp time.Time
-	IsReal    bool
-}
+	"github.com/e6a5/radar/radar/estimation"
+	"github.com/e6a5/radar/radar/model"
+)
 
 // Scanner defines the interface for all signal scanners
 type Scanner interface {
-	// Scan returns detected signals
-	Scan(ctx context.Context) ([]Signal, error)
+	// Scan returns detected signals. Scanners scan whenever they are asked;
+	// the coordinator decides how often.
+	Scan(ctx context.Context) ([]model.Signal, error)
 
 	// Name returns a human-readable name for this scanner
 	Name() string
@@ -43,29 +22,75 @@ type Scanner interface {
 	IsAvailable() bool
 }
 
+// Scanners that hold sockets, connections or goroutines between scans also
+// implement io.Closer; the coordinator closes them when it is closed.
+
+// ScanResult is the outcome of one scanner's run during a scan
+type ScanResult struct {
+	Scanner string         // Name of the scanner
+	Time    time.Time      // When the scanner finished
+	Signals []model.Signal // Observations before they are merged into tracks
+	Err     error
+}
+
+// Observer receives every scan result as it arrives. Observers are called from
+// scan goroutines and must be safe for concurrent use.
+type Observer interface {
+	ObserveScan(result ScanResult)
+}
+
+// DefaultDisplayRange is the distance at the scope's edge when the config
+// does not give one, meters
+const DefaultDisplayRange = 40.0
+
+// edgeLatency is the round trip that places a host at the scope's edge
+const edgeLatency = 50 * time.Millisecond
+
 // Config holds scanner configuration
 type Config struct {
 	ScanInterval  time.Duration
 	MaxSignals    int
 	MaxScanRange  float64
+	DisplayRange  float64 // Distance at the scope's edge, meters; DefaultDisplayRange if zero
 	UseRealData   bool
 	EnableConsent bool
+	Bearings      *BearingAssigner      // Stable bearing assignment (hash-based if nil)
+	TrackTimeout  time.Duration         // Drop tracks not observed for this long (3 scans if zero)
+	ActiveSweep   bool                  // Probe the local subnets to discover hosts
+	Estimator     *estimation.Estimator // Smooths RSSI into distances (unsmoothed, default calibration if nil)
 }
 
-// AddToHistory adds a position entry to signal history
-func (s *Signal) AddToHistory(distance, angle float64, strength int, isReal bool, timestamp time.Time) {
-	entry := PositionHistory{
-		Distance:  distance,
-		Angle:     angle,
-		Strength:  strength,
-		Timestamp: timestamp,
-		IsReal:    isReal,
+// Bearing returns a stable angle for a signal of the given type and ID
+func (c *Config) Bearing(signalType, id string) float64 {
+	if c.Bearings == nil {
+		return HashBearing{}.Bearing(signalType, id)
 	}
+	return c.Bearings.Assign(signalType, id)
+}
 
-	s.History = append(s.History, entry)
+// Estimate converts a reading of a signal into a distance within the scan range
+func (c *Config) Estimate(key string, rssi int, source estimation.Source, now time.Time) estimation.Estimate {
+	var estimate estimation.Estimate
+	if c.Estimator == nil {
+		estimate = estimation.Calibration{}.Single(float64(rssi), source)
+	} else {
+		estimate = c.Estimator.Update(key, float64(rssi), source, now)
+	}
+	return estimate.Clamp(0.5, c.MaxScanRange)
+}
 
-	// Keep only recent history
-	if len(s.History) > s.MaxHistory {
-		s.History = s.History[1:]
+// LatencyDistance places a host by round-trip latency, in proportion to the
+// display range: a 50 ms round trip reaches the scope's edge. Hosts without
+// a measured round trip are placed as if they answered in 10 ms, a fifth of
+// the way out.
+func (c *Config) LatencyDistance(rtt time.Duration) float64 {
+	if rtt <= 0 {
+		rtt = 10 * time.Millisecond
+	}
+	displayRange := c.DisplayRange
+	if displayRange <= 0 {
+		displayRange = DefaultDisplayRange
 	}
+	distance := float64(rtt) / float64(edgeLatency) * displayRange
+	return math.Max(0.5, math.Min(c.MaxScanRange, distance))
 }
@@ -0,0 +1,31 @@
+package scanner
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestLatencyDistance(t *testing.T) {
+	tests := []struct {
+		name   string
+		config Config
+		rtt    time.Duration
+		want   float64
+	}{
+		{"edge of the default range", Config{MaxScanRange: 1000}, 50 * time.Millisecond, DefaultDisplayRange},
+		{"unmeasured", Config{MaxScanRange: 1000}, 0, DefaultDisplayRange / 5},
+		{"same as unmeasured", Config{MaxScanRange: 1000}, 10 * time.Millisecond, DefaultDisplayRange / 5},
+		{"scaled to the display range", Config{MaxScanRange: 1000, DisplayRange: 100}, 25 * time.Millisecond, 50},
+		{"past the edge", Config{MaxScanRange: 1000, DisplayRange: 100}, 100 * time.Millisecond, 200},
+		{"clamped to the scan range", Config{MaxScanRange: 60}, 500 * time.Millisecond, 60},
+		{"clamped to half a meter", Config{MaxScanRange: 1000}, 100 * time.Microsecond, 0.5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.config.LatencyDistance(tt.rtt); math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("LatencyDistance(%v) = %g, want %g", tt.rtt, got, tt.want)
+			}
+		})
+	}
+}
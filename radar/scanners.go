@@ -0,0 +1,114 @@
+package radar
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/e6a5/radar/radar/mdns"
+	"github.com/e6a5/radar/radar/network"
+	"github.com/e6a5/radar/radar/scanner"
+	"github.com/e6a5/radar/radar/ssdp"
+)
+
+// ScannerInfo describes a scanner that can be selected by name
+type ScannerInfo struct {
+	Name        string
+	Description string
+	create      func(config *scanner.Config) scanner.Scanner
+}
+
+// registeredScanners lists the scanners in the order they are added to a coordinator
+var registeredScanners = []ScannerInfo{
+	{
+		Name:        "wifi",
+		Description: "WiFi access points",
+		// Platform-specific implementation is selected at compile time
+		create: createWiFiScanner,
+	},
+	{
+		Name:        "bluetooth",
+		Description: "Bluetooth LE devices (BlueZ on Linux)",
+		create:      createBluetoothScanner,
+	},
+	{
+		Name:        "network",
+		Description: "Network interfaces and active connections",
+		create: func(config *scanner.Config) scanner.Scanner {
+			return network.NewInterfaceScanner(config)
+		},
+	},
+	{
+		Name:        "lan",
+		Description: "Hosts on the local network from the ARP/neighbor table",
+		create: func(config *scanner.Config) scanner.Scanner {
+			return network.NewNeighborScanner(config)
+		},
+	},
+	{
+		Name:        "mdns",
+		Description: "Services announced over mDNS/DNS-SD (printers, casts, AirPlay, HomeKit)",
+		create: func(config *scanner.Config) scanner.Scanner {
+			return mdns.NewMDNSScanner(config, mdns.DefaultOptions())
+		},
+	},
+	{
+		Name:        "ssdp",
+		Description: "UPnP devices announced over SSDP (media renderers, routers, NAS)",
+		create: func(config *scanner.Config) scanner.Scanner {
+			return ssdp.NewSSDPScanner(config, ssdp.DefaultOptions())
+		},
+	},
+}
+
+// Scanners returns the scanners that can be selected by name
+func Scanners() []ScannerInfo {
+	scanners := make([]ScannerInfo, len(registeredScanners))
+	copy(scanners, registeredScanners)
+	return scanners
+}
+
+// NewScanners creates the named scanners, or every registered scanner when
+// names is empty
+func NewScanners(names []string, config *scanner.Config) ([]scanner.Scanner, error) {
+	selected := make(map[string]bool, len(names))
+	for _, name := range names {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		if !isRegisteredScanner(name) {
+			return nil, fmt.Errorf("unknown scanner %q (available: %s)", name, strings.Join(scannerNames(), ", "))
+		}
+		selected[name] = true
+	}
+
+	scanners := make([]scanner.Scanner, 0, len(registeredScanners))
+	for _, info := range registeredScanners {
+		if len(selected) > 0 && !selected[info.Name] {
+			continue
+		}
+		if s := info.create(config); s != nil {
+			scanners = append(scanners, s)
+		}
+	}
+	return scanners, nil
+}
+
+// isRegisteredScanner reports whether name is a registered scanner
+func isRegisteredScanner(name string) bool {
+	for _, info := range registeredScanners {
+		if info.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// scannerNames returns the names of all registered scanners
+func scannerNames() []string {
+	names := make([]string, len(registeredScanners))
+	for i, info := range registeredScanners {
+		names[i] = info.Name
+	}
+	return names
+}
@@ -0,0 +1,319 @@
+package session
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/e6a5/radar/radar/model"
+)
+
+// Playback speed limits
+const (
+	MinSpeed = 0.25
+	MaxSpeed = 16.0
+)
+
+// ReadSession reads every record of a session file. Both gzip-compressed and
+// plain JSON-lines files are accepted. Records are returned in time order.
+func ReadSession(path string) ([]Record, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	reader := bufio.NewReader(file)
+	var input io.Reader = reader
+	if magic, err := reader.Peek(2); err == nil && magic[0] == 0x1f && magic[1] == 0x8b {
+		gz, err := gzip.NewReader(reader)
+		if err != nil {
+			return nil, fmt.Errorf("session: %s: %w", path, err)
+		}
+		defer gz.Close()
+		input = gz
+	}
+
+	records := make([]Record, 0)
+	scanner := bufio.NewScanner(input)
+	scanner.Buffer(make([]byte, 64*1024), 16<<20)
+	line := 0
+	for scanner.Scan() {
+		line++
+		data := bytes.TrimSpace(scanner.Bytes())
+		if len(data) == 0 {
+			continue
+		}
+		var record Record
+		if err := json.Unmarshal(data, &record); err != nil {
+			// A crash can cut the last record short
+			if !scanner.Scan() && errors.Is(scanner.Err(), io.ErrUnexpectedEOF) {
+				break
+			}
+			return nil, fmt.Errorf("session: %s:%d: %w", path, line, err)
+		}
+		if record.Version > FormatVersion {
+			return nil, fmt.Errorf("session: %s:%d: unsupported record version %d", path, line, record.Version)
+		}
+		records = append(records, record)
+	}
+	if err := scanner.Err(); err != nil && !errors.Is(err, io.ErrUnexpectedEOF) {
+		// A recording cut short by a crash still replays up to the last full record
+		return nil, fmt.Errorf("session: %s: %w", path, err)
+	}
+
+	sort.SliceStable(records, func(i, j int) bool {
+		return records[i].Time.Before(records[j].Time)
+	})
+	return records, nil
+}
+
+// Player replays a recorded session as a scanner. Each scan reports what every
+// recorded scanner last saw at the current playback position, so the scope
+// shows the recorded scene at any speed and after any seek.
+type Player struct {
+	name     string
+	start    time.Time
+	duration time.Duration
+	scanners map[string]*scannerTimeline
+
+	position time.Duration // Playback position from the start of the session
+	speed    float64
+	paused   bool
+	loop     bool
+	lastTick time.Time // Wall clock time of the last position update
+	mutex    sync.Mutex
+}
+
+// scannerTimeline holds one recorded scanner's results in time order
+type scannerTimeline struct {
+	records []Record
+	stale   time.Duration // Results older than this no longer count as current
+}
+
+// OpenPlayer loads a session file for replay
+func OpenPlayer(path string) (*Player, error) {
+	records, err := ReadSession(path)
+	if err != nil {
+		return nil, err
+	}
+	return NewPlayer(records)
+}
+
+// NewPlayer creates a player for records sorted by time
+func NewPlayer(records []Record) (*Player, error) {
+	if len(records) == 0 {
+		return nil, errors.New("session: no records to replay")
+	}
+
+	p := &Player{
+		name:     "Session Replay",
+		start:    records[0].Time,
+		duration: records[len(records)-1].Time.Sub(records[0].Time),
+		scanners: make(map[string]*scannerTimeline),
+		speed:    1.0,
+		lastTick: time.Now(),
+	}
+
+	for _, record := range records {
+		timeline, ok := p.scanners[record.Scanner]
+		if !ok {
+			timeline = &scannerTimeline{}
+			p.scanners[record.Scanner] = timeline
+		}
+		timeline.records = append(timeline.records, record)
+	}
+	for _, timeline := range p.scanners {
+		timeline.stale = 3 * medianInterval(timeline.records)
+	}
+	return p, nil
+}
+
+// Name returns the scanner name
+func (p *Player) Name() string {
+	return p.name
+}
+
+// IsAvailable always reports true: replay needs no hardware
+func (p *Player) IsAvailable() bool {
+	return true
+}
+
+// Scan returns the observations current at the playback position
+func (p *Player) Scan(ctx context.Context) ([]model.Signal, error) {
+	now := time.Now()
+
+	p.mutex.Lock()
+	p.advance(now)
+	at := p.start.Add(p.position)
+	p.mutex.Unlock()
+
+	signals := make([]model.Signal, 0)
+	for _, timeline := range p.scanners {
+		record, ok := timeline.current(at)
+		if !ok {
+			continue
+		}
+		for _, s := range record.Signals {
+			signals = append(signals, s.Signal(now))
+		}
+	}
+	return signals, nil
+}
+
+// current returns the latest result at or before t that is not yet stale
+func (t *scannerTimeline) current(at time.Time) (Record, bool) {
+	i := sort.Search(len(t.records), func(i int) bool {
+		return t.records[i].Time.After(at)
+	}) - 1
+	if i < 0 {
+		return Record{}, false
+	}
+	record := t.records[i]
+	if record.Error != "" || at.Sub(record.Time) > t.stale {
+		return Record{}, false
+	}
+	return record, true
+}
+
+// advance moves the playback position forward by the wall time since the last
+// update, scaled by the speed. Callers must hold the lock.
+func (p *Player) advance(now time.Time) {
+	if !p.paused {
+		p.position += time.Duration(float64(now.Sub(p.lastTick)) * p.speed)
+	}
+	p.lastTick = now
+
+	if p.position > p.duration {
+		if p.loop && p.duration > 0 {
+			p.position %= p.duration
+		} else {
+			p.position = p.duration
+		}
+	}
+}
+
+// SetPaused pauses or resumes playback
+func (p *Player) SetPaused(paused bool) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.advance(time.Now())
+	p.paused = paused
+}
+
+// Paused reports whether playback is paused
+func (p *Player) Paused() bool {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	return p.paused
+}
+
+// SetSpeed sets the playback speed, clamped to MinSpeed–MaxSpeed
+func (p *Player) SetSpeed(speed float64) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.advance(time.Now())
+	p.speed = clampSpeed(speed)
+}
+
+// Speed returns the playback speed
+func (p *Player) Speed() float64 {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	return p.speed
+}
+
+// Faster doubles the playback speed
+func (p *Player) Faster() {
+	p.SetSpeed(p.Speed() * 2)
+}
+
+// Slower halves the playback speed
+func (p *Player) Slower() {
+	p.SetSpeed(p.Speed() / 2)
+}
+
+// SetLoop turns looping at the end of the session on or off
+func (p *Player) SetLoop(loop bool) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.loop = loop
+}
+
+// Loop reports whether playback loops
+func (p *Player) Loop() bool {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	return p.loop
+}
+
+// Seek moves playback to an offset from the start of the session
+func (p *Player) Seek(offset time.Duration) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.lastTick = time.Now()
+	p.position = max(0, min(offset, p.duration))
+}
+
+// SeekBy moves playback forward (or backward, if negative) by d
+func (p *Player) SeekBy(d time.Duration) {
+	p.mutex.Lock()
+	p.advance(time.Now())
+	position := p.position + d
+	p.mutex.Unlock()
+	p.Seek(position)
+}
+
+// SeekTime moves playback to a wall clock time within the session
+func (p *Player) SeekTime(t time.Time) {
+	p.Seek(t.Sub(p.start))
+}
+
+// Position returns the playback offset from the start of the session
+func (p *Player) Position() time.Duration {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.advance(time.Now())
+	return p.position
+}
+
+// Duration returns the length of the session
+func (p *Player) Duration() time.Duration {
+	return p.duration
+}
+
+// Start returns the time of the first record
+func (p *Player) Start() time.Time {
+	return p.start
+}
+
+// clampSpeed limits a playback speed to MinSpeed–MaxSpeed
+func clampSpeed(speed float64) float64 {
+	return max(MinSpeed, min(MaxSpeed, speed))
+}
+
+// medianInterval returns the median gap between consecutive records, or 30s
+// when there are too few records to tell
+func medianInterval(records []Record) time.Duration {
+	if len(records) < 2 {
+		return 30 * time.Second
+	}
+	gaps := make([]time.Duration, 0, len(records)-1)
+	for i := 1; i < len(records); i++ {
+		gaps = append(gaps, records[i].Time.Sub(records[i-1].Time))
+	}
+	sort.Slice(gaps, func(i, j int) bool { return gaps[i] < gaps[j] })
+	if median := gaps[len(gaps)/2]; median > 0 {
+		return median
+	}
+	return 30 * time.Second
+}
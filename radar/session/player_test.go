@@ -0,0 +1,204 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/e6a5/radar/radar/model"
+	"github.com/e6a5/radar/radar/scanner"
+)
+
+// openFixture records a nine second session and opens it for replay, paused
+// at the start. WiFi reports an access point every second throughout; BlueZ
+// hears a keyboard for the first two seconds and then fails.
+func openFixture(t *testing.T) *Player {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "session.jsonl.gz")
+	r, err := NewRecorder(path, RecorderOptions{})
+	if err != nil {
+		t.Fatalf("NewRecorder: %v", err)
+	}
+	for i := 0; i < 10; i++ {
+		record(t, r, i, i+1)
+		bluez := scanner.ScanResult{Scanner: "BlueZ", Time: testStart.Add(time.Duration(i) * time.Second)}
+		switch {
+		case i <= 2:
+			bluez.Signals = []model.Signal{{ID: "bt:AA:BB:CC:DD:EE:02", Type: "Bluetooth", Name: "MX Keys", Category: model.CategoryBluetooth, Distance: 2}}
+		case i == 9:
+			bluez.Err = errors.New("adapter removed")
+		default:
+			continue
+		}
+		if err := r.Record(bluez); err != nil {
+			t.Fatalf("Record: %v", err)
+		}
+	}
+	if err := r.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	p, err := OpenPlayer(path)
+	if err != nil {
+		t.Fatalf("OpenPlayer: %v", err)
+	}
+	p.SetPaused(true)
+	p.Seek(0)
+	return p
+}
+
+// scanIDs replays the current position and returns the signals by ID
+func scanIDs(t *testing.T, p *Player) map[string]model.Signal {
+	t.Helper()
+	signals, err := p.Scan(context.Background())
+	if err != nil {
+		t.Fatalf("Scan: %v", err)
+	}
+	byID := make(map[string]model.Signal, len(signals))
+	for _, s := range signals {
+		byID[s.ID] = s
+	}
+	return byID
+}
+
+// elapse moves the player's wall clock back, as if d had passed since its
+// last update
+func elapse(p *Player, d time.Duration) {
+	p.mutex.Lock()
+	p.lastTick = p.lastTick.Add(-d)
+	p.mutex.Unlock()
+}
+
+// near reports whether a playback position is within the scheduling slack of
+// a test of want
+func near(got, want time.Duration) bool {
+	return got >= want && got < want+200*time.Millisecond
+}
+
+func TestPlayerSeek(t *testing.T) {
+	p := openFixture(t)
+	if p.Duration() != 9*time.Second || !p.Start().Equal(testStart) {
+		t.Fatalf("session = %v from %v, want 9s from %v", p.Duration(), p.Start(), testStart)
+	}
+
+	tests := []struct {
+		name     string
+		seek     func()
+		position time.Duration
+		distance float64 // Of the access point at the new position
+	}{
+		{"to a record", func() { p.Seek(3 * time.Second) }, 3 * time.Second, 8},
+		{"between records", func() { p.Seek(3500 * time.Millisecond) }, 3500 * time.Millisecond, 8},
+		{"before the start", func() { p.Seek(-time.Second) }, 0, 5},
+		{"past the end", func() { p.Seek(time.Hour) }, 9 * time.Second, 14},
+		{"backwards", func() { p.SeekBy(-2 * time.Second) }, 7 * time.Second, 12},
+		{"to a time", func() { p.SeekTime(testStart.Add(4 * time.Second)) }, 4 * time.Second, 9},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.seek()
+			if got := p.Position(); got != tt.position {
+				t.Errorf("position = %v, want %v", got, tt.position)
+			}
+			ap, ok := scanIDs(t, p)["wifi:aa:bb:cc:dd:ee:01"]
+			if !ok || ap.Distance != tt.distance {
+				t.Errorf("access point at %g m (present %v), want %g m", ap.Distance, ok, tt.distance)
+			}
+		})
+	}
+}
+
+func TestPlayerPause(t *testing.T) {
+	p := openFixture(t)
+	elapse(p, 5*time.Second)
+	if got := p.Position(); got != 0 {
+		t.Errorf("paused position = %v, want 0", got)
+	}
+
+	p.SetPaused(false)
+	elapse(p, 2*time.Second)
+	if got := p.Position(); !near(got, 2*time.Second) {
+		t.Errorf("position after 2s = %v", got)
+	}
+
+	p.SetSpeed(4)
+	elapse(p, time.Second)
+	if got := p.Position(); !near(got, 6*time.Second) {
+		t.Errorf("position after 1s at 4x = %v, want 6s", got)
+	}
+
+	p.SetPaused(true)
+	position := p.Position()
+	elapse(p, time.Minute)
+	if got := p.Position(); got != position {
+		t.Errorf("position moved from %v to %v while paused", position, got)
+	}
+}
+
+func TestPlayerSpeedLimits(t *testing.T) {
+	p := openFixture(t)
+	p.SetSpeed(100)
+	if p.Speed() != MaxSpeed {
+		t.Errorf("speed = %g, want clamped to %g", p.Speed(), MaxSpeed)
+	}
+	p.Slower()
+	if p.Speed() != MaxSpeed/2 {
+		t.Errorf("speed after Slower = %g, want %g", p.Speed(), MaxSpeed/2)
+	}
+	p.SetSpeed(0)
+	p.Faster()
+	if p.Speed() != 2*MinSpeed {
+		t.Errorf("speed after Faster = %g, want %g", p.Speed(), 2*MinSpeed)
+	}
+}
+
+func TestPlayerLoop(t *testing.T) {
+	for _, loop := range []bool{false, true} {
+		p := openFixture(t)
+		p.SetLoop(loop)
+		p.Seek(8 * time.Second)
+		p.SetPaused(false)
+		elapse(p, 3*time.Second)
+
+		want := 9 * time.Second // Held at the end
+		if loop {
+			want = 2 * time.Second // 11s into a 9s session
+		}
+		if got := p.Position(); !near(got, want) {
+			t.Errorf("loop %v: position 3s after 8s = %v, want %v", loop, got, want)
+		}
+	}
+}
+
+func TestPlayerStaleSignals(t *testing.T) {
+	p := openFixture(t)
+	keyboard := "bt:AA:BB:CC:DD:EE:02"
+
+	tests := []struct {
+		position time.Duration
+		present  bool
+	}{
+		{2 * time.Second, true},
+		{5 * time.Second, true},                   // Three scan intervals after the last sighting
+		{5*time.Second + time.Millisecond, false}, // Stale
+		{9 * time.Second, false},                  // The scanner failed
+	}
+	for _, tt := range tests {
+		p.Seek(tt.position)
+		signals := scanIDs(t, p)
+		if _, ok := signals[keyboard]; ok != tt.present {
+			t.Errorf("at %v: keyboard present %v, want %v", tt.position, ok, tt.present)
+		}
+		if _, ok := signals["wifi:aa:bb:cc:dd:ee:01"]; !ok {
+			t.Errorf("at %v: access point missing", tt.position)
+		}
+	}
+}
+
+func TestNewPlayerEmpty(t *testing.T) {
+	if _, err := NewPlayer(nil); err == nil {
+		t.Error("NewPlayer accepted an empty session")
+	}
+}
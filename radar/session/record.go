@@ -0,0 +1,110 @@
+// Package session records scan results to compressed JSON-lines files and
+// plays them back.
+//
+// A session file is a gzip stream of records, one JSON object per line. Each
+// record holds one scanner's observations from one scan, stamped with the time
+// the scanner finished. Files may consist of several concatenated gzip members,
+// which is what appending to an existing recording produces.
+package session
+
+import (
+	"time"
+
+	"github.com/e6a5/radar/radar/model"
+	"github.com/e6a5/radar/radar/scanner"
+)
+
+// FormatVersion is the version of the record format written by the recorder
+const FormatVersion = 1
+
+// Record is one scanner's result from one scan
+type Record struct {
+	Version int            `json:"v"`
+	Time    time.Time      `json:"time"`
+	Scanner string         `json:"scanner"`
+	Error   string         `json:"error,omitempty"`
+	Signals []SignalRecord `json:"signals"`
+}
+
+// SignalRecord is the recorded form of one observation
+type SignalRecord struct {
+	ID         string            `json:"id"`
+	Type       string            `json:"type"`
+	Icon       string            `json:"icon,omitempty"`
+	Name       string            `json:"name"`
+	Category   string            `json:"category"`
+	Severity   string            `json:"severity,omitempty"`
+	Strength   int               `json:"strength"`
+	Distance   float64           `json:"distance"`
+	Angle      float64           `json:"angle"` // Radians
+	Attributes map[string]string `json:"attributes,omitempty"`
+
+	// Confidence interval around Distance, when the scanner estimates one
+	DistanceLow  float64 `json:"distance_low,omitempty"`
+	DistanceHigh float64 `json:"distance_high,omitempty"`
+}
+
+// NewRecord converts a scan result into a record
+func NewRecord(result scanner.ScanResult) Record {
+	record := Record{
+		Version: FormatVersion,
+		Time:    result.Time,
+		Scanner: result.Scanner,
+		Signals: make([]SignalRecord, 0, len(result.Signals)),
+	}
+	if result.Err != nil {
+		record.Error = result.Err.Error()
+	}
+
+	for _, s := range result.Signals {
+		severity := ""
+		if s.Severity != model.SeverityNormal {
+			severity = s.Severity.String()
+		}
+		record.Signals = append(record.Signals, SignalRecord{
+			ID:         s.Key(),
+			Type:       s.Type,
+			Icon:       s.Icon,
+			Name:       s.Name,
+			Category:   s.Category.String(),
+			Severity:   severity,
+			Strength:   s.Strength,
+			Distance:   s.Distance,
+			Angle:      s.Angle,
+			Attributes: s.Attributes.Clone(),
+
+			DistanceLow:  s.DistanceLow,
+			DistanceHigh: s.DistanceHigh,
+		})
+	}
+	return record
+}
+
+// Signal rebuilds the observation, seen at the given time
+func (r SignalRecord) Signal(seen time.Time) model.Signal {
+	signal := model.Signal{
+		ID:          r.ID,
+		Type:        r.Type,
+		Icon:        r.Icon,
+		Name:        r.Name,
+		Category:    model.ParseCategory(r.Category),
+		Severity:    model.ParseSeverity(r.Severity),
+		Strength:    r.Strength,
+		Distance:    r.Distance,
+		Angle:       r.Angle,
+		Lifetime:    seen,
+		LastSeen:    seen,
+		Persistence: 1.0,
+		History:     make([]model.PositionHistory, 0, 20),
+		MaxHistory:  20,
+		Attributes:  model.Attributes(r.Attributes).Clone(),
+
+		DistanceLow:  r.DistanceLow,
+		DistanceHigh: r.DistanceHigh,
+	}
+	if signal.Icon == "" {
+		signal.Icon = "?"
+	}
+	signal.AddToHistory(signal.Distance, signal.Angle, signal.Strength, true, seen)
+	return signal
+}
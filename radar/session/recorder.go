@@ -0,0 +1,175 @@
+package session
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/e6a5/radar/radar/scanner"
+)
+
+// Default rotation limits
+const (
+	DefaultMaxSize  = 10 << 20 // Compressed bytes per file
+	DefaultMaxFiles = 5        // Rotated files kept besides the active one
+)
+
+// RecorderOptions configures file rotation
+type RecorderOptions struct {
+	MaxSize  int64 // Rotate once the active file reaches this many bytes (DefaultMaxSize if zero)
+	MaxFiles int   // Number of rotated files to keep (DefaultMaxFiles if zero)
+}
+
+// Recorder appends scan results to a session file. When the file grows past
+// MaxSize it is renamed to path.1 (shifting older files to path.2 and so on)
+// and a new file is started.
+type Recorder struct {
+	path    string
+	options RecorderOptions
+	file    *os.File
+	gzip    *gzip.Writer
+	size    int64
+	err     error // First write error seen by ObserveScan
+	mutex   sync.Mutex
+}
+
+// NewRecorder opens path for appending, creating it if needed
+func NewRecorder(path string, options RecorderOptions) (*Recorder, error) {
+	if options.MaxSize <= 0 {
+		options.MaxSize = DefaultMaxSize
+	}
+	if options.MaxFiles <= 0 {
+		options.MaxFiles = DefaultMaxFiles
+	}
+
+	r := &Recorder{
+		path:    path,
+		options: options,
+	}
+	if err := r.open(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// ObserveScan records a scan result, implementing scanner.Observer
+func (r *Recorder) ObserveScan(result scanner.ScanResult) {
+	if err := r.Record(result); err != nil {
+		r.mutex.Lock()
+		if r.err == nil {
+			r.err = err
+		}
+		r.mutex.Unlock()
+	}
+}
+
+// Record appends a scan result to the session file
+func (r *Recorder) Record(result scanner.ScanResult) error {
+	line, err := json.Marshal(NewRecord(result))
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if r.gzip == nil {
+		return errors.New("session: recorder is closed")
+	}
+	if _, err := r.gzip.Write(line); err != nil {
+		return fmt.Errorf("session: writing %s: %w", r.path, err)
+	}
+	// Flush every record so a capture survives a crash
+	if err := r.gzip.Flush(); err != nil {
+		return fmt.Errorf("session: writing %s: %w", r.path, err)
+	}
+
+	if r.size >= r.options.MaxSize {
+		return r.rotate()
+	}
+	return nil
+}
+
+// Err returns the first error seen while recording observed scans
+func (r *Recorder) Err() error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return r.err
+}
+
+// Close flushes and closes the session file
+func (r *Recorder) Close() error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return r.close()
+}
+
+// open starts a new gzip member at the end of the active file
+func (r *Recorder) open() error {
+	file, err := os.OpenFile(r.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("session: %w", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("session: %w", err)
+	}
+
+	r.file = file
+	r.size = info.Size()
+	r.gzip = gzip.NewWriter(&countingWriter{file: file, count: &r.size})
+	return nil
+}
+
+// close finishes the gzip member and closes the file
+func (r *Recorder) close() error {
+	if r.gzip == nil {
+		return nil
+	}
+	err := r.gzip.Close()
+	if closeErr := r.file.Close(); err == nil {
+		err = closeErr
+	}
+	r.gzip = nil
+	r.file = nil
+	return err
+}
+
+// rotate shifts the rotated files up by one and starts a new active file
+func (r *Recorder) rotate() error {
+	if err := r.close(); err != nil {
+		return fmt.Errorf("session: closing %s: %w", r.path, err)
+	}
+
+	os.Remove(rotatedName(r.path, r.options.MaxFiles))
+	for i := r.options.MaxFiles - 1; i >= 1; i-- {
+		os.Rename(rotatedName(r.path, i), rotatedName(r.path, i+1))
+	}
+	if err := os.Rename(r.path, rotatedName(r.path, 1)); err != nil {
+		return fmt.Errorf("session: rotating %s: %w", r.path, err)
+	}
+	return r.open()
+}
+
+// rotatedName returns the name of the n-th rotated file
+func rotatedName(path string, n int) string {
+	return fmt.Sprintf("%s.%d", path, n)
+}
+
+// countingWriter counts the bytes written to the active file
+type countingWriter struct {
+	file  *os.File
+	count *int64
+}
+
+// Write writes p to the file, counting the bytes written
+func (w *countingWriter) Write(p []byte) (int, error) {
+	n, err := w.file.Write(p)
+	*w.count += int64(n)
+	return n, err
+}
@@ -0,0 +1,237 @@
+package session
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/e6a5/radar/radar/model"
+	"github.com/e6a5/radar/radar/scanner"
+)
+
+// testStart is the time of the first fixture scan
+var testStart = time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+// testResult returns the i-th scan of a fixture recording: one access point
+// seen every second, moving a meter further away each scan
+func testResult(i int) scanner.ScanResult {
+	return scanner.ScanResult{
+		Scanner: "WiFi",
+		Time:    testStart.Add(time.Duration(i) * time.Second),
+		Signals: []model.Signal{{
+			ID:         "wifi:aa:bb:cc:dd:ee:01",
+			Type:       "WiFi",
+			Icon:       "📶",
+			Name:       "HomeNet",
+			Category:   model.CategoryWiFi,
+			Severity:   model.SeverityNotice,
+			Strength:   70 - i,
+			Distance:   float64(5 + i),
+			Angle:      1.5,
+			Attributes: model.Attributes{model.AttrSSID: "HomeNet", model.AttrChannel: "6"},
+
+			DistanceLow:  float64(4 + i),
+			DistanceHigh: float64(7 + i),
+		}},
+	}
+}
+
+// record writes the fixture scans from..to-1 with r
+func record(t *testing.T, r *Recorder, from, to int) {
+	t.Helper()
+	for i := from; i < to; i++ {
+		if err := r.Record(testResult(i)); err != nil {
+			t.Fatalf("Record %d: %v", i, err)
+		}
+	}
+}
+
+// scanTimes returns the offsets of records from the fixture start, in seconds
+func scanTimes(records []Record) string {
+	times := make([]string, len(records))
+	for i, r := range records {
+		times[i] = fmt.Sprint(r.Time.Sub(testStart).Seconds())
+	}
+	return strings.Join(times, " ")
+}
+
+// fixtureTimes returns the offsets of fixture scans from..to-1, in seconds
+func fixtureTimes(from, to int) string {
+	times := make([]string, 0, to-from)
+	for i := from; i < to; i++ {
+		times = append(times, fmt.Sprint(i))
+	}
+	return strings.Join(times, " ")
+}
+
+func TestRecorderRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "session.jsonl.gz")
+	r, err := NewRecorder(path, RecorderOptions{})
+	if err != nil {
+		t.Fatalf("NewRecorder: %v", err)
+	}
+	record(t, r, 0, 3)
+	r.ObserveScan(scanner.ScanResult{Scanner: "BlueZ", Time: testStart.Add(1500 * time.Millisecond), Err: errors.New("no adapters")})
+	if err := r.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if err := r.Record(testResult(3)); err == nil {
+		t.Error("Record after Close succeeded")
+	}
+
+	records, err := ReadSession(path)
+	if err != nil {
+		t.Fatalf("ReadSession: %v", err)
+	}
+	if got, want := scanTimes(records), "0 1 1.5 2"; got != want {
+		t.Fatalf("record times = %s, want %s", got, want)
+	}
+	if records[2].Scanner != "BlueZ" || records[2].Error != "no adapters" || len(records[2].Signals) != 0 {
+		t.Errorf("failed scan = %+v", records[2])
+	}
+
+	got := records[1].Signals[0].Signal(records[1].Time)
+	want := testResult(1).Signals[0]
+	if got.ID != want.ID || got.Name != want.Name || got.Icon != want.Icon ||
+		got.Category != want.Category || got.Severity != want.Severity ||
+		got.Strength != want.Strength || got.Distance != want.Distance || got.Angle != want.Angle ||
+		got.DistanceLow != want.DistanceLow || got.DistanceHigh != want.DistanceHigh ||
+		got.Attributes.Get(model.AttrSSID) != "HomeNet" || got.Attributes.Get(model.AttrChannel) != "6" {
+		t.Errorf("replayed signal = %+v\nwant %+v", got, want)
+	}
+	if !got.LastSeen.Equal(records[1].Time) || len(got.History) != 1 {
+		t.Errorf("replayed signal seen %v with %d history entries, want the record time and one", got.LastSeen, len(got.History))
+	}
+}
+
+func TestRecorderAppends(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "session.jsonl.gz")
+	for _, run := range [][2]int{{0, 3}, {3, 5}} {
+		r, err := NewRecorder(path, RecorderOptions{})
+		if err != nil {
+			t.Fatalf("NewRecorder: %v", err)
+		}
+		record(t, r, run[0], run[1])
+		if err := r.Close(); err != nil {
+			t.Fatalf("Close: %v", err)
+		}
+	}
+
+	// Each run added a gzip member
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if members := strings.Count(string(data), "\x1f\x8b\x08"); members != 2 {
+		t.Errorf("file has %d gzip members, want 2", members)
+	}
+
+	records, err := ReadSession(path)
+	if err != nil {
+		t.Fatalf("ReadSession: %v", err)
+	}
+	if got, want := scanTimes(records), fixtureTimes(0, 5); got != want {
+		t.Errorf("record times = %s, want %s", got, want)
+	}
+}
+
+func TestRecorderRotates(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "session.jsonl.gz")
+	r, err := NewRecorder(path, RecorderOptions{MaxSize: 400, MaxFiles: 2})
+	if err != nil {
+		t.Fatalf("NewRecorder: %v", err)
+	}
+	record(t, r, 0, 30)
+	if err := r.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	if _, err := os.Stat(rotatedName(path, 3)); !os.IsNotExist(err) {
+		t.Errorf("%s kept beyond MaxFiles: %v", rotatedName(path, 3), err)
+	}
+
+	// Oldest first, the files hold consecutive scans ending with the last one
+	var all []Record
+	for _, name := range []string{rotatedName(path, 2), rotatedName(path, 1), path} {
+		records, err := ReadSession(name)
+		if err != nil {
+			t.Fatalf("ReadSession %s: %v", filepath.Base(name), err)
+		}
+		if len(records) == 0 {
+			t.Fatalf("%s is empty", filepath.Base(name))
+		}
+		if name != path {
+			if info, _ := os.Stat(name); info.Size() < 400 {
+				t.Errorf("%s rotated at %d bytes, before MaxSize", filepath.Base(name), info.Size())
+			}
+		}
+		all = append(all, records...)
+	}
+	first := int(all[0].Time.Sub(testStart) / time.Second)
+	if first == 0 {
+		t.Error("no scans were rotated out")
+	}
+	if got, want := scanTimes(all), fixtureTimes(first, 30); got != want {
+		t.Errorf("record times = %s, want %s", got, want)
+	}
+}
+
+func TestReadSessionTruncated(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "session.jsonl.gz")
+
+	// A recording appended to, then cut off mid-member by a crash: the last
+	// member has no trailer, and a cut may land anywhere within it
+	r, err := NewRecorder(path, RecorderOptions{})
+	if err != nil {
+		t.Fatalf("NewRecorder: %v", err)
+	}
+	record(t, r, 0, 2)
+	r.Close()
+	r, err = NewRecorder(path, RecorderOptions{})
+	if err != nil {
+		t.Fatalf("NewRecorder: %v", err)
+	}
+	flushed := make([]int64, 0, 3)
+	for i := 2; i < 5; i++ {
+		record(t, r, i, i+1)
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		flushed = append(flushed, info.Size())
+	}
+	r.file.Close() // Crash without finishing the member
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cut := filepath.Join(dir, "cut.jsonl.gz")
+	for size := flushed[0]; size <= int64(len(data)); size++ {
+		if err := os.WriteFile(cut, data[:size], 0644); err != nil {
+			t.Fatal(err)
+		}
+		records, err := ReadSession(cut)
+		if err != nil {
+			t.Fatalf("cut at %d bytes: %v", size, err)
+		}
+
+		// Every record flushed before the cut survives. The record being
+		// written may too, once its data is in but not the flush marker.
+		complete := 2
+		for _, end := range flushed {
+			if size >= end {
+				complete++
+			}
+		}
+		got := scanTimes(records)
+		if got != fixtureTimes(0, complete) && (complete == 5 || got != fixtureTimes(0, complete+1)) {
+			t.Errorf("cut at %d bytes: record times = %s, want %s", size, got, fixtureTimes(0, complete))
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package session
+
+import (
+	"math"
+	"sort"
+	"sync"
+
+	"github.com/e6a5/radar/radar/model"
+	"github.com/e6a5/radar/radar/scanner"
+)
+
+// Tracks folds every record into one signal per identity, keeping the whole
+// recorded position history rather than the scope's recent trail. Signals
+// carry their last recorded state, in order of first sighting, given records sorted by time.
+func Tracks(records []Record) []model.Signal {
+	tracks := &TrackSet{}
+	for _, record := range records {
+		tracks.Add(record)
+	}
+	return tracks.Signals()
+}
+
+// TrackSet folds scan results into one signal per identity as they arrive, so
+// a live scan's position history is kept without keeping every record
+type TrackSet struct {
+	MaxHistory int // Positions kept per signal; all if zero
+
+	tracks map[string]*model.Signal
+	order  []string
+	mutex  sync.Mutex
+}
+
+// ObserveScan folds a scan result into the tracks
+func (t *TrackSet) ObserveScan(result scanner.ScanResult) {
+	t.Add(NewRecord(result))
+}
+
+// Add folds a record into the tracks
+func (t *TrackSet) Add(record Record) {
+	if record.Error != "" {
+		return
+	}
+
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	if t.tracks == nil {
+		t.tracks = make(map[string]*model.Signal)
+	}
+	maxHistory := t.MaxHistory
+	if maxHistory <= 0 {
+		maxHistory = math.MaxInt
+	}
+
+	for _, r := range record.Signals {
+		observed := r.Signal(record.Time)
+		track, ok := t.tracks[observed.ID]
+		if !ok {
+			observed.MaxHistory = maxHistory
+			t.tracks[observed.ID] = &observed
+			t.order = append(t.order, observed.ID)
+			continue
+		}
+
+		first, history := track.Lifetime, track.History
+		*track = observed
+		track.Lifetime = first
+		track.MaxHistory = maxHistory
+		track.History = history
+		track.AddToHistory(observed.Distance, observed.Angle, observed.Strength, true, record.Time)
+	}
+}
+
+// Track returns a copy of the signal with the given ID
+func (t *TrackSet) Track(id string) (model.Signal, bool) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	track, ok := t.tracks[id]
+	if !ok {
+		return model.Signal{}, false
+	}
+	return track.Clone(), true
+}
+
+// Signals returns copies of every signal in order of first sighting
+func (t *TrackSet) Signals() []model.Signal {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	signals := make([]model.Signal, 0, len(t.order))
+	for _, id := range t.order {
+		signals = append(signals, t.tracks[id].Clone())
+	}
+	return signals
+}
+
+// Collector keeps scan results in memory, for callers that want tracks from a
+// live scan without writing a session file
+type Collector struct {
+	records []Record
+	mutex   sync.Mutex
+}
+
+// ObserveScan stores a scan result
+func (c *Collector) ObserveScan(result scanner.ScanResult) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.records = append(c.records, NewRecord(result))
+}
+
+// Records returns the stored results in time order
+func (c *Collector) Records() []Record {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	records := append([]Record(nil), c.records...)
+	sort.SliceStable(records, func(i, j int) bool {
+		return records[i].Time.Before(records[j].Time)
+	})
+	return records
+}
@@ -0,0 +1,67 @@
+package session
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/e6a5/radar/radar/model"
+	"github.com/e6a5/radar/radar/scanner"
+)
+
+func TestTracks(t *testing.T) {
+	records := []Record{NewRecord(testResult(0))}
+	records = append(records, NewRecord(scanner.ScanResult{
+		Scanner: "BlueZ",
+		Time:    testStart.Add(500 * time.Millisecond),
+		Signals: []model.Signal{{ID: "bt:AA:BB:CC:DD:EE:02", Name: "MX Keys", Distance: 2}},
+	}))
+	records = append(records, NewRecord(scanner.ScanResult{Scanner: "BlueZ", Time: testStart.Add(time.Second), Err: errors.New("no adapters")}))
+	records = append(records, NewRecord(testResult(1)), NewRecord(testResult(2)))
+
+	tracks := Tracks(records)
+	if len(tracks) != 2 || tracks[0].ID != "wifi:aa:bb:cc:dd:ee:01" || tracks[1].ID != "bt:AA:BB:CC:DD:EE:02" {
+		t.Fatalf("tracks = %+v, want the access point then the keyboard", tracks)
+	}
+
+	ap := tracks[0]
+	if ap.Distance != 7 || !ap.LastSeen.Equal(testStart.Add(2*time.Second)) || !ap.Lifetime.Equal(testStart) {
+		t.Errorf("access point at %g m, first seen %v, last seen %v; want its last state and first sighting", ap.Distance, ap.Lifetime, ap.LastSeen)
+	}
+	if len(ap.History) != 3 {
+		t.Fatalf("access point has %d positions, want every recorded one", len(ap.History))
+	}
+	for i, h := range ap.History {
+		if h.Distance != float64(5+i) {
+			t.Errorf("position %d at %g m, want %d m", i, h.Distance, 5+i)
+		}
+	}
+}
+
+func TestTrackSetMaxHistory(t *testing.T) {
+	tracks := &TrackSet{MaxHistory: 2}
+	for i := 0; i < 5; i++ {
+		tracks.ObserveScan(testResult(i))
+	}
+
+	ap, ok := tracks.Track("wifi:aa:bb:cc:dd:ee:01")
+	if !ok {
+		t.Fatal("access point not tracked")
+	}
+	if len(ap.History) != 2 || ap.History[0].Distance != 8 || ap.History[1].Distance != 9 {
+		t.Errorf("history = %+v, want the last two positions", ap.History)
+	}
+	if _, ok := tracks.Track("bt:unknown"); ok {
+		t.Error("unknown track found")
+	}
+}
+
+func TestCollectorRecords(t *testing.T) {
+	var c Collector
+	c.ObserveScan(testResult(2))
+	c.ObserveScan(testResult(0))
+	c.ObserveScan(testResult(1))
+	if got, want := scanTimes(c.Records()), "0 1 2"; got != want {
+		t.Errorf("record times = %s, want %s", got, want)
+	}
+}
@@ -5,140 +5,106 @@ import (
 	"math/rand"
 	"time"
 
+	"github.com/e6a5/radar/radar/model"
 	"github.com/gdamore/tcell/v2"
 )
 
-// Historical position point for signal trails
-type PositionHistory struct {
-	Distance    float64
-	Angle       float64
-	Timestamp   time.Time
-	Strength    int
-	WasDetected bool // Was the signal detected at this position
-}
+// Signal is the shared signal model drawn on the scope
+type Signal = model.Signal
 
-type Signal struct {
-	Type        string
-	Icon        string
-	Name        string    // Signal identifier/name (e.g., WiFi SSID, device name)
-	Strength    int       // 0–100
-	Distance    float64   // 0–10 meters
-	Angle       float64   // radians
-	Phase       int       // for animation (wave ring phase)
-	Lifetime    time.Time // when signal was created
-	Color       tcell.Color // Signal's base color
-	LastSeen    time.Time // when signal was last detected by radar sweep
-	Persistence float64   // how long signal stays visible after last sweep (0.0-1.0)
-	// New history tracking
-	History     []PositionHistory // Track signal positions over time
-	MaxHistory  int               // Maximum number of history points to keep
-}
+// PositionHistory is a historical position point for signal trails
+type PositionHistory = model.PositionHistory
+
+// simulatedScale converts the simulation's distances to meters, spreading
+// simulated signals across the default display range
+const simulatedScale = 4.0
 
 func generateSignals() []Signal {
 	types := []struct {
 		typeName string
 		icon     string
-		color    tcell.Color
 		names    []string
 	}{
-		{"WiFi", "≋", tcell.ColorBlue, []string{"MyWiFi_5G", "NETGEAR_2.4G", "Linksys_AC", "TP-Link_Guest"}},
-		{"Bluetooth", "β", tcell.ColorNavy, []string{"iPhone-12", "AirPods-Pro", "MacBook", "Xbox-Controller"}},
-		{"Cellular", "▲", tcell.ColorGreen, []string{"Verizon-LTE", "AT&T-5G", "T-Mobile", "Cell-Tower-1"}},
-		{"Radio", "◈", tcell.ColorPurple, []string{"FM-101.5", "AM-680", "HAM-Radio", "Emergency-Freq"}},
-		{"IoT", "◇", tcell.ColorOrange, []string{"Smart-TV", "Nest-Cam", "Ring-Door", "Alexa-Echo"}},
-		{"Satellite", "★", tcell.ColorYellow, []string{"GPS-III", "Starlink", "ISS", "Weather-Sat"}},
+		{"WiFi", "≋", []string{"MyWiFi_5G", "NETGEAR_2.4G", "Linksys_AC", "TP-Link_Guest"}},
+		{"Bluetooth", "β", []string{"iPhone-12", "AirPods-Pro", "MacBook", "Xbox-Controller"}},
+		{"Cellular", "▲", []string{"Verizon-LTE", "AT&T-5G", "T-Mobile", "Cell-Tower-1"}},
+		{"Radio", "◈", []string{"FM-101.5", "AM-680", "HAM-Radio", "Emergency-Freq"}},
+		{"IoT", "◇", []string{"Smart-TV", "Nest-Cam", "Ring-Door", "Alexa-Echo"}},
+		{"Satellite", "★", []string{"GPS-III", "Starlink", "ISS", "Weather-Sat"}},
 	}
 
 	signals := []Signal{}
 	now := time.Now()
-	
+
 	// Generate initial set of diverse signals
 	for i, t := range types {
 		if i < 4 || rand.Float64() < 0.7 { // Always include first 4, 70% chance for others
-			distance := rand.Float64()*4 + 2
+			distance := (rand.Float64()*4 + 2) * simulatedScale
 			angle := rand.Float64() * 2 * math.Pi
 			strength := rand.Intn(51) + 50
-			
+
 			// Pick a random name from the type's name list
 			signalName := t.names[rand.Intn(len(t.names))]
-			
+
 			s := Signal{
 				Type:        t.typeName,
 				Icon:        t.icon,
 				Name:        signalName,
-				Color:       t.color,
+				Category:    model.CategoryForType(t.typeName),
 				Strength:    strength,
 				Distance:    distance,
 				Angle:       angle,
 				Phase:       rand.Intn(4),
 				Lifetime:    now,
-				LastSeen:    now, // Initially "seen"
-				Persistence: 1.0, // Full brightness initially
+				LastSeen:    now,                            // Initially "seen"
+				Persistence: 1.0,                            // Full brightness initially
 				History:     make([]PositionHistory, 0, 20), // Pre-allocate for 20 positions
-				MaxHistory:  20,  // Keep last 20 positions (about 40 seconds of history)
+				MaxHistory:  20,                             // Keep last 20 positions (about 40 seconds of history)
 			}
-			
+
 			// Add initial position to history
-			s.addToHistory(distance, angle, strength, true, now)
-			
+			s.AddToHistory(distance, angle, strength, true, now)
+
 			signals = append(signals, s)
 		}
 	}
-	
-	return signals
-}
 
-// Add current position to signal history
-func (s *Signal) addToHistory(distance, angle float64, strength int, wasDetected bool, timestamp time.Time) {
-	newPos := PositionHistory{
-		Distance:    distance,
-		Angle:       angle,
-		Timestamp:   timestamp,
-		Strength:    strength,
-		WasDetected: wasDetected,
-	}
-	
-	s.History = append(s.History, newPos)
-	
-	// Keep only recent history
-	if len(s.History) > s.MaxHistory {
-		s.History = s.History[1:] // Remove oldest entry
-	}
+	return signals
 }
 
-// Update signal position and track in history
-func (s *Signal) updatePosition(now time.Time) {
+// updateSimulatedPosition applies realistic movement to a simulated signal
+func updateSimulatedPosition(s *Signal) {
 	// Simulate realistic signal movement
 	switch s.Type {
 	case "WiFi", "Radio", "IoT":
 		// These are typically stationary with minor fluctuations
 		if rand.Float64() < 0.05 { // 5% chance to move slightly
-			s.Distance += (rand.Float64() - 0.5) * 0.2 // Small distance change
-			s.Angle += (rand.Float64() - 0.5) * 0.1   // Small angle change
+			s.Distance += (rand.Float64() - 0.5) * 0.2 * simulatedScale // Small distance change
+			s.Angle += (rand.Float64() - 0.5) * 0.1                     // Small angle change
 		}
 	case "Bluetooth":
 		// Mobile devices - moderate movement
 		if rand.Float64() < 0.15 { // 15% chance to move
-			s.Distance += (rand.Float64() - 0.5) * 0.5
+			s.Distance += (rand.Float64() - 0.5) * 0.5 * simulatedScale
 			s.Angle += (rand.Float64() - 0.5) * 0.2
 		}
 	case "Cellular":
 		// Mobile phones - more movement
 		if rand.Float64() < 0.25 { // 25% chance to move
-			s.Distance += (rand.Float64() - 0.5) * 0.8
+			s.Distance += (rand.Float64() - 0.5) * 0.8 * simulatedScale
 			s.Angle += (rand.Float64() - 0.5) * 0.3
 		}
 	case "Satellite":
 		// Satellites move in predictable patterns
 		if rand.Float64() < 0.20 { // 20% chance to move
 			s.Angle += 0.05 // Consistent orbital movement
-			s.Distance += (rand.Float64() - 0.5) * 0.3
+			s.Distance += (rand.Float64() - 0.5) * 0.3 * simulatedScale
 		}
 	}
-	
+
 	// Keep signals within reasonable bounds
-	s.Distance = math.Max(1.0, math.Min(9.0, s.Distance))
-	
+	s.Distance = math.Max(1.0*simulatedScale, math.Min(9.0*simulatedScale, s.Distance))
+
 	// Normalize angle
 	for s.Angle < 0 {
 		s.Angle += 2 * math.Pi
@@ -148,30 +114,17 @@ func (s *Signal) updatePosition(now time.Time) {
 	}
 }
 
-func getColorByStrength(strength int) tcell.Color {
-	switch {
-	case strength > 80:
-		return tcell.ColorRed
-	case strength > 60:
-		return tcell.ColorOrange
-	case strength > 40:
-		return tcell.ColorYellow
-	default:
-		return tcell.ColorGray
-	}
-}
+// signalColor combines a signal's category color with its strength and persistence
+func (rd *Display) signalColor(s *Signal) tcell.Color {
+	baseColor := rd.categoryColor(s.Category, s.Severity)
 
-// Get enhanced color that combines base signal color with strength and persistence
-func (s *Signal) GetEnhancedColor() tcell.Color {
-	baseColor := s.Color
-	
 	// Apply persistence fading
 	if s.Persistence < 0.3 {
 		return tcell.ColorDarkSlateGray // Very faded
 	} else if s.Persistence < 0.6 {
 		return tcell.ColorGray // Moderately faded
 	}
-	
+
 	// Modify based on strength for fresh signals
 	switch {
 	case s.Strength > 80:
@@ -185,13 +138,27 @@ func (s *Signal) GetEnhancedColor() tcell.Color {
 	}
 }
 
-// Check if signal should be visible based on persistence
-func (s *Signal) IsVisible() bool {
-	return s.Persistence > 0.1
+// categoryColor maps a semantic category and severity to a theme color
+func (rd *Display) categoryColor(category model.Category, severity model.Severity) tcell.Color {
+	theme := rd.getCurrentTheme()
+
+	switch severity {
+	case model.SeverityActive:
+		return theme.SignalConnected
+	case model.SeverityNotice:
+		return theme.SeverityNotice
+	case model.SeverityAlert:
+		return theme.SeverityAlert
+	}
+
+	if color, ok := theme.Categories[category]; ok {
+		return color
+	}
+	return theme.TextPrimary
 }
 
-// Get the visual style based on persistence level
-func (s *Signal) GetVisualStyle(baseStyle tcell.Style) tcell.Style {
+// signalStyle returns the visual style based on persistence level
+func signalStyle(s *Signal, baseStyle tcell.Style) tcell.Style {
 	if s.Persistence > 0.8 {
 		return baseStyle.Bold(true) // Fresh signal - bold
 	} else if s.Persistence > 0.5 {
@@ -199,4 +166,4 @@ func (s *Signal) GetVisualStyle(baseStyle tcell.Style) tcell.Style {
 	} else {
 		return baseStyle.Dim(true) // Fading signal - dim
 	}
-} 
\ No newline at end of file
+}
@@ -0,0 +1,132 @@
+package ssdp
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// maxDescriptionSize caps how much of a device description is read
+const maxDescriptionSize = 1 << 20
+
+// Description is the root device of a UPnP device description document
+type Description struct {
+	DeviceType       string        `xml:"deviceType"`
+	FriendlyName     string        `xml:"friendlyName"`
+	Manufacturer     string        `xml:"manufacturer"`
+	ModelName        string        `xml:"modelName"`
+	ModelNumber      string        `xml:"modelNumber"`
+	ModelDescription string        `xml:"modelDescription"`
+	SerialNumber     string        `xml:"serialNumber"`
+	UDN              string        `xml:"UDN"`
+	PresentationURL  string        `xml:"presentationURL"`
+	Services         []Service     `xml:"serviceList>service"`
+	Devices          []Description `xml:"deviceList>device"`
+}
+
+// Service is a service offered by a UPnP device
+type Service struct {
+	ServiceType string `xml:"serviceType"`
+	ServiceID   string `xml:"serviceId"`
+}
+
+// descriptionDocument is the <root> element of a description
+type descriptionDocument struct {
+	XMLName xml.Name    `xml:"root"`
+	Device  Description `xml:"device"`
+}
+
+// Model returns the model name and number
+func (d *Description) Model() string {
+	return strings.TrimSpace(strings.TrimSpace(d.ModelName) + " " + strings.TrimSpace(d.ModelNumber))
+}
+
+// AllServices returns the service types of the device and its embedded
+// devices, without duplicates
+func (d *Description) AllServices() []string {
+	seen := make(map[string]bool)
+	services := make([]string, 0)
+	var walk func(device *Description)
+	walk = func(device *Description) {
+		for _, s := range device.Services {
+			if s.ServiceType != "" && !seen[s.ServiceType] {
+				seen[s.ServiceType] = true
+				services = append(services, s.ServiceType)
+			}
+		}
+		for i := range device.Devices {
+			walk(&device.Devices[i])
+		}
+	}
+	walk(d)
+	return services
+}
+
+// parseDescription decodes a device description document
+func parseDescription(r io.Reader) (*Description, error) {
+	var doc descriptionDocument
+	decoder := xml.NewDecoder(r)
+	// Some devices declare encodings other than UTF-8 for plain ASCII documents
+	decoder.CharsetReader = func(charset string, input io.Reader) (io.Reader, error) {
+		return input, nil
+	}
+	if err := decoder.Decode(&doc); err != nil {
+		return nil, err
+	}
+	return &doc.Device, nil
+}
+
+// fetchDescription downloads and parses the description at location
+func fetchDescription(ctx context.Context, client *http.Client, location string) (*Description, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, location, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("ssdp: %s: %s", location, resp.Status)
+	}
+	return parseDescription(io.LimitReader(resp.Body, maxDescriptionSize))
+}
+
+// checkLocation only allows description URLs on the local network: plain
+// HTTP to an address literal that is private, link-local, loopback or the
+// announcing host itself. Announcements are unauthenticated, so anything on
+// the link could otherwise make the scanner fetch arbitrary URLs.
+func checkLocation(location string, source net.IP) error {
+	u, err := url.Parse(location)
+	if err != nil {
+		return err
+	}
+	if u.Scheme != "http" {
+		return fmt.Errorf("ssdp: unsupported location scheme %q", u.Scheme)
+	}
+	ip := net.ParseIP(u.Hostname())
+	if ip == nil {
+		return errors.New("ssdp: location host is not an address")
+	}
+	if ip.Equal(source) || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLoopback() {
+		return nil
+	}
+	return fmt.Errorf("ssdp: location %s is outside the local network", ip)
+}
+
+// shortType returns the last parts of a URN, e.g. "MediaRenderer:1" for
+// "urn:schemas-upnp-org:device:MediaRenderer:1"
+func shortType(urn string) string {
+	parts := strings.Split(urn, ":")
+	if len(parts) >= 2 && strings.HasPrefix(urn, "urn:") {
+		return strings.Join(parts[len(parts)-2:], ":")
+	}
+	return urn
+}
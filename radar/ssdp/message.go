@@ -0,0 +1,103 @@
+package ssdp
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Notification subtypes
+const (
+	ntsAlive  = "ssdp:alive"
+	ntsByeBye = "ssdp:byebye"
+	ntsUpdate = "ssdp:update"
+)
+
+// defaultMaxAge is assumed when an announcement has no usable max-age
+const defaultMaxAge = 30 * time.Minute
+
+// announcement is an M-SEARCH response or a NOTIFY message
+type announcement struct {
+	uuid     string // Device UUID from the USN
+	target   string // ST or NT: the device or service type announced
+	location string // URL of the device description
+	server   string
+	nts      string // Notification subtype; ntsAlive for search responses
+	maxAge   time.Duration
+}
+
+// encodeSearch builds an M-SEARCH request
+func encodeSearch(group, target string, mx int) []byte {
+	return []byte(fmt.Sprintf("M-SEARCH * HTTP/1.1\r\n"+
+		"HOST: %s\r\n"+
+		"MAN: \"ssdp:discover\"\r\n"+
+		"MX: %d\r\n"+
+		"ST: %s\r\n"+
+		"USER-AGENT: radar/1.0 UPnP/1.1\r\n"+
+		"\r\n", group, mx, target))
+}
+
+// parseAnnouncement parses an M-SEARCH response or NOTIFY request. Other
+// messages, such as M-SEARCH requests from other control points, are rejected.
+func parseAnnouncement(data []byte) (announcement, bool) {
+	reader := bufio.NewReader(bytes.NewReader(data))
+	var header http.Header
+	var a announcement
+
+	if bytes.HasPrefix(data, []byte("HTTP/")) {
+		resp, err := http.ReadResponse(reader, nil)
+		if err != nil || resp.StatusCode != http.StatusOK {
+			return announcement{}, false
+		}
+		resp.Body.Close()
+		header = resp.Header
+		a.target = header.Get("ST")
+		a.nts = ntsAlive
+	} else {
+		req, err := http.ReadRequest(reader)
+		if err != nil || req.Method != "NOTIFY" {
+			return announcement{}, false
+		}
+		header = req.Header
+		a.target = header.Get("NT")
+		a.nts = header.Get("NTS")
+	}
+
+	a.uuid = usnUUID(header.Get("USN"))
+	if a.uuid == "" {
+		return announcement{}, false
+	}
+	a.location = header.Get("LOCATION")
+	a.server = header.Get("SERVER")
+	a.maxAge = parseMaxAge(header.Get("CACHE-CONTROL"))
+	return a, true
+}
+
+// usnUUID returns the UUID of a USN such as
+// "uuid:2f402f80-da50-11e1-9b23-00178809ea66::upnp:rootdevice"
+func usnUUID(usn string) string {
+	usn = strings.TrimSpace(usn)
+	if !strings.HasPrefix(strings.ToLower(usn), "uuid:") {
+		return ""
+	}
+	uuid, _, _ := strings.Cut(usn[len("uuid:"):], "::")
+	return strings.ToLower(uuid)
+}
+
+// parseMaxAge reads the max-age directive of a CACHE-CONTROL header
+func parseMaxAge(value string) time.Duration {
+	for _, directive := range strings.Split(value, ",") {
+		name, age, ok := strings.Cut(strings.TrimSpace(directive), "=")
+		if !ok || !strings.EqualFold(strings.TrimSpace(name), "max-age") {
+			continue
+		}
+		if seconds, err := strconv.Atoi(strings.TrimSpace(age)); err == nil && seconds > 0 {
+			return time.Duration(seconds) * time.Second
+		}
+	}
+	return defaultMaxAge
+}
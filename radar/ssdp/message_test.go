@@ -0,0 +1,79 @@
+package ssdp
+
+import (
+	"net"
+	"testing"
+	"time"
+)
+
+func TestParseAnnouncement(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want announcement
+		ok   bool
+	}{
+		{
+			name: "search response",
+			data: "HTTP/1.1 200 OK\r\nCache-Control: max-age = 120\r\nLocation: http://192.0.2.1:1400/desc.xml\r\n" +
+				"Server: Linux UPnP/1.0 Sonos/70.3\r\nST: upnp:rootdevice\r\nUSN: uuid:RINCON_1234::upnp:rootdevice\r\n\r\n",
+			want: announcement{uuid: "rincon_1234", target: "upnp:rootdevice", location: "http://192.0.2.1:1400/desc.xml",
+				server: "Linux UPnP/1.0 Sonos/70.3", nts: ntsAlive, maxAge: 2 * time.Minute},
+			ok: true,
+		},
+		{
+			name: "notify without max-age",
+			data: "NOTIFY * HTTP/1.1\r\nHOST: 239.255.255.250:1900\r\nNT: urn:schemas-upnp-org:device:Printer:1\r\n" +
+				"NTS: ssdp:byebye\r\nUSN: uuid:abc\r\n\r\n",
+			want: announcement{uuid: "abc", target: "urn:schemas-upnp-org:device:Printer:1", nts: ntsByeBye, maxAge: defaultMaxAge},
+			ok:   true,
+		},
+		{
+			name: "search request from another control point",
+			data: string(encodeSearch(Group, "ssdp:all", 1)),
+		},
+		{
+			name: "error response",
+			data: "HTTP/1.1 404 Not Found\r\nUSN: uuid:abc\r\n\r\n",
+		},
+		{
+			name: "USN without a UUID",
+			data: "HTTP/1.1 200 OK\r\nST: upnp:rootdevice\r\nUSN: upnp:rootdevice\r\n\r\n",
+		},
+		{
+			name: "garbage",
+			data: "\x00\x01not http",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := parseAnnouncement([]byte(tt.data))
+			if ok != tt.ok || got != tt.want {
+				t.Errorf("parseAnnouncement = %+v, %v\nwant %+v, %v", got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
+
+func TestCheckLocation(t *testing.T) {
+	source := net.ParseIP("203.0.113.9")
+	tests := []struct {
+		location string
+		ok       bool
+	}{
+		{"http://192.168.1.20:49152/desc.xml", true},
+		{"http://10.0.0.1/rootDesc.xml", true},
+		{"http://127.0.0.1:8080/d.xml", true},
+		{"http://[fe80::1]:80/d.xml", true},
+		{"http://203.0.113.9/d.xml", true}, // The announcing host itself
+		{"http://198.51.100.7/d.xml", false},
+		{"https://192.168.1.20/desc.xml", false},
+		{"http://router.local/desc.xml", false},
+		{"file:///etc/passwd", false},
+	}
+	for _, tt := range tests {
+		if err := checkLocation(tt.location, source); (err == nil) != tt.ok {
+			t.Errorf("checkLocation(%q) = %v, want allowed %v", tt.location, err, tt.ok)
+		}
+	}
+}
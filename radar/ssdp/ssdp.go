@@ -0,0 +1,468 @@
+// Package ssdp discovers UPnP devices on the local network with the Simple
+// Service Discovery Protocol and reads their device descriptions.
+package ssdp
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/e6a5/radar/radar/model"
+	"github.com/e6a5/radar/radar/scanner"
+)
+
+// Group is the SSDP multicast group and port
+const Group = "239.255.255.250:1900"
+
+const (
+	searchInterval = time.Minute             // Time between M-SEARCH requests
+	searchMX       = 1                       // Seconds devices may wait before answering
+	searchWindow   = 1500 * time.Millisecond // Time the first scan waits for answers
+	fetchTimeout   = 5 * time.Second
+	retryFetch     = 5 * time.Minute // Time before retrying a failed description fetch
+)
+
+// Options selects where the scanner searches and listens
+type Options struct {
+	Interface    string       // Interface to search and listen on; the system default if empty
+	Group        string       // Multicast group and port
+	SearchTarget string       // M-SEARCH target, e.g. "ssdp:all" or "upnp:rootdevice"
+	Client       *http.Client // Fetches device descriptions
+}
+
+// DefaultOptions searches for all devices on the standard group
+func DefaultOptions() Options {
+	return Options{
+		Group:        Group,
+		SearchTarget: "ssdp:all",
+		Client:       &http.Client{Timeout: fetchTimeout},
+	}
+}
+
+// Device is a UPnP device heard on the link
+type Device struct {
+	UUID        string
+	Address     net.IP   // Where the device's announcements come from
+	Location    string   // Description URL
+	Server      string   // SERVER header: OS, UPnP version and product
+	Targets     []string // Device and service types announced
+	Description *Description
+	LastSeen    time.Time
+	Expires     time.Time
+
+	fetching  bool
+	fetchedAt time.Time // When the description was last fetched or attempted
+	fetchedOf string    // Location the description came from
+}
+
+// SSDPScanner searches for UPnP devices, listens for their announcements and
+// fetches their device descriptions
+type SSDPScanner struct {
+	config     *scanner.Config
+	options    Options
+	group      *net.UDPAddr
+	search     *net.UDPConn // Sends M-SEARCH and receives the unicast answers
+	notify     *net.UDPConn // Receives NOTIFY messages on the group port; nil if the port is taken
+	devices    map[string]*Device
+	started    bool
+	closed     bool
+	lastSearch time.Time
+	mutex      sync.Mutex
+}
+
+// NewSSDPScanner creates a new SSDP scanner
+func NewSSDPScanner(config *scanner.Config, options Options) *SSDPScanner {
+	if options.Client == nil {
+		options.Client = &http.Client{Timeout: fetchTimeout}
+	}
+	if options.SearchTarget == "" {
+		options.SearchTarget = "ssdp:all"
+	}
+	return &SSDPScanner{
+		config:  config,
+		options: options,
+		devices: make(map[string]*Device),
+	}
+}
+
+// Name returns the scanner name
+func (s *SSDPScanner) Name() string {
+	return "SSDP Device Scanner"
+}
+
+// IsAvailable checks for an interface that can send multicast
+func (s *SSDPScanner) IsAvailable() bool {
+	if s.options.Interface != "" {
+		_, err := net.InterfaceByName(s.options.Interface)
+		return err == nil
+	}
+	interfaces, err := net.Interfaces()
+	if err != nil {
+		return false
+	}
+	for _, iface := range interfaces {
+		if iface.Flags&net.FlagUp != 0 && iface.Flags&net.FlagMulticast != 0 && iface.Flags&net.FlagLoopback == 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// Scan searches for devices and returns one signal per root device
+func (s *SSDPScanner) Scan(ctx context.Context) ([]model.Signal, error) {
+	first, err := s.start()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	s.sendSearch(now)
+
+	// Give devices a moment to answer the first search and serve their descriptions
+	if first {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(searchWindow):
+		}
+		now = time.Now()
+	}
+
+	return s.signals(now), nil
+}
+
+// Devices returns the devices heard so far, sorted by UUID
+func (s *SSDPScanner) Devices() []Device {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.expire(time.Now())
+
+	devices := make([]Device, 0, len(s.devices))
+	for _, d := range s.devices {
+		copied := *d
+		copied.Targets = append([]string(nil), d.Targets...)
+		devices = append(devices, copied)
+	}
+	sort.Slice(devices, func(i, j int) bool {
+		return devices[i].UUID < devices[j].UUID
+	})
+	return devices
+}
+
+// start opens the sockets once, reporting whether this call did
+func (s *SSDPScanner) start() (bool, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.closed {
+		return false, net.ErrClosed
+	}
+	if s.started {
+		return false, nil
+	}
+
+	var iface *net.Interface
+	if s.options.Interface != "" {
+		found, err := net.InterfaceByName(s.options.Interface)
+		if err != nil {
+			return false, err
+		}
+		iface = found
+	}
+	group, err := net.ResolveUDPAddr("udp4", s.options.Group)
+	if err != nil {
+		return false, err
+	}
+	if !group.IP.IsMulticast() {
+		return false, errors.New("ssdp: group is not a multicast address")
+	}
+
+	// A multicast listener on port 0 is an ephemeral socket that sends on the
+	// chosen interface and receives the unicast search answers
+	search, err := net.ListenMulticastUDP("udp4", iface, &net.UDPAddr{IP: group.IP})
+	if err != nil {
+		return false, err
+	}
+	s.search = search
+	s.group = group
+	go s.listen(search)
+
+	// Announcements need the group port, which another control point may hold
+	if notify, err := net.ListenMulticastUDP("udp4", iface, group); err == nil {
+		s.notify = notify
+		go s.listen(notify)
+	}
+
+	s.started = true
+	return true, nil
+}
+
+// sendSearch sends an M-SEARCH every searchInterval
+func (s *SSDPScanner) sendSearch(now time.Time) {
+	s.mutex.Lock()
+	if s.search == nil || now.Sub(s.lastSearch) < searchInterval {
+		s.mutex.Unlock()
+		return
+	}
+	s.lastSearch = now
+	search := s.search
+	s.mutex.Unlock()
+
+	// UDP is lossy; send the request twice as UPnP recommends
+	payload := encodeSearch(s.group.String(), s.options.SearchTarget, searchMX)
+	for i := 0; i < 2; i++ {
+		search.WriteToUDP(payload, s.group)
+	}
+}
+
+// Close closes the sockets, which stops the listeners. A closed scanner
+// fails every later scan.
+func (s *SSDPScanner) Close() error {
+	s.mutex.Lock()
+	conns := []*net.UDPConn{s.search, s.notify}
+	s.search, s.notify = nil, nil
+	s.closed = true
+	s.mutex.Unlock()
+
+	var errs []error
+	for _, conn := range conns {
+		if conn == nil {
+			continue
+		}
+		if err := conn.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// listen reads announcements until the socket fails
+func (s *SSDPScanner) listen(conn *net.UDPConn) {
+	buf := make([]byte, 8192)
+	for {
+		n, src, err := conn.ReadFromUDP(buf)
+		if err != nil {
+			return
+		}
+		if a, ok := parseAnnouncement(buf[:n]); ok {
+			s.apply(a, src.IP, time.Now())
+		}
+	}
+}
+
+// apply records an announcement and fetches the device description when
+// its location is new
+func (s *SSDPScanner) apply(a announcement, source net.IP, now time.Time) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if a.nts == ntsByeBye {
+		delete(s.devices, a.uuid)
+		return
+	}
+	if a.nts != ntsAlive && a.nts != ntsUpdate {
+		return
+	}
+
+	device, ok := s.devices[a.uuid]
+	if !ok {
+		device = &Device{UUID: a.uuid}
+		s.devices[a.uuid] = device
+	}
+	device.Address = source
+	device.LastSeen = now
+	if expires := now.Add(a.maxAge); expires.After(device.Expires) {
+		device.Expires = expires
+	}
+	if a.server != "" {
+		device.Server = a.server
+	}
+	if a.target != "" && !containsString(device.Targets, a.target) {
+		device.Targets = append(device.Targets, a.target)
+	}
+	if a.location != "" {
+		device.Location = a.location
+	}
+
+	stale := device.fetchedOf != device.Location || (device.Description == nil && now.Sub(device.fetchedAt) >= retryFetch)
+	if device.Location != "" && !device.fetching && stale {
+		if err := checkLocation(device.Location, source); err == nil {
+			device.fetching = true
+			go s.fetch(device.UUID, device.Location)
+		}
+	}
+}
+
+// fetch downloads a device description and stores it on the device
+func (s *SSDPScanner) fetch(uuid, location string) {
+	ctx, cancel := context.WithTimeout(context.Background(), fetchTimeout)
+	defer cancel()
+	description, err := fetchDescription(ctx, s.options.Client, location)
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	device, ok := s.devices[uuid]
+	if !ok {
+		return
+	}
+	device.fetching = false
+	device.fetchedAt = time.Now()
+	device.fetchedOf = location
+	if err == nil {
+		device.Description = description
+	}
+}
+
+// expire drops devices whose announcements ran out. Callers must hold the lock.
+func (s *SSDPScanner) expire(now time.Time) {
+	for uuid, device := range s.devices {
+		if now.After(device.Expires) {
+			delete(s.devices, uuid)
+		}
+	}
+}
+
+// signals converts the devices into signals, most recently heard first.
+// Embedded devices announce their own UUIDs; those described by another
+// device's description are folded into it.
+func (s *SSDPScanner) signals(now time.Time) []model.Signal {
+	s.mutex.Lock()
+	s.expire(now)
+	embedded := make(map[string]bool)
+	for _, device := range s.devices {
+		if device.Description != nil {
+			markEmbedded(device.Description.Devices, embedded)
+		}
+	}
+	devices := make([]Device, 0, len(s.devices))
+	for uuid, device := range s.devices {
+		if !embedded[uuid] {
+			devices = append(devices, *device)
+		}
+	}
+	s.mutex.Unlock()
+
+	sort.Slice(devices, func(i, j int) bool {
+		if !devices[i].LastSeen.Equal(devices[j].LastSeen) {
+			return devices[i].LastSeen.After(devices[j].LastSeen)
+		}
+		return devices[i].UUID < devices[j].UUID
+	})
+
+	signals := make([]model.Signal, 0, len(devices))
+	for i := range devices {
+		signals = append(signals, deviceSignal(&devices[i], s.config, now))
+		if len(signals) >= s.config.MaxSignals {
+			break
+		}
+	}
+	return signals
+}
+
+// markEmbedded records the UUIDs of embedded devices
+func markEmbedded(devices []Description, embedded map[string]bool) {
+	for i := range devices {
+		if uuid := usnUUID(devices[i].UDN); uuid != "" {
+			embedded[uuid] = true
+		}
+		markEmbedded(devices[i].Devices, embedded)
+	}
+}
+
+// containsString reports whether list contains value
+func containsString(list []string, value string) bool {
+	for _, v := range list {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// deviceSignal converts a device into a signal
+func deviceSignal(d *Device, config *scanner.Config, now time.Time) model.Signal {
+	name := d.Address.String()
+	deviceType := ""
+	attrs := model.Attributes{}
+	if desc := d.Description; desc != nil {
+		if desc.FriendlyName != "" {
+			name = desc.FriendlyName
+		}
+		deviceType = shortType(desc.DeviceType)
+		services := make([]string, 0)
+		for _, service := range desc.AllServices() {
+			services = append(services, shortType(service))
+		}
+		attrs.Set(model.AttrVendor, desc.Manufacturer)
+		attrs.Set("model", desc.Model())
+		attrs.Set("description", desc.ModelDescription)
+		attrs.Set("serial", desc.SerialNumber)
+		attrs.Set("device_type", deviceType)
+		attrs.Set(model.AttrServices, strings.Join(services, ", "))
+		attrs.Set("presentation_url", desc.PresentationURL)
+	} else if d.Server != "" {
+		name = d.Server
+	}
+	attrs.Set(model.AttrIP, d.Address.String())
+	attrs.Set("uuid", d.UUID)
+	attrs.Set("server", d.Server)
+	attrs.Set("location", d.Location)
+
+	key := d.UUID
+	signal := model.Signal{
+		ID:       "ssdp:" + key,
+		Type:     "IoT",
+		Icon:     deviceIcon(deviceType, d.Targets),
+		Name:     name,
+		Category: model.CategoryIoT,
+		Severity: model.SeverityNormal,
+		Strength: d.strength(now),
+		// Where the lan scanner places hosts of unknown latency
+		Distance:    config.LatencyDistance(0),
+		Angle:       config.Bearing("IoT", "ssdp:"+key),
+		Phase:       0,
+		Lifetime:    now,
+		LastSeen:    now,
+		Persistence: 1.0,
+		History:     make([]model.PositionHistory, 0, 20),
+		MaxHistory:  20,
+		Attributes:  attrs,
+	}
+
+	signal.AddToHistory(signal.Distance, signal.Angle, signal.Strength, true, now)
+	return signal
+}
+
+// deviceIcon picks a symbol for the device type, falling back to the
+// announced types when there is no description
+func deviceIcon(deviceType string, targets []string) string {
+	types := append([]string{deviceType}, targets...)
+	for _, t := range types {
+		switch {
+		case strings.Contains(t, "MediaRenderer"), strings.Contains(t, "MediaServer"):
+			return "▶"
+		case strings.Contains(t, "Printer"):
+			return "⎙"
+		case strings.Contains(t, "InternetGatewayDevice"), strings.Contains(t, "WANDevice"):
+			return "⇄"
+		}
+	}
+	return "◇"
+}
+
+// strength fades from 90 for a device heard this scan to 30 for one silent
+// for five minutes
+func (d *Device) strength(now time.Time) int {
+	age := now.Sub(d.LastSeen).Minutes() / 5
+	if age > 1 {
+		age = 1
+	}
+	if age < 0 {
+		age = 0
+	}
+	return int(90 - 60*age)
+}
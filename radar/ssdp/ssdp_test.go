@@ -0,0 +1,361 @@
+package ssdp
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/e6a5/radar/radar/model"
+	"github.com/e6a5/radar/radar/scanner"
+)
+
+const (
+	testTV      = "5c1b0a2e-0000-4000-8000-000000000001"
+	testTuner   = "5c1b0a2e-0000-4000-8000-000000000002" // Embedded in the TV
+	testPrinter = "5c1b0a2e-0000-4000-8000-000000000003"
+)
+
+// tvDescription is a media renderer with an embedded tuner
+const tvDescription = `<?xml version="1.0" encoding="ISO-8859-1"?>
+<root xmlns="urn:schemas-upnp-org:device-1-0">
+  <specVersion><major>1</major><minor>0</minor></specVersion>
+  <device>
+    <deviceType>urn:schemas-upnp-org:device:MediaRenderer:1</deviceType>
+    <friendlyName>Living Room TV</friendlyName>
+    <manufacturer>Acme Electronics</manufacturer>
+    <modelName>Vista</modelName>
+    <modelNumber>55X</modelNumber>
+    <serialNumber>SN-0042</serialNumber>
+    <UDN>uuid:` + testTV + `</UDN>
+    <serviceList>
+      <service>
+        <serviceType>urn:schemas-upnp-org:service:RenderingControl:1</serviceType>
+        <serviceId>urn:upnp-org:serviceId:RenderingControl</serviceId>
+      </service>
+      <service>
+        <serviceType>urn:schemas-upnp-org:service:AVTransport:1</serviceType>
+        <serviceId>urn:upnp-org:serviceId:AVTransport</serviceId>
+      </service>
+    </serviceList>
+    <deviceList>
+      <device>
+        <deviceType>urn:schemas-upnp-org:device:Tuner:1</deviceType>
+        <UDN>uuid:` + testTuner + `</UDN>
+        <serviceList>
+          <service><serviceType>urn:schemas-upnp-org:service:AVTransport:1</serviceType></service>
+          <service><serviceType>urn:schemas-upnp-org:service:Tuner:1</serviceType></service>
+        </serviceList>
+      </device>
+    </deviceList>
+    <presentationURL>http://192.0.2.20/</presentationURL>
+  </device>
+</root>`
+
+// printerDescription is a printer known only from its NOTIFY announcements
+const printerDescription = `<?xml version="1.0"?>
+<root xmlns="urn:schemas-upnp-org:device-1-0">
+  <device>
+    <deviceType>urn:schemas-upnp-org:device:Printer:1</deviceType>
+    <friendlyName>Hallway Printer</friendlyName>
+    <manufacturer>Inkwell</manufacturer>
+    <modelName>LaserJet</modelName>
+    <UDN>uuid:` + testPrinter + `</UDN>
+  </device>
+</root>`
+
+// descriptionServer serves the device descriptions and counts the requests
+func descriptionServer(t *testing.T) (*httptest.Server, func(path string) int) {
+	t.Helper()
+	var mutex sync.Mutex
+	requests := make(map[string]int)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mutex.Lock()
+		requests[r.URL.Path]++
+		mutex.Unlock()
+		switch r.URL.Path {
+		case "/tv.xml":
+			w.Header().Set("Content-Type", "text/xml")
+			fmt.Fprint(w, tvDescription)
+		case "/printer.xml":
+			w.Header().Set("Content-Type", "text/xml")
+			fmt.Fprint(w, printerDescription)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server, func(path string) int {
+		mutex.Lock()
+		defer mutex.Unlock()
+		return requests[path]
+	}
+}
+
+// fakeDevice answers M-SEARCH requests on a loopback group for the TV and
+// its embedded tuner, and sends NOTIFY messages for the printer
+type fakeDevice struct {
+	t        *testing.T
+	conn     *net.UDPConn
+	group    *net.UDPAddr
+	location string // Base URL of the description server
+
+	mutex    sync.Mutex
+	searches []string // ST of every M-SEARCH received
+}
+
+// loopbackGroup returns an SSDP group on a free port and the loopback
+// interface to join it on
+func loopbackGroup(t *testing.T) (*net.Interface, *net.UDPAddr) {
+	t.Helper()
+	interfaces, err := net.Interfaces()
+	if err != nil {
+		t.Skipf("no interfaces: %v", err)
+	}
+	var lo *net.Interface
+	for i := range interfaces {
+		if interfaces[i].Flags&net.FlagLoopback != 0 && interfaces[i].Flags&net.FlagUp != 0 {
+			lo = &interfaces[i]
+		}
+	}
+	if lo == nil {
+		t.Skip("no loopback interface")
+	}
+	probe, err := net.ListenUDP("udp4", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Skipf("no IPv4 loopback: %v", err)
+	}
+	port := probe.LocalAddr().(*net.UDPAddr).Port
+	probe.Close()
+	return lo, &net.UDPAddr{IP: net.IPv4(239, 255, 255, 250), Port: port}
+}
+
+// newFakeDevice joins the group and answers searches until the test ends
+func newFakeDevice(t *testing.T, lo *net.Interface, group *net.UDPAddr, location string) *fakeDevice {
+	t.Helper()
+	conn, err := net.ListenMulticastUDP("udp4", lo, group)
+	if err != nil {
+		t.Skipf("cannot join %s on %s: %v", group, lo.Name, err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	d := &fakeDevice{t: t, conn: conn, group: group, location: location}
+	go d.serve()
+	return d
+}
+
+func (d *fakeDevice) serve() {
+	buf := make([]byte, 8192)
+	for {
+		n, src, err := d.conn.ReadFromUDP(buf)
+		if err != nil {
+			return
+		}
+		req, err := http.ReadRequest(bufio.NewReader(bytes.NewReader(buf[:n])))
+		if err != nil || req.Method != "M-SEARCH" {
+			continue // Our own NOTIFY messages come back on the group
+		}
+		if req.Header.Get("MAN") != `"ssdp:discover"` || req.Header.Get("MX") == "" {
+			d.t.Errorf("M-SEARCH headers = %v", req.Header)
+			continue
+		}
+		d.mutex.Lock()
+		d.searches = append(d.searches, req.Header.Get("ST"))
+		d.mutex.Unlock()
+
+		// The root device and its embedded device each answer ssdp:all
+		for _, answer := range []struct{ uuid, target string }{
+			{testTV, "upnp:rootdevice"},
+			{testTV, "urn:schemas-upnp-org:device:MediaRenderer:1"},
+			{testTuner, "urn:schemas-upnp-org:device:Tuner:1"},
+		} {
+			d.conn.WriteToUDP([]byte("HTTP/1.1 200 OK\r\n"+
+				"CACHE-CONTROL: max-age=1800\r\n"+
+				"EXT:\r\n"+
+				"LOCATION: "+d.location+"/tv.xml\r\n"+
+				"SERVER: Linux/5.10 UPnP/1.0 Vista/3.1\r\n"+
+				"ST: "+answer.target+"\r\n"+
+				"USN: uuid:"+answer.uuid+"::"+answer.target+"\r\n"+
+				"\r\n"), src)
+		}
+	}
+}
+
+// notify announces the printer on the group
+func (d *fakeDevice) notify(nts string) {
+	d.conn.WriteToUDP([]byte("NOTIFY * HTTP/1.1\r\n"+
+		"HOST: "+d.group.String()+"\r\n"+
+		"CACHE-CONTROL: max-age=900\r\n"+
+		"LOCATION: "+d.location+"/printer.xml\r\n"+
+		"NT: urn:schemas-upnp-org:device:Printer:1\r\n"+
+		"NTS: "+nts+"\r\n"+
+		"SERVER: RTOS/1.0 UPnP/1.0 Inkwell/2.0\r\n"+
+		"USN: uuid:"+testPrinter+"::urn:schemas-upnp-org:device:Printer:1\r\n"+
+		"\r\n"), d.group)
+}
+
+// Searches returns the search targets received so far
+func (d *fakeDevice) Searches() []string {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	return append([]string(nil), d.searches...)
+}
+
+// scanUntil scans until done accepts the signals or the context ends
+func scanUntil(ctx context.Context, t *testing.T, s *SSDPScanner, done func(map[string]model.Signal) bool) map[string]model.Signal {
+	t.Helper()
+	var bySignal map[string]model.Signal
+	for ctx.Err() == nil {
+		signals, err := s.Scan(ctx)
+		if err != nil {
+			t.Fatalf("Scan: %v", err)
+		}
+		bySignal = make(map[string]model.Signal)
+		for _, signal := range signals {
+			bySignal[signal.ID] = signal
+		}
+		if done(bySignal) {
+			return bySignal
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	t.Fatalf("signals never settled: %+v", bySignal)
+	return nil
+}
+
+func TestSSDPScannerLoopback(t *testing.T) {
+	server, requests := descriptionServer(t)
+	lo, group := loopbackGroup(t)
+	device := newFakeDevice(t, lo, group, server.URL)
+
+	s := NewSSDPScanner(&scanner.Config{MaxSignals: 10, MaxScanRange: 1000}, Options{
+		Interface: lo.Name,
+		Group:     group.String(),
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// The TV answers the search, the printer announces itself
+	described := func(signals map[string]model.Signal) bool {
+		return signals["ssdp:"+testTV].Attributes.Get(model.AttrVendor) != "" &&
+			signals["ssdp:"+testPrinter].Attributes.Get(model.AttrVendor) != ""
+	}
+	signals := scanUntil(ctx, t, s, func(signals map[string]model.Signal) bool {
+		if signals["ssdp:"+testPrinter].ID == "" {
+			device.notify(ntsAlive)
+		}
+		return described(signals)
+	})
+
+	if searches := device.Searches(); len(searches) == 0 || searches[0] != "ssdp:all" {
+		t.Errorf("searches = %q, want ssdp:all", searches)
+	}
+	if len(signals) != 2 {
+		t.Errorf("got %d signals, want the TV with its tuner folded in and the printer", len(signals))
+	}
+
+	tests := []struct {
+		uuid, name, icon string
+		attributes       map[string]string
+	}{
+		{
+			uuid: testTV,
+			name: "Living Room TV",
+			icon: "▶",
+			attributes: map[string]string{
+				model.AttrVendor:   "Acme Electronics",
+				"model":            "Vista 55X",
+				"serial":           "SN-0042",
+				"device_type":      "MediaRenderer:1",
+				model.AttrServices: "RenderingControl:1, AVTransport:1, Tuner:1",
+				"server":           "Linux/5.10 UPnP/1.0 Vista/3.1",
+				"location":         server.URL + "/tv.xml",
+			},
+		},
+		{
+			uuid: testPrinter,
+			name: "Hallway Printer",
+			icon: "⎙",
+			attributes: map[string]string{
+				model.AttrVendor: "Inkwell",
+				"model":          "LaserJet",
+				"device_type":    "Printer:1",
+				"location":       server.URL + "/printer.xml",
+			},
+		},
+	}
+	for _, tt := range tests {
+		signal := signals["ssdp:"+tt.uuid]
+		if signal.Name != tt.name || signal.Icon != tt.icon {
+			t.Errorf("%s = %q %s, want %q %s", tt.uuid, signal.Name, signal.Icon, tt.name, tt.icon)
+		}
+		for key, want := range tt.attributes {
+			if got := signal.Attributes.Get(key); got != want {
+				t.Errorf("%s %s = %q, want %q", tt.name, key, got, want)
+			}
+		}
+		if want := s.config.LatencyDistance(0); signal.Distance != want {
+			t.Errorf("%s distance = %g, want %g like a LAN host of unknown latency", tt.name, signal.Distance, want)
+		}
+	}
+
+	// Every search is answered twice over, but each device fetches its
+	// description once: the TV and the tuner announced inside it
+	if n := requests("/tv.xml"); n > 2 {
+		t.Errorf("fetched the TV's description %d times, want once per device", n)
+	}
+	var tv Device
+	for _, d := range s.Devices() {
+		if d.UUID == testTV {
+			tv = d
+		}
+	}
+	if strings.Join(tv.Targets, " ") != "upnp:rootdevice urn:schemas-upnp-org:device:MediaRenderer:1" {
+		t.Errorf("TV targets = %q", tv.Targets)
+	}
+
+	// The printer says goodbye
+	scanUntil(ctx, t, s, func(signals map[string]model.Signal) bool {
+		if _, ok := signals["ssdp:"+testPrinter]; ok {
+			device.notify(ntsByeBye)
+			return false
+		}
+		_, ok := signals["ssdp:"+testTV]
+		return ok
+	})
+}
+
+func TestSSDPScannerClose(t *testing.T) {
+	lo, group := loopbackGroup(t)
+	s := NewSSDPScanner(&scanner.Config{MaxSignals: 10, MaxScanRange: 1000}, Options{
+		Interface: lo.Name,
+		Group:     group.String(),
+	})
+	if _, err := s.start(); err != nil {
+		t.Fatalf("start: %v", err)
+	}
+	conns := []*net.UDPConn{s.search, s.notify}
+
+	if err := s.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	for _, conn := range conns {
+		if conn == nil {
+			continue
+		}
+		if err := conn.SetReadDeadline(time.Now()); !errors.Is(err, net.ErrClosed) {
+			t.Errorf("socket %s still open after Close: %v", conn.LocalAddr(), err)
+		}
+	}
+	if _, err := s.Scan(context.Background()); !errors.Is(err, net.ErrClosed) {
+		t.Errorf("Scan after Close = %v, want net.ErrClosed", err)
+	}
+}
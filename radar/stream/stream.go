@@ -0,0 +1,79 @@
+// Package stream encodes radar signals as JSON lines, one object per
+// observation, so radar output can be piped into other tools.
+package stream
+
+import (
+	"encoding/json"
+	"io"
+	"math"
+	"time"
+
+	"github.com/e6a5/radar/radar/model"
+)
+
+// Observation is the JSON form of one signal observation
+type Observation struct {
+	ID           string            `json:"id"`
+	Type         string            `json:"type"`
+	Name         string            `json:"name"`
+	Category     string            `json:"category"`
+	Strength     int               `json:"strength"`
+	Distance     float64           `json:"distance"`
+	DistanceLow  float64           `json:"distance_low,omitempty"` // Confidence interval around Distance
+	DistanceHigh float64           `json:"distance_high,omitempty"`
+	Bearing      float64           `json:"bearing"` // Degrees clockwise from east (3 o'clock) as drawn; screen y grows downwards, so 90 is straight down
+	FirstSeen    time.Time         `json:"first_seen"`
+	LastSeen     time.Time         `json:"last_seen"`
+	Timestamp    time.Time         `json:"timestamp"`
+	Metadata     map[string]string `json:"metadata,omitempty"`
+}
+
+// NewObservation converts a signal into an observation stamped with now
+func NewObservation(s model.Signal, now time.Time) Observation {
+	return Observation{
+		ID:           s.Key(),
+		Type:         s.Type,
+		Name:         s.Name,
+		Category:     s.Category.String(),
+		Strength:     s.Strength,
+		Distance:     model.Round(s.Distance, 2),
+		DistanceLow:  model.Round(s.DistanceLow, 2),
+		DistanceHigh: model.Round(s.DistanceHigh, 2),
+		Bearing:      model.Round(bearingDegrees(s.Angle), 1),
+		FirstSeen:    s.Lifetime,
+		LastSeen:     s.LastSeen,
+		Timestamp:    now,
+		Metadata:     s.Attributes.Clone(),
+	}
+}
+
+// Encoder writes observations as JSON lines
+type Encoder struct {
+	encoder *json.Encoder
+}
+
+// NewEncoder creates an encoder writing to w
+func NewEncoder(w io.Writer) *Encoder {
+	encoder := json.NewEncoder(w)
+	encoder.SetEscapeHTML(false)
+	return &Encoder{encoder: encoder}
+}
+
+// Encode writes one line per signal
+func (e *Encoder) Encode(signals []model.Signal, now time.Time) error {
+	for _, s := range signals {
+		if err := e.encoder.Encode(NewObservation(s, now)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// bearingDegrees converts a scope angle in radians to degrees within [0, 360)
+func bearingDegrees(angle float64) float64 {
+	degrees := math.Mod(angle*180/math.Pi, 360)
+	if degrees < 0 {
+		degrees += 360
+	}
+	return degrees
+}
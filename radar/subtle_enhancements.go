@@ -17,9 +17,9 @@ func (rd *Display) drawEnhancedSignalsSubtle(screen tcell.Screen) {
 		}
 
 		// Calculate signal position with enhanced smoothness
-		phaseOffset := float64(s.Phase) * 0.3
+		phaseOffset := float64(s.Phase) * 0.03 * rd.scopeRange()
 		distance := s.Distance + phaseOffset
-		scaleFactor := maxRadius / 10.0
+		scaleFactor := rd.scopeScale(maxRadius)
 
 		signalX := rd.centerX + int(math.Round(math.Cos(s.Angle)*distance*scaleFactor))
 		signalY := rd.centerY + int(math.Round(math.Sin(s.Angle)*distance*scaleFactor*0.5))
@@ -231,35 +231,33 @@ func (rd *Display) drawEnhancedRangeRingsSubtle(screen tcell.Screen) {
 		rd.drawCircleEnhanced(screen, rd.centerX, rd.centerY, radius, ringChar, ringColor)
 
 		// Enhanced range labels with better positioning
-		if ring*10 <= 40 {
-			label := fmt.Sprintf("%dm", ring*10)
-
-			// Try multiple label positions
-			labelPositions := []struct{ x, y int }{
-				{rd.centerX + int(radius) - len(label)/2, rd.centerY - 1},
-				{rd.centerX + int(radius) - len(label)/2, rd.centerY + int(radius*0.5) + 1},
-				{rd.centerX - int(radius) + 1, rd.centerY},
-				{rd.centerX, rd.centerY - int(radius*0.5) - 1},
-			}
+		label := rangeLabel(rd.scopeRange() * float64(ring) / 4)
+
+		// Try multiple label positions
+		labelPositions := []struct{ x, y int }{
+			{rd.centerX + int(radius) - len(label)/2, rd.centerY - 1},
+			{rd.centerX + int(radius) - len(label)/2, rd.centerY + int(radius*0.5) + 1},
+			{rd.centerX - int(radius) + 1, rd.centerY},
+			{rd.centerX, rd.centerY - int(radius*0.5) - 1},
+		}
 
-			for _, pos := range labelPositions {
-				if pos.x > 0 && pos.x < rd.width-len(label) &&
-					pos.y > 2 && pos.y < rd.height-3 {
+		for _, pos := range labelPositions {
+			if pos.x > 0 && pos.x < rd.width-len(label) &&
+				pos.y > 2 && pos.y < rd.height-3 {
 
-					// Draw subtle background
-					for i := 0; i < len(label); i++ {
-						screen.SetContent(pos.x+i, pos.y, ' ', nil,
-							tcell.StyleDefault.Background(tcell.ColorDarkSlateGray))
-					}
+				// Draw subtle background
+				for i := 0; i < len(label); i++ {
+					screen.SetContent(pos.x+i, pos.y, ' ', nil,
+						tcell.StyleDefault.Background(tcell.ColorDarkSlateGray))
+				}
 
-					// Draw label
-					for i, r := range label {
-						screen.SetContent(pos.x+i, pos.y, r, nil,
-							tcell.StyleDefault.Foreground(tcell.ColorYellow).
-								Background(tcell.ColorDarkSlateGray).Bold(true))
-					}
-					break
+				// Draw label
+				for i, r := range label {
+					screen.SetContent(pos.x+i, pos.y, r, nil,
+						tcell.StyleDefault.Foreground(tcell.ColorYellow).
+							Background(tcell.ColorDarkSlateGray).Bold(true))
 				}
+				break
 			}
 		}
 	}
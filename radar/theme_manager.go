@@ -1,6 +1,11 @@
 package radar
 
-import "github.com/gdamore/tcell/v2"
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+)
 
 // Theme selection for enhanced visuals
 type ThemeType int
@@ -48,6 +53,10 @@ func getClassicGreenTheme() RadarTheme {
 		SignalPoor:      tcell.ColorDarkGreen,
 		SignalConnected: tcell.ColorWhite,
 
+		Categories:     defaultCategoryColors(),
+		SeverityNotice: tcell.ColorYellow,
+		SeverityAlert:  tcell.ColorRed,
+
 		AccentPrimary:   tcell.ColorGreen,
 		AccentSecondary: tcell.ColorLime,
 		TextPrimary:     tcell.ColorWhite,
@@ -77,6 +86,10 @@ func getBlueNeonTheme() RadarTheme {
 		SignalPoor:      tcell.ColorNavy,
 		SignalConnected: tcell.ColorWhite,
 
+		Categories:     defaultCategoryColors(),
+		SeverityNotice: tcell.ColorYellow,
+		SeverityAlert:  tcell.ColorRed,
+
 		AccentPrimary:   tcell.ColorBlue,
 		AccentSecondary: tcell.ColorDarkBlue,
 		TextPrimary:     tcell.ColorWhite,
@@ -106,6 +119,10 @@ func getMilitaryTheme() RadarTheme {
 		SignalPoor:      tcell.ColorGray,
 		SignalConnected: tcell.ColorLime,
 
+		Categories:     defaultCategoryColors(),
+		SeverityNotice: tcell.ColorYellow,
+		SeverityAlert:  tcell.ColorRed,
+
 		AccentPrimary:   tcell.ColorOlive,
 		AccentSecondary: tcell.ColorYellow,
 		TextPrimary:     tcell.ColorWhite,
@@ -126,3 +143,19 @@ func GetThemeName(themeType ThemeType) string {
 		return "Modern Dark"
 	}
 }
+
+// ParseThemeType returns the theme selected by a name such as "classic-green"
+func ParseThemeType(name string) (ThemeType, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "", "modern", "modern-dark":
+		return ThemeModernDark, nil
+	case "classic", "classic-green":
+		return ThemeClassicGreen, nil
+	case "neon", "blue-neon":
+		return ThemeBlueNeon, nil
+	case "military":
+		return ThemeMilitary, nil
+	default:
+		return ThemeModernDark, fmt.Errorf("unknown theme %q (available: modern-dark, classic-green, blue-neon, military)", name)
+	}
+}
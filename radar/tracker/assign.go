@@ -0,0 +1,113 @@
+package tracker
+
+import "math"
+
+// infeasible stands in for an infinite cost inside the Hungarian algorithm
+const infeasible = 1e12
+
+// assign solves the assignment problem for a cost matrix whose infinite
+// entries are forbidden pairings. It returns the column assigned to each row,
+// or -1 for rows left unassigned, pairing as many rows as possible at the
+// lowest total cost.
+func assign(cost [][]float64) []int {
+	rows := len(cost)
+	if rows == 0 {
+		return nil
+	}
+	cols := len(cost[0])
+
+	// The Hungarian algorithm below needs at least as many columns as rows
+	transposed := rows > cols
+	n, m := rows, cols
+	if transposed {
+		n, m = cols, rows
+	}
+	at := func(i, j int) float64 {
+		if transposed {
+			i, j = j, i
+		}
+		c := cost[i][j]
+		if math.IsInf(c, 1) {
+			return infeasible
+		}
+		return c
+	}
+
+	match := hungarian(n, m, at)
+
+	result := make([]int, rows)
+	for i := range result {
+		result[i] = -1
+	}
+	for i, j := range match {
+		row, col := i, j
+		if transposed {
+			row, col = j, i
+		}
+		if !math.IsInf(cost[row][col], 1) {
+			result[row] = col
+		}
+	}
+	return result
+}
+
+// hungarian returns the minimum cost column for each of n rows of an n×m cost
+// matrix, n ≤ m, using the potentials form of the Hungarian algorithm
+func hungarian(n, m int, cost func(i, j int) float64) []int {
+	// Rows and columns are 1-based; column 0 is a sentinel
+	u := make([]float64, n+1)
+	v := make([]float64, m+1)
+	p := make([]int, m+1)   // Row matched to each column
+	way := make([]int, m+1) // Previous column on the augmenting path
+
+	for i := 1; i <= n; i++ {
+		p[0] = i
+		j0 := 0
+		minv := make([]float64, m+1)
+		used := make([]bool, m+1)
+		for j := range minv {
+			minv[j] = math.Inf(1)
+		}
+
+		for p[j0] != 0 {
+			used[j0] = true
+			i0, delta, j1 := p[j0], math.Inf(1), 0
+			for j := 1; j <= m; j++ {
+				if used[j] {
+					continue
+				}
+				if cur := cost(i0-1, j-1) - u[i0] - v[j]; cur < minv[j] {
+					minv[j] = cur
+					way[j] = j0
+				}
+				if minv[j] < delta {
+					delta = minv[j]
+					j1 = j
+				}
+			}
+			for j := 0; j <= m; j++ {
+				if used[j] {
+					u[p[j]] += delta
+					v[j] -= delta
+				} else {
+					minv[j] -= delta
+				}
+			}
+			j0 = j1
+		}
+
+		for j0 != 0 {
+			j1 := way[j0]
+			p[j0] = p[j1]
+			j0 = j1
+		}
+	}
+
+	match := make([]int, n)
+	for j := 1; j <= m; j++ {
+		if p[j] != 0 {
+			match[p[j]-1] = j - 1
+		}
+	}
+	return match
+}
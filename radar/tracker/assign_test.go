@@ -0,0 +1,146 @@
+package tracker
+
+import (
+	"math"
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+var inf = math.Inf(1)
+
+func TestAssign(t *testing.T) {
+	tests := []struct {
+		name string
+		cost [][]float64
+		want []int
+	}{
+		{"empty", nil, nil},
+		{"single", [][]float64{{3}}, []int{0}},
+		{
+			name: "square",
+			cost: [][]float64{
+				{4, 1, 3},
+				{2, 0, 5},
+				{3, 2, 2},
+			},
+			want: []int{1, 0, 2}, // 1 + 2 + 2, although row 1's cheapest is column 1
+		},
+		{
+			name: "more columns than rows",
+			cost: [][]float64{
+				{9, 2, 7, 1},
+				{6, 4, 3, 8},
+			},
+			want: []int{3, 2},
+		},
+		{
+			name: "more rows than columns",
+			cost: [][]float64{
+				{1, 5},
+				{2, 1},
+				{0.5, 0.6},
+			},
+			want: []int{-1, 1, 0}, // 1 + 0.5 beats 1 + 0.6
+		},
+		{
+			name: "forbidden pairs left unassigned",
+			cost: [][]float64{
+				{inf, 1},
+				{inf, inf},
+			},
+			want: []int{1, -1},
+		},
+		{
+			name: "as many pairs as possible before the cheapest",
+			cost: [][]float64{
+				{1, 2},
+				{inf, 100},
+			},
+			want: []int{0, 1},
+		},
+		{
+			name: "all forbidden",
+			cost: [][]float64{{inf, inf}, {inf, inf}},
+			want: []int{-1, -1},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := assign(tt.cost); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("assign = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAssignMatchesBruteForce(t *testing.T) {
+	random := rand.New(rand.NewSource(1))
+	for round := 0; round < 500; round++ {
+		rows, cols := 1+random.Intn(5), 1+random.Intn(5)
+		cost := make([][]float64, rows)
+		for i := range cost {
+			cost[i] = make([]float64, cols)
+			for j := range cost[i] {
+				cost[i][j] = float64(random.Intn(20))
+				if random.Intn(4) == 0 {
+					cost[i][j] = inf
+				}
+			}
+		}
+
+		got := assign(cost)
+		used := make(map[int]bool)
+		for i, j := range got {
+			if j < 0 {
+				continue
+			}
+			if used[j] || math.IsInf(cost[i][j], 1) {
+				t.Fatalf("assign(%v) = %v pairs column %d twice or through a forbidden entry", cost, got, j)
+			}
+			used[j] = true
+		}
+		wantPairs, wantTotal := bruteForce(cost)
+		if pairs, total := score(cost, got); pairs != wantPairs || total != wantTotal {
+			t.Fatalf("assign(%v) = %v with %d pairs costing %g, want %d pairs costing %g",
+				cost, got, pairs, total, wantPairs, wantTotal)
+		}
+	}
+}
+
+// score returns the number of pairs in an assignment and their total cost
+func score(cost [][]float64, assignment []int) (pairs int, total float64) {
+	for i, j := range assignment {
+		if j >= 0 {
+			pairs++
+			total += cost[i][j]
+		}
+	}
+	return pairs, total
+}
+
+// bruteForce returns the most pairs any assignment makes and the lowest cost
+// of making that many
+func bruteForce(cost [][]float64) (bestPairs int, bestTotal float64) {
+	cols := len(cost[0])
+	used := make([]bool, cols)
+	var walk func(row, pairs int, total float64)
+	walk = func(row, pairs int, total float64) {
+		if row == len(cost) {
+			if pairs > bestPairs || (pairs == bestPairs && total < bestTotal) {
+				bestPairs, bestTotal = pairs, total
+			}
+			return
+		}
+		walk(row+1, pairs, total)
+		for j := 0; j < cols; j++ {
+			if !used[j] && !math.IsInf(cost[row][j], 1) {
+				used[j] = true
+				walk(row+1, pairs+1, total+cost[row][j])
+				used[j] = false
+			}
+		}
+	}
+	walk(0, 0, 0)
+	return bestPairs, bestTotal
+}
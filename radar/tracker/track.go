@@ -0,0 +1,202 @@
+// Package tracker turns plots, the positions at which signals are detected on
+// each scan, into tracks: numbered, filtered positions with a velocity
+// estimate that are confirmed, coasted and dropped by M-of-N rules as in a
+// track-while-scan radar.
+package tracker
+
+import (
+	"math"
+	"math/bits"
+	"time"
+
+	"github.com/e6a5/radar/radar/model"
+)
+
+// State is the stage of a track's life
+type State int
+
+const (
+	StateTentative State = iota // Initiated, not yet seen on enough scans to be trusted
+	StateConfirmed              // Seen on at least M of the last N scans
+	StateCoasting               // Confirmed, but missed on the latest scans; extrapolated
+)
+
+// String returns the state name
+func (s State) String() string {
+	switch s {
+	case StateConfirmed:
+		return "confirmed"
+	case StateCoasting:
+		return "coasting"
+	default:
+		return "tentative"
+	}
+}
+
+// Plot is one detection of a signal during a scan
+type Plot struct {
+	Key      string         // Identity of the detected signal; empty if unknown
+	Category model.Category // Plots only join tracks of the same category
+	Distance float64        // Meters
+	Angle    float64        // Radians
+}
+
+// position returns the plot in cartesian coordinates, meters
+func (p Plot) position() (x, y float64) {
+	return p.Distance * math.Cos(p.Angle), p.Distance * math.Sin(p.Angle)
+}
+
+// Track is a target followed across scans. Positions are cartesian
+// coordinates in meters with x along the scope's east axis and y along the
+// angle's direction of increase.
+type Track struct {
+	Number    int            // Track number shown on the scope
+	Key       string         // Identity of the signal behind the track; empty if unknown
+	Category  model.Category // Category of the plots that formed the track
+	State     State
+	X, Y      float64   // Filtered position at Updated, meters
+	VX, VY    float64   // Velocity, meters per second
+	Initiated time.Time // When the first plot was seen
+	Updated   time.Time // When the last plot was associated
+
+	hits    uint32 // One bit per scan, most recent lowest, set if a plot was associated
+	scans   int    // Scans since initiation, including the first
+	updates int    // Plots associated, including the first
+	misses  int    // Consecutive scans without a plot
+}
+
+// newTrack initiates a tentative track from a plot
+func newTrack(number int, plot Plot, now time.Time) *Track {
+	x, y := plot.position()
+	return &Track{
+		Number:    number,
+		Key:       plot.Key,
+		Category:  plot.Category,
+		State:     StateTentative,
+		X:         x,
+		Y:         y,
+		Initiated: now,
+		Updated:   now,
+		hits:      1,
+		scans:     1,
+		updates:   1,
+	}
+}
+
+// Predict returns the track's extrapolated position at a time as a distance
+// in meters and a scope angle
+func (t *Track) Predict(at time.Time) (distance, angle float64) {
+	x, y := t.predictXY(at)
+	return math.Hypot(x, y), normalizeAngle(math.Atan2(y, x))
+}
+
+// predictXY returns the track's extrapolated cartesian position at a time
+func (t *Track) predictXY(at time.Time) (x, y float64) {
+	dt := at.Sub(t.Updated).Seconds()
+	return t.X + t.VX*dt, t.Y + t.VY*dt
+}
+
+// Speed returns the estimated speed in meters per second
+func (t *Track) Speed() float64 {
+	return math.Hypot(t.VX, t.VY)
+}
+
+// Course returns the direction of travel as a scope angle in radians
+func (t *Track) Course() float64 {
+	return normalizeAngle(math.Atan2(t.VY, t.VX))
+}
+
+// Hits returns how many of the last n scans had a plot for the track
+func (t *Track) Hits(n int) int {
+	if n >= 32 {
+		return bits.OnesCount32(t.hits)
+	}
+	return bits.OnesCount32(t.hits & (1<<uint(n) - 1))
+}
+
+// Misses returns the number of consecutive scans without a plot
+func (t *Track) Misses() int {
+	return t.misses
+}
+
+// update folds an associated plot into the track with an alpha-beta filter.
+// The first velocity comes from the first two plots; a keyed plot outside the
+// gate restarts the filter, since the jump is a new fix rather than motion.
+func (t *Track) update(plot Plot, now time.Time, config Config) {
+	x, y := plot.position()
+	dt := now.Sub(t.Updated).Seconds()
+	px, py := t.predictXY(now)
+
+	switch {
+	case dt <= 0:
+		t.X, t.Y = x, y
+	case math.Hypot(x-px, y-py) > t.gate(now, config):
+		t.X, t.Y = x, y
+		t.VX, t.VY = 0, 0
+		t.updates = 0
+	case t.updates == 1:
+		t.VX, t.VY = (x-t.X)/dt, (y-t.Y)/dt
+		t.X, t.Y = x, y
+	default:
+		rx, ry := x-px, y-py
+		t.X, t.Y = px+config.Alpha*rx, py+config.Alpha*ry
+		t.VX += config.Beta * rx / dt
+		t.VY += config.Beta * ry / dt
+	}
+
+	if plot.Key != "" {
+		t.Key = plot.Key
+	}
+	t.Updated = now
+	t.updates++
+}
+
+// gate returns how far from its predicted position at a time a plot may be
+// to join the track. The gate grows with the predicted range, since distance
+// estimates err in proportion to it, and widens with every missed scan.
+func (t *Track) gate(at time.Time, config Config) float64 {
+	x, y := t.predictXY(at)
+	gate := math.Max(config.MinGate, config.Gate*math.Hypot(x, y))
+	return gate * float64(1+t.misses)
+}
+
+// score records the outcome of a scan and moves the track through its states.
+// It reports whether the track should be dropped.
+func (t *Track) score(hit bool, config Config) bool {
+	t.hits <<= 1
+	t.scans++
+	if hit {
+		t.hits |= 1
+		t.misses = 0
+	} else {
+		t.misses++
+	}
+
+	switch t.State {
+	case StateTentative:
+		if t.Hits(config.ConfirmScans) >= config.ConfirmHits {
+			t.State = StateConfirmed
+			return false
+		}
+		// Drop once M hits within the first N scans are out of reach
+		return t.scans-t.Hits(32) > config.ConfirmScans-config.ConfirmHits
+	case StateConfirmed:
+		if !hit {
+			t.State = StateCoasting
+		}
+	case StateCoasting:
+		if hit {
+			t.State = StateConfirmed
+		}
+	}
+	return t.misses >= config.DropScans
+}
+
+// normalizeAngle returns an angle within [0, 2π)
+func normalizeAngle(angle float64) float64 {
+	angle = math.Mod(angle, 2*math.Pi)
+	if angle < 0 {
+		angle += 2 * math.Pi
+	}
+	return angle
+}
@@ -0,0 +1,165 @@
+package tracker
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+// maxTrackNumber is the highest track number before numbering wraps to 1
+const maxTrackNumber = 999
+
+// Config tunes association and the track life cycle
+type Config struct {
+	Gate         float64 // Largest distance from a track's predicted position to a plot it can take, as a fraction of its predicted range
+	MinGate      float64 // Smallest gate, meters, for tracks close to the center
+	ConfirmHits  int     // M: a tentative track is confirmed after M hits ...
+	ConfirmScans int     // ... within its last N scans
+	DropScans    int     // A confirmed track is dropped after this many scans in a row without a hit
+	Alpha        float64 // Position gain of the alpha-beta filter, 0-1
+	Beta         float64 // Velocity gain of the alpha-beta filter, 0-1
+}
+
+// DefaultConfig returns a 2-of-3 confirmation, 3-scan coasting configuration.
+// Distances estimated from signal strength err in proportion to the distance,
+// so the gate spans 30% of a track's range, and at least a meter.
+func DefaultConfig() Config {
+	return Config{
+		Gate:         0.3,
+		MinGate:      1.0,
+		ConfirmHits:  2,
+		ConfirmScans: 3,
+		DropScans:    3,
+		Alpha:        0.6,
+		Beta:         0.3,
+	}
+}
+
+// Tracker associates each scan's plots with tracks. It is not safe for
+// concurrent use.
+type Tracker struct {
+	config Config
+	tracks []*Track
+	next   int // Last track number handed out
+}
+
+// NewTracker creates a tracker with the given configuration
+func NewTracker(config Config) *Tracker {
+	return &Tracker{config: config}
+}
+
+// Scan folds one scan's plots into the tracks and returns, for each plot, the
+// number of the track it now belongs to. Plots with a key join the track of
+// the same key; the rest are assigned to unkeyed tracks by global nearest
+// neighbor within the gate, and plots left over initiate tentative tracks.
+func (t *Tracker) Scan(plots []Plot, now time.Time) []int {
+	numbers := make([]int, len(plots))
+	hit := make(map[*Track]bool, len(t.tracks))
+
+	// Identified plots: the key decides
+	byKey := make(map[string]*Track)
+	for _, track := range t.tracks {
+		if track.Key != "" {
+			byKey[track.Key] = track
+		}
+	}
+	anonymous := make([]int, 0, len(plots))
+	for i, plot := range plots {
+		if plot.Key == "" {
+			anonymous = append(anonymous, i)
+			continue
+		}
+		if track, ok := byKey[plot.Key]; ok && !hit[track] {
+			track.update(plot, now, t.config)
+			hit[track] = true
+			numbers[i] = track.Number
+		}
+	}
+
+	// Anonymous plots: global nearest neighbor among unkeyed tracks
+	candidates := make([]*Track, 0, len(t.tracks))
+	for _, track := range t.tracks {
+		if track.Key == "" && !hit[track] {
+			candidates = append(candidates, track)
+		}
+	}
+	if len(anonymous) > 0 && len(candidates) > 0 {
+		cost := make([][]float64, len(anonymous))
+		for row, i := range anonymous {
+			cost[row] = make([]float64, len(candidates))
+			x, y := plots[i].position()
+			for col, track := range candidates {
+				px, py := track.predictXY(now)
+				d := math.Hypot(x-px, y-py)
+				if plots[i].Category != track.Category || d > track.gate(now, t.config) {
+					d = math.Inf(1)
+				}
+				cost[row][col] = d
+			}
+		}
+		for row, col := range assign(cost) {
+			if col < 0 {
+				continue
+			}
+			i, track := anonymous[row], candidates[col]
+			track.update(plots[i], now, t.config)
+			hit[track] = true
+			numbers[i] = track.Number
+		}
+	}
+
+	// Score every existing track, then initiate tracks for unassociated plots
+	kept := t.tracks[:0]
+	for _, track := range t.tracks {
+		if !track.score(hit[track], t.config) {
+			kept = append(kept, track)
+		}
+	}
+	t.tracks = kept
+	for i, plot := range plots {
+		if numbers[i] != 0 {
+			continue
+		}
+		track := newTrack(t.nextNumber(), plot, now)
+		t.tracks = append(t.tracks, track)
+		numbers[i] = track.Number
+	}
+
+	return numbers
+}
+
+// nextNumber returns the next free track number, wrapping after maxTrackNumber
+func (t *Tracker) nextNumber() int {
+	used := make(map[int]bool, len(t.tracks))
+	for _, track := range t.tracks {
+		used[track.Number] = true
+	}
+	for {
+		t.next = t.next%maxTrackNumber + 1
+		if !used[t.next] {
+			return t.next
+		}
+	}
+}
+
+// Tracks returns copies of the current tracks ordered by number
+func (t *Tracker) Tracks() []Track {
+	tracks := make([]Track, len(t.tracks))
+	for i, track := range t.tracks {
+		tracks[i] = *track
+	}
+	sort.Slice(tracks, func(i, j int) bool {
+		return tracks[i].Number < tracks[j].Number
+	})
+	return tracks
+}
+
+// Track returns a copy of the track with the given number
+func (t *Tracker) Track(number int) (Track, bool) {
+	for _, track := range t.tracks {
+		if track.Number == number {
+			return *track, true
+		}
+	}
+	return Track{}, false
+}
@@ -0,0 +1,186 @@
+package tracker
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/e6a5/radar/radar/model"
+)
+
+var epoch = time.Unix(1700000000, 0)
+
+// scanAt returns the time of the nth scan, one second apart
+func scanAt(n int) time.Time {
+	return epoch.Add(time.Duration(n) * time.Second)
+}
+
+func TestTrackLifeCycle(t *testing.T) {
+	plot := Plot{Key: "wifi:a", Category: model.CategoryWiFi, Distance: 10, Angle: 1}
+
+	// Each step is one scan: whether the signal was detected, and the track's
+	// state after it, or dropped
+	tests := []struct {
+		name  string
+		hits  []bool
+		state []State
+		drop  int // Scan after which the track is gone; -1 if never
+	}{
+		{
+			name:  "confirmed on the second hit",
+			hits:  []bool{true, true, true},
+			state: []State{StateTentative, StateConfirmed, StateConfirmed},
+			drop:  -1,
+		},
+		{
+			name:  "confirmed on 2 of 3",
+			hits:  []bool{true, false, true},
+			state: []State{StateTentative, StateTentative, StateConfirmed},
+			drop:  -1,
+		},
+		{
+			name:  "tentative dropped once 2 of 3 is out of reach",
+			hits:  []bool{true, false, false},
+			state: []State{StateTentative, StateTentative},
+			drop:  2,
+		},
+		{
+			name:  "coasts on a miss and recovers",
+			hits:  []bool{true, true, false, false, true},
+			state: []State{StateTentative, StateConfirmed, StateCoasting, StateCoasting, StateConfirmed},
+			drop:  -1,
+		},
+		{
+			name:  "dropped after 3 misses",
+			hits:  []bool{true, true, false, false, false},
+			state: []State{StateTentative, StateConfirmed, StateCoasting, StateCoasting},
+			drop:  4,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tracker := NewTracker(DefaultConfig())
+			number := 0
+			for n, hit := range tt.hits {
+				var plots []Plot
+				if hit {
+					plots = []Plot{plot}
+				}
+				if numbers := tracker.Scan(plots, scanAt(n)); hit {
+					if number != 0 && numbers[0] != number {
+						t.Fatalf("scan %d: plot joined track %d, want %d", n, numbers[0], number)
+					}
+					number = numbers[0]
+				}
+
+				track, ok := tracker.Track(number)
+				if n == tt.drop {
+					if ok {
+						t.Fatalf("scan %d: track is %s, want dropped", n, track.State)
+					}
+					return
+				}
+				if !ok {
+					t.Fatalf("scan %d: track dropped, want %s", n, tt.state[n])
+				}
+				if track.State != tt.state[n] {
+					t.Errorf("scan %d: state = %s, want %s", n, track.State, tt.state[n])
+				}
+			}
+		})
+	}
+}
+
+func TestTrackHitsAndMisses(t *testing.T) {
+	tracker := NewTracker(DefaultConfig())
+	plot := Plot{Key: "bt:a", Distance: 5}
+	for n, hit := range []bool{true, true, false, true, false, false} {
+		var plots []Plot
+		if hit {
+			plots = []Plot{plot}
+		}
+		tracker.Scan(plots, scanAt(n))
+	}
+	track := tracker.Tracks()[0]
+	if track.Hits(3) != 1 || track.Hits(6) != 3 || track.Misses() != 2 {
+		t.Errorf("hits in 3 = %d, in 6 = %d, misses = %d; want 1, 3, 2", track.Hits(3), track.Hits(6), track.Misses())
+	}
+}
+
+func TestGateScalesWithRange(t *testing.T) {
+	tests := []struct {
+		name     string
+		from, to float64 // Distances of the two plots along the same bearing, meters
+		joins    bool
+	}{
+		{"close and within a meter", 2, 2.8, true},
+		{"close but beyond the minimum gate", 2, 3.5, false},
+		{"ten meters off at 50 m", 50, 60, true},
+		{"twenty meters off at 50 m", 50, 70, false},
+		{"a hundred meters off at 500 m", 500, 600, true},
+		{"two hundred meters off at 500 m", 500, 700, false},
+		{"two hundred and fifty meters off at 900 m", 900, 1150, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tracker := NewTracker(DefaultConfig())
+			first := tracker.Scan([]Plot{{Category: model.CategoryNetwork, Distance: tt.from, Angle: 0.5}}, scanAt(0))
+			second := tracker.Scan([]Plot{{Category: model.CategoryNetwork, Distance: tt.to, Angle: 0.5}}, scanAt(1))
+			if joins := first[0] == second[0]; joins != tt.joins {
+				t.Errorf("plot at %gm after one at %gm joined = %v, want %v (gate %g)",
+					tt.to, tt.from, joins, tt.joins, DefaultConfig().Gate*tt.from)
+			}
+		})
+	}
+}
+
+func TestGateWidensWithMisses(t *testing.T) {
+	tracker := NewTracker(DefaultConfig())
+	first := tracker.Scan([]Plot{{Distance: 100}}, scanAt(0))
+	tracker.Scan(nil, scanAt(1))
+	// 50 m is beyond the 30 m gate of a track just seen, within the 60 m of one missed once
+	second := tracker.Scan([]Plot{{Distance: 150}}, scanAt(2))
+	if first[0] != second[0] {
+		t.Errorf("plot 50 m off after a miss started track %d, want it to join %d", second[0], first[0])
+	}
+}
+
+func TestAnonymousAssociation(t *testing.T) {
+	tracker := NewTracker(DefaultConfig())
+	plots := []Plot{
+		{Category: model.CategoryWiFi, Distance: 20, Angle: 0},
+		{Category: model.CategoryWiFi, Distance: 20, Angle: math.Pi / 2},
+		{Category: model.CategoryBluetooth, Distance: 20, Angle: math.Pi},
+	}
+	first := tracker.Scan(plots, scanAt(0))
+
+	// The plots come back in another order, nudged; the Bluetooth plot moves
+	// next to a WiFi track but stays with its own category
+	second := tracker.Scan([]Plot{
+		{Category: model.CategoryBluetooth, Distance: 20, Angle: 0.05},
+		{Category: model.CategoryWiFi, Distance: 21, Angle: math.Pi / 2},
+		{Category: model.CategoryWiFi, Distance: 19, Angle: 0},
+	}, scanAt(1))
+	if second[1] != first[1] || second[2] != first[0] {
+		t.Errorf("WiFi plots joined tracks %v, want %v", second[1:], []int{first[1], first[0]})
+	}
+	if second[0] == first[0] || second[0] == first[2] {
+		t.Errorf("Bluetooth plot outside its track's gate joined track %d, want a new one", second[0])
+	}
+}
+
+func TestTrackVelocity(t *testing.T) {
+	tracker := NewTracker(DefaultConfig())
+	var number int
+	// Moving away along the x axis at 2 m/s
+	for n := 0; n < 10; n++ {
+		number = tracker.Scan([]Plot{{Key: "conn:a", Distance: 20 + 2*float64(n)}}, scanAt(n))[0]
+	}
+	track, _ := tracker.Track(number)
+	if math.Abs(track.Speed()-2) > 0.01 || math.Abs(track.Course()) > 0.01 {
+		t.Errorf("speed %g m/s on course %g, want 2 m/s on 0", track.Speed(), track.Course())
+	}
+	if distance, _ := track.Predict(scanAt(10)); math.Abs(distance-40) > 0.1 {
+		t.Errorf("predicted distance = %g, want 40", distance)
+	}
+}
@@ -1,15 +1,15 @@
 package radar
 
 import (
-	"fmt"
 	"math"
 
+	"github.com/e6a5/radar/radar/model"
 	"github.com/gdamore/tcell/v2"
 )
 
 // Get current theme for display components
 func (rd *Display) getCurrentTheme() RadarTheme {
-	return GetModernDarkTheme() // Default theme for now
+	return GetRadarTheme(rd.config.Theme)
 }
 
 // Enhanced visual constants for modern radar UI
@@ -63,6 +63,11 @@ type RadarTheme struct {
 	SignalPoor      tcell.Color
 	SignalConnected tcell.Color
 
+	// Signal colors by category and severity
+	Categories     map[model.Category]tcell.Color
+	SeverityNotice tcell.Color
+	SeverityAlert  tcell.Color
+
 	// UI accents
 	AccentPrimary   tcell.Color
 	AccentSecondary tcell.Color
@@ -92,6 +97,10 @@ func GetModernDarkTheme() RadarTheme {
 		SignalPoor:      tcell.ColorGray,
 		SignalConnected: tcell.ColorBlue,
 
+		Categories:     defaultCategoryColors(),
+		SeverityNotice: tcell.ColorYellow,
+		SeverityAlert:  tcell.ColorRed,
+
 		AccentPrimary:   tcell.ColorBlue,
 		AccentSecondary: tcell.ColorBlue,
 		TextPrimary:     tcell.ColorWhite,
@@ -99,6 +108,21 @@ func GetModernDarkTheme() RadarTheme {
 	}
 }
 
+// defaultCategoryColors returns the signal color used for each category
+func defaultCategoryColors() map[model.Category]tcell.Color {
+	return map[model.Category]tcell.Color{
+		model.CategoryUnknown:   tcell.ColorWhite,
+		model.CategoryWiFi:      tcell.ColorBlue,
+		model.CategoryBluetooth: tcell.ColorNavy,
+		model.CategoryCellular:  tcell.ColorGreen,
+		model.CategoryRadio:     tcell.ColorPurple,
+		model.CategoryIoT:       tcell.ColorOrange,
+		model.CategorySatellite: tcell.ColorYellow,
+		model.CategoryNetwork:   tcell.ColorGreen,
+		model.CategoryInterface: tcell.ColorBlue,
+	}
+}
+
 // Enhanced background grid with crosshairs and coordinate system
 func (rd *Display) drawEnhancedBackground(screen tcell.Screen) {
 	theme := rd.getCurrentTheme()
@@ -236,8 +260,7 @@ func (rd *Display) drawEnhancedRangeRings(screen tcell.Screen) {
 
 // Enhanced range labels with modern styling
 func (rd *Display) drawEnhancedRangeLabel(screen tcell.Screen, ring int, radius float64, theme RadarTheme) {
-	distance := ring * 10
-	label := fmt.Sprintf("%dm", distance)
+	label := rangeLabel(rd.scopeRange() * float64(ring) / 4)
 
 	// Multiple label positions for better visibility
 	positions := []struct {
@@ -385,7 +408,7 @@ func (rd *Display) drawEnhancedSignals(screen tcell.Screen) {
 		}
 
 		// Enhanced signal positioning with zoom support
-		scaleFactor := maxRadiusDisplay / rd.config.MaxScanRange * 10.0
+		scaleFactor := rd.scopeScale(maxRadiusDisplay)
 		distance := s.Distance * scaleFactor
 
 		if distance > maxRadiusDisplay {
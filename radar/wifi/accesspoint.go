@@ -0,0 +1,131 @@
+package wifi
+
+import (
+	"time"
+
+	"github.com/e6a5/radar/radar/estimation"
+	"github.com/e6a5/radar/radar/model"
+	"github.com/e6a5/radar/radar/scanner"
+)
+
+// AccessPoint describes one BSS reported by a WiFi scanning backend
+type AccessPoint struct {
+	BSSID        string
+	SSID         string
+	Frequency    int // MHz
+	Channel      int
+	ChannelWidth int // MHz
+	RSSI         int // dBm
+	Quality      int // 0-100, for backends that report a percentage rather than dBm
+	Rate         int // Mbit/s
+	Security     string
+	Connected    bool
+	// Raw 802.11 information elements, when the backend exposes them
+	InformationElements []byte
+}
+
+// Key returns the identity used for the access point, preferring the BSSID
+func (ap AccessPoint) Key() string {
+	if ap.BSSID != "" {
+		return ap.BSSID
+	}
+	return ap.SSID
+}
+
+// accessPointSignal converts an access point into a radar signal
+func accessPointSignal(ap AccessPoint, config *scanner.Config, now time.Time) model.Signal {
+	strength := rssiToStrength(ap.RSSI)
+	if ap.Quality > 0 {
+		strength = ap.Quality
+	}
+	estimate := config.Estimate("wifi:"+ap.Key(), ap.RSSI, estimation.Source{Frequency: ap.Frequency}, now)
+
+	// Get friendly display name
+	vendor, _ := lookupVendorString(ap.BSSID)
+	displayName := GetFriendlyDisplayNameWithVendor(ap.SSID, vendor, strength, ap.Connected)
+
+	severity := model.SeverityNormal
+	if ap.Connected {
+		severity = model.SeverityActive
+	}
+
+	signal := model.Signal{
+		ID:           "wifi:" + ap.Key(),
+		Type:         "WiFi",
+		Icon:         "≋",
+		Name:         displayName,
+		Category:     model.CategoryWiFi,
+		Severity:     severity,
+		Strength:     strength,
+		Distance:     estimate.Distance,
+		DistanceLow:  estimate.Low,
+		DistanceHigh: estimate.High,
+		Angle:        config.Bearing("WiFi", "wifi:"+ap.Key()),
+		Phase:        0,
+		Lifetime:     now,
+		LastSeen:     now,
+		Persistence:  1.0,
+		History:      make([]model.PositionHistory, 0, 20),
+		MaxHistory:   20,
+		Attributes:   accessPointAttributes(ap),
+	}
+
+	signal.AddToHistory(signal.Distance, signal.Angle, signal.Strength, true, now)
+	return signal
+}
+
+// accessPointAttributes collects the per-AP details shown alongside the signal
+func accessPointAttributes(ap AccessPoint) model.Attributes {
+	attrs := model.Attributes{}
+	attrs.Set(model.AttrSSID, ap.SSID)
+	attrs.Set(model.AttrBSSID, ap.BSSID)
+	if vendor, random := lookupVendorString(ap.BSSID); random {
+		attrs.SetBool(model.AttrRandomMAC, true)
+	} else {
+		attrs.Set(model.AttrVendor, vendor)
+	}
+	attrs.SetInt(model.AttrRSSI, ap.RSSI)
+	attrs.SetBool(model.AttrConnected, ap.Connected)
+	attrs.Set(model.AttrSecurity, ap.Security)
+	if ap.Channel != 0 {
+		attrs.SetInt(model.AttrChannel, ap.Channel)
+	}
+	if ap.Frequency != 0 {
+		attrs.SetInt(model.AttrFrequency, ap.Frequency)
+	}
+	if ap.ChannelWidth != 0 {
+		attrs.SetInt(model.AttrChannelWidth, ap.ChannelWidth)
+	}
+	if ap.Rate != 0 {
+		attrs.SetInt(model.AttrRate, ap.Rate)
+	}
+	return attrs
+}
+
+// frequencyToChannel converts a center frequency in MHz to an 802.11 channel number
+func frequencyToChannel(freq int) int {
+	switch {
+	case freq == 2484:
+		return 14
+	case freq >= 2412 && freq < 2484:
+		return (freq - 2407) / 5
+	case freq >= 5955 && freq <= 7115:
+		// 6 GHz band
+		return (freq - 5950) / 5
+	case freq >= 5000 && freq < 5950:
+		return (freq - 5000) / 5
+	default:
+		return 0
+	}
+}
+
+// rssiToStrength converts RSSI to percentage
+func rssiToStrength(rssi int) int {
+	if rssi >= -30 {
+		return 100
+	}
+	if rssi <= -90 {
+		return 0
+	}
+	return int(100 * (float64(rssi+90) / 60.0))
+}
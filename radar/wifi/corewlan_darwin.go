@@ -131,19 +131,17 @@ import "C"
 import (
 	"context"
 	"fmt"
-	"math"
-	"math/rand"
+	"strings"
 	"time"
 	"unsafe"
 
+	"github.com/e6a5/radar/radar/model"
 	"github.com/e6a5/radar/radar/scanner"
-	"github.com/gdamore/tcell/v2"
 )
 
 // CoreWLANScanner implements WiFi scanning using Apple's CoreWLAN framework
 type CoreWLANScanner struct {
-	lastScan time.Time
-	config   *scanner.Config
+	config *scanner.Config
 }
 
 // NewCoreWLANScanner creates a new CoreWLAN-based WiFi scanner
@@ -164,16 +162,10 @@ func (c *CoreWLANScanner) IsAvailable() bool {
 }
 
 // Scan scans for available WiFi networks using CoreWLAN
-func (c *CoreWLANScanner) Scan(ctx context.Context) ([]scanner.Signal, error) {
-	signals := make([]scanner.Signal, 0)
+func (c *CoreWLANScanner) Scan(ctx context.Context) ([]model.Signal, error) {
+	signals := make([]model.Signal, 0)
 	now := time.Now()
 
-	// Rate limiting
-	if now.Sub(c.lastScan) < c.config.ScanInterval {
-		return signals, nil
-	}
-	c.lastScan = now
-
 	// Scan for networks
 	result := C.scanWiFiNetworks()
 	if result == nil {
@@ -181,6 +173,13 @@ func (c *CoreWLANScanner) Scan(ctx context.Context) ([]scanner.Signal, error) {
 	}
 	defer C.freeWiFiScanResult(result)
 
+	// The connected network comes first; the scan lists it again, without
+	// the connection, and that copy is skipped
+	current := c.getCurrentNetwork()
+	if current != nil {
+		signals = append(signals, accessPointSignal(*current, c.config, now))
+	}
+
 	// Convert C results to Go signals
 	networkCount := int(result.count)
 	if networkCount == 0 {
@@ -197,43 +196,22 @@ func (c *CoreWLANScanner) Scan(ctx context.Context) ([]scanner.Signal, error) {
 			continue
 		}
 
-		rssi := int(network.rssi)
-		strength := rssiToStrength(rssi)
-		distance := rssiToDistance(rssi, c.config.MaxScanRange)
-
-		// Get friendly display name
-		displayName := GetFriendlyDisplayName(ssid, strength, false)
-
-		signal := scanner.Signal{
-			Type:        "WiFi",
-			Icon:        "≋",
-			Name:        displayName,
-			Color:       tcell.ColorBlue,
-			Strength:    strength,
-			Distance:    distance,
-			Angle:       rand.Float64() * 2 * math.Pi,
-			Phase:       0,
-			Lifetime:    now,
-			LastSeen:    now,
-			Persistence: 1.0,
-			History:     make([]scanner.PositionHistory, 0, 20),
-			MaxHistory:  20,
+		ap := AccessPoint{
+			BSSID: C.GoString(network.bssid),
+			SSID:  ssid,
+			RSSI:  int(network.rssi),
 		}
-
-		signal.AddToHistory(signal.Distance, signal.Angle, signal.Strength, true, now)
-		signals = append(signals, signal)
-	}
-
-	// Add current network if connected
-	if currentNetwork := c.getCurrentNetwork(now); currentNetwork != nil {
-		signals = append([]scanner.Signal{*currentNetwork}, signals...)
+		if current != nil && strings.EqualFold(ap.Key(), current.Key()) {
+			continue
+		}
+		signals = append(signals, accessPointSignal(ap, c.config, now))
 	}
 
 	return signals, nil
 }
 
 // getCurrentNetwork gets the currently connected WiFi network
-func (c *CoreWLANScanner) getCurrentNetwork(now time.Time) *scanner.Signal {
+func (c *CoreWLANScanner) getCurrentNetwork() *AccessPoint {
 	network := C.getCurrentWiFiNetwork()
 	if network == nil {
 		return nil
@@ -245,52 +223,10 @@ func (c *CoreWLANScanner) getCurrentNetwork(now time.Time) *scanner.Signal {
 		return nil
 	}
 
-	rssi := int(network.rssi)
-	strength := rssiToStrength(rssi)
-	distance := rssiToDistance(rssi, c.config.MaxScanRange)
-
-	// Get friendly display name for connected network
-	displayName := GetFriendlyDisplayName(ssid, strength, true)
-
-	signal := scanner.Signal{
-		Type:        "WiFi",
-		Icon:        "≋",
-		Name:        displayName,
-		Color:       tcell.ColorGreen,
-		Strength:    strength,
-		Distance:    distance,
-		Angle:       rand.Float64() * 2 * math.Pi,
-		Phase:       0,
-		Lifetime:    now,
-		LastSeen:    now,
-		Persistence: 1.0,
-		History:     make([]scanner.PositionHistory, 0, 20),
-		MaxHistory:  20,
-	}
-
-	signal.AddToHistory(signal.Distance, signal.Angle, signal.Strength, true, now)
-	return &signal
-}
-
-// rssiToStrength converts RSSI to percentage
-func rssiToStrength(rssi int) int {
-	if rssi >= -30 {
-		return 100
-	}
-	if rssi <= -90 {
-		return 0
-	}
-	return int(100 * (float64(rssi+90) / 60.0))
-}
-
-// rssiToDistance converts RSSI to radar distance
-func rssiToDistance(rssi int, maxRange float64) float64 {
-	distance := math.Pow(10, float64(-rssi-30)/20.0) * 2.0
-	if distance < 0.5 {
-		distance = 0.5
-	}
-	if distance > maxRange {
-		distance = maxRange
+	return &AccessPoint{
+		BSSID:     C.GoString(network.bssid),
+		SSID:      ssid,
+		RSSI:      int(network.rssi),
+		Connected: true,
 	}
-	return distance
 }
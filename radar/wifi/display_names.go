@@ -68,7 +68,17 @@ var networkMappings = []NetworkMapping{
 
 // GetFriendlyDisplayName converts a raw SSID to a human-readable display name
 func GetFriendlyDisplayName(ssid string, strength int, isConnected bool) string {
+	return GetFriendlyDisplayNameWithVendor(ssid, "", strength, isConnected)
+}
+
+// GetFriendlyDisplayNameWithVendor converts a raw SSID to a human-readable
+// display name, naming the access point's vendor when the SSID alone says
+// little about the network
+func GetFriendlyDisplayNameWithVendor(ssid, vendor string, strength int, isConnected bool) string {
 	if ssid == "" {
+		if vendor != "" {
+			return vendor + " Network"
+		}
 		return "Unknown Network"
 	}
 
@@ -77,12 +87,20 @@ func GetFriendlyDisplayName(ssid string, strength int, isConnected bool) string
 
 	// Check for hidden networks
 	if cleanSSID == "" || cleanSSID == "<hidden>" || cleanSSID == "Hidden Network" {
+		if vendor != "" {
+			return fmt.Sprintf("Hidden %s Network (%d%%)", vendor, strength)
+		}
 		return fmt.Sprintf("Hidden Network (%d%%)", strength)
 	}
 
 	// Try to match against known patterns
 	displayName, networkType := mapSSIDToFriendlyName(cleanSSID)
 
+	// Generic home and office names don't say whose router it is
+	if vendor != "" && (networkType == "home" || networkType == "business") && !strings.Contains(displayName, "(") {
+		displayName += " (" + vendor + ")"
+	}
+
 	// Add signal strength indicator for weak signals
 	if strength < 30 {
 		displayName += " (Weak)"
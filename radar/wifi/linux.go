@@ -5,21 +5,18 @@ package wifi
 
 import (
 	"context"
-	"math"
-	"math/rand"
 	"os/exec"
 	"strconv"
 	"strings"
 	"time"
 
+	"github.com/e6a5/radar/radar/model"
 	"github.com/e6a5/radar/radar/scanner"
-	"github.com/gdamore/tcell/v2"
 )
 
 // LinuxWiFiScanner implements WiFi scanning for Linux systems
 type LinuxWiFiScanner struct {
-	lastScan time.Time
-	config   *scanner.Config
+	config *scanner.Config
 }
 
 // NewLinuxWiFiScanner creates a new Linux WiFi scanner
@@ -34,25 +31,27 @@ func (l *LinuxWiFiScanner) Name() string {
 	return "Linux WiFi Scanner"
 }
 
-// IsAvailable checks if nmcli or iw is available
+// IsAvailable checks if nl80211, nmcli or iw is available
 func (l *LinuxWiFiScanner) IsAvailable() bool {
+	if nl80211Available() {
+		return true
+	}
 	_, err1 := exec.LookPath("nmcli")
 	_, err2 := exec.LookPath("iw")
 	return err1 == nil || err2 == nil
 }
 
 // Scan scans for WiFi networks using available Linux tools
-func (l *LinuxWiFiScanner) Scan(ctx context.Context) ([]scanner.Signal, error) {
-	signals := make([]scanner.Signal, 0)
+func (l *LinuxWiFiScanner) Scan(ctx context.Context) ([]model.Signal, error) {
+	signals := make([]model.Signal, 0)
 	now := time.Now()
 
-	// Rate limiting
-	if now.Sub(l.lastScan) < l.config.ScanInterval {
-		return signals, nil
+	// Prefer the native nl80211 backend
+	if nlSignals, err := l.scanWithNL80211(ctx, now); err == nil && len(nlSignals) > 0 {
+		return nlSignals, nil
 	}
-	l.lastScan = now
 
-	// Try nmcli first
+	// Fall back to nmcli
 	if nmcliSignals, err := l.scanWithNmcli(ctx, now); err == nil && len(nmcliSignals) > 0 {
 		return nmcliSignals, nil
 	}
@@ -65,35 +64,54 @@ func (l *LinuxWiFiScanner) Scan(ctx context.Context) ([]scanner.Signal, error) {
 	return signals, nil
 }
 
+// scanWithNL80211 scans WiFi networks over nl80211 generic netlink
+func (l *LinuxWiFiScanner) scanWithNL80211(ctx context.Context, now time.Time) ([]model.Signal, error) {
+	signals := make([]model.Signal, 0)
+
+	aps, err := scanNL80211(ctx)
+	if err != nil {
+		return signals, err
+	}
+
+	for _, ap := range aps {
+		if ap.SSID == "" {
+			continue // Hidden networks carry no usable name
+		}
+		signals = append(signals, accessPointSignal(ap, l.config, now))
+		if len(signals) >= l.config.MaxSignals {
+			break
+		}
+	}
+
+	return signals, nil
+}
+
+// nmcliFields are the columns requested from nmcli in terse mode
+const nmcliFields = "IN-USE,BSSID,SSID,CHAN,FREQ,RATE,SIGNAL,SECURITY"
+
 // scanWithNmcli scans WiFi networks using NetworkManager
-func (l *LinuxWiFiScanner) scanWithNmcli(ctx context.Context, now time.Time) ([]scanner.Signal, error) {
-	signals := make([]scanner.Signal, 0)
+func (l *LinuxWiFiScanner) scanWithNmcli(ctx context.Context, now time.Time) ([]model.Signal, error) {
+	signals := make([]model.Signal, 0)
 
 	// Refresh scan
 	exec.CommandContext(ctx, "nmcli", "dev", "wifi", "rescan").Run()
 
-	// Get WiFi list
-	cmd := exec.CommandContext(ctx, "nmcli", "dev", "wifi", "list")
+	// Get WiFi list in terse mode so SSIDs with spaces survive parsing
+	cmd := exec.CommandContext(ctx, "nmcli", "-t", "-f", nmcliFields, "dev", "wifi", "list")
 	output, err := cmd.Output()
 	if err != nil {
-		cmd = exec.CommandContext(ctx, "nmcli", "dev", "wifi")
-		output, err = cmd.Output()
-		if err != nil {
-			return signals, err
-		}
+		return signals, err
 	}
 
-	lines := strings.Split(string(output), "\n")
-	for i, line := range lines {
-		if i == 0 || strings.TrimSpace(line) == "" {
+	for _, line := range strings.Split(string(output), "\n") {
+		ap, ok := parseNmcliLine(line)
+		if !ok {
 			continue
 		}
 
-		if signal := l.parseNmcliLine(line, now); signal != nil {
-			signals = append(signals, *signal)
-			if len(signals) >= l.config.MaxSignals {
-				break
-			}
+		signals = append(signals, accessPointSignal(ap, l.config, now))
+		if len(signals) >= l.config.MaxSignals {
+			break
 		}
 	}
 
@@ -101,8 +119,8 @@ func (l *LinuxWiFiScanner) scanWithNmcli(ctx context.Context, now time.Time) ([]
 }
 
 // scanWithIw scans WiFi networks using iw
-func (l *LinuxWiFiScanner) scanWithIw(ctx context.Context, now time.Time) ([]scanner.Signal, error) {
-	signals := make([]scanner.Signal, 0)
+func (l *LinuxWiFiScanner) scanWithIw(ctx context.Context, now time.Time) ([]model.Signal, error) {
+	signals := make([]model.Signal, 0)
 
 	// Find wireless interface
 	interfacesCmd := exec.CommandContext(ctx, "iw", "dev")
@@ -135,12 +153,26 @@ func (l *LinuxWiFiScanner) scanWithIw(ctx context.Context, now time.Time) ([]sca
 	}
 
 	lines = strings.Split(string(output), "\n")
-	var currentSSID string
+	var currentBSSID, currentSSID string
 	var currentRSSI int
 
 	for _, line := range lines {
 		line = strings.TrimSpace(line)
 
+		// A new BSS entry ends the previous one
+		if strings.HasPrefix(line, "BSS ") {
+			if currentSSID != "" {
+				signals = append(signals, l.iwSignal(currentBSSID, currentSSID, currentRSSI, now))
+				if len(signals) >= l.config.MaxSignals {
+					return signals, nil
+				}
+			}
+			currentBSSID = parseIwBSSID(line)
+			currentSSID = ""
+			currentRSSI = -50
+			continue
+		}
+
 		if strings.HasPrefix(line, "SSID:") {
 			currentSSID = strings.TrimSpace(strings.TrimPrefix(line, "SSID:"))
 		} else if strings.Contains(line, "signal:") {
@@ -155,132 +187,118 @@ func (l *LinuxWiFiScanner) scanWithIw(ctx context.Context, now time.Time) ([]sca
 				}
 			}
 		}
+	}
 
-		// End of BSS entry
-		if strings.HasPrefix(line, "BSS") && currentSSID != "" {
-			strength := rssiToStrength(currentRSSI)
-			distance := rssiToDistance(currentRSSI, l.config.MaxScanRange)
-
-			// Get friendly display name
-			displayName := GetFriendlyDisplayName(currentSSID, strength, false)
-
-			signal := scanner.Signal{
-				Type:        "WiFi",
-				Icon:        "≋",
-				Name:        displayName,
-				Color:       tcell.ColorBlue,
-				Strength:    strength,
-				Distance:    distance,
-				Angle:       rand.Float64() * 2 * math.Pi,
-				Phase:       0,
-				Lifetime:    now,
-				LastSeen:    now,
-				Persistence: 1.0,
-				History:     make([]scanner.PositionHistory, 0, 20),
-				MaxHistory:  20,
-			}
-
-			signal.AddToHistory(signal.Distance, signal.Angle, signal.Strength, true, now)
-			signals = append(signals, signal)
-
-			currentSSID = ""
-			currentRSSI = -50
-
-			if len(signals) >= l.config.MaxSignals {
-				break
-			}
-		}
+	// Flush the last BSS entry
+	if currentSSID != "" && len(signals) < l.config.MaxSignals {
+		signals = append(signals, l.iwSignal(currentBSSID, currentSSID, currentRSSI, now))
 	}
 
 	return signals, nil
 }
 
-// parseNmcliLine parses a line from nmcli output
-func (l *LinuxWiFiScanner) parseNmcliLine(line string, now time.Time) *scanner.Signal {
-	line = strings.TrimSpace(line)
-	if line == "" || strings.HasPrefix(line, "IN-USE") || strings.HasPrefix(line, "SSID") {
-		return nil
+// iwSignal builds a signal for one BSS entry of iw scan output
+func (l *LinuxWiFiScanner) iwSignal(bssid, ssid string, rssi int, now time.Time) model.Signal {
+	ap := AccessPoint{
+		BSSID: bssid,
+		SSID:  ssid,
+		RSSI:  rssi,
 	}
+	return accessPointSignal(ap, l.config, now)
+}
 
-	// Remove active connection indicator
-	if strings.HasPrefix(line, "*") {
-		line = strings.TrimSpace(line[1:])
+// parseIwBSSID extracts the BSSID from an iw "BSS aa:bb:cc:dd:ee:ff(on wlan0)" line
+func parseIwBSSID(line string) string {
+	fields := strings.Fields(strings.TrimPrefix(line, "BSS "))
+	if len(fields) == 0 {
+		return ""
 	}
-
-	parts := strings.Fields(line)
-	if len(parts) < 4 {
-		return nil
+	bssid := fields[0]
+	if i := strings.Index(bssid, "("); i >= 0 {
+		bssid = bssid[:i]
 	}
+	return strings.ToLower(bssid)
+}
 
-	ssid := parts[0]
-	if ssid == "" || ssid == "SSID" || ssid == "--" {
-		return nil
+// parseNmcliLine parses one line of `nmcli -t -f IN-USE,BSSID,SSID,CHAN,FREQ,RATE,SIGNAL,SECURITY` output
+func parseNmcliLine(line string) (AccessPoint, bool) {
+	line = strings.TrimRight(line, "\r")
+	if line == "" {
+		return AccessPoint{}, false
 	}
 
-	// Find signal strength
-	var strength int = 50
-	for _, part := range parts {
-		if val, err := strconv.Atoi(part); err == nil {
-			if val >= 0 && val <= 100 {
-				strength = val
-				break
-			} else if val < 0 && val >= -100 {
-				strength = rssiToStrength(val)
-				break
-			}
-		}
+	fields := splitTerse(line)
+	if len(fields) != 8 {
+		return AccessPoint{}, false
 	}
 
-	distance := float64(100-strength) / 15.0
-	if distance < 0.5 {
-		distance = 0.5
-	}
-	if distance > 10 {
-		distance = 10
+	ssid := fields[2]
+	if ssid == "" || ssid == "--" {
+		return AccessPoint{}, false
 	}
 
-	// Get friendly display name
-	displayName := GetFriendlyDisplayName(ssid, strength, false)
-
-	signal := scanner.Signal{
-		Type:        "WiFi",
-		Icon:        "≋",
-		Name:        displayName,
-		Color:       tcell.ColorBlue,
-		Strength:    strength,
-		Distance:    distance,
-		Angle:       rand.Float64() * 2 * math.Pi,
-		Phase:       0,
-		Lifetime:    now,
-		LastSeen:    now,
-		Persistence: 1.0,
-		History:     make([]scanner.PositionHistory, 0, 20),
-		MaxHistory:  20,
+	quality, err := strconv.Atoi(fields[6])
+	if err != nil {
+		return AccessPoint{}, false
 	}
 
-	signal.AddToHistory(signal.Distance, signal.Angle, signal.Strength, true, now)
-	return &signal
+	ap := AccessPoint{
+		BSSID:     strings.ToLower(fields[1]),
+		SSID:      ssid,
+		Channel:   atoiPrefix(fields[3]),
+		Frequency: atoiPrefix(fields[4]),
+		Rate:      atoiPrefix(fields[5]),
+		Quality:   quality,
+		RSSI:      nmcliQualityToRSSI(quality),
+		Security:  strings.TrimSpace(fields[7]),
+		Connected: strings.TrimSpace(fields[0]) == "*",
+	}
+	if ap.Security == "" || ap.Security == "--" {
+		ap.Security = "Open"
+	}
+	if ap.Channel == 0 {
+		ap.Channel = frequencyToChannel(ap.Frequency)
+	}
+	return ap, true
 }
 
-// rssiToStrength converts RSSI to percentage
-func rssiToStrength(rssi int) int {
-	if rssi >= -30 {
-		return 100
+// splitTerse splits an nmcli terse line on ':' while honouring the \: and \\ escapes
+func splitTerse(line string) []string {
+	fields := make([]string, 0, 8)
+	var current strings.Builder
+	escaped := false
+
+	for _, r := range line {
+		switch {
+		case escaped:
+			current.WriteRune(r)
+			escaped = false
+		case r == '\\':
+			escaped = true
+		case r == ':':
+			fields = append(fields, current.String())
+			current.Reset()
+		default:
+			current.WriteRune(r)
+		}
 	}
-	if rssi <= -90 {
+	return append(fields, current.String())
+}
+
+// atoiPrefix parses the leading integer of values like "2437 MHz" or "130 Mbit/s"
+func atoiPrefix(s string) int {
+	fields := strings.Fields(s)
+	if len(fields) == 0 {
 		return 0
 	}
-	return int(100 * (float64(rssi+90) / 60.0))
+	n, _ := strconv.Atoi(fields[0])
+	return n
 }
 
-// rssiToDistance converts RSSI to radar distance
-func rssiToDistance(rssi int, maxRange float64) float64 {
-	distance := math.Pow(10, float64(-rssi-30)/20.0) * 2.0
-	if distance < 0.5 {
-		distance = 0.5
-	}
-	if distance > maxRange {
-		distance = maxRange
-	}
-	return distance
+// nmcliQualityToRSSI inverts NetworkManager's signal percentage, which clamps
+// the level to [-100, -40] dBm and maps those 60 dB linearly onto 0-100:
+// quality = (dBm + 100) * 100 / 60
+func nmcliQualityToRSSI(quality int) int {
+	quality = max(0, min(100, quality))
+	return (quality*60+50)/100 - 100
 }
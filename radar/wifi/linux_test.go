@@ -0,0 +1,107 @@
+//go:build linux
+// +build linux
+
+package wifi
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNmcliQualityToRSSI(t *testing.T) {
+	tests := []struct {
+		quality int
+		want    int
+	}{
+		{100, -40},
+		{0, -100},
+		{50, -70},
+		{75, -55},
+		{120, -40}, // Out of range percentages are clamped
+		{-5, -100},
+	}
+	for _, tt := range tests {
+		if got := nmcliQualityToRSSI(tt.quality); got != tt.want {
+			t.Errorf("nmcliQualityToRSSI(%d) = %d, want %d", tt.quality, got, tt.want)
+		}
+	}
+
+	// NetworkManager's own conversion must round-trip within a decibel
+	for dBm := -100; dBm <= -40; dBm++ {
+		quality := 100 - int(100.0*float64(-40-dBm)/60.0)
+		if got := nmcliQualityToRSSI(quality); got < dBm-1 || got > dBm+1 {
+			t.Errorf("%d dBm: quality %d inverts to %d dBm", dBm, quality, got)
+		}
+	}
+}
+
+func TestSplitTerse(t *testing.T) {
+	tests := []struct {
+		name string
+		line string
+		want []string
+	}{
+		{"plain", "a:b:c", []string{"a", "b", "c"}},
+		{"escaped colons", `AA\:BB\:CC:x`, []string{"AA:BB:CC", "x"}},
+		{"escaped backslash", `a\\b:c`, []string{`a\b`, "c"}},
+		{"backslash before a colon separator", `a\\:b`, []string{`a\`, "b"}},
+		{"empty fields", "::", []string{"", "", ""}},
+		{"empty line", "", []string{""}},
+		{"trailing escape dropped", `a\`, []string{"a"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := splitTerse(tt.line); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("splitTerse(%q) = %q, want %q", tt.line, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseNmcliLine(t *testing.T) {
+	tests := []struct {
+		name string
+		line string
+		want AccessPoint
+		ok   bool
+	}{
+		{
+			name: "connected network",
+			line: `*:AA\:BB\:CC\:DD\:EE\:01:HomeNet:6:2437 MHz:130 Mbit/s:75:WPA2`,
+			want: AccessPoint{BSSID: "aa:bb:cc:dd:ee:01", SSID: "HomeNet", Channel: 6, Frequency: 2437, Rate: 130, Quality: 75, RSSI: -55, Security: "WPA2", Connected: true},
+			ok:   true,
+		},
+		{
+			name: "SSID with colons",
+			line: ` :AA\:BB\:CC\:DD\:EE\:02:Cafe\:Guest\:5G:36:5180 MHz:540 Mbit/s:50:WPA2 WPA3`,
+			want: AccessPoint{BSSID: "aa:bb:cc:dd:ee:02", SSID: "Cafe:Guest:5G", Channel: 36, Frequency: 5180, Rate: 540, Quality: 50, RSSI: -70, Security: "WPA2 WPA3"},
+			ok:   true,
+		},
+		{
+			name: "SSID with a backslash and spaces",
+			line: ` :AA\:BB\:CC\:DD\:EE\:03:Lab \\ Floor 2:11:2462 MHz:54 Mbit/s:100:WPA1`,
+			want: AccessPoint{BSSID: "aa:bb:cc:dd:ee:03", SSID: `Lab \ Floor 2`, Channel: 11, Frequency: 2462, Rate: 54, Quality: 100, RSSI: -40, Security: "WPA1"},
+			ok:   true,
+		},
+		{
+			name: "open network without a channel",
+			line: " :AA\\:BB\\:CC\\:DD\\:EE\\:04:Airport::2412 MHz:54 Mbit/s:0:--\r",
+			want: AccessPoint{BSSID: "aa:bb:cc:dd:ee:04", SSID: "Airport", Channel: 1, Frequency: 2412, Rate: 54, RSSI: -100, Security: "Open"},
+			ok:   true,
+		},
+		{"hidden network", ` :AA\:BB\:CC\:DD\:EE\:05::6:2437 MHz:54 Mbit/s:40:WPA2`, AccessPoint{}, false},
+		{"hidden network shown as --", ` :AA\:BB\:CC\:DD\:EE\:05:--:6:2437 MHz:54 Mbit/s:40:WPA2`, AccessPoint{}, false},
+		{"short line", ` :AA\:BB\:CC\:DD\:EE\:06:HomeNet:6:2437 MHz`, AccessPoint{}, false},
+		{"unescaped BSSID", ` :AA:BB:CC:DD:EE:07:HomeNet:6:2437 MHz:54 Mbit/s:40:WPA2`, AccessPoint{}, false},
+		{"signal not a number", ` :AA\:BB\:CC\:DD\:EE\:08:HomeNet:6:2437 MHz:54 Mbit/s:--:WPA2`, AccessPoint{}, false},
+		{"empty line", "", AccessPoint{}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := parseNmcliLine(tt.line)
+			if ok != tt.ok || !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseNmcliLine(%q) = %+v, %v\nwant %+v, %v", tt.line, got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
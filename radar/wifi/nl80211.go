@@ -0,0 +1,340 @@
+package wifi
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+)
+
+// Netlink and nl80211 protocol constants used by the message parser. They are
+// kept here rather than taken from golang.org/x/sys/unix so the parser can be
+// exercised with captured messages on any platform.
+const (
+	nlmsgHeaderLen  = 16
+	genlHeaderLen   = 4
+	nlattrHeaderLen = 4
+
+	nlmsgError = 0x2
+	nlmsgDone  = 0x3
+
+	nlaTypeMask = 0x3fff
+
+	ctrlAttrFamilyID     = 1
+	ctrlAttrMcastGroups  = 7
+	ctrlAttrMcastGrpName = 1
+	ctrlAttrMcastGrpID   = 2
+
+	nl80211CmdNewScanResults = 34
+	nl80211CmdScanAborted    = 35
+
+	nl80211AttrIfindex = 3
+	nl80211AttrIfname  = 4
+	nl80211AttrIftype  = 5
+	nl80211AttrBSS     = 0x2f
+
+	nl80211BSSBSSID               = 1
+	nl80211BSSFrequency           = 2
+	nl80211BSSCapability          = 5
+	nl80211BSSInformationElements = 6
+	nl80211BSSSignalMBM           = 7
+	nl80211BSSSignalUnspec        = 8
+	nl80211BSSStatus              = 9
+	nl80211BSSBeaconIEs           = 11
+
+	nl80211BSSStatusAssociated = 1
+	nl80211IftypeStation       = 2
+)
+
+// 802.11 information element IDs
+const (
+	ieSSID         = 0
+	ieHTOperation  = 61
+	ieRSN          = 48
+	ieVHTOperation = 192
+	ieVendor       = 221
+)
+
+// netlinkMessage is one message of a netlink stream
+type netlinkMessage struct {
+	Type    uint16
+	Flags   uint16
+	Seq     uint32
+	Payload []byte
+}
+
+// netlinkAttr is one netlink attribute; nested attributes keep their raw value
+type netlinkAttr struct {
+	Type  uint16
+	Value []byte
+}
+
+// parseNetlinkMessages splits a buffer received from a netlink socket into messages
+func parseNetlinkMessages(data []byte) ([]netlinkMessage, error) {
+	messages := make([]netlinkMessage, 0)
+	for len(data) >= nlmsgHeaderLen {
+		length := int(binary.NativeEndian.Uint32(data[0:4]))
+		if length < nlmsgHeaderLen || length > len(data) {
+			return messages, fmt.Errorf("netlink: invalid message length %d", length)
+		}
+
+		messages = append(messages, netlinkMessage{
+			Type:    binary.NativeEndian.Uint16(data[4:6]),
+			Flags:   binary.NativeEndian.Uint16(data[6:8]),
+			Seq:     binary.NativeEndian.Uint32(data[8:12]),
+			Payload: data[nlmsgHeaderLen:length],
+		})
+
+		if align4(length) >= len(data) {
+			break
+		}
+		data = data[align4(length):]
+	}
+	return messages, nil
+}
+
+// netlinkError extracts the errno carried by an NLMSG_ERROR message (0 is an ACK)
+func netlinkError(msg netlinkMessage) int32 {
+	if len(msg.Payload) < 4 {
+		return 0
+	}
+	return -int32(binary.NativeEndian.Uint32(msg.Payload[0:4]))
+}
+
+// parseAttributes decodes a sequence of netlink attributes
+func parseAttributes(data []byte) ([]netlinkAttr, error) {
+	attrs := make([]netlinkAttr, 0)
+	for len(data) >= nlattrHeaderLen {
+		length := int(binary.NativeEndian.Uint16(data[0:2]))
+		if length < nlattrHeaderLen || length > len(data) {
+			return attrs, fmt.Errorf("netlink: invalid attribute length %d", length)
+		}
+
+		attrs = append(attrs, netlinkAttr{
+			Type:  binary.NativeEndian.Uint16(data[2:4]) & nlaTypeMask,
+			Value: data[nlattrHeaderLen:length],
+		})
+
+		if align4(length) >= len(data) {
+			break
+		}
+		data = data[align4(length):]
+	}
+	return attrs, nil
+}
+
+// encodeAttribute appends a netlink attribute to buf
+func encodeAttribute(buf []byte, attrType uint16, value []byte) []byte {
+	header := make([]byte, nlattrHeaderLen)
+	binary.NativeEndian.PutUint16(header[0:2], uint16(nlattrHeaderLen+len(value)))
+	binary.NativeEndian.PutUint16(header[2:4], attrType)
+	buf = append(buf, header...)
+	buf = append(buf, value...)
+	for len(buf)%4 != 0 {
+		buf = append(buf, 0)
+	}
+	return buf
+}
+
+// genlAttributes returns the attributes that follow the generic netlink header
+func genlAttributes(msg netlinkMessage) ([]netlinkAttr, error) {
+	if len(msg.Payload) < genlHeaderLen {
+		return nil, errors.New("netlink: short generic netlink message")
+	}
+	return parseAttributes(msg.Payload[genlHeaderLen:])
+}
+
+// scanEvent decodes a message from the nl80211 scan multicast group,
+// reporting the interface whose scan finished and whether it was aborted
+func scanEvent(msg netlinkMessage) (ifindex uint32, aborted bool, ok bool) {
+	if len(msg.Payload) < genlHeaderLen {
+		return 0, false, false
+	}
+	switch msg.Payload[0] {
+	case nl80211CmdNewScanResults:
+	case nl80211CmdScanAborted:
+		aborted = true
+	default:
+		return 0, false, false
+	}
+
+	attrs, err := genlAttributes(msg)
+	if err != nil {
+		return 0, false, false
+	}
+	for _, attr := range attrs {
+		if attr.Type == nl80211AttrIfindex && len(attr.Value) >= 4 {
+			return binary.NativeEndian.Uint32(attr.Value), aborted, true
+		}
+	}
+	return 0, false, false
+}
+
+// parseScanResults decodes the access points in a NL80211_CMD_GET_SCAN dump
+func parseScanResults(messages []netlinkMessage) ([]AccessPoint, error) {
+	aps := make([]AccessPoint, 0)
+	for _, msg := range messages {
+		if msg.Type == nlmsgDone || msg.Type == nlmsgError {
+			continue
+		}
+
+		attrs, err := genlAttributes(msg)
+		if err != nil {
+			return aps, err
+		}
+
+		for _, attr := range attrs {
+			if attr.Type != nl80211AttrBSS {
+				continue
+			}
+			if ap, ok := parseBSS(attr.Value); ok {
+				aps = append(aps, ap)
+			}
+		}
+	}
+	return aps, nil
+}
+
+// parseBSS decodes a nested NL80211_ATTR_BSS attribute
+func parseBSS(data []byte) (AccessPoint, bool) {
+	attrs, err := parseAttributes(data)
+	if err != nil {
+		return AccessPoint{}, false
+	}
+
+	var ap AccessPoint
+	var capability uint16
+	var beaconIEs []byte
+	for _, attr := range attrs {
+		switch attr.Type {
+		case nl80211BSSBSSID:
+			if len(attr.Value) == 6 {
+				ap.BSSID = net.HardwareAddr(attr.Value).String()
+			}
+		case nl80211BSSFrequency:
+			if len(attr.Value) >= 4 {
+				ap.Frequency = int(binary.NativeEndian.Uint32(attr.Value))
+			}
+		case nl80211BSSCapability:
+			if len(attr.Value) >= 2 {
+				capability = binary.NativeEndian.Uint16(attr.Value)
+			}
+		case nl80211BSSInformationElements:
+			ap.InformationElements = attr.Value
+		case nl80211BSSBeaconIEs:
+			beaconIEs = attr.Value
+		case nl80211BSSSignalMBM:
+			if len(attr.Value) >= 4 {
+				// Signal strength in mBm (hundredths of a dBm)
+				ap.RSSI = int(int32(binary.NativeEndian.Uint32(attr.Value))) / 100
+			}
+		case nl80211BSSSignalUnspec:
+			if len(attr.Value) >= 1 && ap.RSSI == 0 {
+				// Unitless 0-100 quality, used by drivers that cannot report dBm
+				ap.RSSI = int(attr.Value[0])*60/100 - 90
+			}
+		case nl80211BSSStatus:
+			if len(attr.Value) >= 4 {
+				ap.Connected = binary.NativeEndian.Uint32(attr.Value) == nl80211BSSStatusAssociated
+			}
+		}
+	}
+
+	if ap.BSSID == "" {
+		return AccessPoint{}, false
+	}
+
+	// Probe responses carry the most complete IEs; fall back to the beacon
+	ies := ap.InformationElements
+	if len(ies) == 0 {
+		ies = beaconIEs
+		ap.InformationElements = beaconIEs
+	}
+
+	ap.Channel = frequencyToChannel(ap.Frequency)
+	ap.SSID, ap.ChannelWidth, ap.Security = parseInformationElements(ies, capability)
+	return ap, true
+}
+
+// parseInformationElements extracts the SSID, channel width (MHz) and security
+// suite from raw 802.11 information elements
+func parseInformationElements(ies []byte, capability uint16) (ssid string, width int, security string) {
+	width = 20
+	security = "Open"
+	if capability&0x0010 != 0 {
+		// Privacy bit without WPA/RSN elements means WEP
+		security = "WEP"
+	}
+
+	for len(ies) >= 2 {
+		id, length := ies[0], int(ies[1])
+		if 2+length > len(ies) {
+			break
+		}
+		data := ies[2 : 2+length]
+
+		switch id {
+		case ieSSID:
+			ssid = string(data)
+		case ieHTOperation:
+			// STA channel width bit plus a secondary channel offset means 40 MHz
+			if len(data) >= 2 && data[1]&0x04 != 0 && data[1]&0x03 != 0 && width < 40 {
+				width = 40
+			}
+		case ieVHTOperation:
+			if len(data) >= 3 {
+				switch data[0] {
+				case 1:
+					width = 80
+					// A second segment 8 channels away signals 160 MHz
+					if data[2] != 0 {
+						width = 160
+					}
+				case 2, 3:
+					width = 160
+				}
+			}
+		case ieRSN:
+			security = parseRSN(data)
+		case ieVendor:
+			// Microsoft WPA element (OUI 00:50:F2, type 1)
+			if len(data) >= 4 && data[0] == 0x00 && data[1] == 0x50 && data[2] == 0xf2 && data[3] == 1 && security != "WPA2" && security != "WPA3" {
+				security = "WPA"
+			}
+		}
+
+		ies = ies[2+length:]
+	}
+	return ssid, width, security
+}
+
+// parseRSN distinguishes WPA2 from WPA3 using the AKM suites of an RSN element
+func parseRSN(data []byte) string {
+	// version(2) + group cipher(4) + pairwise count(2)
+	if len(data) < 8 {
+		return "WPA2"
+	}
+	pairwise := int(binary.LittleEndian.Uint16(data[6:8]))
+	offset := 8 + 4*pairwise
+	if len(data) < offset+2 {
+		return "WPA2"
+	}
+	akmCount := int(binary.LittleEndian.Uint16(data[offset : offset+2]))
+	offset += 2
+
+	for i := 0; i < akmCount && offset+4 <= len(data); i++ {
+		suite := data[offset : offset+4]
+		// 00-0F-AC:8 is SAE, 9 is SAE with fast transition, 24 and 25 are
+		// their variants with a group-dependent hash
+		if suite[0] == 0x00 && suite[1] == 0x0f && suite[2] == 0xac && (suite[3] == 8 || suite[3] == 9 || suite[3] == 24 || suite[3] == 25) {
+			return "WPA3"
+		}
+		offset += 4
+	}
+	return "WPA2"
+}
+
+// align4 rounds n up to the netlink alignment
+func align4(n int) int {
+	return (n + 3) &^ 3
+}
@@ -0,0 +1,352 @@
+//go:build linux
+// +build linux
+
+package wifi
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+// nl80211Conn is a generic netlink socket bound to the nl80211 family
+type nl80211Conn struct {
+	fd        int
+	familyID  uint16
+	scanGroup uint32 // multicast group id of the "scan" group (0 if unknown)
+	seq       uint32
+}
+
+// dialNL80211 opens a generic netlink socket and resolves the nl80211 family
+func dialNL80211() (*nl80211Conn, error) {
+	fd, err := unix.Socket(unix.AF_NETLINK, unix.SOCK_RAW|unix.SOCK_CLOEXEC, unix.NETLINK_GENERIC)
+	if err != nil {
+		return nil, fmt.Errorf("nl80211: socket: %w", err)
+	}
+	if err := unix.Bind(fd, &unix.SockaddrNetlink{Family: unix.AF_NETLINK}); err != nil {
+		unix.Close(fd)
+		return nil, fmt.Errorf("nl80211: bind: %w", err)
+	}
+
+	// Bound reads so a missing reply cannot hang a scan
+	tv := unix.NsecToTimeval((5 * time.Second).Nanoseconds())
+	unix.SetsockoptTimeval(fd, unix.SOL_SOCKET, unix.SO_RCVTIMEO, &tv)
+
+	conn := &nl80211Conn{fd: fd}
+	if err := conn.resolveFamily(); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return conn, nil
+}
+
+// Close releases the socket
+func (c *nl80211Conn) Close() error {
+	return unix.Close(c.fd)
+}
+
+// resolveFamily looks up the nl80211 family id and its scan multicast group
+func (c *nl80211Conn) resolveFamily() error {
+	attrs := encodeAttribute(nil, unix.CTRL_ATTR_FAMILY_NAME, append([]byte("nl80211"), 0))
+	messages, err := c.execute(unix.GENL_ID_CTRL, unix.CTRL_CMD_GETFAMILY, 0, attrs)
+	if err != nil {
+		return fmt.Errorf("nl80211: resolving family: %w", err)
+	}
+
+	for _, msg := range messages {
+		if msg.Type == nlmsgDone || msg.Type == nlmsgError {
+			continue
+		}
+		attrs, err := genlAttributes(msg)
+		if err != nil {
+			return err
+		}
+		for _, attr := range attrs {
+			switch attr.Type {
+			case ctrlAttrFamilyID:
+				if len(attr.Value) >= 2 {
+					c.familyID = binary.NativeEndian.Uint16(attr.Value)
+				}
+			case ctrlAttrMcastGroups:
+				c.scanGroup = findMulticastGroup(attr.Value, "scan")
+			}
+		}
+	}
+
+	if c.familyID == 0 {
+		return errors.New("nl80211: family not available")
+	}
+	return nil
+}
+
+// findMulticastGroup returns the id of a named group in CTRL_ATTR_MCAST_GROUPS
+func findMulticastGroup(data []byte, name string) uint32 {
+	groups, err := parseAttributes(data)
+	if err != nil {
+		return 0
+	}
+	for _, group := range groups {
+		attrs, err := parseAttributes(group.Value)
+		if err != nil {
+			continue
+		}
+		var groupName string
+		var groupID uint32
+		for _, attr := range attrs {
+			switch attr.Type {
+			case ctrlAttrMcastGrpName:
+				groupName = string(trimNull(attr.Value))
+			case ctrlAttrMcastGrpID:
+				if len(attr.Value) >= 4 {
+					groupID = binary.NativeEndian.Uint32(attr.Value)
+				}
+			}
+		}
+		if groupName == name {
+			return groupID
+		}
+	}
+	return 0
+}
+
+// stationInterfaces returns the ifindex of every wireless interface in station mode
+func (c *nl80211Conn) stationInterfaces() ([]uint32, error) {
+	messages, err := c.execute(c.familyID, unix.NL80211_CMD_GET_INTERFACE, unix.NLM_F_DUMP, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	indexes := make([]uint32, 0)
+	for _, msg := range messages {
+		if msg.Type == nlmsgDone || msg.Type == nlmsgError {
+			continue
+		}
+		attrs, err := genlAttributes(msg)
+		if err != nil {
+			return indexes, err
+		}
+
+		var ifindex, iftype uint32
+		for _, attr := range attrs {
+			switch attr.Type {
+			case nl80211AttrIfindex:
+				if len(attr.Value) >= 4 {
+					ifindex = binary.NativeEndian.Uint32(attr.Value)
+				}
+			case nl80211AttrIftype:
+				if len(attr.Value) >= 4 {
+					iftype = binary.NativeEndian.Uint32(attr.Value)
+				}
+			}
+		}
+		if ifindex != 0 && iftype == nl80211IftypeStation {
+			indexes = append(indexes, ifindex)
+		}
+	}
+	return indexes, nil
+}
+
+// resultsReserve is the time kept before the ctx deadline for dumping the
+// scan results once the scans are done
+const resultsReserve = 500 * time.Millisecond
+
+// triggerScans asks the kernel for a fresh scan on every interface at once and
+// waits until they all complete or the time left before ctx expires runs down
+// to resultsReserve. Unprivileged users cannot trigger scans; callers then
+// fall back to the cached results of the last scan.
+func (c *nl80211Conn) triggerScans(ctx context.Context, interfaces []uint32) error {
+	if c.scanGroup == 0 {
+		return errors.New("nl80211: scan multicast group not found")
+	}
+
+	// Subscribe before triggering so no completion event can be missed
+	events, err := unix.Socket(unix.AF_NETLINK, unix.SOCK_RAW|unix.SOCK_CLOEXEC, unix.NETLINK_GENERIC)
+	if err != nil {
+		return err
+	}
+	defer unix.Close(events)
+	if err := unix.Bind(events, &unix.SockaddrNetlink{Family: unix.AF_NETLINK}); err != nil {
+		return err
+	}
+	if err := unix.SetsockoptInt(events, unix.SOL_NETLINK, unix.NETLINK_ADD_MEMBERSHIP, int(c.scanGroup)); err != nil {
+		return err
+	}
+
+	pending := make(map[uint32]bool, len(interfaces))
+	var errs []error
+	for _, ifindex := range interfaces {
+		attrs := encodeAttribute(nil, nl80211AttrIfindex, uint32Bytes(ifindex))
+		if _, err := c.execute(c.familyID, unix.NL80211_CMD_TRIGGER_SCAN, unix.NLM_F_ACK, attrs); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		pending[ifindex] = true
+	}
+
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(10 * time.Second)
+	}
+	deadline = deadline.Add(-resultsReserve)
+
+	buf := make([]byte, os.Getpagesize()*4)
+	for len(pending) > 0 {
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			errs = append(errs, context.DeadlineExceeded)
+			break
+		}
+		tv := unix.NsecToTimeval(remaining.Nanoseconds())
+		unix.SetsockoptTimeval(events, unix.SOL_SOCKET, unix.SO_RCVTIMEO, &tv)
+
+		n, _, err := unix.Recvfrom(events, buf, 0)
+		if err != nil {
+			errs = append(errs, err)
+			break
+		}
+		messages, err := parseNetlinkMessages(buf[:n])
+		if err != nil {
+			errs = append(errs, err)
+			break
+		}
+		for _, msg := range messages {
+			ifindex, aborted, ok := scanEvent(msg)
+			if !ok || !pending[ifindex] {
+				continue
+			}
+			delete(pending, ifindex)
+			if aborted {
+				errs = append(errs, fmt.Errorf("nl80211: scan aborted on interface %d", ifindex))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// scanResults dumps the kernel's BSS table for an interface
+func (c *nl80211Conn) scanResults(ifindex uint32) ([]AccessPoint, error) {
+	attrs := encodeAttribute(nil, nl80211AttrIfindex, uint32Bytes(ifindex))
+	messages, err := c.execute(c.familyID, unix.NL80211_CMD_GET_SCAN, unix.NLM_F_DUMP, attrs)
+	if err != nil {
+		return nil, err
+	}
+	return parseScanResults(messages)
+}
+
+// execute sends a generic netlink request and collects the reply messages
+func (c *nl80211Conn) execute(family uint16, cmd uint8, flags uint16, attrs []byte) ([]netlinkMessage, error) {
+	seq := atomic.AddUint32(&c.seq, 1)
+
+	length := nlmsgHeaderLen + genlHeaderLen + len(attrs)
+	req := make([]byte, nlmsgHeaderLen+genlHeaderLen, length)
+	binary.NativeEndian.PutUint32(req[0:4], uint32(length))
+	binary.NativeEndian.PutUint16(req[4:6], family)
+	binary.NativeEndian.PutUint16(req[6:8], unix.NLM_F_REQUEST|flags)
+	binary.NativeEndian.PutUint32(req[8:12], seq)
+	req[nlmsgHeaderLen] = cmd
+	req[nlmsgHeaderLen+1] = 1 // generic netlink version
+	req = append(req, attrs...)
+
+	if err := unix.Sendto(c.fd, req, 0, &unix.SockaddrNetlink{Family: unix.AF_NETLINK}); err != nil {
+		return nil, err
+	}
+
+	dump := flags&unix.NLM_F_DUMP == unix.NLM_F_DUMP
+	wantAck := flags&unix.NLM_F_ACK != 0
+	replies := make([]netlinkMessage, 0)
+	buf := make([]byte, os.Getpagesize()*16)
+
+	for {
+		n, _, err := unix.Recvfrom(c.fd, buf, 0)
+		if err != nil {
+			return replies, err
+		}
+		messages, err := parseNetlinkMessages(buf[:n])
+		if err != nil {
+			return replies, err
+		}
+
+		for _, msg := range messages {
+			if msg.Seq != seq {
+				continue
+			}
+			switch msg.Type {
+			case nlmsgDone:
+				return replies, nil
+			case nlmsgError:
+				if errno := netlinkError(msg); errno != 0 {
+					return replies, unix.Errno(errno)
+				}
+				return replies, nil
+			}
+
+			// Copy out of the shared receive buffer
+			msg.Payload = append([]byte(nil), msg.Payload...)
+			replies = append(replies, msg)
+			if !dump && !wantAck {
+				return replies, nil
+			}
+		}
+	}
+}
+
+// uint32Bytes encodes v in native byte order
+func uint32Bytes(v uint32) []byte {
+	b := make([]byte, 4)
+	binary.NativeEndian.PutUint32(b, v)
+	return b
+}
+
+// trimNull strips a trailing NUL terminator
+func trimNull(b []byte) []byte {
+	for len(b) > 0 && b[len(b)-1] == 0 {
+		b = b[:len(b)-1]
+	}
+	return b
+}
+
+// scanNL80211 runs a scan on every station interface and returns the access points seen
+func scanNL80211(ctx context.Context) ([]AccessPoint, error) {
+	conn, err := dialNL80211()
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	interfaces, err := conn.stationInterfaces()
+	if err != nil {
+		return nil, err
+	}
+	if len(interfaces) == 0 {
+		return nil, errors.New("nl80211: no wireless interfaces")
+	}
+
+	// Best effort: without CAP_NET_ADMIN we still get the last scan's results
+	conn.triggerScans(ctx, interfaces)
+
+	aps := make([]AccessPoint, 0)
+	for _, ifindex := range interfaces {
+		results, err := conn.scanResults(ifindex)
+		if err != nil {
+			continue
+		}
+		aps = append(aps, results...)
+	}
+	return aps, nil
+}
+
+// nl80211Available reports whether the kernel exposes the nl80211 family
+func nl80211Available() bool {
+	conn, err := dialNL80211()
+	if err != nil {
+		return false
+	}
+	conn.Close()
+	return true
+}
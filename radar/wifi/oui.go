@@ -0,0 +1,286 @@
+package wifi
+
+import (
+	"bytes"
+	"context"
+	_ "embed"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// embeddedOUI is a snapshot of the IEEE MA-L registry covering vendors
+// commonly found on home and office networks, in the IEEE CSV format
+//
+//go:embed oui.csv
+var embeddedOUI []byte
+
+// OUIRegistryURLs are the IEEE registries in CSV form: MA-L assigns 24-bit
+// prefixes, MA-M 28-bit and MA-S 36-bit ones
+var OUIRegistryURLs = []string{
+	"https://standards-oui.ieee.org/oui/oui.csv",
+	"https://standards-oui.ieee.org/oui28/mam.csv",
+	"https://standards-oui.ieee.org/oui36/oui36.csv",
+}
+
+// prefixBits are the assignment sizes, longest first so the most specific
+// assignment wins
+var prefixBits = []int{36, 28, 24}
+
+// OUIDatabase maps IEEE MAC address block assignments to organization names
+type OUIDatabase struct {
+	blocks map[int]map[uint64]string // Prefix length in bits -> prefix -> organization
+}
+
+// ParseOUIDatabase reads one or more concatenated IEEE registry CSV files
+func ParseOUIDatabase(r io.Reader) (*OUIDatabase, error) {
+	db := &OUIDatabase{blocks: make(map[int]map[uint64]string)}
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+
+	for {
+		row, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		// Concatenated registries repeat the header row
+		if len(row) < 3 || strings.EqualFold(row[0], "Registry") {
+			continue
+		}
+		assignment := strings.TrimSpace(row[1])
+		bits := len(assignment) * 4
+		prefix, err := strconv.ParseUint(assignment, 16, 64)
+		if err != nil || (bits != 24 && bits != 28 && bits != 36) {
+			continue
+		}
+		if db.blocks[bits] == nil {
+			db.blocks[bits] = make(map[uint64]string)
+		}
+		db.blocks[bits][prefix] = strings.TrimSpace(row[2])
+	}
+
+	if db.Len() == 0 {
+		return nil, errors.New("oui: no assignments found")
+	}
+	return db, nil
+}
+
+// Len returns the number of assignments
+func (db *OUIDatabase) Len() int {
+	n := 0
+	for _, block := range db.blocks {
+		n += len(block)
+	}
+	return n
+}
+
+// Lookup returns the organization assigned the address's prefix
+func (db *OUIDatabase) Lookup(mac net.HardwareAddr) string {
+	if len(mac) < 6 {
+		return ""
+	}
+	var address uint64
+	for _, b := range mac[:6] {
+		address = address<<8 | uint64(b)
+	}
+	for _, bits := range prefixBits {
+		if org, ok := db.blocks[bits][address>>(48-bits)]; ok {
+			return org
+		}
+	}
+	return ""
+}
+
+// merge adds the assignments of other, replacing existing ones
+func (db *OUIDatabase) merge(other *OUIDatabase) {
+	for bits, block := range other.blocks {
+		if db.blocks[bits] == nil {
+			db.blocks[bits] = make(map[uint64]string)
+		}
+		for prefix, org := range block {
+			db.blocks[bits][prefix] = org
+		}
+	}
+}
+
+var (
+	ouiOnce sync.Once
+	ouiDB   *OUIDatabase
+)
+
+// ouiDatabase returns the embedded registry, extended by the file at
+// DefaultOUIPath when one has been installed
+func ouiDatabase() *OUIDatabase {
+	ouiOnce.Do(func() {
+		db, err := ParseOUIDatabase(bytes.NewReader(embeddedOUI))
+		if err != nil {
+			db = &OUIDatabase{blocks: make(map[int]map[uint64]string)}
+		}
+		if data, err := os.ReadFile(DefaultOUIPath()); err == nil {
+			if installed, err := ParseOUIDatabase(bytes.NewReader(data)); err == nil {
+				db.merge(installed)
+			}
+		}
+		ouiDB = db
+	})
+	return ouiDB
+}
+
+// DefaultOUIPath returns where UpdateOUIDatabase installs the full registry
+func DefaultOUIPath() string {
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		return "oui.csv"
+	}
+	return filepath.Join(configDir, "radar", "oui.csv")
+}
+
+// UpdateOUIDatabase downloads the IEEE registries and installs them at path,
+// returning the number of assignments. The file is only replaced once every
+// registry has downloaded and parsed.
+func UpdateOUIDatabase(ctx context.Context, client *http.Client, urls []string, path string) (int, error) {
+	var data bytes.Buffer
+	for _, url := range urls {
+		if err := download(ctx, client, url, &data); err != nil {
+			return 0, err
+		}
+	}
+	db, err := ParseOUIDatabase(bytes.NewReader(data.Bytes()))
+	if err != nil {
+		return 0, err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return 0, err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".oui-*.csv")
+	if err != nil {
+		return 0, err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data.Bytes()); err != nil {
+		tmp.Close()
+		return 0, err
+	}
+	if err := tmp.Close(); err != nil {
+		return 0, err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return 0, err
+	}
+	return db.Len(), nil
+}
+
+// download appends the body of url to w, ending it with a newline so the
+// next registry starts on its own row
+func download(ctx context.Context, client *http.Client, url string, w *bytes.Buffer) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	// The IEEE server rejects requests without a browser-like user agent
+	req.Header.Set("User-Agent", "Mozilla/5.0 (compatible; radar)")
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s: %s", url, resp.Status)
+	}
+	if _, err := io.Copy(w, resp.Body); err != nil {
+		return err
+	}
+	w.WriteByte('\n')
+	return nil
+}
+
+// IsLocallyAdministered reports whether an address was assigned locally
+// rather than by its manufacturer, as randomized client and hotspot
+// addresses are
+func IsLocallyAdministered(mac net.HardwareAddr) bool {
+	return len(mac) > 0 && mac[0]&0x02 != 0
+}
+
+// LookupVendor returns a short name for the manufacturer of a MAC address or
+// BSSID, or "" when it is unknown or locally administered
+func LookupVendor(mac net.HardwareAddr) string {
+	if IsLocallyAdministered(mac) {
+		return ""
+	}
+	return shortVendor(ouiDatabase().Lookup(mac))
+}
+
+// lookupVendorString is LookupVendor for a textual address
+func lookupVendorString(address string) (string, bool) {
+	mac, err := net.ParseMAC(address)
+	if err != nil {
+		return "", false
+	}
+	return LookupVendor(mac), IsLocallyAdministered(mac)
+}
+
+// legalSuffixes are dropped from registry names to keep them short
+var legalSuffixes = []string{
+	"inc", "incorporated", "corp", "corporation", "corporate", "co", "ltd",
+	"llc", "gmbh", "ab", "bv", "technologies", "technology",
+}
+
+// shortVendor trims a registry organization name for display, e.g.
+// "TP-LINK TECHNOLOGIES CO.,LTD." becomes "TP-LINK"
+func shortVendor(org string) string {
+	// "Aruba, a Hewlett Packard Enterprise Company"
+	if name, _, ok := strings.Cut(org, ", a "); ok {
+		org = name
+	}
+	words := strings.FieldsFunc(org, func(r rune) bool {
+		return unicode.IsSpace(r) || r == ','
+	})
+	for len(words) > 1 {
+		last := strings.ToLower(strings.TrimRight(words[len(words)-1], "."))
+		if !isLegalSuffix(last) {
+			break
+		}
+		words = words[:len(words)-1]
+	}
+	for i, word := range words {
+		// "BUFFALO.INC"
+		if name, suffix, ok := strings.Cut(word, "."); ok && isLegalSuffix(strings.ToLower(suffix)) {
+			word = name
+		}
+		words[i] = titleCase(word)
+	}
+	return strings.Join(words, " ")
+}
+
+// isLegalSuffix reports whether a lowercased word is a legal-form suffix
+func isLegalSuffix(word string) bool {
+	for _, suffix := range legalSuffixes {
+		if word == suffix {
+			return true
+		}
+	}
+	return false
+}
+
+// titleCase turns long all-capitals words such as "NETGEAR" into "Netgear",
+// leaving acronyms and hyphenated brands alone
+func titleCase(word string) string {
+	if len(word) < 5 || strings.ToUpper(word) != word || strings.ContainsAny(word, "-.0123456789") {
+		return word
+	}
+	return word[:1] + strings.ToLower(word[1:])
+}
@@ -6,6 +6,7 @@ package wifi
 import (
 	"context"
 
+	"github.com/e6a5/radar/radar/model"
 	"github.com/e6a5/radar/radar/scanner"
 )
 
@@ -32,6 +33,6 @@ func (s *StubWiFiScanner) IsAvailable() bool {
 }
 
 // Scan returns empty signals for unsupported platforms
-func (s *StubWiFiScanner) Scan(ctx context.Context) ([]scanner.Signal, error) {
-	return []scanner.Signal{}, nil
+func (s *StubWiFiScanner) Scan(ctx context.Context) ([]model.Signal, error) {
+	return []model.Signal{}, nil
 }
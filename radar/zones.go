@@ -0,0 +1,203 @@
+package radar
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/e6a5/radar/radar/guard"
+	"github.com/gdamore/tcell/v2"
+)
+
+// alarmFlashTime is how long the border flashes after an alarm
+const alarmFlashTime = 5 * time.Second
+
+// observeZones checks a signal the sweep has just passed against the guard zones
+func (rd *Display) observeZones(s *Signal, now time.Time) {
+	rd.raiseAlarms(rd.guard.Observe(s, now))
+}
+
+// expireZones raises leave alarms for signals that left the scope while
+// inside a zone
+func (rd *Display) expireZones(now time.Time) {
+	present := make(map[string]bool, len(rd.signals))
+	for i := range rd.signals {
+		present[guard.PresenceKey(&rd.signals[i])] = true
+	}
+	rd.raiseAlarms(rd.guard.Expire(func(key string) bool { return present[key] }, now))
+}
+
+// raiseAlarms announces guard zone events with the configured alarms
+func (rd *Display) raiseAlarms(events []guard.Event) {
+	if len(events) == 0 {
+		return
+	}
+
+	message := "ALARM: " + events[0].String()
+	if len(events) > 1 {
+		message += fmt.Sprintf(" (+%d more)", len(events)-1)
+	}
+	rd.setNotice(message, true)
+
+	if rd.config.AlarmBell {
+		rd.bellPending = true
+	}
+	if rd.config.AlarmFlash {
+		rd.alarmUntil = time.Now().Add(alarmFlashTime)
+	}
+
+	for _, event := range events {
+		if rd.config.AlarmLog != "" {
+			if err := guard.AppendLog(rd.config.AlarmLog, event); err != nil {
+				rd.setNotice("Alarm not logged: "+err.Error(), true)
+			}
+		}
+		if rd.config.AlarmHook != "" {
+			go func(command string, event guard.Event) {
+				if err := guard.RunHook(context.Background(), command, event); err != nil {
+					replaceLatest(rd.alarmErrors, err)
+				}
+			}(rd.config.AlarmHook, event)
+		}
+	}
+}
+
+// checkAlarmErrors shows the latest failure of an alarm hook, if any
+func (rd *Display) checkAlarmErrors() {
+	select {
+	case err := <-rd.alarmErrors:
+		rd.setNotice(err.Error(), true)
+	default:
+	}
+}
+
+// addZone adds a guard zone built from the EBL/VRM cursors: the sector
+// clockwise from the first bearing line to the second, between the two range
+// markers, or with one cursor the whole disc within its range marker
+func (rd *Display) addZone() {
+	if len(rd.cursors) == 0 {
+		rd.setNotice("Add EBL/VRM cursors with "+rd.keyFor(ActionCursor)+" to outline a zone first", true)
+		return
+	}
+	for _, c := range rd.cursors {
+		if c.Anchor != "" {
+			rd.setNotice("Zones are drawn from the center: unanchor the cursors first", true)
+			return
+		}
+	}
+
+	zone := guard.Zone{
+		Name:     rd.nextZoneName(),
+		MaxRange: rd.cursors[0].Range,
+		OnEnter:  true,
+	}
+	if len(rd.cursors) > 1 {
+		first, second := rd.cursors[0], rd.cursors[1]
+		zone.MinRange = math.Min(first.Range, second.Range)
+		zone.MaxRange = math.Max(first.Range, second.Range)
+		zone.StartBearing = first.Bearing
+		zone.EndBearing = second.Bearing
+	}
+	if zone.MaxRange <= zone.MinRange {
+		rd.setNotice("The range markers outline an empty zone", true)
+		return
+	}
+
+	rd.config.GuardZones = append(rd.config.GuardZones, zone)
+	rd.guard.SetZones(rd.config.GuardZones)
+	rd.setNotice(fmt.Sprintf("Added %s (%.1f-%.1fm)", zone.Name, zone.MinRange, zone.MaxRange), false)
+}
+
+// nextZoneName returns the first unused "Zone N" name
+func (rd *Display) nextZoneName() string {
+	used := make(map[string]bool, len(rd.config.GuardZones))
+	for _, zone := range rd.config.GuardZones {
+		used[zone.Name] = true
+	}
+	for n := 1; ; n++ {
+		if name := fmt.Sprintf("Zone %d", n); !used[name] {
+			return name
+		}
+	}
+}
+
+// removeZone removes the most recently added guard zone
+func (rd *Display) removeZone() {
+	if len(rd.config.GuardZones) == 0 {
+		rd.setNotice("No guard zones", true)
+		return
+	}
+	last := rd.config.GuardZones[len(rd.config.GuardZones)-1]
+	rd.config.GuardZones = append([]guard.Zone(nil), rd.config.GuardZones[:len(rd.config.GuardZones)-1]...)
+	rd.guard.SetZones(rd.config.GuardZones)
+	rd.setNotice("Removed "+last.Name, false)
+}
+
+// drawZones outlines each guard zone, bright while a signal is inside it
+func (rd *Display) drawZones(screen tcell.Screen, scaleFactor float64) {
+	plot := func(distance, angle float64, char rune, style tcell.Style) {
+		x := rd.centerX + int(math.Round(math.Cos(angle)*distance*scaleFactor))
+		y := rd.centerY + int(math.Round(math.Sin(angle)*distance*scaleFactor*0.5))
+		if x >= 0 && x < rd.width && y >= 3 && y < rd.height-3 {
+			screen.SetContent(x, y, char, nil, style)
+		}
+	}
+
+	for _, zone := range rd.guard.Zones() {
+		style := tcell.StyleDefault.Foreground(tcell.ColorDarkRed)
+		if rd.guard.Occupied(zone.Name) > 0 {
+			style = tcell.StyleDefault.Foreground(tcell.ColorRed).Bold(true)
+		}
+		span := zone.Span()
+
+		// Arcs at both ranges, about one cell per step
+		for _, distance := range []float64{zone.MinRange, zone.MaxRange} {
+			radius := distance * scaleFactor
+			if radius < 1 {
+				continue
+			}
+			for a := 0.0; a <= span; a += 1 / radius {
+				plot(distance, zone.StartBearing+a, '┄', style)
+			}
+		}
+
+		// Radial edges, unless the zone is a full ring
+		if span < 2*math.Pi {
+			for _, angle := range []float64{zone.StartBearing, zone.EndBearing} {
+				for d := zone.MinRange; d <= zone.MaxRange; d += 1 / scaleFactor {
+					plot(d, angle, '┆', style)
+				}
+			}
+		}
+
+		// Name just outside the middle of the outer arc
+		middle := zone.StartBearing + span/2
+		x := rd.centerX + int(math.Round(math.Cos(middle)*zone.MaxRange*scaleFactor))
+		y := rd.centerY + int(math.Round(math.Sin(middle)*zone.MaxRange*scaleFactor*0.5))
+		if y < rd.centerY {
+			y--
+		} else {
+			y++
+		}
+		for i, r := range []rune(zone.Name) {
+			if lx := x - len(zone.Name)/2 + i; lx >= 0 && lx < rd.width && y >= 3 && y < rd.height-3 {
+				screen.SetContent(lx, y, r, nil, style)
+			}
+		}
+	}
+}
+
+// drawAlarmBorder flashes the panel borders red after an alarm
+func (rd *Display) drawAlarmBorder(screen tcell.Screen) {
+	now := time.Now()
+	if now.After(rd.alarmUntil) || now.UnixMilli()/500%2 == 0 {
+		return
+	}
+	style := tcell.StyleDefault.Foreground(tcell.ColorRed).Bold(true)
+	for _, y := range []int{0, rd.height - 3, rd.height - 1} {
+		for x := 0; x < rd.width; x++ {
+			screen.SetContent(x, y, '═', nil, style)
+		}
+	}
+}